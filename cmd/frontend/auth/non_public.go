@@ -96,6 +96,12 @@ func AllowAnonymousRequest(req *http.Request) bool {
 		return true
 	}
 
+	// Code hosts deliver webhooks anonymously. repo-updater authenticates each
+	// delivery with the connection's webhook secret.
+	if strings.HasPrefix(req.URL.Path, "/.api/webhooks/") {
+		return true
+	}
+
 	apiRouteName := matchedRouteName(req, router.Router())
 	if apiRouteName == router.UI {
 		// Test against UI router. (Some of its handlers inject private data into the title or meta tags.)
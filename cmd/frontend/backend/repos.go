@@ -16,6 +16,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/inventory"
 	"github.com/sourcegraph/sourcegraph/pkg/rcache"
@@ -63,6 +64,15 @@ func (s *repos) GetByName(ctx context.Context, name api.RepoName) (_ *types.Repo
 	defer done()
 
 	repo, err := db.Repos.GetByName(ctx, name)
+	if errcode.IsNotFound(err) {
+		// The repository may have been renamed on its code host, in which case
+		// we return it under its new name so that callers redirect to it.
+		if renamed, err := db.Repos.GetByRedirect(ctx, name); err == nil {
+			return renamed, nil
+		} else if !errcode.IsNotFound(err) {
+			return nil, err
+		}
+	}
 	if err != nil && envvar.SourcegraphDotComMode() {
 		// Automatically add repositories on Sourcegraph.com.
 		if err := s.AddGitHubDotComRepository(ctx, name); err != nil {
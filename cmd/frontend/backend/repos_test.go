@@ -1,12 +1,14 @@
 package backend
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
 )
@@ -36,6 +38,30 @@ func TestReposService_Get(t *testing.T) {
 	}
 }
 
+func TestReposService_GetByName_redirect(t *testing.T) {
+	var s repos
+	ctx := testContext()
+
+	renamed := &types.Repo{ID: 1, Name: "github.com/new/r"}
+	db.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		return nil, &errcode.Mock{Message: "repo not found", IsNotFound: true}
+	}
+	db.Mocks.Repos.GetByRedirect = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		if name != "github.com/old/r" {
+			t.Errorf("got redirect lookup for %q, want %q", name, "github.com/old/r")
+		}
+		return renamed, nil
+	}
+
+	repo, err := s.GetByName(ctx, "github.com/old/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repo, renamed) {
+		t.Errorf("got %+v, want %+v", repo, renamed)
+	}
+}
+
 func TestReposService_List(t *testing.T) {
 	var s repos
 	ctx := testContext()
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// discussionNotificationDeliveries provides access to the
+// `discussion_notification_deliveries` table, which is both the queue of
+// notifications of discussion activity to send to chat services and webhooks,
+// and the log of their delivery.
+//
+// For a detailed overview of the schema, see schema.md.
+type discussionNotificationDeliveries struct{}
+
+// Create queues the notification for delivery as soon as possible.
+func (*discussionNotificationDeliveries) Create(ctx context.Context, d *types.DiscussionNotificationDelivery) (*types.DiscussionNotificationDelivery, error) {
+	if d == nil {
+		return nil, errors.New("delivery is nil")
+	}
+	if d.ID != 0 {
+		return nil, errors.New("delivery.ID must be zero")
+	}
+	if (d.UserID == nil) == (d.OrgID == nil) {
+		return nil, errors.New("exactly one of delivery.UserID and delivery.OrgID must be specified")
+	}
+
+	d.CreatedAt = time.Now()
+	d.NextAttemptAt = &d.CreatedAt
+	err := dbconn.Global.QueryRowContext(ctx, `INSERT INTO discussion_notification_deliveries(
+		event,
+		thread_id,
+		comment_id,
+		user_id,
+		org_id,
+		target_type,
+		url,
+		body,
+		signature,
+		next_attempt_at,
+		created_at
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10) RETURNING id`,
+		d.Event,
+		d.ThreadID,
+		d.CommentID,
+		d.UserID,
+		d.OrgID,
+		d.TargetType,
+		d.URL,
+		d.Body,
+		d.Signature,
+		d.CreatedAt,
+	).Scan(&d.ID)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListDue returns up to limit notifications whose next delivery attempt is
+// due, least recently due first.
+func (d *discussionNotificationDeliveries) ListDue(ctx context.Context, limit int) ([]*types.DiscussionNotificationDelivery, error) {
+	return d.getBySQL(ctx, "SELECT "+discussionNotificationDeliveriesColumns+" FROM discussion_notification_deliveries WHERE next_attempt_at <= now() ORDER BY next_attempt_at ASC LIMIT $1", limit)
+}
+
+// RecordAttempt records an attempt to deliver the notification. If deliveryErr
+// is nil, the notification was delivered. Otherwise, nextAttemptAt is the time
+// of the next attempt, or nil if delivery is given up.
+func (*discussionNotificationDeliveries) RecordAttempt(ctx context.Context, id int64, deliveryErr error, nextAttemptAt *time.Time) error {
+	var (
+		lastError   *string
+		deliveredAt *time.Time
+	)
+	if deliveryErr != nil {
+		msg := deliveryErr.Error()
+		lastError = &msg
+	} else {
+		now := time.Now()
+		deliveredAt = &now
+		nextAttemptAt = nil
+	}
+	_, err := dbconn.Global.ExecContext(ctx, `
+		UPDATE discussion_notification_deliveries
+		SET attempts=attempts + 1, last_error=$1, delivered_at=$2, next_attempt_at=$3
+		WHERE id=$4`,
+		lastError, deliveredAt, nextAttemptAt, id)
+	return err
+}
+
+// DeleteCreatedBefore deletes the log of notifications created before the
+// given time.
+func (*discussionNotificationDeliveries) DeleteCreatedBefore(ctx context.Context, t time.Time) error {
+	_, err := dbconn.Global.ExecContext(ctx, "DELETE FROM discussion_notification_deliveries WHERE created_at < $1", t)
+	return err
+}
+
+type DiscussionNotificationDeliveriesListOptions struct {
+	// LimitOffset specifies SQL LIMIT and OFFSET counts. It may be nil (no limit / offset).
+	*LimitOffset
+
+	// Failed, when true, specifies that only notifications whose last delivery
+	// attempt failed should be returned.
+	Failed bool
+}
+
+// List lists the notifications, most recent first.
+func (d *discussionNotificationDeliveries) List(ctx context.Context, opts *DiscussionNotificationDeliveriesListOptions) ([]*types.DiscussionNotificationDelivery, error) {
+	if opts == nil {
+		return nil, errors.New("options must not be nil")
+	}
+	q := sqlf.Sprintf("SELECT "+discussionNotificationDeliveriesColumns+" FROM discussion_notification_deliveries WHERE %s ORDER BY id DESC %s", d.getListSQL(opts), opts.LimitOffset.SQL())
+	return d.getBySQL(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+}
+
+func (d *discussionNotificationDeliveries) Count(ctx context.Context, opts *DiscussionNotificationDeliveriesListOptions) (int, error) {
+	if opts == nil {
+		return 0, errors.New("options must not be nil")
+	}
+	q := sqlf.Sprintf("SELECT count(id) FROM discussion_notification_deliveries WHERE %s", d.getListSQL(opts))
+	var count int
+	err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (*discussionNotificationDeliveries) getListSQL(opts *DiscussionNotificationDeliveriesListOptions) *sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if opts.Failed {
+		conds = append(conds, sqlf.Sprintf("delivered_at IS NULL AND last_error IS NOT NULL"))
+	}
+	return sqlf.Join(conds, "AND")
+}
+
+const discussionNotificationDeliveriesColumns = `
+	id,
+	event,
+	thread_id,
+	comment_id,
+	user_id,
+	org_id,
+	target_type,
+	url,
+	body,
+	signature,
+	attempts,
+	last_error,
+	next_attempt_at,
+	delivered_at,
+	created_at`
+
+// getBySQL returns the notifications that the query returns the
+// discussionNotificationDeliveriesColumns of.
+func (*discussionNotificationDeliveries) getBySQL(ctx context.Context, query string, args ...interface{}) ([]*types.DiscussionNotificationDelivery, error) {
+	rows, err := dbconn.Global.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*types.DiscussionNotificationDelivery{}
+	for rows.Next() {
+		d := &types.DiscussionNotificationDelivery{}
+		err := rows.Scan(
+			&d.ID,
+			&d.Event,
+			&d.ThreadID,
+			&d.CommentID,
+			&d.UserID,
+			&d.OrgID,
+			&d.TargetType,
+			&d.URL,
+			&d.Body,
+			&d.Signature,
+			&d.Attempts,
+			&d.LastError,
+			&d.NextAttemptAt,
+			&d.DeliveredAt,
+			&d.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
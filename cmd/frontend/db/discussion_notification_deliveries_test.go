@@ -0,0 +1,120 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestDiscussionNotificationDeliveries(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	user, err := Users.Create(ctx, NewUser{
+		Email:                 "a@a.com",
+		Username:              "u",
+		Password:              "p",
+		EmailVerificationCode: "c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a repository to comply with the postgres repo constraint.
+	if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: "myrepo", Description: "", Fork: false, Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Repos.GetByName(ctx, "myrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thread, err := DiscussionThreads.Create(ctx, &types.DiscussionThread{
+		AuthorUserID: user.ID,
+		Title:        "Hello world!",
+		TargetRepo:   &types.DiscussionThreadTargetRepo{RepoID: repo.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	comment, err := DiscussionComments.Create(ctx, &types.DiscussionComment{
+		ThreadID:     thread.ID,
+		AuthorUserID: user.ID,
+		Contents:     "Hello world!",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		d, err := DiscussionNotificationDeliveries.Create(ctx, &types.DiscussionNotificationDelivery{
+			Event:      types.DiscussionNotificationEventThreadCreated,
+			ThreadID:   thread.ID,
+			CommentID:  comment.ID,
+			UserID:     &user.ID,
+			TargetType: "webhook",
+			URL:        "https://example.com",
+			Body:       "{}",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, d.ID)
+	}
+
+	due, err := DiscussionNotificationDeliveries.ListDue(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 2 || due[0].ID != ids[0] || due[1].ID != ids[1] {
+		t.Fatalf("got %d due deliveries, want both", len(due))
+	}
+
+	// Deliver the first one, and fail to deliver the second one.
+	if err := DiscussionNotificationDeliveries.RecordAttempt(ctx, ids[0], nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	retryAt := time.Now().Add(time.Hour)
+	if err := DiscussionNotificationDeliveries.RecordAttempt(ctx, ids[1], errors.New("oops"), &retryAt); err != nil {
+		t.Fatal(err)
+	}
+	due, err = DiscussionNotificationDeliveries.ListDue(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 0 {
+		t.Errorf("got %d due deliveries, want none", len(due))
+	}
+
+	failed, err := DiscussionNotificationDeliveries.List(ctx, &DiscussionNotificationDeliveriesListOptions{Failed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 {
+		t.Fatalf("got %d failed deliveries, want 1", len(failed))
+	}
+	if d := failed[0]; d.ID != ids[1] || d.Attempts != 1 || d.LastError == nil || *d.LastError != "oops" || d.NextAttemptAt == nil || d.DeliveredAt != nil {
+		t.Errorf("unexpected failed delivery %+v", d)
+	}
+	if count, err := DiscussionNotificationDeliveries.Count(ctx, &DiscussionNotificationDeliveriesListOptions{}); err != nil {
+		t.Fatal(err)
+	} else if count != 2 {
+		t.Errorf("got count %d, want 2", count)
+	}
+
+	if err := DiscussionNotificationDeliveries.DeleteCreatedBefore(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := DiscussionNotificationDeliveries.Count(ctx, &DiscussionNotificationDeliveriesListOptions{}); err != nil {
+		t.Fatal(err)
+	} else if count != 0 {
+		t.Errorf("got count %d after deleting, want 0", count)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions/searchquery"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
@@ -70,6 +71,16 @@ func (t *discussionThreads) Create(ctx context.Context, newThread *types.Discuss
 	if newThread.DeletedAt != nil {
 		return nil, errors.New("newThread.DeletedAt must not be specified")
 	}
+	if newThread.Status == "" {
+		newThread.Status = types.DiscussionThreadStatusOpen
+	} else if !validThreadStatus(newThread.Status) {
+		return nil, fmt.Errorf("newThread.Status %q is invalid", newThread.Status)
+	}
+	labels, err := normalizeThreadLabels(newThread.Labels)
+	if err != nil {
+		return nil, errors.Wrap(err, "newThread.Labels")
+	}
+	newThread.Labels = labels
 	if newThread.TargetRepo != nil {
 		if rev := newThread.TargetRepo.Revision; rev != nil {
 			if !git.IsAbsoluteRevision(*rev) {
@@ -85,20 +96,29 @@ func (t *discussionThreads) Create(ctx context.Context, newThread *types.Discuss
 	// First, create the thread itself. Initially it will have no target.
 	newThread.CreatedAt = time.Now()
 	newThread.UpdatedAt = newThread.CreatedAt
-	err := dbconn.Global.QueryRowContext(ctx, `INSERT INTO discussion_threads(
+	err = dbconn.Global.QueryRowContext(ctx, `INSERT INTO discussion_threads(
 		author_user_id,
 		title,
 		created_at,
-		updated_at
-	) VALUES ($1, $2, $3, $4) RETURNING id`,
+		updated_at,
+		status,
+		labels
+	) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
 		newThread.AuthorUserID,
 		newThread.Title,
 		newThread.CreatedAt,
 		newThread.UpdatedAt,
+		newThread.Status,
+		pq.Array(newThread.Labels),
 	).Scan(&newThread.ID)
 	if err != nil {
 		return nil, errors.Wrap(err, "create thread")
 	}
+	if len(newThread.AssigneeUserIDs) > 0 {
+		if err := t.setAssignees(ctx, newThread.ID, newThread.AssigneeUserIDs); err != nil {
+			return nil, errors.Wrap(err, "set thread assignees")
+		}
+	}
 
 	// Create the thread target and have it reference the thread we just created.
 	var (
@@ -143,6 +163,17 @@ type DiscussionThreadsUpdateOptions struct {
 	// Archive, when non-nil, specifies whether the thread is archived or not.
 	Archive *bool
 
+	// Status, when non-nil, specifies the new status of the thread.
+	Status *types.DiscussionThreadStatus
+
+	// AssigneeUserIDs, when non-nil, specifies the users assigned to the
+	// thread. It replaces the existing assignees.
+	AssigneeUserIDs *[]int32
+
+	// Labels, when non-nil, specifies the labels of the thread. It replaces
+	// the existing labels.
+	Labels *[]string
+
 	// Delete, when true, specifies that the thread should be deleted. This
 	// operation cannot be undone.
 	Delete bool
@@ -178,6 +209,31 @@ func (t *discussionThreads) Update(ctx context.Context, threadID int64, opts *Di
 			return nil, err
 		}
 	}
+	if opts.Status != nil {
+		if !validThreadStatus(*opts.Status) {
+			return nil, fmt.Errorf("invalid thread status %q", *opts.Status)
+		}
+		anyUpdate = true
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE discussion_threads SET status=$1 WHERE id=$2 AND deleted_at IS NULL", *opts.Status, threadID); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Labels != nil {
+		labels, err := normalizeThreadLabels(*opts.Labels)
+		if err != nil {
+			return nil, err
+		}
+		anyUpdate = true
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE discussion_threads SET labels=$1 WHERE id=$2 AND deleted_at IS NULL", pq.Array(labels), threadID); err != nil {
+			return nil, err
+		}
+	}
+	if opts.AssigneeUserIDs != nil {
+		anyUpdate = true
+		if err := t.setAssignees(ctx, threadID, *opts.AssigneeUserIDs); err != nil {
+			return nil, err
+		}
+	}
 	if opts.Delete {
 		anyUpdate = true
 		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE discussion_threads SET deleted_at=$1 WHERE id=$2 AND deleted_at IS NULL", now, threadID); err != nil {
@@ -279,6 +335,22 @@ type DiscussionThreadsListOptions struct {
 	CreatedBefore *time.Time
 	CreatedAfter  *time.Time
 
+	// Statuses, when len() > 0, specifies that only threads with one of these
+	// statuses should be returned.
+	Statuses    []types.DiscussionThreadStatus
+	NotStatuses []types.DiscussionThreadStatus
+
+	// AssigneeUserIDs, when len() > 0, specifies that only threads assigned
+	// to one of these users should be returned.
+	AssigneeUserIDs    []int32
+	NotAssigneeUserIDs []int32
+
+	// Labels, when len() > 0, specifies that only threads with all of these
+	// labels should be returned. NotLabels excludes threads with any of its
+	// labels.
+	Labels    []string
+	NotLabels []string
+
 	// Whether or not to return results in ascending (oldest first) order. When
 	// false, descending (latest first) order is used.
 	AscendingOrder bool
@@ -292,6 +364,15 @@ type DiscussionThreadsListOptions struct {
 func (opts *DiscussionThreadsListOptions) SetFromQuery(ctx context.Context, query string) {
 	userList := func(value string) (users []*types.User) {
 		for _, username := range strings.Fields(value) {
+			if username == "@me" {
+				// The current user, e.g. "assignee:@me".
+				if a := actor.FromContext(ctx); a.IsAuthenticated() {
+					if user, err := Users.GetByID(ctx, a.UID); err == nil {
+						users = append(users, user)
+					}
+				}
+				continue
+			}
 			username = strings.TrimSpace(strings.TrimPrefix(username, "@"))
 			user, err := Users.GetByUsername(ctx, username)
 			if err != nil {
@@ -376,6 +457,33 @@ func (opts *DiscussionThreadsListOptions) SetFromQuery(ctx context.Context, quer
 			opts.NotAuthorUserIDs = userIDsList(value)
 		},
 
+		// syntax: "is:open", "is:resolved" or "is:wontfix"
+		"is": func(value string) {
+			opts.Statuses = append(opts.Statuses, parseThreadStatus(value))
+		},
+		"-is": func(value string) {
+			opts.NotStatuses = append(opts.NotStatuses, parseThreadStatus(value))
+		},
+
+		// syntax: "assignee:slimsag" or "assignee:@me" or `assignee:"slimsag @jack"`
+		"assignee": func(value string) {
+			opts.AssigneeUserIDs = userIDsList(value)
+			if len(opts.AssigneeUserIDs) == 0 {
+				opts.AssigneeUserIDs = []int32{-1}
+			}
+		},
+		"-assignee": func(value string) {
+			opts.NotAssigneeUserIDs = userIDsList(value)
+		},
+
+		// syntax: "label:security" or `label:"security bug"` (both labels)
+		"label": func(value string) {
+			opts.Labels = append(opts.Labels, strings.Fields(value)...)
+		},
+		"-label": func(value string) {
+			opts.NotLabels = append(opts.NotLabels, strings.Fields(value)...)
+		},
+
 		// syntax: "repo:github.com/gorilla/mux" or "repo:some/repo"
 		// TODO(slimsag:discussions): support list syntax here.
 		"repo": func(value string) {
@@ -580,6 +688,24 @@ func (*discussionThreads) getListSQL(opts *DiscussionThreadsListOptions) (conds
 	if opts.CreatedAfter != nil {
 		conds = append(conds, sqlf.Sprintf("created_at > %v", *opts.CreatedAfter))
 	}
+	if len(opts.Statuses) > 0 {
+		conds = append(conds, sqlf.Sprintf("status = ANY(%v)", pq.Array(threadStatusStrings(opts.Statuses))))
+	}
+	if len(opts.NotStatuses) > 0 {
+		conds = append(conds, sqlf.Sprintf("status != ALL(%v)", pq.Array(threadStatusStrings(opts.NotStatuses))))
+	}
+	if len(opts.AssigneeUserIDs) > 0 {
+		conds = append(conds, sqlf.Sprintf("id IN (SELECT thread_id FROM discussion_threads_assignees WHERE user_id = ANY(%v))", pq.Array(opts.AssigneeUserIDs)))
+	}
+	if len(opts.NotAssigneeUserIDs) > 0 {
+		conds = append(conds, sqlf.Sprintf("id NOT IN (SELECT thread_id FROM discussion_threads_assignees WHERE user_id = ANY(%v))", pq.Array(opts.NotAssigneeUserIDs)))
+	}
+	if len(opts.Labels) > 0 {
+		conds = append(conds, sqlf.Sprintf("labels @> %v", pq.Array(opts.Labels)))
+	}
+	if len(opts.NotLabels) > 0 {
+		conds = append(conds, sqlf.Sprintf("NOT (labels && %v)", pq.Array(opts.NotLabels)))
+	}
 
 	if opts.TargetRepoID != nil || opts.TargetRepoPath != nil || opts.NotTargetRepoID != nil || opts.NotTargetRepoPath != nil {
 		targetRepoConds := []*sqlf.Query{}
@@ -620,6 +746,19 @@ func (*discussionThreads) getCountBySQL(ctx context.Context, query string, args
 	return count, err
 }
 
+// setAssignees replaces the assignees of the thread with the given users.
+func (*discussionThreads) setAssignees(ctx context.Context, threadID int64, userIDs []int32) error {
+	if _, err := dbconn.Global.ExecContext(ctx, "DELETE FROM discussion_threads_assignees WHERE thread_id=$1", threadID); err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	_, err := dbconn.Global.ExecContext(ctx, `INSERT INTO discussion_threads_assignees(thread_id, user_id)
+		SELECT $1, user_id FROM unnest($2::integer[]) AS user_id ON CONFLICT DO NOTHING`, threadID, pq.Array(userIDs))
+	return err
+}
+
 // createTargetRepo handles the creation of a repo-based discussion thread target.
 func (t *discussionThreads) createTargetRepo(ctx context.Context, tr *types.DiscussionThreadTargetRepo, threadID int64) (*types.DiscussionThreadTargetRepo, error) {
 	var fields []*sqlf.Query
@@ -671,7 +810,10 @@ func (t *discussionThreads) getBySQL(ctx context.Context, query string, args ...
 			t.target_repo_id,
 			t.created_at,
 			t.archived_at,
-			t.updated_at
+			t.updated_at,
+			t.status,
+			t.labels,
+			ARRAY(SELECT user_id FROM discussion_threads_assignees a WHERE a.thread_id=t.id ORDER BY user_id)
 		FROM discussion_threads t `+query, args...)
 	if err != nil {
 		return nil, err
@@ -683,6 +825,7 @@ func (t *discussionThreads) getBySQL(ctx context.Context, query string, args ...
 		var (
 			thread       types.DiscussionThread
 			targetRepoID *int64
+			assignees    pq.Int64Array
 		)
 		err := rows.Scan(
 			&thread.ID,
@@ -692,10 +835,16 @@ func (t *discussionThreads) getBySQL(ctx context.Context, query string, args ...
 			&thread.CreatedAt,
 			&thread.ArchivedAt,
 			&thread.UpdatedAt,
+			&thread.Status,
+			pq.Array(&thread.Labels),
+			&assignees,
 		)
 		if err != nil {
 			return nil, err
 		}
+		for _, userID := range assignees {
+			thread.AssigneeUserIDs = append(thread.AssigneeUserIDs, int32(userID))
+		}
 		if targetRepoID != nil {
 			thread.TargetRepo, err = t.getTargetRepo(ctx, *targetRepoID)
 			if err != nil {
@@ -762,6 +911,59 @@ func (t *discussionThreads) getTargetRepo(ctx context.Context, targetRepoID int6
 	return tr, nil
 }
 
+// validThreadStatus reports whether status is a valid thread status.
+func validThreadStatus(status types.DiscussionThreadStatus) bool {
+	switch status {
+	case types.DiscussionThreadStatusOpen, types.DiscussionThreadStatusResolved, types.DiscussionThreadStatusWontFix:
+		return true
+	}
+	return false
+}
+
+// parseThreadStatus parses the value of an "is:" search query operator. It
+// accepts "wont-fix" and "won't-fix" as spellings of "wontfix". Invalid values
+// are returned as-is, so that they match no threads.
+func parseThreadStatus(value string) types.DiscussionThreadStatus {
+	value = strings.ToLower(value)
+	switch value {
+	case "wont-fix", "won't-fix", "wontfix", "won'tfix":
+		return types.DiscussionThreadStatusWontFix
+	}
+	return types.DiscussionThreadStatus(value)
+}
+
+func threadStatusStrings(statuses []types.DiscussionThreadStatus) []string {
+	s := make([]string, len(statuses))
+	for i, status := range statuses {
+		s[i] = string(status)
+	}
+	return s
+}
+
+// normalizeThreadLabels trims and deduplicates the labels, and checks that
+// they can be used in a search query ("label:foo").
+func normalizeThreadLabels(labels []string) ([]string, error) {
+	normalized := []string{}
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			return nil, errors.New("labels must not be empty")
+		}
+		if strings.ContainsAny(label, " \t\r\n") {
+			return nil, fmt.Errorf("label %q must not contain whitespace", label)
+		}
+		if len([]rune(label)) > 100 {
+			return nil, fmt.Errorf("label %q too long (must be less than 100 UTF-8 characters)", label)
+		}
+		if !seen[label] {
+			seen[label] = true
+			normalized = append(normalized, label)
+		}
+	}
+	return normalized, nil
+}
+
 // extraFuzzy turns a string like "cat" into "%c%a%t%". It can be used with a
 // LIKE query to filter out results that cannot possibly match a fuzzy search
 // query. This returns 'extra fuzzy' results, which are usually subsequently
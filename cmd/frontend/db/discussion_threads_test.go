@@ -319,6 +319,118 @@ func TestDiscussionThreads_Delete(t *testing.T) {
 	}
 }
 
+func TestDiscussionThreads_StatusAssigneesLabels(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	user, err := Users.Create(ctx, NewUser{
+		Email:                 "a@a.com",
+		Username:              "u",
+		Password:              "p",
+		EmailVerificationCode: "c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignee, err := Users.Create(ctx, NewUser{
+		Email:                 "b@b.com",
+		Username:              "u2",
+		Password:              "p",
+		EmailVerificationCode: "c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a repository to comply with the postgres repo constraint.
+	if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: "myrepo", Description: "", Fork: false, Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Repos.GetByName(ctx, "myrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the thread.
+	thread, err := DiscussionThreads.Create(ctx, &types.DiscussionThread{
+		AuthorUserID: user.ID,
+		Title:        "Hello world!",
+		TargetRepo:   &types.DiscussionThreadTargetRepo{RepoID: repo.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Status != types.DiscussionThreadStatusOpen {
+		t.Errorf("got status %q, want open", thread.Status)
+	}
+
+	// Update the thread.
+	resolved := types.DiscussionThreadStatusResolved
+	gotThread, err := DiscussionThreads.Update(ctx, thread.ID, &DiscussionThreadsUpdateOptions{
+		Status:          &resolved,
+		AssigneeUserIDs: &[]int32{assignee.ID},
+		Labels:          &[]string{" security ", "bug", "security"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotThread.Status != resolved {
+		t.Errorf("got status %q, want %q", gotThread.Status, resolved)
+	}
+	if want := []int32{assignee.ID}; !reflect.DeepEqual(gotThread.AssigneeUserIDs, want) {
+		t.Errorf("got assignees %v, want %v", gotThread.AssigneeUserIDs, want)
+	}
+	if want := []string{"security", "bug"}; !reflect.DeepEqual(gotThread.Labels, want) {
+		t.Errorf("got labels %q, want %q", gotThread.Labels, want)
+	}
+
+	// Invalid updates are rejected.
+	invalid := types.DiscussionThreadStatus("invalid")
+	if _, err := DiscussionThreads.Update(ctx, thread.ID, &DiscussionThreadsUpdateOptions{Status: &invalid}); err == nil {
+		t.Error("expected error for invalid status")
+	}
+	if _, err := DiscussionThreads.Update(ctx, thread.ID, &DiscussionThreadsUpdateOptions{Labels: &[]string{"two words"}}); err == nil {
+		t.Error("expected error for label with whitespace")
+	}
+
+	// Filter threads.
+	tests := map[string]int{
+		"is:resolved":                       1,
+		"is:open":                           0,
+		"-is:resolved":                      0,
+		"assignee:u2":                       1,
+		"assignee:u":                        0,
+		"-assignee:u2":                      0,
+		"label:security":                    1,
+		`label:"security bug"`:              1,
+		"label:security label:other":        0,
+		"-label:bug":                        0,
+		"is:resolved assignee:u2 label:bug": 1,
+	}
+	for query, want := range tests {
+		opts := &DiscussionThreadsListOptions{}
+		opts.SetFromQuery(ctx, query)
+		threads, err := DiscussionThreads.List(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(threads) != want {
+			t.Errorf("%s: got %d threads, want %d", query, len(threads), want)
+		}
+	}
+
+	// Remove the assignees.
+	gotThread, err = DiscussionThreads.Update(ctx, thread.ID, &DiscussionThreadsUpdateOptions{AssigneeUserIDs: &[]int32{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotThread.AssigneeUserIDs) != 0 {
+		t.Errorf("got assignees %v, want none", gotThread.AssigneeUserIDs)
+	}
+}
+
 func boolPtr(b bool) *bool {
 	return &b
 }
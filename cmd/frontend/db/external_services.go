@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
 	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
 	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
@@ -31,7 +33,9 @@ type externalServices struct{}
 // external services.
 var ExternalServiceKinds = map[string]ExternalServiceKind{
 	"AWSCODECOMMIT":   {CodeHost: true, Definition: "AWSCodeCommitConnection"},
+	"BITBUCKETCLOUD":  {CodeHost: true, Definition: "BitbucketCloudConnection"},
 	"BITBUCKETSERVER": {CodeHost: true, Definition: "BitbucketServerConnection"},
+	"GITEA":           {CodeHost: true, Definition: "GiteaConnection"},
 	"GITHUB":          {CodeHost: true, Definition: "GitHubConnection"},
 	"GITLAB":          {CodeHost: true, Definition: "GitLabConnection"},
 	"GITOLITE":        {CodeHost: true, Definition: "GitoliteConnection"},
@@ -65,7 +69,9 @@ func (o ExternalServicesListOptions) sqlConditions() []*sqlf.Query {
 	return conds
 }
 
-func (e *externalServices) validateConfig(kind, config string) error {
+// ValidateConfig validates the config of an external service of the given kind against the kind's
+// JSON Schema.
+func (e *externalServices) ValidateConfig(kind, config string) error {
 	ext, ok := ExternalServiceKinds[kind]
 	if !ok {
 		return fmt.Errorf("invalid external service kind: %s", kind)
@@ -107,6 +113,13 @@ func (e *externalServices) validateConfig(kind, config string) error {
 
 	// Extra validation not based on JSON Schema.
 	switch kind {
+	case "BITBUCKETSERVER":
+		var c schema.BitbucketServerConnection
+		if err = json.Unmarshal(normalized, &c); err != nil {
+			return err
+		}
+		err = validateBitbucketServerConnection(&c)
+
 	case "GITHUB":
 		var c schema.GitHubConnection
 		if err = json.Unmarshal(normalized, &c); err != nil {
@@ -161,6 +174,13 @@ func validateOtherExternalServiceConnection(c *schema.OtherExternalServiceConnec
 	return nil
 }
 
+func validateBitbucketServerConnection(c *schema.BitbucketServerConnection) (err error) {
+	if c.Authorization != nil {
+		err = validateTTL("authorization.ttl", c.Authorization.Ttl, "3h")
+	}
+	return err
+}
+
 func validateGithubConnection(c *schema.GitHubConnection) (err error) {
 	if c.Authorization != nil {
 		err = validateTTL("authorization.ttl", c.Authorization.Ttl, "3h")
@@ -189,7 +209,7 @@ func validateTTL(field, ttl, def string) error {
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) Create(ctx context.Context, externalService *types.ExternalService) error {
-	if err := c.validateConfig(externalService.Kind, externalService.Config); err != nil {
+	if err := c.ValidateConfig(externalService.Kind, externalService.Config); err != nil {
 		return err
 	}
 
@@ -220,7 +240,7 @@ func (c *externalServices) Update(ctx context.Context, id int64, update *Externa
 			return err
 		}
 
-		if err := c.validateConfig(externalService.Kind, *update.Config); err != nil {
+		if err := c.ValidateConfig(externalService.Kind, *update.Config); err != nil {
 			return err
 		}
 	}
@@ -355,12 +375,34 @@ func (c *externalServices) ListAWSCodeCommitConnections(ctx context.Context) ([]
 	return connections, nil
 }
 
+// ListBitbucketCloudConnections returns a list of BitbucketCloudConnection configs.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListBitbucketCloudConnections(ctx context.Context) ([]*schema.BitbucketCloudConnection, error) {
+	var connections []*schema.BitbucketCloudConnection
+	if err := c.listConfigs(ctx, "BITBUCKETCLOUD", &connections); err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
 // ListBitbucketServerConnections returns a list of BitbucketServer configs.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) ListBitbucketServerConnections(ctx context.Context) ([]*schema.BitbucketServerConnection, error) {
 	var connections []*schema.BitbucketServerConnection
-	if err := c.listConfigs(ctx, "BITBUCKET", &connections); err != nil {
+	if err := c.listConfigs(ctx, "BITBUCKETSERVER", &connections); err != nil {
+		return nil, err
+	}
+	return connections, nil
+}
+
+// ListGiteaConnections returns a list of GiteaConnection configs.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListGiteaConnections(ctx context.Context) ([]*schema.GiteaConnection, error) {
+	var connections []*schema.GiteaConnection
+	if err := c.listConfigs(ctx, "GITEA", &connections); err != nil {
 		return nil, err
 	}
 	return connections, nil
@@ -576,8 +618,104 @@ func (c *externalServices) Count(ctx context.Context, opt ExternalServicesListOp
 	return count, nil
 }
 
+// SetSyncStatus records the outcome of the last sync of the repositories of an external service,
+// replacing the previously recorded outcome. It sets the added, removed and unchanged counts of
+// status from status.Repos and the repositories synced by the previous sync, which are stored with
+// the outcome.
+func (c *externalServices) SetSyncStatus(ctx context.Context, status *api.ExternalServiceSyncStatus) error {
+	errs := status.Errors
+	if errs == nil {
+		errs = []string{}
+	}
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		var previous []string
+		err := tx.QueryRowContext(ctx, "SELECT repos FROM external_service_sync_status WHERE external_service_id=$1 FOR UPDATE", status.ExternalServiceID).Scan(pq.Array(&previous))
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		repos := countSyncedRepos(status, previous)
+
+		_, err = tx.ExecContext(ctx, `
+INSERT INTO external_service_sync_status(external_service_id, started_at, finished_at, repos_added, repos_removed, repos_unchanged, errors, repos)
+VALUES($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (external_service_id) DO UPDATE SET
+	started_at=excluded.started_at, finished_at=excluded.finished_at,
+	repos_added=excluded.repos_added, repos_removed=excluded.repos_removed, repos_unchanged=excluded.repos_unchanged,
+	errors=excluded.errors, repos=excluded.repos`,
+			status.ExternalServiceID, status.StartedAt, status.FinishedAt, status.ReposAdded, status.ReposRemoved, status.ReposUnchanged, pq.Array(errs), pq.Array(repos),
+		)
+		return err
+	})
+}
+
+// countSyncedRepos sets the added, removed and unchanged counts of status relative to the
+// repositories synced by the previous sync, and returns the repositories to store for the next
+// sync. If listing the repositories failed (status.Incomplete), missing repositories are not
+// counted as removed, and they still count as synced for the next sync.
+func countSyncedRepos(status *api.ExternalServiceSyncStatus, previous []string) []string {
+	synced := make(map[string]bool, len(status.Repos))
+	for _, repo := range status.Repos {
+		synced[string(repo)] = true
+	}
+	prev := make(map[string]bool, len(previous))
+	for _, repo := range previous {
+		prev[repo] = true
+	}
+
+	status.ReposAdded, status.ReposRemoved, status.ReposUnchanged = 0, 0, 0
+	for repo := range synced {
+		if prev[repo] {
+			status.ReposUnchanged++
+		} else {
+			status.ReposAdded++
+		}
+	}
+	for repo := range prev {
+		if synced[repo] {
+			continue
+		}
+		if status.Incomplete {
+			synced[repo] = true
+		} else {
+			status.ReposRemoved++
+		}
+	}
+
+	repos := make([]string, 0, len(synced))
+	for repo := range synced {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// GetSyncStatus returns the outcome of the last sync of the repositories of an external service,
+// or nil if the external service has not been synced yet.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) GetSyncStatus(ctx context.Context, id int64) (*api.ExternalServiceSyncStatus, error) {
+	if Mocks.ExternalServices.GetSyncStatus != nil {
+		return Mocks.ExternalServices.GetSyncStatus(id)
+	}
+
+	status := api.ExternalServiceSyncStatus{ExternalServiceID: id}
+	err := dbconn.Global.QueryRowContext(ctx, `
+SELECT started_at, finished_at, repos_added, repos_removed, repos_unchanged, errors
+FROM external_service_sync_status
+WHERE external_service_id=$1`, id,
+	).Scan(&status.StartedAt, &status.FinishedAt, &status.ReposAdded, &status.ReposRemoved, &status.ReposUnchanged, pq.Array(&status.Errors))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
 // MockExternalServices mocks the external services store.
 type MockExternalServices struct {
-	GetByID func(id int64) (*types.ExternalService, error)
-	List    func(opt ExternalServicesListOptions) ([]*types.ExternalService, error)
+	GetByID       func(id int64) (*types.ExternalService, error)
+	GetSyncStatus func(id int64) (*api.ExternalServiceSyncStatus, error)
+	List          func(opt ExternalServicesListOptions) ([]*types.ExternalService, error)
 }
@@ -7,6 +7,7 @@ import (
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/kylelemons/godebug/pretty"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
 )
 
 func TestExternalServices_ValidateConfig(t *testing.T) {
@@ -417,7 +418,7 @@ func TestExternalServices_ValidateConfig(t *testing.T) {
 		tc := tc
 		t.Run(tc.kind+"/"+tc.desc, func(t *testing.T) {
 			var have []string
-			switch e := tc.ext.validateConfig(tc.kind, tc.config).(type) {
+			switch e := tc.ext.ValidateConfig(tc.kind, tc.config).(type) {
 			case nil:
 				have = append(have, "<nil>")
 			case *multierror.Error:
@@ -430,3 +431,32 @@ func TestExternalServices_ValidateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestCountSyncedRepos(t *testing.T) {
+	sync := func(previous []string, incomplete bool, repos ...api.RepoName) ([3]int, []string) {
+		status := &api.ExternalServiceSyncStatus{Repos: repos, Incomplete: incomplete}
+		stored := countSyncedRepos(status, previous)
+		return [3]int{status.ReposAdded, status.ReposRemoved, status.ReposUnchanged}, stored
+	}
+
+	counts, stored := sync(nil, false, "a", "b")
+	if want := [3]int{2, 0, 0}; counts != want {
+		t.Errorf("first sync: have added/removed/unchanged %v, want %v", counts, want)
+	}
+	counts, stored = sync(stored, false, "b", "c")
+	if want := [3]int{1, 1, 1}; counts != want {
+		t.Errorf("second sync: have added/removed/unchanged %v, want %v", counts, want)
+	}
+
+	// Repos missing because listing failed are not removed.
+	counts, stored = sync(stored, true, "d")
+	if want := [3]int{1, 0, 0}; counts != want {
+		t.Errorf("failed sync: have added/removed/unchanged %v, want %v", counts, want)
+	}
+	if want := []string{"b", "c", "d"}; !reflect.DeepEqual(stored, want) {
+		t.Errorf("failed sync: have stored repos %q, want %q", stored, want)
+	}
+	if counts, _ := sync(stored, false, "b", "c", "d"); counts != [3]int{0, 0, 3} {
+		t.Errorf("sync after failed sync: have added/removed/unchanged %v, want %v", counts, [3]int{0, 0, 3})
+	}
+}
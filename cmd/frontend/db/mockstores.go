@@ -17,6 +17,8 @@ type MockStores struct {
 	Users      MockUsers
 	UserEmails MockUserEmails
 
+	UserPermissions MockUserPermissions
+
 	Phabricator MockPhabricator
 
 	ExternalAccounts MockExternalAccounts
@@ -6,6 +6,7 @@ import (
 	regexpsyntax "regexp/syntax"
 	"strings"
 
+	multierror "github.com/hashicorp/go-multierror"
 	"github.com/keegancsmith/sqlf"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
@@ -14,7 +15,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	log15 "gopkg.in/inconshreveable/log15.v2"
 )
 
 type repoNotFoundErr struct {
@@ -100,7 +103,7 @@ func (s *repos) Count(ctx context.Context, opt ReposListOptions) (int, error) {
 }
 
 func (s *repos) getBySQL(ctx context.Context, querySuffix *sqlf.Query) ([]*types.Repo, error) {
-	q := sqlf.Sprintf("SELECT id, name, description, language, enabled, created_at, updated_at, external_id, external_service_type, external_service_id FROM repo %s", querySuffix)
+	q := sqlf.Sprintf("SELECT id, name, description, language, enabled, COALESCE(fork, false), archived, created_at, updated_at, external_id, external_service_type, external_service_id FROM repo %s", querySuffix)
 	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
 	if err != nil {
 		return nil, err
@@ -118,6 +121,8 @@ func (s *repos) getBySQL(ctx context.Context, querySuffix *sqlf.Query) ([]*types
 			&repo.Description,
 			&repo.Language,
 			&repo.Enabled,
+			&repo.Fork,
+			&repo.Archived,
 			&repo.CreatedAt,
 			&repo.UpdatedAt,
 			&spec.id, &spec.serviceType, &spec.serviceID,
@@ -157,6 +162,13 @@ type ReposListOptions struct {
 	// the query are strings which are regular expression patterns.
 	PatternQuery query.Q
 
+	// Names if non-empty only includes repositories with one of these names.
+	Names []api.RepoName
+
+	// AfterID if non-zero only includes repositories with a greater ID. Along with the default
+	// ordering by ID, this lets callers page through all repositories with a cursor.
+	AfterID api.RepoID
+
 	// Enabled includes enabled repositories in the list.
 	Enabled bool
 
@@ -349,6 +361,18 @@ func (*repos) listSQL(opt ReposListOptions) (conds []*sqlf.Query, err error) {
 		conds = append(conds, cond)
 	}
 
+	if len(opt.Names) > 0 {
+		items := make([]*sqlf.Query, len(opt.Names))
+		for i, name := range opt.Names {
+			items[i] = sqlf.Sprintf("%s", name)
+		}
+		conds = append(conds, sqlf.Sprintf("name IN (%s)", sqlf.Join(items, ",")))
+	}
+
+	if opt.AfterID != 0 {
+		conds = append(conds, sqlf.Sprintf("id > %s", opt.AfterID))
+	}
+
 	if opt.Enabled && opt.Disabled {
 		// nothing to do
 	} else if opt.Enabled && !opt.Disabled {
@@ -598,6 +622,11 @@ INSERT INTO repo(name, description, fork, language, enabled, external_id, extern
 // Upsert updates the repository if it already exists (keyed on name) and
 // inserts it if it does not.
 //
+// If no repository has the name but one has the same external repository
+// (op.ExternalRepo), the repository was renamed or transferred on its code
+// host. It is then renamed in place, so that it keeps its ID (and the
+// discussions and other data that refer to it) and its clone.
+//
 // If repo exists, op.Enabled is ignored.
 func (s *repos) Upsert(ctx context.Context, op api.InsertRepoOp) error {
 	if Mocks.Repos.Upsert != nil {
@@ -618,6 +647,23 @@ func (s *repos) Upsert(ctx context.Context, op api.InsertRepoOp) error {
 		if _, ok := err.(*repoNotFoundErr); !ok {
 			return err
 		}
+		if op.ExternalRepo != nil {
+			renamed, err := s.GetByExternalRepo(ctx, *op.ExternalRepo)
+			if err != nil {
+				return err
+			}
+			if renamed != nil {
+				if err := s.rename(ctx, renamed, op); err != nil {
+					return err
+				}
+				// The repository is cloned again under its new name on
+				// demand if its clone can't be moved, so this is not fatal.
+				if err := renameRepoClone(ctx, renamed.Name, op.Name); err != nil {
+					log15.Warn("Failed to move clone of renamed repository on gitserver.", "from", renamed.Name, "to", op.Name, "error", err)
+				}
+				return nil
+			}
+		}
 		insert = true // missing
 	} else {
 		enabled = r.Enabled
@@ -637,6 +683,77 @@ func (s *repos) Upsert(ctx context.Context, op api.InsertRepoOp) error {
 	return err
 }
 
+// GetByExternalRepo returns the repository with the given external
+// repository, or nil if there is none.
+func (s *repos) GetByExternalRepo(ctx context.Context, spec api.ExternalRepoSpec) (*types.Repo, error) {
+	if Mocks.Repos.GetByExternalRepo != nil {
+		return Mocks.Repos.GetByExternalRepo(ctx, spec)
+	}
+
+	repos, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE external_service_type=%s AND external_service_id=%s AND external_id=%s ORDER BY id LIMIT 1", spec.ServiceType, spec.ServiceID, spec.ID))
+	if err != nil || len(repos) == 0 {
+		return nil, err
+	}
+	return repos[0], nil
+}
+
+// renameRepoClone moves the clone of a renamed repository on gitserver. It is
+// a variable so that tests can mock it.
+var renameRepoClone = func(ctx context.Context, from, to api.RepoName) error {
+	return gitserver.DefaultClient.RenameRepo(ctx, from, to)
+}
+
+// rename renames repo to op.Name and updates it from op. Requests for the old
+// name are redirected to the new one (see GetByRedirect). The caller must move
+// the clone on gitserver.
+func (s *repos) rename(ctx context.Context, repo *types.Repo, op api.InsertRepoOp) (err error) {
+	tx, err := dbconn.Global.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			rollErr := tx.Rollback()
+			if rollErr != nil {
+				err = multierror.Append(err, rollErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	spec := (&dbExternalRepoSpec{}).fromAPISpec(op.ExternalRepo)
+	if _, err := tx.ExecContext(ctx, "UPDATE repo SET name=$1, uri=$1, description=$2, fork=$3, external_id=$4, external_service_type=$5, external_service_id=$6, archived=$7 WHERE id=$8",
+		op.Name, op.Description, op.Fork, spec.id, spec.serviceType, spec.serviceID, op.Archived, repo.ID); err != nil {
+		return err
+	}
+	// The new name may have been the old name of this or another repository.
+	if _, err := tx.ExecContext(ctx, "DELETE FROM repo_redirects WHERE name=$1", op.Name); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO repo_redirects(name, repo_id) VALUES($1, $2) ON CONFLICT (name) DO UPDATE SET repo_id=excluded.repo_id, created_at=now()", repo.Name, repo.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetByRedirect returns the repository that was previously named name (see
+// Upsert), or an error that satisfies errcode.IsNotFound if there is none.
+func (s *repos) GetByRedirect(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+	if Mocks.Repos.GetByRedirect != nil {
+		return Mocks.Repos.GetByRedirect(ctx, name)
+	}
+
+	repos, err := s.getBySQL(ctx, sqlf.Sprintf("WHERE id=(SELECT repo_id FROM repo_redirects WHERE name=%s) LIMIT 1", name))
+	if err != nil {
+		return nil, err
+	}
+	if len(repos) == 0 {
+		return nil, &repoNotFoundErr{Name: name}
+	}
+	return repos[0], nil
+}
+
 // dbExternalRepoSpec is convenience type for inserting or selecting *api.ExternalRepoSpec database data.
 type dbExternalRepoSpec struct{ id, serviceType, serviceID *string }
 
@@ -142,6 +142,26 @@ func TestRepos_List_fork(t *testing.T) {
 	}
 }
 
+func TestRepos_List_names(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	ctx := dbtesting.TestContext(t)
+
+	ctx = actor.WithActor(ctx, &actor.Actor{})
+
+	a := mustCreate(ctx, t, &types.Repo{Name: "a/r"})
+	mustCreate(ctx, t, &types.Repo{Name: "b/r"})
+	c := mustCreate(ctx, t, &types.Repo{Name: "c/r"})
+
+	repos, err := Repos.List(ctx, ReposListOptions{Enabled: true, Names: []api.RepoName{"a/r", "c/r", "d/r"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSONEqual(t, append(append([]*types.Repo(nil), a...), c...), repos)
+}
+
 func TestRepos_List_pagination(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -10,12 +10,14 @@ import (
 )
 
 type MockRepos struct {
-	Get       func(ctx context.Context, repo api.RepoID) (*types.Repo, error)
-	GetByName func(ctx context.Context, repo api.RepoName) (*types.Repo, error)
-	List      func(v0 context.Context, v1 ReposListOptions) ([]*types.Repo, error)
-	Delete    func(ctx context.Context, repo api.RepoID) error
-	Count     func(ctx context.Context, opt ReposListOptions) (int, error)
-	Upsert    func(api.InsertRepoOp) error
+	Get               func(ctx context.Context, repo api.RepoID) (*types.Repo, error)
+	GetByName         func(ctx context.Context, repo api.RepoName) (*types.Repo, error)
+	GetByRedirect     func(ctx context.Context, repo api.RepoName) (*types.Repo, error)
+	GetByExternalRepo func(ctx context.Context, spec api.ExternalRepoSpec) (*types.Repo, error)
+	List              func(v0 context.Context, v1 ReposListOptions) ([]*types.Repo, error)
+	Delete            func(ctx context.Context, repo api.RepoID) error
+	Count             func(ctx context.Context, opt ReposListOptions) (int, error)
+	Upsert            func(api.InsertRepoOp) error
 }
 
 func (s *MockRepos) MockGet(t *testing.T, wantRepo api.RepoID) (called *bool) {
@@ -2,15 +2,30 @@ package db
 
 import (
 	"context"
+	"strconv"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
 	log15 "gopkg.in/inconshreveable/log15.v2"
 )
 
+var storedPermsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "authz",
+	Name:      "stored_perms_checks",
+	Help:      "Number of repository permission checks against permissions synced in the background, by whether the stored permissions were fresh enough to be used.",
+}, []string{"fresh"})
+
+func init() {
+	prometheus.MustRegister(storedPermsCounter)
+}
+
 var mockAuthzFilter func(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*types.Repo, error)
 
 // authzFilter is the enforcement mechanism for repository permissions. It accepts a list of repositories
@@ -38,6 +53,14 @@ func authzFilter(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*typ
 		if currentUser.SiteAdmin {
 			return repos, nil
 		}
+
+		filteredRepos, ok, err := authzFilterStored(ctx, currentUser.ID, repos, p)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return filteredRepos, nil
+		}
 	}
 
 	filteredRepoNames, err := getFilteredRepoNames(ctx, currentUser, authz.ToRepos(repos), p)
@@ -54,6 +77,70 @@ func authzFilter(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*typ
 	return filteredRepos, nil
 }
 
+// authzFilterStored is like authzFilter, except that it uses the permissions synced in the
+// background (see UserPermissions) instead of calling the authz providers, which takes a single
+// query. It returns ok == false if background sync is disabled or if the user's stored permissions
+// from an authz provider that owns any of the repositories are missing or older than the maximum
+// staleness. In that case the caller must check the permissions at request time instead.
+func authzFilterStored(ctx context.Context, userID int32, repos []*types.Repo, p authz.Perm) (filtered []*types.Repo, ok bool, err error) {
+	enabled, _, maxStaleness := conf.PermissionsBackgroundSync()
+	authzAllowByDefault, authzProviders := authz.GetProviders()
+	if !enabled || len(authzProviders) == 0 {
+		return nil, false, nil
+	}
+
+	repoIDsByName := make(map[api.RepoName]api.RepoID, len(repos))
+	for _, repo := range repos {
+		repoIDsByName[repo.Name] = repo.ID
+	}
+
+	var owned []ProviderRepo
+	unverified := authz.ToRepos(repos)
+	for _, authzProvider := range authzProviders {
+		if len(unverified) == 0 {
+			break
+		}
+		mine, others := authzProvider.Repos(ctx, unverified)
+		unverified = others
+		for repo := range mine {
+			owned = append(owned, ProviderRepo{
+				RepoID:      repoIDsByName[repo.RepoName],
+				ServiceType: authzProvider.ServiceType(),
+				ServiceID:   authzProvider.ServiceID(),
+			})
+		}
+	}
+
+	accepted := make(map[api.RepoID]struct{})
+	if len(owned) > 0 {
+		repoIDs, ok, err := UserPermissions.Authorized(ctx, userID, p, owned, time.Now().Add(-maxStaleness))
+		if err != nil {
+			return nil, false, err
+		}
+		storedPermsCounter.WithLabelValues(strconv.FormatBool(ok)).Inc()
+		if !ok {
+			return nil, false, nil
+		}
+		for _, id := range repoIDs {
+			accepted[id] = struct{}{}
+		}
+	}
+
+	if authzAllowByDefault {
+		for repo := range unverified {
+			accepted[repoIDsByName[repo.RepoName]] = struct{}{}
+		}
+	}
+
+	filtered = make([]*types.Repo, 0, len(accepted))
+	for _, repo := range repos {
+		if _, ok := accepted[repo.ID]; ok {
+			filtered = append(filtered, repo)
+		}
+	}
+	return filtered, true, nil
+}
+
 // isInternalActor returns true if the actor represents an internal agent (i.e., non-user-bound
 // request that originates from within Sourcegraph itself).
 //
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func Test_authzFilter_storedPerms(t *testing.T) {
+	defer func() { Mocks = MockStores{} }()
+	defer authz.SetProviders(true, nil)
+	defer conf.Mock(nil)
+
+	// At request time, the provider only grants access to r2, so that the tests
+	// can tell whether the stored permissions were used.
+	authz.SetProviders(true, []authz.Provider{
+		&MockAuthzProvider{
+			serviceID:   "https://gitlab.mine/",
+			serviceType: "gitlab",
+			repos: map[api.RepoName]struct{}{
+				"gitlab.mine/r1": {},
+				"gitlab.mine/r2": {},
+				"gitlab.mine/r3": {},
+			},
+			perms: map[extsvc.ExternalAccount]map[api.RepoName]map[authz.Perm]bool{
+				{}: {"gitlab.mine/r2": {authz.Read: true}},
+			},
+		},
+	})
+	user := &types.User{ID: 1}
+	Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) { return user, nil }
+	Mocks.ExternalAccounts.List = func(ExternalAccountsListOptions) ([]*extsvc.ExternalAccount, error) { return nil, nil }
+
+	repos := []*types.Repo{
+		{ID: 1, Name: "gitlab.mine/r1"},
+		{ID: 2, Name: "gitlab.mine/r2"},
+		{ID: 3, Name: "gitlab.mine/r3"},
+		{ID: 4, Name: "github.com/foo/bar"},
+	}
+	stored := func(age time.Duration, repoIDs ...api.RepoID) []*UserRepoPermissions {
+		return []*UserRepoPermissions{{
+			UserID:      user.ID,
+			Perm:        authz.Read,
+			ServiceType: "gitlab",
+			ServiceID:   "https://gitlab.mine/",
+			RepoIDs:     repoIDs,
+			UpdatedAt:   time.Now().Add(-age),
+		}}
+	}
+
+	tests := []struct {
+		description string
+		enabled     bool
+		stored      []*UserRepoPermissions
+		want        []api.RepoName
+	}{
+		{
+			description: "fresh stored permissions are used",
+			enabled:     true,
+			stored:      stored(time.Minute, 1, 3),
+			want:        []api.RepoName{"gitlab.mine/r1", "gitlab.mine/r3", "github.com/foo/bar"},
+		},
+		{
+			description: "stale stored permissions fall back to request time",
+			enabled:     true,
+			stored:      stored(2*time.Hour, 1, 3),
+			want:        []api.RepoName{"gitlab.mine/r2", "github.com/foo/bar"},
+		},
+		{
+			description: "missing stored permissions fall back to request time",
+			enabled:     true,
+			want:        []api.RepoName{"gitlab.mine/r2", "github.com/foo/bar"},
+		},
+		{
+			description: "stored permissions are ignored if background sync is disabled",
+			stored:      stored(time.Minute, 1, 3),
+			want:        []api.RepoName{"gitlab.mine/r2", "github.com/foo/bar"},
+		},
+	}
+	for _, test := range tests {
+		conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+			PermissionsBackgroundSync: &schema.PermissionsBackgroundSync{Enabled: test.enabled, MaxStaleness: "1h"},
+		}})
+		Mocks.UserPermissions.Authorized = func(ctx context.Context, userID int32, perm authz.Perm, owned []ProviderRepo, updatedAfter time.Time) ([]api.RepoID, bool, error) {
+			if userID != user.ID || perm != authz.Read || len(owned) != 3 {
+				t.Fatalf("unexpected call Authorized(%d, %q, %v)", userID, perm, owned)
+			}
+			var repoIDs []api.RepoID
+			for _, r := range owned {
+				var synced *UserRepoPermissions
+				for _, p := range test.stored {
+					if p.ServiceType == r.ServiceType && p.ServiceID == r.ServiceID && !p.UpdatedAt.Before(updatedAfter) {
+						synced = p
+					}
+				}
+				if synced == nil {
+					return nil, false, nil
+				}
+				for _, id := range synced.RepoIDs {
+					if id == r.RepoID {
+						repoIDs = append(repoIDs, id)
+					}
+				}
+			}
+			return repoIDs, true, nil
+		}
+
+		ctx := actor.WithActor(context.Background(), &actor.Actor{UID: user.ID})
+		filtered, err := authzFilter(ctx, repos, authz.Read)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []api.RepoName
+		for _, repo := range filtered {
+			got = append(got, repo.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.description, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
@@ -170,3 +171,58 @@ func TestRepos_Upsert(t *testing.T) {
 		t.Fatalf("rp.Name: %q != %q", rp.Description, "asdfasdf")
 	}
 }
+
+func TestRepos_Upsert_rename(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	var renamedFrom, renamedTo api.RepoName
+	defer func(orig func(context.Context, api.RepoName, api.RepoName) error) { renameRepoClone = orig }(renameRepoClone)
+	renameRepoClone = func(ctx context.Context, from, to api.RepoName) error {
+		renamedFrom, renamedTo = from, to
+		return nil
+	}
+
+	ext := &api.ExternalRepoSpec{ID: "r1", ServiceType: "github", ServiceID: "https://github.com/"}
+	if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: "github.com/old/r", Enabled: true, ExternalRepo: ext}); err != nil {
+		t.Fatal(err)
+	}
+	old, err := Repos.GetByName(ctx, "github.com/old/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: "github.com/new/r", Description: "d", Enabled: true, ExternalRepo: ext}); err != nil {
+		t.Fatal(err)
+	}
+
+	rp, err := Repos.GetByName(ctx, "github.com/new/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rp.ID != old.ID {
+		t.Errorf("got repo ID %d, want %d", rp.ID, old.ID)
+	}
+	if rp.Description != "d" {
+		t.Errorf("rp.Description: %q != %q", rp.Description, "d")
+	}
+	if _, err := Repos.GetByName(ctx, "github.com/old/r"); !errcode.IsNotFound(err) {
+		t.Errorf("got err %v, want not found", err)
+	}
+	if renamedFrom != "github.com/old/r" || renamedTo != "github.com/new/r" {
+		t.Errorf("got clone renamed from %q to %q", renamedFrom, renamedTo)
+	}
+
+	redirected, err := Repos.GetByRedirect(ctx, "github.com/old/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redirected.ID != old.ID {
+		t.Errorf("got redirect to repo ID %d, want %d", redirected.ID, old.ID)
+	}
+	if _, err := Repos.GetByRedirect(ctx, "github.com/new/r"); !errcode.IsNotFound(err) {
+		t.Errorf("got err %v, want not found", err)
+	}
+}
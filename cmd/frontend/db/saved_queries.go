@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
 )
@@ -16,6 +17,10 @@ type SavedQueryInfo struct {
 	LastExecuted time.Time
 	LatestResult time.Time
 	ExecDuration time.Duration
+
+	// ResultFingerprints are the fingerprints of the query's search results,
+	// or nil if they were never recorded.
+	ResultFingerprints []string
 }
 
 // Get gets the saved query information for the given query. nil
@@ -27,9 +32,9 @@ func (s *savedQueries) Get(ctx context.Context, query string) (*SavedQueryInfo,
 	var execDurationNs int64
 	err := dbconn.Global.QueryRowContext(
 		ctx,
-		"SELECT last_executed, latest_result, exec_duration_ns FROM saved_queries WHERE query=$1",
+		"SELECT last_executed, latest_result, exec_duration_ns, result_fingerprints FROM saved_queries WHERE query=$1",
 		query,
-	).Scan(&info.LastExecuted, &info.LatestResult, &execDurationNs)
+	).Scan(&info.LastExecuted, &info.LatestResult, &execDurationNs, pq.Array(&info.ResultFingerprints))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -47,10 +52,11 @@ func (s *savedQueries) Get(ctx context.Context, query string) (*SavedQueryInfo,
 func (s *savedQueries) Set(ctx context.Context, info *SavedQueryInfo) error {
 	res, err := dbconn.Global.ExecContext(
 		ctx,
-		"UPDATE saved_queries SET last_executed=$1, latest_result=$2, exec_duration_ns=$3 WHERE query=$4",
+		"UPDATE saved_queries SET last_executed=$1, latest_result=$2, exec_duration_ns=$3, result_fingerprints=$4 WHERE query=$5",
 		info.LastExecuted,
 		info.LatestResult,
 		int64(info.ExecDuration),
+		pq.Array(info.ResultFingerprints),
 		info.Query,
 	)
 	if err != nil {
@@ -64,11 +70,12 @@ func (s *savedQueries) Set(ctx context.Context, info *SavedQueryInfo) error {
 		// Didn't update any row, so insert a new one.
 		_, err := dbconn.Global.ExecContext(
 			ctx,
-			"INSERT INTO saved_queries(query, last_executed, latest_result, exec_duration_ns) VALUES($1, $2, $3, $4)",
+			"INSERT INTO saved_queries(query, last_executed, latest_result, exec_duration_ns, result_fingerprints) VALUES($1, $2, $3, $4, $5)",
 			info.Query,
 			info.LastExecuted,
 			info.LatestResult,
 			int64(info.ExecDuration),
+			pq.Array(info.ResultFingerprints),
 		)
 		if err != nil {
 			return errors.Wrap(err, "INSERT")
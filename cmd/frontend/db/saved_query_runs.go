@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// savedQueryRuns provides access to the `saved_query_runs` table, which is the
+// run history of saved queries.
+//
+// Runs are keyed by the saved query (its settings subject and key) and its
+// search query, so that a saved query's history only includes its own runs of
+// its current search query.
+type savedQueryRuns struct{}
+
+// maxSavedQueryRuns is the number of most recent runs of a saved query that
+// are kept.
+const maxSavedQueryRuns = 100
+
+// SavedQueryRun describes a single run of a saved query.
+type SavedQueryRun struct {
+	ID           int64
+	Spec         api.SavedQueryIDSpec
+	Query        string
+	StartedAt    time.Time
+	ExecDuration time.Duration
+	ResultCount  *int32  // nil if the search failed or the count may not be shown
+	Error        *string // nil if the search succeeded
+	Notified     bool    // whether notifications of new results were sent
+}
+
+// savedQueryRunsCond returns the condition matching the runs of the saved
+// query with the given spec and search query.
+func savedQueryRunsCond(spec api.SavedQueryIDSpec, query string) *sqlf.Query {
+	var subject *sqlf.Query
+	switch {
+	case spec.Subject.Org != nil:
+		subject = sqlf.Sprintf("org_id=%d AND user_id IS NULL", *spec.Subject.Org)
+	case spec.Subject.User != nil:
+		subject = sqlf.Sprintf("user_id=%d AND org_id IS NULL", *spec.Subject.User)
+	default:
+		// No org and no user represents global site settings.
+		subject = sqlf.Sprintf("user_id IS NULL AND org_id IS NULL")
+	}
+	return sqlf.Sprintf("query=%s AND key=%s AND %s", query, spec.Key, subject)
+}
+
+// Create records a run of a saved query. The saved query info for run.Query
+// must already exist (see savedQueries.Set).
+//
+// Only the maxSavedQueryRuns most recent runs of each saved query are kept.
+func (*savedQueryRuns) Create(ctx context.Context, run *SavedQueryRun) error {
+	if run.ID != 0 {
+		return errors.New("run.ID must be zero")
+	}
+	err := dbconn.Global.QueryRowContext(ctx, `INSERT INTO saved_query_runs(
+		query,
+		user_id,
+		org_id,
+		key,
+		started_at,
+		exec_duration_ns,
+		result_count,
+		error,
+		notified
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		run.Query,
+		run.Spec.Subject.User,
+		run.Spec.Subject.Org,
+		run.Spec.Key,
+		run.StartedAt,
+		int64(run.ExecDuration),
+		run.ResultCount,
+		run.Error,
+		run.Notified,
+	).Scan(&run.ID)
+	if err != nil {
+		return errors.Wrap(err, "INSERT")
+	}
+
+	cond := savedQueryRunsCond(run.Spec, run.Query)
+	q := sqlf.Sprintf(`
+		DELETE FROM saved_query_runs
+		WHERE %s AND id NOT IN (SELECT id FROM saved_query_runs WHERE %s ORDER BY id DESC LIMIT %s)`,
+		cond, cond, maxSavedQueryRuns)
+	_, err = dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	return errors.Wrap(err, "DELETE")
+}
+
+// List lists the runs of the saved query with the given spec and search query,
+// most recent first.
+func (*savedQueryRuns) List(ctx context.Context, spec api.SavedQueryIDSpec, query string, opt *LimitOffset) ([]*SavedQueryRun, error) {
+	q := sqlf.Sprintf(`
+		SELECT id, user_id, org_id, key, query, started_at, exec_duration_ns, result_count, error, notified
+		FROM saved_query_runs
+		WHERE %s
+		ORDER BY id DESC
+		%s`,
+		savedQueryRunsCond(spec, query), opt.SQL())
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []*SavedQueryRun{}
+	for rows.Next() {
+		var (
+			run            SavedQueryRun
+			execDurationNs int64
+		)
+		if err := rows.Scan(&run.ID, &run.Spec.Subject.User, &run.Spec.Subject.Org, &run.Spec.Key, &run.Query, &run.StartedAt, &execDurationNs, &run.ResultCount, &run.Error, &run.Notified); err != nil {
+			return nil, err
+		}
+		run.Spec.Subject.Site = run.Spec.Subject.User == nil && run.Spec.Subject.Org == nil
+		run.ExecDuration = time.Duration(execDurationNs)
+		runs = append(runs, &run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// Count counts the runs of the saved query with the given spec and search
+// query.
+func (*savedQueryRuns) Count(ctx context.Context, spec api.SavedQueryIDSpec, query string) (int, error) {
+	q := sqlf.Sprintf("SELECT count(*) FROM saved_query_runs WHERE %s", savedQueryRunsCond(spec, query))
+	var count int
+	err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count)
+	return count, err
+}
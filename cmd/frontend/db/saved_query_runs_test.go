@@ -0,0 +1,90 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestSavedQueryRuns(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	user, err := Users.Create(ctx, NewUser{
+		Email:                 "a@example.com",
+		Username:              "u",
+		Password:              "p",
+		EmailVerificationCode: "c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := api.SavedQueryIDSpec{Subject: api.SettingsSubject{User: &user.ID}, Key: "k"}
+	siteSpec := api.SavedQueryIDSpec{Subject: api.SettingsSubject{Site: true}, Key: "k"}
+
+	const query = "type:diff foo"
+	if err := SavedQueries.Set(ctx, &SavedQueryInfo{Query: query, LastExecuted: time.Now(), LatestResult: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	errMsg := "search failed"
+	resultCount := int32(3)
+	for i := 0; i < maxSavedQueryRuns+2; i++ {
+		run := &SavedQueryRun{Spec: spec, Query: query, StartedAt: time.Now(), ExecDuration: time.Second}
+		if i%2 == 0 {
+			run.ResultCount = &resultCount
+			run.Notified = true
+		} else {
+			run.Error = &errMsg
+		}
+		if err := SavedQueryRuns.Create(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Runs of other saved queries with the same search query are kept
+	// separately.
+	if err := SavedQueryRuns.Create(ctx, &SavedQueryRun{Spec: siteSpec, Query: query, StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := SavedQueryRuns.Count(ctx, siteSpec, query); err != nil {
+		t.Fatal(err)
+	} else if count != 1 {
+		t.Errorf("got count %d for other saved query, want 1", count)
+	}
+
+	// Only the most recent runs are kept.
+	if count, err := SavedQueryRuns.Count(ctx, spec, query); err != nil {
+		t.Fatal(err)
+	} else if count != maxSavedQueryRuns {
+		t.Errorf("got count %d, want %d", count, maxSavedQueryRuns)
+	}
+
+	runs, err := SavedQueryRuns.List(ctx, spec, query, &LimitOffset{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if r := runs[0]; r.Spec.Key != spec.Key || r.Spec.Subject.User == nil || *r.Spec.Subject.User != user.ID || r.Error == nil || *r.Error != errMsg || r.ResultCount != nil || r.Notified || r.ExecDuration != time.Second {
+		t.Errorf("unexpected most recent run %+v", r)
+	}
+	if r := runs[1]; r.Error != nil || r.ResultCount == nil || *r.ResultCount != resultCount || !r.Notified {
+		t.Errorf("unexpected run %+v", r)
+	}
+
+	// Deleting the saved query info deletes its runs.
+	if err := SavedQueries.Delete(ctx, query); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := SavedQueryRuns.Count(ctx, spec, query); err != nil {
+		t.Fatal(err)
+	} else if count != 0 {
+		t.Errorf("got count %d after deleting, want 0", count)
+	}
+}
@@ -9,6 +9,7 @@ var (
 	Repos                     = &repos{}
 	Phabricator               = &phabricator{}
 	SavedQueries              = &savedQueries{}
+	SavedQueryRuns            = &savedQueryRuns{}
 	Orgs                      = &orgs{}
 	OrgMembers                = &orgMembers{}
 	Settings                  = &settings{}
@@ -19,5 +20,9 @@ var (
 
 	ExternalAccounts = &userExternalAccounts{}
 
+	UserPermissions = &userPermissions{}
+
 	OrgInvitations = &orgInvitations{}
+
+	DiscussionNotificationDeliveries = &discussionNotificationDeliveries{}
 )
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// UserRepoPermissions is the set of repositories on which a user has a permission, as last synced
+// from a single authz provider (identified by its service type and ID).
+type UserRepoPermissions struct {
+	UserID      int32
+	Perm        authz.Perm
+	ServiceType string
+	ServiceID   string
+	RepoIDs     []api.RepoID
+	UpdatedAt   time.Time
+}
+
+// userPermissions provides access to the `user_permissions` table, which stores repository
+// permissions synced in the background from authz providers.
+//
+// For a detailed overview of the schema, see schema.md.
+type userPermissions struct{}
+
+// Upsert stores the given permissions, replacing the permissions previously synced for the same
+// user, permission and authz provider. It sets p.UpdatedAt to the time of the update.
+func (*userPermissions) Upsert(ctx context.Context, p *UserRepoPermissions) error {
+	if Mocks.UserPermissions.Upsert != nil {
+		return Mocks.UserPermissions.Upsert(ctx, p)
+	}
+
+	q := sqlf.Sprintf(`
+INSERT INTO user_permissions(user_id, permission, object_type, object_ids, service_type, service_id, updated_at)
+VALUES(%s, %s, 'repos', %s, %s, %s, now())
+ON CONFLICT ON CONSTRAINT user_permissions_unique DO UPDATE SET object_ids=excluded.object_ids, updated_at=excluded.updated_at
+RETURNING updated_at`,
+		p.UserID, string(p.Perm), toInt64Array(p.RepoIDs), p.ServiceType, p.ServiceID,
+	)
+	return dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&p.UpdatedAt)
+}
+
+// List returns the permissions of the user stored for each authz provider. The RepoIDs of each
+// returned entry are restricted to the given repositories, so that checking the permissions of a
+// list of repositories takes a single query regardless of how many repositories the user can access.
+func (*userPermissions) List(ctx context.Context, userID int32, perm authz.Perm, repoIDs []api.RepoID) ([]*UserRepoPermissions, error) {
+	if Mocks.UserPermissions.List != nil {
+		return Mocks.UserPermissions.List(ctx, userID, perm, repoIDs)
+	}
+
+	q := sqlf.Sprintf(`
+SELECT service_type, service_id, ARRAY(SELECT unnest(object_ids) INTERSECT SELECT unnest(%s::integer[])), updated_at
+FROM user_permissions
+WHERE user_id=%s AND permission=%s AND object_type='repos'
+ORDER BY service_type, service_id`,
+		toInt64Array(repoIDs), userID, string(perm),
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var perms []*UserRepoPermissions
+	for rows.Next() {
+		p := UserRepoPermissions{UserID: userID, Perm: perm}
+		var ids pq.Int64Array
+		if err := rows.Scan(&p.ServiceType, &p.ServiceID, &ids, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			p.RepoIDs = append(p.RepoIDs, api.RepoID(id))
+		}
+		perms = append(perms, &p)
+	}
+	return perms, rows.Err()
+}
+
+// ProviderRepo is a repository along with the authz provider that owns it.
+type ProviderRepo struct {
+	RepoID      api.RepoID
+	ServiceType string
+	ServiceID   string
+}
+
+// Authorized returns the IDs of the given repositories on which the user has the permission,
+// according to the permissions stored for the authz provider that owns each repository. It takes a
+// single query that joins the repositories with the stored permissions. It returns ok == false if
+// the stored permissions of the user from any of those authz providers are missing or were last
+// synced before updatedAfter, in which case they can't be used.
+func (*userPermissions) Authorized(ctx context.Context, userID int32, perm authz.Perm, repos []ProviderRepo, updatedAfter time.Time) (repoIDs []api.RepoID, ok bool, err error) {
+	if Mocks.UserPermissions.Authorized != nil {
+		return Mocks.UserPermissions.Authorized(ctx, userID, perm, repos, updatedAfter)
+	}
+
+	ids := make(pq.Int64Array, len(repos))
+	serviceTypes := make(pq.StringArray, len(repos))
+	serviceIDs := make(pq.StringArray, len(repos))
+	for i, r := range repos {
+		ids[i] = int64(r.RepoID)
+		serviceTypes[i] = r.ServiceType
+		serviceIDs[i] = r.ServiceID
+	}
+
+	q := sqlf.Sprintf(`
+SELECT r.repo_id, p.user_id IS NOT NULL, COALESCE(r.repo_id = ANY(p.object_ids), false)
+FROM unnest(%s::integer[], %s::text[], %s::text[]) AS r(repo_id, service_type, service_id)
+LEFT JOIN user_permissions p ON p.service_type=r.service_type AND p.service_id=r.service_id
+	AND p.user_id=%s AND p.permission=%s AND p.object_type='repos' AND p.updated_at >= %s
+ORDER BY r.repo_id`,
+		ids, serviceTypes, serviceIDs, userID, string(perm), updatedAfter,
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	ok = true
+	for rows.Next() {
+		var (
+			id                int32
+			synced, permitted bool
+		)
+		if err := rows.Scan(&id, &synced, &permitted); err != nil {
+			return nil, false, err
+		}
+		if !synced {
+			ok = false
+		}
+		if permitted {
+			repoIDs = append(repoIDs, api.RepoID(id))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		return nil, false, nil
+	}
+	return repoIDs, true, nil
+}
+
+// ListStaleUsers returns the IDs of up to limit users whose permissions have never been synced or
+// were least recently synced before updatedBefore, least recently synced first. Site admins and
+// deleted users are excluded, because their permissions are never checked.
+func (*userPermissions) ListStaleUsers(ctx context.Context, perm authz.Perm, updatedBefore time.Time, limit int) ([]int32, error) {
+	if Mocks.UserPermissions.ListStaleUsers != nil {
+		return Mocks.UserPermissions.ListStaleUsers(ctx, perm, updatedBefore, limit)
+	}
+
+	q := sqlf.Sprintf(`
+SELECT u.id FROM users u
+LEFT JOIN user_permissions p ON p.user_id=u.id AND p.permission=%s AND p.object_type='repos'
+WHERE u.deleted_at IS NULL AND NOT u.site_admin
+GROUP BY u.id
+HAVING MIN(p.updated_at) IS NULL OR MIN(p.updated_at) < %s
+ORDER BY MIN(p.updated_at) ASC NULLS FIRST, u.id
+LIMIT %s`,
+		string(perm), updatedBefore, limit,
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, rows.Err()
+}
+
+func toInt64Array(ids []api.RepoID) pq.Int64Array {
+	a := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		a[i] = int64(id)
+	}
+	return a
+}
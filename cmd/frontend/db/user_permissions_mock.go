@@ -0,0 +1,16 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+type MockUserPermissions struct {
+	Upsert         func(ctx context.Context, p *UserRepoPermissions) error
+	List           func(ctx context.Context, userID int32, perm authz.Perm, repoIDs []api.RepoID) ([]*UserRepoPermissions, error)
+	Authorized     func(ctx context.Context, userID int32, perm authz.Perm, repos []ProviderRepo, updatedAfter time.Time) ([]api.RepoID, bool, error)
+	ListStaleUsers func(ctx context.Context, perm authz.Perm, updatedBefore time.Time, limit int) ([]int32, error)
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestUserPermissions_Authorized(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	user, err := Users.Create(ctx, NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UserPermissions.Upsert(ctx, &UserRepoPermissions{
+		UserID:      user.ID,
+		Perm:        authz.Read,
+		ServiceType: "gitlab",
+		ServiceID:   "https://gitlab.mine/",
+		RepoIDs:     []api.RepoID{1, 3},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	gitlab := func(id api.RepoID) ProviderRepo {
+		return ProviderRepo{RepoID: id, ServiceType: "gitlab", ServiceID: "https://gitlab.mine/"}
+	}
+	tests := []struct {
+		description  string
+		repos        []ProviderRepo
+		updatedAfter time.Time
+		wantRepoIDs  []api.RepoID
+		wantOK       bool
+	}{
+		{
+			description:  "stored permissions are used",
+			repos:        []ProviderRepo{gitlab(1), gitlab(2), gitlab(3)},
+			updatedAfter: time.Now().Add(-time.Hour),
+			wantRepoIDs:  []api.RepoID{1, 3},
+			wantOK:       true,
+		},
+		{
+			description:  "stale stored permissions are not used",
+			repos:        []ProviderRepo{gitlab(1), gitlab(2), gitlab(3)},
+			updatedAfter: time.Now().Add(time.Hour),
+		},
+		{
+			description: "missing stored permissions of another provider are not used",
+			repos: []ProviderRepo{
+				gitlab(1),
+				{RepoID: 4, ServiceType: "github", ServiceID: "https://github.com/"},
+			},
+			updatedAfter: time.Now().Add(-time.Hour),
+		},
+	}
+	for _, test := range tests {
+		repoIDs, ok, err := UserPermissions.Authorized(ctx, user.ID, authz.Read, test.repos, test.updatedAfter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != test.wantOK || !reflect.DeepEqual(repoIDs, test.wantRepoIDs) {
+			t.Errorf("%s: got %v, %v, want %v, %v", test.description, repoIDs, ok, test.wantRepoIDs, test.wantOK)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package graphqlbackend
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func (*schemaResolver) DiscussionNotificationDeliveries(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+	Failed bool
+}) (*discussionNotificationDeliveriesConnectionResolver, error) {
+	// 🚨 SECURITY: Only site admins can view the notification log, because it
+	// reveals the activity in threads and the settings of users.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	opt := &db.DiscussionNotificationDeliveriesListOptions{Failed: args.Failed}
+	args.ConnectionArgs.Set(&opt.LimitOffset)
+	return &discussionNotificationDeliveriesConnectionResolver{opt: opt}, nil
+}
+
+// 🚨 SECURITY: When instantiating a
+// discussionNotificationDeliveriesConnectionResolver value, the caller MUST
+// check that the current user is a site admin.
+type discussionNotificationDeliveriesConnectionResolver struct {
+	opt *db.DiscussionNotificationDeliveriesListOptions
+
+	// cache results because they are used by multiple fields
+	once       sync.Once
+	deliveries []*types.DiscussionNotificationDelivery
+	err        error
+}
+
+func (r *discussionNotificationDeliveriesConnectionResolver) compute(ctx context.Context) ([]*types.DiscussionNotificationDelivery, error) {
+	r.once.Do(func() {
+		opt2 := *r.opt
+		if opt2.LimitOffset != nil {
+			tmp := *opt2.LimitOffset
+			opt2.LimitOffset = &tmp
+			opt2.Limit++ // so we can detect if there is a next page
+		}
+
+		r.deliveries, r.err = db.DiscussionNotificationDeliveries.List(ctx, &opt2)
+	})
+	return r.deliveries, r.err
+}
+
+func (r *discussionNotificationDeliveriesConnectionResolver) Nodes(ctx context.Context) ([]*discussionNotificationDeliveryResolver, error) {
+	deliveries, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.LimitOffset != nil && len(deliveries) > r.opt.Limit {
+		deliveries = deliveries[:r.opt.Limit]
+	}
+
+	var l []*discussionNotificationDeliveryResolver
+	for _, d := range deliveries {
+		l = append(l, &discussionNotificationDeliveryResolver{d: d})
+	}
+	return l, nil
+}
+
+func (r *discussionNotificationDeliveriesConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	withoutLimit := *r.opt
+	withoutLimit.LimitOffset = nil
+	count, err := db.DiscussionNotificationDeliveries.Count(ctx, &withoutLimit)
+	return int32(count), err
+}
+
+func (r *discussionNotificationDeliveriesConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	deliveries, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && len(deliveries) > r.opt.Limit), nil
+}
+
+var (
+	discussionNotificationEvents = map[types.DiscussionNotificationEvent]string{
+		types.DiscussionNotificationEventThreadCreated: "THREAD_CREATED",
+		types.DiscussionNotificationEventCommentAdded:  "COMMENT_ADDED",
+		types.DiscussionNotificationEventMention:       "MENTION",
+	}
+	discussionNotificationTargetTypes = map[string]string{
+		"slack":          "SLACK",
+		"microsoftTeams": "MICROSOFT_TEAMS",
+		"webhook":        "WEBHOOK",
+	}
+)
+
+// 🚨 SECURITY: When instantiating a discussionNotificationDeliveryResolver
+// value, the caller MUST check that the current user is a site admin.
+type discussionNotificationDeliveryResolver struct {
+	d *types.DiscussionNotificationDelivery
+}
+
+func (r *discussionNotificationDeliveryResolver) Event() string {
+	return discussionNotificationEvents[r.d.Event]
+}
+
+func (r *discussionNotificationDeliveryResolver) Thread(ctx context.Context) (*discussionThreadResolver, error) {
+	thread, err := db.DiscussionThreads.Get(ctx, r.d.ThreadID)
+	if _, ok := err.(*db.ErrThreadNotFound); ok {
+		return nil, nil // the thread was deleted
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "DiscussionThreads.Get")
+	}
+	return &discussionThreadResolver{t: thread}, nil
+}
+
+func (r *discussionNotificationDeliveryResolver) Comment(ctx context.Context) (*discussionCommentResolver, error) {
+	comment, err := db.DiscussionComments.Get(ctx, r.d.CommentID)
+	if _, ok := err.(*db.ErrCommentNotFound); ok {
+		return nil, nil // the comment was deleted
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "DiscussionComments.Get")
+	}
+	return &discussionCommentResolver{c: comment}, nil
+}
+
+func (r *discussionNotificationDeliveryResolver) Subject(ctx context.Context) (*settingsSubject, error) {
+	if r.d.OrgID != nil {
+		org, err := OrgByIDInt32(ctx, *r.d.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		return &settingsSubject{org: org}, nil
+	}
+	user, err := UserByIDInt32(ctx, *r.d.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &settingsSubject{user: user}, nil
+}
+
+func (r *discussionNotificationDeliveryResolver) TargetType() string {
+	return discussionNotificationTargetTypes[r.d.TargetType]
+}
+
+func (r *discussionNotificationDeliveryResolver) TargetHost() string {
+	u, err := url.Parse(r.d.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func (r *discussionNotificationDeliveryResolver) Attempts() int32 { return r.d.Attempts }
+
+func (r *discussionNotificationDeliveryResolver) LastError() *string { return r.d.LastError }
+
+func (r *discussionNotificationDeliveryResolver) NextAttemptAt() *string {
+	if r.d.NextAttemptAt == nil {
+		return nil
+	}
+	return strptr(r.d.NextAttemptAt.Format(time.RFC3339))
+}
+
+func (r *discussionNotificationDeliveryResolver) DeliveredAt() *string {
+	if r.d.DeliveredAt == nil {
+		return nil
+	}
+	return strptr(r.d.DeliveredAt.Format(time.RFC3339))
+}
+
+func (r *discussionNotificationDeliveryResolver) CreatedAt() string {
+	return r.d.CreatedAt.Format(time.RFC3339)
+}
@@ -267,11 +267,22 @@ func (r *discussionsMutationResolver) CreateThread(ctx context.Context, args *st
 	return &discussionThreadResolver{t: thread}, nil
 }
 
+// discussionThreadStatuses maps the GraphQL DiscussionThreadStatus enum
+// values to the thread statuses.
+var discussionThreadStatuses = map[string]types.DiscussionThreadStatus{
+	"OPEN":     types.DiscussionThreadStatusOpen,
+	"RESOLVED": types.DiscussionThreadStatusResolved,
+	"WONT_FIX": types.DiscussionThreadStatusWontFix,
+}
+
 func (r *discussionsMutationResolver) UpdateThread(ctx context.Context, args *struct {
 	Input *struct {
-		ThreadID graphql.ID
-		Archive  *bool
-		Delete   *bool
+		ThreadID  graphql.ID
+		Archive   *bool
+		Delete    *bool
+		Status    *string
+		Assignees *[]graphql.ID
+		Labels    *[]string
 	}
 }) (*discussionThreadResolver, error) {
 	// 🚨 SECURITY: Only signed in users may update a discussion thread.
@@ -296,10 +307,48 @@ func (r *discussionsMutationResolver) UpdateThread(ctx context.Context, args *st
 	if err != nil {
 		return nil, err
 	}
-	thread, err := db.DiscussionThreads.Update(ctx, threadID, &db.DiscussionThreadsUpdateOptions{
+	opts := &db.DiscussionThreadsUpdateOptions{
 		Archive: args.Input.Archive,
 		Delete:  delete,
-	})
+		Labels:  args.Input.Labels,
+	}
+	if args.Input.Status != nil {
+		status, ok := discussionThreadStatuses[*args.Input.Status]
+		if !ok {
+			return nil, fmt.Errorf("invalid discussion thread status %q", *args.Input.Status)
+		}
+		opts.Status = &status
+	}
+
+	// Determine the newly assigned users, so that we can notify them.
+	var newAssignees []int32
+	if args.Input.Assignees != nil {
+		oldThread, err := db.DiscussionThreads.Get(ctx, threadID)
+		if err != nil {
+			return nil, err
+		}
+		oldAssignees := make(map[int32]bool, len(oldThread.AssigneeUserIDs))
+		for _, userID := range oldThread.AssigneeUserIDs {
+			oldAssignees[userID] = true
+		}
+		assignees := []int32{}
+		for _, id := range *args.Input.Assignees {
+			userID, err := UnmarshalUserID(id)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := db.Users.GetByID(ctx, userID); err != nil {
+				return nil, err
+			}
+			assignees = append(assignees, userID)
+			if !oldAssignees[userID] {
+				newAssignees = append(newAssignees, userID)
+			}
+		}
+		opts.AssigneeUserIDs = &assignees
+	}
+
+	thread, err := db.DiscussionThreads.Update(ctx, threadID, opts)
 	if err != nil {
 		return nil, errors.Wrap(err, "DiscussionThreads.Update")
 	}
@@ -307,6 +356,7 @@ func (r *discussionsMutationResolver) UpdateThread(ctx context.Context, args *st
 		// deleted
 		return nil, nil
 	}
+	discussions.NotifyAssigned(thread, currentUser.user.ID, newAssignees)
 	return &discussionThreadResolver{t: thread}, nil
 }
 
@@ -430,12 +480,47 @@ func (r *discussionThreadTargetRepoResolver) Selection() *discussionThreadTarget
 	return &discussionThreadTargetRepoSelectionResolver{t: r.t}
 }
 
+// anchor returns the location of the thread's selection in the given
+// revision, or nil if the selected lines were deleted. It may only be called
+// if the thread has a path, a revision and a selection.
+func (r *discussionThreadTargetRepoResolver) anchor(ctx context.Context, rev string) (*discussions.Anchor, error) {
+	repo, err := repositoryByIDInt32(ctx, r.t.RepoID)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.Commit(ctx, &repositoryCommitArgs{Rev: rev})
+	if err != nil {
+		return nil, err
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, repo.repo)
+	if err != nil {
+		return nil, err
+	}
+	return discussions.TrackAnchor(ctx, *cachedRepo, r.t, api.CommitID(commit.OID()))
+}
+
+// hasAnchor tells if the thread's selection can be tracked precisely through
+// the diffs from the revision it was created on.
+func (r *discussionThreadTargetRepoResolver) hasAnchor() bool {
+	return r.t.Path != nil && r.t.Revision != nil && r.t.HasSelection()
+}
+
 func (r *discussionThreadTargetRepoResolver) RelativePath(ctx context.Context, args *struct {
 	Rev string
 }) (*string, error) {
 	if r.t.Path == nil {
 		return nil, nil
 	}
+	if r.hasAnchor() {
+		anchor, err := r.anchor(ctx, args.Rev)
+		if err != nil {
+			return nil, err
+		}
+		if anchor == nil {
+			return nil, nil // the selected lines were deleted
+		}
+		return &anchor.Path, nil
+	}
 	repo, err := repositoryByIDInt32(ctx, r.t.RepoID)
 	if err != nil {
 		return nil, err
@@ -569,6 +654,21 @@ func (r *discussionThreadTargetRepoResolver) RelativeSelection(ctx context.Conte
 	if !r.t.HasSelection() {
 		return nil, nil
 	}
+	if r.hasAnchor() {
+		anchor, err := r.anchor(ctx, args.Rev)
+		if err != nil {
+			return nil, err
+		}
+		if anchor == nil {
+			return nil, nil // the selected lines were deleted
+		}
+		return &discussionSelectionRangeResolver{
+			startLine:      int32(anchor.Lines.StartLine),
+			startCharacter: *r.t.StartCharacter,
+			endLine:        int32(anchor.Lines.EndLine),
+			endCharacter:   *r.t.EndCharacter,
+		}, nil
+	}
 	path, err := r.RelativePath(ctx, args)
 	if err != nil {
 		return nil, err
@@ -667,6 +767,34 @@ func (d *discussionThreadResolver) ArchivedAt(ctx context.Context) *string {
 	return strptr(d.t.ArchivedAt.Format(time.RFC3339))
 }
 
+func (d *discussionThreadResolver) Status() string {
+	for value, status := range discussionThreadStatuses {
+		if status == d.t.Status {
+			return value
+		}
+	}
+	return "OPEN"
+}
+
+func (d *discussionThreadResolver) Assignees(ctx context.Context) ([]*UserResolver, error) {
+	assignees := make([]*UserResolver, 0, len(d.t.AssigneeUserIDs))
+	for _, userID := range d.t.AssigneeUserIDs {
+		user, err := UserByIDInt32(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		assignees = append(assignees, user)
+	}
+	return assignees, nil
+}
+
+func (d *discussionThreadResolver) Labels() []string {
+	if d.t.Labels == nil {
+		return []string{}
+	}
+	return d.t.Labels
+}
+
 func (d *discussionThreadResolver) Comments(ctx context.Context, args *struct {
 	graphqlutil.ConnectionArgs
 }) *discussionCommentsConnectionResolver {
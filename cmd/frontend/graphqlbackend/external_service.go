@@ -9,6 +9,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"time"
 )
 
@@ -73,3 +74,42 @@ func (r *externalServiceResolver) CreatedAt() string {
 func (r *externalServiceResolver) UpdatedAt() string {
 	return r.externalService.UpdatedAt.Format(time.RFC3339)
 }
+
+func (r *externalServiceResolver) LastSync(ctx context.Context) (*externalServiceSyncStatusResolver, error) {
+	status, err := db.ExternalServices.GetSyncStatus(ctx, r.externalService.ID)
+	if err != nil || status == nil {
+		return nil, err
+	}
+	return &externalServiceSyncStatusResolver{status: status}, nil
+}
+
+type externalServiceSyncStatusResolver struct {
+	status *api.ExternalServiceSyncStatus
+}
+
+func (r *externalServiceSyncStatusResolver) StartedAt() string {
+	return r.status.StartedAt.Format(time.RFC3339)
+}
+
+func (r *externalServiceSyncStatusResolver) FinishedAt() string {
+	return r.status.FinishedAt.Format(time.RFC3339)
+}
+
+func (r *externalServiceSyncStatusResolver) ReposAdded() int32 {
+	return int32(r.status.ReposAdded)
+}
+
+func (r *externalServiceSyncStatusResolver) ReposRemoved() int32 {
+	return int32(r.status.ReposRemoved)
+}
+
+func (r *externalServiceSyncStatusResolver) ReposUnchanged() int32 {
+	return int32(r.status.ReposUnchanged)
+}
+
+func (r *externalServiceSyncStatusResolver) Errors() []string {
+	if r.status.Errors == nil {
+		return []string{}
+	}
+	return r.status.Errors
+}
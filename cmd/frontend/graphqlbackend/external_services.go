@@ -14,6 +14,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
 )
 
 func (r *schemaResolver) AddExternalService(ctx context.Context, args *struct {
@@ -88,7 +89,7 @@ func (*schemaResolver) UpdateExternalService(ctx context.Context, args *struct {
 
 // Eagerly trigger a repo-updater sync.
 func syncExternalService(ctx context.Context, svc *types.ExternalService) error {
-	return repoupdater.DefaultClient.SyncExternalService(ctx, api.ExternalService{
+	_, err := repoupdater.DefaultClient.SyncExternalService(ctx, api.ExternalService{
 		ID:          svc.ID,
 		Kind:        svc.Kind,
 		DisplayName: svc.DisplayName,
@@ -97,8 +98,90 @@ func syncExternalService(ctx context.Context, svc *types.ExternalService) error
 		UpdatedAt:   svc.UpdatedAt,
 		DeletedAt:   svc.DeletedAt,
 	})
+	return err
 }
 
+func (*schemaResolver) DryRunExternalService(ctx context.Context, args *struct {
+	Input *struct {
+		ID     *graphql.ID
+		Kind   string
+		Config string
+	}
+}) (*externalServiceDryRunResolver, error) {
+	// 🚨 SECURITY: Only site admins may dry run external services (the configs have secrets).
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := db.ExternalServices.ValidateConfig(args.Input.Kind, args.Input.Config); err != nil {
+		return nil, err
+	}
+
+	req := protocol.ExternalServiceDryRunRequest{
+		Proposed: api.ExternalService{Kind: args.Input.Kind, Config: args.Input.Config},
+	}
+	if args.Input.ID != nil {
+		id, err := unmarshalExternalServiceID(*args.Input.ID)
+		if err != nil {
+			return nil, err
+		}
+		current, err := db.ExternalServices.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		req.Current = &api.ExternalService{
+			ID:          current.ID,
+			Kind:        current.Kind,
+			DisplayName: current.DisplayName,
+			Config:      current.Config,
+		}
+		req.Proposed.ID = current.ID
+		req.Proposed.DisplayName = current.DisplayName
+	}
+
+	result, err := repoupdater.DefaultClient.DryRunExternalService(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &externalServiceDryRunResolver{result: result}, nil
+}
+
+type externalServiceDryRunResolver struct {
+	result *protocol.ExternalServiceDryRunResult
+}
+
+func (r *externalServiceDryRunResolver) Added() []string {
+	return repoNamesToStrings(r.result.Added)
+}
+
+func (r *externalServiceDryRunResolver) Removed() []string {
+	return repoNamesToStrings(r.result.Removed)
+}
+
+func (r *externalServiceDryRunResolver) Renamed() []*externalServiceDryRunRenameResolver {
+	renamed := make([]*externalServiceDryRunRenameResolver, len(r.result.Renamed))
+	for i, rename := range r.result.Renamed {
+		renamed[i] = &externalServiceDryRunRenameResolver{rename: rename}
+	}
+	return renamed
+}
+
+func (r *externalServiceDryRunResolver) Unchanged() int32 { return int32(r.result.Unchanged) }
+
+func (r *externalServiceDryRunResolver) Errors() []string {
+	if r.result.Errors == nil {
+		return []string{}
+	}
+	return r.result.Errors
+}
+
+type externalServiceDryRunRenameResolver struct {
+	rename protocol.RepoRename
+}
+
+func (r *externalServiceDryRunRenameResolver) From() string { return string(r.rename.From) }
+func (r *externalServiceDryRunRenameResolver) To() string   { return string(r.rename.To) }
+
 func (*schemaResolver) DeleteExternalService(ctx context.Context, args *struct {
 	ExternalService graphql.ID
 }) (*EmptyResponse, error) {
@@ -130,6 +130,14 @@ func reposourceCloneURLToRepoName(ctx context.Context, cloneURL string) (repoNam
 		repoSources = append(repoSources, reposource.GitLab{GitLabConnection: c})
 	}
 
+	bitbucketClouds, err := db.ExternalServices.ListBitbucketCloudConnections(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range bitbucketClouds {
+		repoSources = append(repoSources, reposource.BitbucketCloud{BitbucketCloudConnection: c})
+	}
+
 	bitbuckets, err := db.ExternalServices.ListBitbucketServerConnections(ctx)
 	if err != nil {
 		return "", err
@@ -138,6 +146,14 @@ func reposourceCloneURLToRepoName(ctx context.Context, cloneURL string) (repoNam
 		repoSources = append(repoSources, reposource.BitbucketServer{BitbucketServerConnection: c})
 	}
 
+	giteas, err := db.ExternalServices.ListGiteaConnections(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range giteas {
+		repoSources = append(repoSources, reposource.Gitea{GiteaConnection: c})
+	}
+
 	awscodecommits, err := db.ExternalServices.ListAWSCodeCommitConnections(ctx)
 	if err != nil {
 		return "", err
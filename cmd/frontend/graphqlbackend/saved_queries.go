@@ -22,6 +22,7 @@ type savedQueryResolver struct {
 	description                         string
 	query                               string
 	showOnHomepage, notify, notifySlack bool
+	schedule                            string
 }
 
 func savedQueryByID(ctx context.Context, id graphql.ID) (*savedQueryResolver, error) {
@@ -52,6 +53,10 @@ func savedQueryByID(ctx context.Context, id graphql.ID) (*savedQueryResolver, er
 }
 
 func (r savedQueryResolver) ID() graphql.ID {
+	return marshalSavedQueryID(r.spec())
+}
+
+func (r savedQueryResolver) spec() api.SavedQueryIDSpec {
 	var subject api.SettingsSubject
 	switch {
 	case r.subject.user != nil:
@@ -61,10 +66,10 @@ func (r savedQueryResolver) ID() graphql.ID {
 	case r.subject.site != nil:
 		subject.Site = true
 	}
-	return marshalSavedQueryID(api.SavedQueryIDSpec{
+	return api.SavedQueryIDSpec{
 		Subject: subject,
 		Key:     r.key,
-	})
+	}
 }
 
 func marshalSavedQueryID(spec api.SavedQueryIDSpec) graphql.ID {
@@ -88,6 +93,13 @@ func (r savedQueryResolver) NotifySlack() bool {
 	return r.notifySlack
 }
 
+func (r savedQueryResolver) Schedule() *string {
+	if r.schedule == "" {
+		return nil
+	}
+	return &r.schedule
+}
+
 func (r savedQueryResolver) Subject() *settingsSubject { return r.subject }
 
 func (r savedQueryResolver) Key() *string {
@@ -113,6 +125,7 @@ func toSavedQueryResolver(index int, subject *settingsSubject, entry api.ConfigS
 		showOnHomepage: entry.ShowOnHomepage,
 		notify:         entry.Notify,
 		notifySlack:    entry.NotifySlack,
+		schedule:       entry.Schedule,
 	}
 }
 
@@ -332,3 +345,22 @@ func (r *schemaResolver) SendSavedSearchTestNotification(ctx context.Context, ar
 	go queryrunnerapi.Client.TestNotification(context.Background(), spec)
 	return &EmptyResponse{}, nil
 }
+
+func (r *schemaResolver) RunSavedQuery(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: Look it up to ensure the actor has access to it.
+	if _, err := savedQueryByID(ctx, args.ID); err != nil {
+		return nil, err
+	}
+
+	spec, err := unmarshalSavedQueryID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := queryrunnerapi.Client.RunSavedQuery(ctx, spec); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
@@ -0,0 +1,87 @@
+package graphqlbackend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func (r savedQueryResolver) Runs(ctx context.Context, args *graphqlutil.ConnectionArgs) *savedQueryRunConnectionResolver {
+	var opt *db.LimitOffset
+	args.Set(&opt)
+	return &savedQueryRunConnectionResolver{spec: r.spec(), query: r.query, opt: opt}
+}
+
+type savedQueryRunConnectionResolver struct {
+	spec  api.SavedQueryIDSpec
+	query string
+	opt   *db.LimitOffset
+
+	// cache results because they are used by multiple fields
+	once sync.Once
+	runs []*db.SavedQueryRun
+	err  error
+}
+
+func (r *savedQueryRunConnectionResolver) compute(ctx context.Context) ([]*db.SavedQueryRun, error) {
+	r.once.Do(func() {
+		opt := r.opt
+		if opt != nil {
+			tmp := *opt
+			opt = &tmp
+			opt.Limit++ // so we can detect if there is a next page
+		}
+
+		r.runs, r.err = db.SavedQueryRuns.List(ctx, r.spec, r.query, opt)
+	})
+	return r.runs, r.err
+}
+
+func (r *savedQueryRunConnectionResolver) Nodes(ctx context.Context) ([]*savedQueryRunResolver, error) {
+	runs, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt != nil && len(runs) > r.opt.Limit {
+		runs = runs[:r.opt.Limit]
+	}
+
+	l := make([]*savedQueryRunResolver, len(runs))
+	for i, run := range runs {
+		l[i] = &savedQueryRunResolver{run: run}
+	}
+	return l, nil
+}
+
+func (r *savedQueryRunConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.SavedQueryRuns.Count(ctx, r.spec, r.query)
+	return int32(count), err
+}
+
+func (r *savedQueryRunConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	runs, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt != nil && len(runs) > r.opt.Limit), nil
+}
+
+type savedQueryRunResolver struct {
+	run *db.SavedQueryRun
+}
+
+func (r *savedQueryRunResolver) StartedAt() string { return r.run.StartedAt.Format(time.RFC3339) }
+
+func (r *savedQueryRunResolver) DurationMilliseconds() int32 {
+	return int32(r.run.ExecDuration / time.Millisecond)
+}
+
+func (r *savedQueryRunResolver) ResultCount() *int32 { return r.run.ResultCount }
+
+func (r *savedQueryRunResolver) Error() *string { return r.run.Error }
+
+func (r *savedQueryRunResolver) Notified() bool { return r.run.Notified }
@@ -47,6 +47,11 @@ type Mutation {
     updateExternalService(input: UpdateExternalServiceInput!): ExternalService!
     # Delete an external service. Only site admins may perform this mutation.
     deleteExternalService(externalService: ID!): EmptyResponse!
+    # Lists the repositories of a proposed external service configuration and compares them with
+    # those of the external service's current configuration, without adding, updating or removing
+    # anything. Dry runs are not supported for AWS CodeCommit, Gitolite and Phabricator external
+    # services. Only site admins may perform this mutation.
+    dryRunExternalService(input: DryRunExternalServiceInput!): ExternalServiceDryRun!
     # Enables or disables a repository. A disabled repository is only
     # accessible to site admins and never appears in search results.
     #
@@ -102,6 +107,12 @@ type Mutation {
     #
     # Only site admins may perform this mutation.
     randomizeUserPassword(user: ID!): RandomizeUserPasswordResult!
+    # Syncs the user's repository permissions from the code hosts now, instead of waiting for the next
+    # background sync. This is useful after the user was granted access to repositories on a code host.
+    # It fails if "permissions.backgroundSync" is not enabled in the site configuration.
+    #
+    # Only the user and site admins may perform this mutation.
+    syncUserPermissions(user: ID!): EmptyResponse!
     # Adds an email address to the user's account. The email address will be marked as unverified until the user
     # has followed the email verification process.
     #
@@ -244,6 +255,23 @@ type Mutation {
         # When the diff was created.
         date: String
     ): GitCommit
+    # Replaces the matches of a search query (see Query.searchReplace), and creates a commit with the changes
+    # to each repository on a new branch in Sourcegraph's mirror of the repository. The branches can then be
+    # reviewed and fetched with git. It is an error if the branch already exists in any of the repositories,
+    # or if the search hits the result limit (use "count:" in the query to raise the limit).
+    #
+    # Only site admins may perform this mutation.
+    createCommitsFromSearchReplace(
+        # The search query. It is run as a file content search.
+        query: String!
+        # The replacement for each match (see Query.searchReplace).
+        replacement: String!
+        # The name of the branch to create (such as "replace-foo"). Only letters, digits, "_", "-" and "/" are
+        # allowed.
+        branch: String!
+        # The commit message. Defaults to a description of the replacement.
+        message: String
+    ): [GitCommit!]!
     # Logs a user event.
     logUserEvent(event: UserEvent!, userCookieID: String!): EmptyResponse
     # Sends a test notification for the saved search. Be careful: this will send a notifcation (email and other
@@ -254,6 +282,15 @@ type Mutation {
         # ID of the saved search.
         id: ID!
     ): EmptyResponse
+    # Runs the saved search as soon as possible, regardless of its schedule. It is not run more often than at its
+    # default interval, which is proportional to how long it takes to execute. If there are new results, notifications
+    # are sent to its subscribers as usual. The run is recorded in the saved search's run history.
+    #
+    # Only subscribers to this saved search may perform this action.
+    runSavedQuery(
+        # ID of the saved search.
+        id: ID!
+    ): EmptyResponse
     # All mutations that update settings (global, organization, and user settings) are under this field.
     #
     # Only the settings subject whose settings are being mutated (and site admins) may perform this mutation.
@@ -324,6 +361,16 @@ input UpdateExternalServiceInput {
     config: String
 }
 
+# A proposed configuration of a new or existing external service.
+input DryRunExternalServiceInput {
+    # The id of the existing external service, or null for a new external service.
+    id: ID
+    # The kind of the external service. It must match the kind of an existing external service.
+    kind: ExternalServiceKind!
+    # The proposed JSON configuration of the external service.
+    config: String!
+}
+
 # A selection within a file.
 input DiscussionThreadTargetRepoSelectionInput {
     # The line that the selection started on (zero-based, inclusive).
@@ -434,6 +481,17 @@ input DiscussionThreadUpdateInput {
     # When non-null, indicates that the thread should be deleted. Only admins
     # can perform this action.
     Delete: Boolean
+
+    # When non-null, sets the status of the thread.
+    status: DiscussionThreadStatus
+
+    # When non-null, sets the users (IDs) assigned to the thread, replacing
+    # the existing assignees. Newly assigned users are notified.
+    assignees: [ID!]
+
+    # When non-null, sets the labels of the thread, replacing the existing
+    # labels. Labels must not contain whitespace.
+    labels: [String!]
 }
 
 # Describes an update mutation to an existing comment in a thread.
@@ -743,6 +801,14 @@ type Query {
         # When present, lists only the comments created by this author.
         authorUserID: ID
     ): DiscussionCommentConnection!
+    # Lists the notifications of discussion activity for chat services and
+    # webhooks, most recent first. Only site admins may list them.
+    discussionNotificationDeliveries(
+        # Returns the first n notifications from the list.
+        first: Int
+        # When true, lists only the notifications whose last delivery attempt failed.
+        failed: Boolean = false
+    ): DiscussionNotificationDeliveryConnection!
     # Renders Markdown to HTML. The returned HTML is already sanitized and
     # escaped and thus is always safe to render.
     renderMarkdown(markdown: String!, options: MarkdownOptions): String!
@@ -763,6 +829,18 @@ type Query {
         # The search query (such as "foo" or "repo:myrepo foo").
         query: String = ""
     ): Search
+    # Previews replacing each match of a search query, returning the changes to each repository as a unified
+    # diff. The query is run as a file content search, so the number of files changed is limited like the
+    # number of search results (use "count:" to raise the limit). Queries with OR or NOT and structural
+    # queries are not supported.
+    searchReplace(
+        # The search query (such as "repo:myrepo foo\((\w+)\)").
+        query: String!
+        # The replacement for each match. It may refer to the capture groups of the query's pattern with $1
+        # or ${name} (use $$ for a literal $). If the query has multiple search terms, they are combined into
+        # one pattern in which each term is a capture group.
+        replacement: String!
+    ): SearchReplaceResults!
     # All saved queries configured for the current user, merged from all configurations.
     savedQueries: [SavedQuery!]!
     # All repository groups for the current user, merged from all configurations.
@@ -957,6 +1035,42 @@ type SavedQuery {
     notify: Boolean!
     # Whether or not to notify on Slack.
     notifySlack: Boolean!
+    # The schedule on which the saved query is run to check for new results (a cron expression, or one of @hourly,
+    # @daily, @weekly and @monthly), or null if it is run at an interval proportional to how long it takes to
+    # execute.
+    schedule: String
+    # The history of runs of the saved query's current search query, most recent first. Only the 100 most recent
+    # runs are kept.
+    runs(
+        # Returns the first n runs from the list.
+        first: Int
+    ): SavedQueryRunConnection!
+}
+
+# A list of runs of a saved query.
+type SavedQueryRunConnection {
+    # A list of runs.
+    nodes: [SavedQueryRun!]!
+    # The total count of runs in the connection. This total count may be larger than the number of nodes in this
+    # object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# A run of a saved query.
+type SavedQueryRun {
+    # The time when the run started.
+    startedAt: String!
+    # The time it took to execute the search query, in milliseconds.
+    durationMilliseconds: Int!
+    # The number of search results in repositories that the saved query's owner can read (every member of it, for an
+    # organization), or null if the search failed or the saved query is in global settings.
+    resultCount: Int
+    # The error that the search failed with, or null if it succeeded.
+    error: String
+    # Whether notifications of new search results were sent.
+    notified: Boolean!
 }
 
 # A search query description.
@@ -1048,7 +1162,9 @@ type ExternalServiceConnection {
 # A specific kind of external service.
 enum ExternalServiceKind {
     AWSCODECOMMIT
+    BITBUCKETCLOUD
     BITBUCKETSERVER
+    GITEA
     GITHUB
     GITLAB
     GITOLITE
@@ -1070,6 +1186,48 @@ type ExternalService implements Node {
     createdAt: String!
     # When the external service was last updated.
     updatedAt: String!
+    # The outcome of the last sync of the external service's repositories, or null if its repositories
+    # have not been synced yet.
+    lastSync: ExternalServiceSyncStatus
+}
+
+# The outcome of a sync of the repositories of an external service.
+type ExternalServiceSyncStatus {
+    # When the sync started.
+    startedAt: String!
+    # When the sync finished.
+    finishedAt: String!
+    # The number of repositories that were synced, but not by the previous sync.
+    reposAdded: Int!
+    # The number of repositories that were synced by the previous sync, but not by this one.
+    reposRemoved: Int!
+    # The number of repositories that were synced by both the previous sync and this one.
+    reposUnchanged: Int!
+    # Errors that occurred while listing or syncing repositories.
+    errors: [String!]!
+}
+
+# The repositories that a proposed external service configuration would add, remove or rename.
+type ExternalServiceDryRun {
+    # The names of the repositories that would be added.
+    added: [String!]!
+    # The names of the repositories that would no longer be synced.
+    removed: [String!]!
+    # The repositories that would be synced under a different name.
+    renamed: [ExternalServiceDryRunRename!]!
+    # The number of repositories that would be synced under the same name.
+    unchanged: Int!
+    # Errors that occurred while listing repositories. If there are any, the lists of repositories
+    # may be incomplete.
+    errors: [String!]!
+}
+
+# A repository that a proposed external service configuration would rename.
+type ExternalServiceDryRunRename {
+    # The current name of the repository.
+    from: String!
+    # The name of the repository with the proposed configuration.
+    to: String!
 }
 
 # A list of repositories.
@@ -1979,6 +2137,36 @@ type HighlightedFile {
     html: String!
 }
 
+# The result of replacing each match of a search query.
+type SearchReplaceResults {
+    # The changes to each repository (at each searched revision) with matches.
+    patches: [RepositoryPatch!]!
+    # Whether the limit on search results was hit, in which case some matches were not replaced.
+    limitHit: Boolean!
+    # An alert message that should be displayed before the patches, if any.
+    alert: SearchAlert
+}
+
+# The changes made to a repository by a search-and-replace.
+type RepositoryPatch {
+    # The repository.
+    repository: Repository!
+    # The commit that the changes are based on.
+    baseCommit: GitCommit
+    # The changes to each file, ordered by path.
+    filePatches: [FilePatch!]!
+    # The changes to all files as a unified diff, which can be applied with "git apply".
+    diff: String!
+}
+
+# The changes made to a file by a search-and-replace.
+type FilePatch {
+    # The path of the file, relative to the repository root.
+    path: String!
+    # The changes to the file as a unified diff.
+    diff: String!
+}
+
 # A file match.
 type FileMatch {
     # The file containing the match.
@@ -1995,6 +2183,10 @@ type FileMatch {
     symbols: [Symbol!]!
     # The line matches.
     lineMatches: [LineMatch!]!
+    # The range of each match in the file. Unlike lineMatches, a range may span multiple lines (for
+    # example, for the regular expression "foo\nbar"). Such a match is also reported in lineMatches as
+    # a match on each line it spans.
+    matchRanges: [Range!]!
     # Whether or not the limit was hit.
     limitHit: Boolean!
 }
@@ -2442,6 +2634,10 @@ type DiscussionThreadTargetRepo {
     # Where the path would be relative to the given Git revision specifier
     # (branch/commit/etc). i.e., accounting for file renames, deletions, etc.
     #
+    # If the thread has a selection and was created on a specific revision, this
+    # is the path of the file that the selected lines are in, which differs from
+    # the path field if the lines were moved to another file.
+    #
     # null is returned if there is no path relative to the specified revision,
     # e.g. if the file was deleted or the path field was null.
     relativePath(rev: String!): String
@@ -2449,14 +2645,16 @@ type DiscussionThreadTargetRepo {
     # Where the selection would be relative to the given Git revision specifier
     # (branch/commit/etc).
     #
-    # The implementation relies on a hueristic which is generally good enough,
-    # but under certain circumstances may not be as accurate as e.g. determining
-    # this placement by walking through the Git history.
+    # If the thread was created on a specific revision, the selection is mapped
+    # through the diff between that revision and the given one. Lines that were
+    # moved (to another place in the file, or to another file, see relativePath)
+    # are followed. Otherwise, the implementation relies on a hueristic which is
+    # generally good enough, but under certain circumstances may not be as
+    # accurate.
     #
-    # If determining the relative placement is not possible (file was renamed
-    # or removed, the selection no longer exists in the file, or the hueristic
-    # failed) null is returned and it should be assumed the selection does not
-    # exist in this revision.
+    # If determining the relative placement is not possible (file was removed,
+    # the selected lines were deleted, or the hueristic failed) null is returned
+    # and it should be assumed the selection does not exist in this revision.
     relativeSelection(rev: String!): DiscussionSelectionRange
 }
 
@@ -2466,6 +2664,16 @@ type DiscussionThreadTargetRepo {
 # do not understand gracefully.
 union DiscussionThreadTarget = DiscussionThreadTargetRepo
 
+# The status of a discussion thread.
+enum DiscussionThreadStatus {
+    # The thread needs attention.
+    OPEN
+    # The thread was resolved.
+    RESOLVED
+    # The thread was closed without resolving it.
+    WONT_FIX
+}
+
 # A discussion thread around some target (e.g. a file in a repo).
 type DiscussionThread {
     # The discussion thread ID (globally unique).
@@ -2498,6 +2706,15 @@ type DiscussionThread {
     # The date when the discussion thread was archived (or null if it has not).
     archivedAt: String
 
+    # The status of the discussion thread.
+    status: DiscussionThreadStatus!
+
+    # The users assigned to the discussion thread.
+    assignees: [User!]!
+
+    # The labels of the discussion thread.
+    labels: [String!]!
+
     # The comments in the discussion thread.
     comments(
         # Returns the first n comments from the list.
@@ -2589,6 +2806,80 @@ type DiscussionCommentConnection {
     pageInfo: PageInfo!
 }
 
+# The kind of discussion activity that a notification is about.
+enum DiscussionNotificationEvent {
+    # A thread was created.
+    THREAD_CREATED
+    # A comment was added to a thread.
+    COMMENT_ADDED
+    # The user was mentioned in a new thread or comment.
+    MENTION
+}
+
+# The kind of service that a notification of discussion activity is sent to.
+enum DiscussionNotificationTargetType {
+    # A Slack incoming webhook.
+    SLACK
+    # A Microsoft Teams incoming webhook.
+    MICROSOFT_TEAMS
+    # A generic webhook, which is sent a JSON description of the event.
+    WEBHOOK
+}
+
+# A notification of discussion activity for a chat service or webhook that a
+# user or organization subscribed to in their settings (in the
+# "notifications.discussions" setting).
+type DiscussionNotificationDelivery {
+    # The kind of activity that the notification is about.
+    event: DiscussionNotificationEvent!
+
+    # The thread that the activity happened in, or null if it was deleted.
+    thread: DiscussionThread
+
+    # The comment that the activity is about, or null if it was deleted.
+    comment: DiscussionComment
+
+    # The user or organization in whose settings the subscription is.
+    subject: SettingsSubject!
+
+    # The kind of service that the notification is sent to.
+    targetType: DiscussionNotificationTargetType!
+
+    # The host name of the URL that the notification is sent to. The full URL
+    # is not exposed because it often contains a secret.
+    targetHost: String!
+
+    # The number of attempts to deliver the notification.
+    attempts: Int!
+
+    # The error of the last delivery attempt, if it failed.
+    lastError: String
+
+    # The date when delivery is next attempted, if the notification was not
+    # delivered yet and delivery was not given up.
+    nextAttemptAt: String
+
+    # The date when the notification was delivered, if it was.
+    deliveredAt: String
+
+    # The date when the notification was created.
+    createdAt: String!
+}
+
+# A list of notifications of discussion activity.
+type DiscussionNotificationDeliveryConnection {
+    # A list of notifications.
+    nodes: [DiscussionNotificationDelivery!]!
+
+    # The total count of notifications in the connection. This total count may
+    # be larger than the number of nodes in this object when the result is
+    # paginated.
+    totalCount: Int!
+
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
 # RepositoryOrderBy enumerates the ways a repositories list can be ordered.
 enum RepositoryOrderBy {
     REPO_URI # deprecated (use the equivalent REPOSITORY_NAME)
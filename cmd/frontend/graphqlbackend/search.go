@@ -68,6 +68,9 @@ func (r *schemaResolver) Search(args *struct {
 		log15.Debug("graphql search failed to parse", "query", args.Query, "error", err)
 		return nil, err
 	}
+	if query.Hierarchical {
+		return newBooleanSearcherResolver(query)
+	}
 	return &searchResolver{
 		query: query,
 	}, nil
@@ -94,6 +97,14 @@ type searchResolver struct {
 	repoResults               []*searchSuggestionResolver
 	repoOverLimit             bool
 	repoErr                   error
+
+	// stream, if non-nil, is called with results and the status of the
+	// repositories they came from as they are found. See search_stream.go.
+	stream func([]*searchResultResolver, *searchResultsCommon)
+
+	// replacement, if non-nil, is the template that each match is replaced
+	// with. File matches then contain a patch. See search_replace.go.
+	replacement *string
 }
 
 // rawQuery returns the original query string input.
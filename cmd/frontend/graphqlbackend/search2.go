@@ -14,6 +14,7 @@ import (
 	sgbackend "github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	frontendsearch "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	frontendquery "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
@@ -23,6 +24,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/pkg/search"
 	"github.com/sourcegraph/sourcegraph/pkg/search/backend"
 	"github.com/sourcegraph/sourcegraph/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
 	log15 "gopkg.in/inconshreveable/log15.v2"
 )
@@ -54,14 +56,46 @@ func newSearcherResolver(qStr string) (*searcherResolver, error) {
 	}, nil
 }
 
+// newBooleanSearcherResolver returns a resolver for q, which uses boolean
+// operators (OR, NOT or negated search terms). Only hierarchical search can
+// evaluate such queries.
+func newBooleanSearcherResolver(q *frontendquery.Query) (*searcherResolver, error) {
+	hq, err := q.HierarchicalQuery()
+	if err != nil {
+		return nil, err
+	}
+	return &searcherResolver{
+		Searcher: Search().Text,
+		Q:        hq,
+		Options:  &search.Options{},
+	}, nil
+}
+
 func (r *searcherResolver) Results(ctx context.Context) (*searchResultsResolver, error) {
-	sCtx := &searchContext{}
 	start := time.Now()
+	sCtx, q, opts, err := r.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Do the search
+	result, err := r.Searcher.Search(ctx, q, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toResultsResolver(ctx, sCtx, opts, result, start)
+}
+
+// prepare scopes the search to repositories, returning the query and options
+// to pass to r.Searcher.
+func (r *searcherResolver) prepare(ctx context.Context) (*searchContext, query.Q, *search.Options, error) {
+	sCtx := &searchContext{}
 
 	// 1. Scope the request to repositories
 	dbQ, err := frontendsearch.RepoQuery(r.Q)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	maxRepoListSize := maxReposToSearch()
 	repos, err := sgbackend.Repos.List(ctx, db.ReposListOptions{
@@ -71,7 +105,7 @@ func (r *searcherResolver) Results(ctx context.Context) (*searchResultsResolver,
 		// TODO forks and archived
 	})
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 	sCtx.CacheRepo(repos...)
 	opts := r.Options.ShallowCopy()
@@ -83,15 +117,15 @@ func (r *searcherResolver) Results(ctx context.Context) (*searchResultsResolver,
 	// 3. Adjust query so repo: atoms become reposets:
 	q, err := query.ExpandRepo(r.Q, createListFunc(opts.Repositories))
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	// 4. Do the search
-	result, err := r.Searcher.Search(ctx, q, opts)
-	if err != nil {
-		return nil, err
-	}
+	return sCtx, q, opts, nil
+}
 
+// toResultsResolver converts result into a searchResultsResolver for a
+// search which started at start.
+func (r *searcherResolver) toResultsResolver(ctx context.Context, sCtx *searchContext, opts *search.Options, result *search.Result, start time.Time) (*searchResultsResolver, error) {
 	// 5. To ship hierarchical search sooner we are using the old file match
 	//    resolver. However, we should just be returning a resolver which is a
 	//    light wrapper around a search.Result.
@@ -139,6 +173,14 @@ func toSearchResultResolvers(ctx context.Context, sCtx *searchContext, r *search
 			})
 		}
 
+		ranges := make([]*matchRange, len(file.MatchRanges))
+		for i, r := range file.MatchRanges {
+			ranges[i] = &matchRange{
+				JStart: matchLocation{JOffset: int32(r.Start.Offset), JLine: int32(r.Start.Line), JColumn: int32(r.Start.Column)},
+				JEnd:   matchLocation{JOffset: int32(r.End.Offset), JLine: int32(r.End.Line), JColumn: int32(r.End.Column)},
+			}
+		}
+
 		repo, err := sCtx.GetRepo(ctx, file.Repository.Name)
 		if err != nil {
 			return nil, err
@@ -148,6 +190,7 @@ func toSearchResultResolvers(ctx context.Context, sCtx *searchContext, r *search
 			fileMatch: &fileMatchResolver{
 				JPath:        file.Path,
 				JLineMatches: lines,
+				JMatchRanges: ranges,
 				JLimitHit:    fileLimitHit,
 				uri:          fileMatchURI(file.Repository.Name, string(file.Repository.Commit), file.Path),
 				repo:         repo,
@@ -418,6 +461,7 @@ func Search() *SearchProviders {
 					return git.ResolveRevision(ctx, gitserver.Repo{Name: name}, nil, spec, &git.ResolveRevisionOptions{NoEnsureRevision: true})
 				},
 			},
+			Ranker: search.NewDefaultRanker(rankRepoMetadata, rankSymbolLines),
 		}
 
 		searchP = &SearchProviders{
@@ -428,3 +472,118 @@ func Search() *SearchProviders {
 	})
 	return searchP
 }
+
+// fileMatchRanker returns the ranker used by rankFileMatches, or nil if file
+// matches are not ranked. Tests may replace it.
+var fileMatchRanker = func() search.Ranker { return Search().Text.Ranker }
+
+// rankFileMatches orders matches by relevance with the ranker of the text
+// search provider, and records their rank so that sortResults keeps that
+// order. It reports whether the matches were ranked. Ranking is best effort,
+// so if a signal fails the matches are ordered by the other signals.
+func rankFileMatches(ctx context.Context, matches []*fileMatchResolver) bool {
+	ranker := fileMatchRanker()
+	if ranker == nil {
+		return false
+	}
+
+	type key struct {
+		repo   api.RepoName
+		commit api.CommitID
+		path   string
+	}
+	files := make([]search.FileMatch, len(matches))
+	byKey := make(map[key][]*fileMatchResolver, len(matches))
+	for i, fm := range matches {
+		files[i] = search.FileMatch{
+			Path:        fm.JPath,
+			Repository:  search.Repository{Name: fm.repo.Name, Commit: fm.commitID},
+			LineMatches: make([]search.LineMatch, len(fm.JLineMatches)),
+		}
+		for j, lm := range fm.JLineMatches {
+			// The signals only look at the line numbers and the number of
+			// fragments, so the fragments' offsets (in runes instead of
+			// bytes) are good enough.
+			fragments := make([]search.LineFragmentMatch, len(lm.JOffsetAndLengths))
+			for k, ol := range lm.JOffsetAndLengths {
+				fragments[k] = search.LineFragmentMatch{LineOffset: int(ol[0]), MatchLength: int(ol[1])}
+			}
+			files[i].LineMatches[j] = search.LineMatch{
+				Line:          []byte(lm.JPreview),
+				LineNumber:    int(lm.JLineNumber) + 1,
+				LineFragments: fragments,
+			}
+		}
+		k := key{repo: fm.repo.Name, commit: fm.commitID, path: fm.JPath}
+		byKey[k] = append(byKey[k], fm)
+	}
+
+	if err := ranker.Rank(ctx, files); err != nil {
+		log15.Warn("failed to rank file matches", "error", err)
+	}
+
+	for i, f := range files {
+		k := key{repo: f.Repository.Name, commit: f.Repository.Commit, path: f.Path}
+		fm := byKey[k][0]
+		byKey[k] = byKey[k][1:]
+		fm.rank = i + 1
+		matches[i] = fm
+	}
+	return true
+}
+
+// rankRepoMetadata returns the metadata used to rank results from the named
+// repositories.
+func rankRepoMetadata(ctx context.Context, names []api.RepoName) (map[api.RepoName]search.RepoMetadata, error) {
+	repos, err := db.Repos.List(ctx, db.ReposListOptions{Enabled: true, Names: names})
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[api.RepoName]search.RepoMetadata, len(repos))
+	for _, repo := range repos {
+		// We don't store star counts yet.
+		metadata[repo.Name] = search.RepoMetadata{Fork: repo.Fork, Archived: repo.Archived}
+	}
+	return metadata, nil
+}
+
+// maxRankSymbols is the maximum number of symbols we ask the symbols service
+// for when ranking the results from a repository. This is the most the
+// symbols service returns.
+const maxRankSymbols = 500
+
+// rankSymbolLines returns the lines of the symbols defined in paths, as
+// found by the symbols service.
+func rankSymbolLines(ctx context.Context, repo search.Repository, paths []string) (map[string][]int, error) {
+	commit := repo.Commit
+	if commit == "" {
+		// Results from the index do not include the commit, but are always
+		// for the default branch.
+		var err error
+		commit, err = git.ResolveRevision(ctx, gitserver.Repo{Name: repo.Name}, nil, "", &git.ResolveRevisionOptions{NoEnsureRevision: true})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = regexp.QuoteMeta(p)
+	}
+	symbols, err := sgbackend.Symbols.ListTags(ctx, protocol.SearchArgs{
+		Repo:            repo.Name,
+		CommitID:        commit,
+		IsRegExp:        true,
+		IsCaseSensitive: true,
+		IncludePatterns: []string{"^(" + strings.Join(quoted, "|") + ")$"},
+		First:           maxRankSymbols,
+	})
+	if err != nil {
+		return nil, err
+	}
+	lines := make(map[string][]int, len(paths))
+	for _, s := range symbols {
+		lines[s.Path] = append(lines[s.Path], s.Line)
+	}
+	return lines, nil
+}
@@ -0,0 +1,275 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+type searchReplaceArgs struct {
+	Query       string
+	Replacement string
+}
+
+// SearchReplace previews replacing the matches of a search query. Searcher
+// computes a patch for each matching file, which we group by repository.
+func (r *schemaResolver) SearchReplace(ctx context.Context, args *searchReplaceArgs) (*searchReplaceResultsResolver, error) {
+	return searchReplace(ctx, args)
+}
+
+var mockSearchReplace func(args *searchReplaceArgs) (*searchReplaceResultsResolver, error)
+
+func searchReplace(ctx context.Context, args *searchReplaceArgs) (*searchReplaceResultsResolver, error) {
+	if mockSearchReplace != nil {
+		return mockSearchReplace(args)
+	}
+
+	q, err := query.ParseAndCheck(args.Query)
+	if err != nil {
+		return nil, err
+	}
+	if q.Hierarchical {
+		return nil, errors.New("replacements are not supported in queries with OR or NOT")
+	}
+	if q.IsStructural() {
+		return nil, errors.New("replacements are not supported in structural queries")
+	}
+
+	sr := &searchResolver{query: q, replacement: &args.Replacement}
+	results, err := sr.doResults(ctx, "file")
+	if err != nil {
+		return nil, err
+	}
+
+	var fileMatches []*fileMatchResolver
+	for _, result := range results.results {
+		if result.fileMatch != nil {
+			fileMatches = append(fileMatches, result.fileMatch)
+		}
+	}
+	return &searchReplaceResultsResolver{
+		patches:  toRepositoryPatches(fileMatches),
+		limitHit: results.LimitHit(),
+		alert:    results.alert,
+	}, nil
+}
+
+// toRepositoryPatches groups the patches of fileMatches by repository and
+// commit, in the order the repositories first appear.
+func toRepositoryPatches(fileMatches []*fileMatchResolver) []*repositoryPatchResolver {
+	type key struct {
+		repo     api.RepoName
+		commitID api.CommitID
+	}
+	var patches []*repositoryPatchResolver
+	byKey := map[key]*repositoryPatchResolver{}
+	for _, fm := range fileMatches {
+		if fm.JPatch == "" {
+			continue
+		}
+		k := key{repo: fm.repo.Name, commitID: fm.commitID}
+		p, ok := byKey[k]
+		if !ok {
+			p = &repositoryPatchResolver{repo: fm.repo, commitID: fm.commitID}
+			byKey[k] = p
+			patches = append(patches, p)
+		}
+		p.files = append(p.files, &filePatchResolver{path: fm.JPath, diff: fm.JPatch})
+	}
+	for _, p := range patches {
+		sort.Slice(p.files, func(i, j int) bool { return p.files[i].path < p.files[j].path })
+	}
+	return patches
+}
+
+// searchReplaceResultsResolver is a resolver for the GraphQL type
+// `SearchReplaceResults`
+type searchReplaceResultsResolver struct {
+	patches  []*repositoryPatchResolver
+	limitHit bool
+	alert    *searchAlert
+}
+
+func (r *searchReplaceResultsResolver) Patches() []*repositoryPatchResolver { return r.patches }
+func (r *searchReplaceResultsResolver) LimitHit() bool                      { return r.limitHit }
+func (r *searchReplaceResultsResolver) Alert() *searchAlert                 { return r.alert }
+
+// repositoryPatchResolver is a resolver for the GraphQL type
+// `RepositoryPatch`
+type repositoryPatchResolver struct {
+	repo     *types.Repo
+	commitID api.CommitID
+	files    []*filePatchResolver
+}
+
+func (r *repositoryPatchResolver) Repository() *repositoryResolver {
+	return &repositoryResolver{repo: r.repo}
+}
+
+func (r *repositoryPatchResolver) BaseCommit(ctx context.Context) (*gitCommitResolver, error) {
+	return (&repositoryResolver{repo: r.repo}).Commit(ctx, &repositoryCommitArgs{Rev: string(r.commitID)})
+}
+
+func (r *repositoryPatchResolver) FilePatches() []*filePatchResolver { return r.files }
+
+func (r *repositoryPatchResolver) Diff() string {
+	var b strings.Builder
+	for _, f := range r.files {
+		b.WriteString(f.diff)
+	}
+	return b.String()
+}
+
+// filePatchResolver is a resolver for the GraphQL type `FilePatch`
+type filePatchResolver struct {
+	path string
+	diff string
+}
+
+func (r *filePatchResolver) Path() string { return r.path }
+func (r *filePatchResolver) Diff() string { return r.diff }
+
+// branchNamePattern matches the branch names we allow
+// createCommitsFromSearchReplace to create. It is stricter than
+// git-check-ref-format.
+var branchNamePattern = regexp.MustCompile(`^[\w-]+(/[\w-]+)*$`)
+
+func validateBranchName(branch string) error {
+	if !branchNamePattern.MatchString(branch) || strings.HasPrefix(branch, "-") {
+		return fmt.Errorf("invalid branch name %q (only letters, digits, '_', '-' and '/' are allowed)", branch)
+	}
+	return nil
+}
+
+// CreateCommitsFromSearchReplace creates a commit on a new branch in our
+// mirror of each repository with changes from a search-and-replace, so that
+// the changes can be reviewed and exported with git.
+func (r *schemaResolver) CreateCommitsFromSearchReplace(ctx context.Context, args *struct {
+	Query       string
+	Replacement string
+	Branch      string
+	Message     *string
+}) ([]*gitCommitResolver, error) {
+	// 🚨 SECURITY: Only site admins may create commits, since they are
+	// visible to all users with access to the repository.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateBranchName(args.Branch); err != nil {
+		return nil, err
+	}
+
+	user, err := db.Users.GetByCurrentAuthUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	authorName := user.DisplayName
+	if authorName == "" {
+		authorName = user.Username
+	}
+	authorEmail, _, err := db.UserEmails.GetPrimaryEmail(ctx, user.ID)
+	if err != nil && !errcode.IsNotFound(err) {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Replace matches of %q with %q", args.Query, args.Replacement)
+	if args.Message != nil && *args.Message != "" {
+		message = *args.Message
+	}
+
+	results, err := searchReplace(ctx, &searchReplaceArgs{Query: args.Query, Replacement: args.Replacement})
+	if err != nil {
+		return nil, err
+	}
+	// Committing only some of the matches would silently leave the rest of
+	// them unreplaced.
+	if results.limitHit {
+		return nil, errors.New("the search hit the result limit, so not all matches would be replaced (add \"count:\" to the query to raise the limit)")
+	}
+
+	targetRef := "refs/heads/" + args.Branch
+
+	// Check that the branch doesn't exist in any of the repositories before
+	// creating any commits, so that we don't leave partial results behind.
+	// The update of the ref on gitserver also refuses to overwrite it, in
+	// case it is created in the meantime.
+	for _, p := range results.patches {
+		if err := checkBranchNotExists(ctx, p.repo, targetRef); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	commits := make([]*gitCommitResolver, 0, len(results.patches))
+	for _, p := range results.patches {
+		_, err := gitserver.DefaultClient.CreateCommitFromPatch(ctx, protocol.CreateCommitFromPatchRequest{
+			Repo:        p.repo.Name,
+			BaseCommit:  p.commitID,
+			TargetRef:   targetRef,
+			NoOverwrite: true,
+			Patch:       p.Diff(),
+			CommitInfo: protocol.PatchCommitInfo{
+				AuthorName:  authorName,
+				AuthorEmail: authorEmail,
+				Message:     message,
+				Date:        now,
+			},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating commit in %s", p.repo.Name)
+		}
+
+		// The commit only exists in our mirror, so don't try to fetch it
+		// from the code host (see ResolvePhabricatorDiff).
+		cachedRepo, err := backend.CachedGitRepo(ctx, p.repo)
+		if err != nil {
+			return nil, err
+		}
+		commitID, err := git.ResolveRevision(ctx, *cachedRepo, nil, targetRef, &git.ResolveRevisionOptions{
+			NoEnsureRevision: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		commit, err := (&repositoryResolver{repo: p.repo}).Commit(ctx, &repositoryCommitArgs{Rev: string(commitID), InputRevspec: &args.Branch})
+		if err != nil {
+			return nil, err
+		}
+		if commit != nil {
+			commits = append(commits, commit)
+		}
+	}
+	return commits, nil
+}
+
+// checkBranchNotExists returns an error if ref exists in our mirror of repo.
+func checkBranchNotExists(ctx context.Context, repo *types.Repo, ref string) error {
+	cachedRepo, err := backend.CachedGitRepo(ctx, repo)
+	if err != nil {
+		return err
+	}
+	_, err = git.ResolveRevision(ctx, *cachedRepo, nil, ref, &git.ResolveRevisionOptions{
+		NoEnsureRevision: true,
+	})
+	if err == nil {
+		return fmt.Errorf("branch %q already exists in %s", strings.TrimPrefix(ref, "refs/heads/"), repo.Name)
+	}
+	if git.IsRevisionNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+)
+
+func TestToRepositoryPatches(t *testing.T) {
+	repoA := &types.Repo{Name: "a"}
+	repoB := &types.Repo{Name: "b"}
+	fileMatches := []*fileMatchResolver{
+		{repo: repoB, commitID: "c1", JPath: "y", JPatch: "diff y\n"},
+		{repo: repoA, commitID: "c2", JPath: "z", JPatch: "diff z\n"},
+		{repo: repoB, commitID: "c1", JPath: "x", JPatch: "diff x\n"},
+		{repo: repoB, commitID: "c1", JPath: "unchanged"},
+		{repo: repoB, commitID: "c3", JPath: "x", JPatch: "diff x@c3\n"},
+	}
+
+	type patch struct {
+		repo, commitID, diff string
+	}
+	var got []patch
+	for _, p := range toRepositoryPatches(fileMatches) {
+		got = append(got, patch{repo: string(p.repo.Name), commitID: string(p.commitID), diff: p.Diff()})
+	}
+	want := []patch{
+		{repo: "b", commitID: "c1", diff: "diff x\ndiff y\n"},
+		{repo: "a", commitID: "c2", diff: "diff z\n"},
+		{repo: "b", commitID: "c3", diff: "diff x@c3\n"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestValidateBranchName(t *testing.T) {
+	for _, branch := range []string{"replace-foo", "sourcegraph/replace_foo-2"} {
+		if err := validateBranchName(branch); err != nil {
+			t.Errorf("%q: unexpected error: %s", branch, err)
+		}
+	}
+	for _, branch := range []string{"", "-foo", "a..b", "a/", "/a", "a//b", "a b", "a.lock", "refs/heads/x~1"} {
+		if err := validateBranchName(branch); err == nil {
+			t.Errorf("%q: got err == nil, want error", branch)
+		}
+	}
+}
+
+func TestCreateCommitsFromSearchReplace_limitHit(t *testing.T) {
+	resetMocks()
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 1, Username: "alice", SiteAdmin: true}, nil
+	}
+	db.Mocks.UserEmails.GetPrimaryEmail = func(ctx context.Context, id int32) (string, bool, error) {
+		return "alice@example.com", true, nil
+	}
+	mockSearchReplace = func(args *searchReplaceArgs) (*searchReplaceResultsResolver, error) {
+		return &searchReplaceResultsResolver{
+			patches:  toRepositoryPatches([]*fileMatchResolver{{repo: &types.Repo{Name: "a"}, commitID: "c1", JPath: "x", JPatch: "diff x\n"}}),
+			limitHit: true,
+		}, nil
+	}
+	defer func() {
+		db.Mocks.Users.GetByCurrentAuthUser = nil
+		db.Mocks.UserEmails.GetPrimaryEmail = nil
+		mockSearchReplace = nil
+	}()
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	commits, err := (&schemaResolver{}).CreateCommitsFromSearchReplace(ctx, &struct {
+		Query       string
+		Replacement string
+		Branch      string
+		Message     *string
+	}{Query: "foo", Replacement: "bar", Branch: "replace-foo"})
+	if err == nil || !strings.Contains(err.Error(), "count:") {
+		t.Errorf("got err %v, want an error suggesting count:", err)
+	}
+	if commits != nil {
+		t.Errorf("got commits %v, want nil", commits)
+	}
+}
@@ -561,11 +561,16 @@ type getPatternInfoOptions struct {
 // getPatternInfo gets the search pattern info for the query in the resolver.
 func (r *searchResolver) getPatternInfo(opts *getPatternInfoOptions) (*search.PatternInfo, error) {
 	var patternsToCombine []string
+	isStructural := r.query.IsStructural() && (opts == nil || !opts.forceFileSearch)
 	if opts == nil || !opts.forceFileSearch {
 		for _, v := range r.query.Values(query.FieldDefault) {
 			// Treat quoted strings as literal strings to match, not regexps.
 			var pattern string
 			switch {
+			case isStructural:
+				// Search terms are strings in structural queries (regexp
+				// patterns are rejected when the query is checked).
+				pattern = asString(v)
 			case v.String != nil:
 				pattern = regexp.QuoteMeta(*v.String)
 			case v.Regexp != nil:
@@ -611,6 +616,15 @@ func (r *searchResolver) getPatternInfo(opts *getPatternInfoOptions) (*search.Pa
 		PathPatternsAreRegExps:       true,
 		PathPatternsAreCaseSensitive: r.query.IsCaseSensitive(),
 	}
+	if isStructural {
+		patternInfo.IsRegExp = false
+		patternInfo.IsStructuralPat = true
+		patternInfo.Pattern = strings.Join(patternsToCombine, " ")
+	}
+	if r.replacement != nil && (opts == nil || !opts.forceFileSearch) {
+		patternInfo.IsReplace = true
+		patternInfo.Replacement = *r.replacement
+	}
 	if len(excludePatterns) > 0 {
 		patternInfo.ExcludePattern = unionRegExps(excludePatterns)
 	}
@@ -670,6 +684,13 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		return nil, err
 	}
 	tr.LazyPrintf("searching %d repos, %d missing", len(repos), len(missingRepoRevs))
+	if r.stream != nil && len(repos) > 0 && !overLimit {
+		resolved := make([]*types.Repo, len(repos))
+		for i, repo := range repos {
+			resolved[i] = repo.Repo
+		}
+		r.stream(nil, &searchResultsCommon{repos: resolved})
+	}
 	if len(repos) == 0 {
 		alert, err := r.alertForNoResolvedRepos(ctx)
 		if err != nil {
@@ -709,6 +730,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			resultTypes = []string{"file", "path", "repo", "ref"}
 		}
 	}
+	if args.Pattern.IsStructuralPat || args.Pattern.IsReplace {
+		// Structural templates and replacements only match file contents.
+		resultTypes = []string{"file"}
+	}
 	seenResultTypes := make(map[string]struct{}, len(resultTypes))
 	for _, resultType := range resultTypes {
 		if resultType == "file" {
@@ -777,6 +802,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*repoCommon)
 					commonMu.Unlock()
 				}
+				r.send(repoResults, repoCommon)
 			})
 		case "symbol":
 			wg := waitGroup(len(resultTypes) == 1)
@@ -791,7 +817,9 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					multiErr = multierror.Append(multiErr, errors.Wrap(err, "symbol search failed"))
 					multiErrMu.Unlock()
 				}
+				symbolResults := make([]*searchResultResolver, 0, len(symbolFileMatches))
 				for _, symbolFileMatch := range symbolFileMatches {
+					symbolResults = append(symbolResults, &searchResultResolver{fileMatch: symbolFileMatch})
 					key := symbolFileMatch.uri
 					fileMatchesMu.Lock()
 					if m, ok := fileMatches[key]; ok {
@@ -809,6 +837,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*symbolsCommon)
 					commonMu.Unlock()
 				}
+				r.send(symbolResults, symbolsCommon)
 			})
 		case "file", "path":
 			if searchedFileContentsOrPaths {
@@ -821,7 +850,17 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				fileResults, fileCommon, err := searchFilesInRepos(ctx, &args)
+				var stream fileMatchStream
+				if r.stream != nil {
+					stream = func(matches []*fileMatchResolver, common *searchResultsCommon) {
+						fileResults := make([]*searchResultResolver, len(matches))
+						for i, fm := range matches {
+							fileResults[i] = &searchResultResolver{fileMatch: fm}
+						}
+						r.send(fileResults, common)
+					}
+				}
+				fileResults, fileCommon, err := searchFilesInReposStream(ctx, &args, stream)
 				// Timeouts are reported through searchResultsCommon so don't report an error for them
 				if err != nil && !isContextError(ctx, err) {
 					multiErrMu.Lock()
@@ -836,6 +875,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 						// merge line match results with an existing symbol result
 						m.JLimitHit = m.JLimitHit || r.JLimitHit
 						m.JLineMatches = r.JLineMatches
+						m.JMatchRanges = r.JMatchRanges
 					} else {
 						fileMatches[key] = r
 						resultsMu.Lock()
@@ -872,6 +912,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*diffCommon)
 					commonMu.Unlock()
 				}
+				r.send(diffResults, diffCommon)
 			})
 		case "commit":
 			wg := waitGroup(len(resultTypes) == 1)
@@ -896,6 +937,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*commitCommon)
 					commonMu.Unlock()
 				}
+				r.send(commitResults, commitCommon)
 			})
 		}
 	}
@@ -972,7 +1014,29 @@ func getSearchResultURIs(c *searchResultResolver) (string, string) {
 
 // compareSearchResults checks to see if a is less than b.
 // It is implemented separately for easier testing.
+//
+// Repository matches come first, then file matches and then diffs. File
+// matches which were ranked (see rankFileMatches) are ordered by relevance,
+// before those which were not.
 func compareSearchResults(a, b *searchResultResolver) bool {
+	if a.repo != nil || b.repo != nil {
+		if (a.repo != nil) != (b.repo != nil) {
+			return a.repo != nil
+		}
+		return a.repo.repo.Name < b.repo.repo.Name
+	}
+
+	if a.fileMatch != nil && b.fileMatch != nil && a.fileMatch.rank != b.fileMatch.rank {
+		switch {
+		case a.fileMatch.rank == 0:
+			return false
+		case b.fileMatch.rank == 0:
+			return true
+		default:
+			return a.fileMatch.rank < b.fileMatch.rank
+		}
+	}
+
 	arepo, afile := getSearchResultURIs(a)
 	brepo, bfile := getSearchResultURIs(b)
 
@@ -985,7 +1049,8 @@ func compareSearchResults(a, b *searchResultResolver) bool {
 }
 
 func sortResults(r []*searchResultResolver) {
-	sort.Slice(r, func(i, j int) bool { return compareSearchResults(r[i], r[j]) })
+	// Diffs are already ordered, so keep their order.
+	sort.SliceStable(r, func(i, j int) bool { return compareSearchResults(r[i], r[j]) })
 }
 
 func (g *searchResultResolver) ToRepository() (*repositoryResolver, bool) {
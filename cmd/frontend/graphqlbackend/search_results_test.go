@@ -529,6 +529,69 @@ func TestCompareSearchResults(t *testing.T) {
 			},
 			aIsLess: true,
 		},
+		// ranked file matches, in relevance order
+		{
+			a: &searchResultResolver{
+				fileMatch: &fileMatchResolver{
+					repo: &types.Repo{
+						Name: api.RepoName("b"),
+					},
+					JPath: "a",
+					rank:  1,
+				},
+			},
+			b: &searchResultResolver{
+				fileMatch: &fileMatchResolver{
+					repo: &types.Repo{
+						Name: api.RepoName("a"),
+					},
+					JPath: "a",
+					rank:  2,
+				},
+			},
+			aIsLess: true,
+		},
+		// unranked file matches come after ranked ones
+		{
+			a: &searchResultResolver{
+				fileMatch: &fileMatchResolver{
+					repo: &types.Repo{
+						Name: api.RepoName("a"),
+					},
+					JPath: "a",
+				},
+			},
+			b: &searchResultResolver{
+				fileMatch: &fileMatchResolver{
+					repo: &types.Repo{
+						Name: api.RepoName("b"),
+					},
+					JPath: "a",
+					rank:  2,
+				},
+			},
+			aIsLess: false,
+		},
+		// repo matches come before file matches
+		{
+			a: &searchResultResolver{
+				repo: &repositoryResolver{
+					repo: &types.Repo{
+						Name: api.RepoName("b"),
+					},
+				},
+			},
+			b: &searchResultResolver{
+				fileMatch: &fileMatchResolver{
+					repo: &types.Repo{
+						Name: api.RepoName("a"),
+					},
+					JPath: "a",
+					rank:  1,
+				},
+			},
+			aIsLess: true,
+		},
 	}
 
 	for i, test := range tests {
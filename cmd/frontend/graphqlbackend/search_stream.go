@@ -0,0 +1,439 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/search/backend"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// This file contains the streaming search API. Instead of waiting for every
+// repository to be searched before responding, matches and progress are sent
+// to the client as soon as they are found. It is served as Server-Sent Events
+// by the httpapi package.
+
+// SearchStreamEvent is sent by StreamSearch. Exactly one of its fields is
+// set.
+type SearchStreamEvent struct {
+	// Matches are the results found since the previous event.
+	Matches []*SearchStreamMatch
+
+	// Progress is the status of the search so far. It is sent after every
+	// batch of Matches, and a final time with Done set.
+	Progress *SearchStreamProgress
+}
+
+// SearchStreamMatch is a single search result.
+type SearchStreamMatch struct {
+	// Type is one of "file", "symbol", "repo" or "commit".
+	Type string `json:"type"`
+
+	Repository string `json:"repository"`
+	Commit     string `json:"commit,omitempty"`
+	Revision   string `json:"revision,omitempty"`
+
+	// File and symbol matches.
+	Path        string                   `json:"path,omitempty"`
+	LineMatches []*SearchStreamLineMatch `json:"lineMatches,omitempty"`
+	MatchRanges []*SearchStreamRange     `json:"matchRanges,omitempty"`
+	Symbols     []*SearchStreamSymbol    `json:"symbols,omitempty"`
+	LimitHit    bool                     `json:"limitHit,omitempty"`
+
+	// Commit and diff matches.
+	URL            string `json:"url,omitempty"`
+	Label          string `json:"label,omitempty"`
+	Detail         string `json:"detail,omitempty"`
+	MessagePreview string `json:"messagePreview,omitempty"`
+	DiffPreview    string `json:"diffPreview,omitempty"`
+}
+
+// SearchStreamLineMatch is a matching line in a file.
+type SearchStreamLineMatch struct {
+	Preview          string     `json:"preview"`
+	LineNumber       int32      `json:"lineNumber"`
+	OffsetAndLengths [][2]int32 `json:"offsetAndLengths"`
+}
+
+// SearchStreamRange is the range of a match in a file, which may span
+// multiple lines. Start is inclusive and end is exclusive.
+type SearchStreamRange struct {
+	Start SearchStreamPosition `json:"start"`
+	End   SearchStreamPosition `json:"end"`
+}
+
+// SearchStreamPosition is a zero-based position in a file. Character is
+// measured in characters (not bytes).
+type SearchStreamPosition struct {
+	Line      int32 `json:"line"`
+	Character int32 `json:"character"`
+}
+
+// SearchStreamSymbol is a symbol matching a type:symbol search.
+type SearchStreamSymbol struct {
+	Name          string `json:"name"`
+	ContainerName string `json:"containerName,omitempty"`
+	Kind          string `json:"kind"`
+	Language      string `json:"language,omitempty"`
+	URL           string `json:"url"`
+}
+
+// SearchStreamProgress describes how far along a search is.
+type SearchStreamProgress struct {
+	// Done is true for the last event of a search.
+	Done bool `json:"done"`
+
+	// MatchCount is the number of matches sent so far.
+	MatchCount int `json:"matchCount"`
+
+	// Repositories is the number of repositories being searched.
+	Repositories int `json:"repositories"`
+
+	// RepositoryStatus is the number of repositories in each
+	// search.RepositoryStatusType, eg "searched", "cloning", "timedout" and
+	// "missing".
+	RepositoryStatus map[search.RepositoryStatusType]int `json:"repositoryStatus"`
+
+	LimitHit            bool  `json:"limitHit"`
+	IndexUnavailable    bool  `json:"indexUnavailable,omitempty"`
+	ElapsedMilliseconds int64 `json:"elapsedMilliseconds"`
+
+	// ApproximateResultCount and Alert are only set once Done.
+	ApproximateResultCount string             `json:"approximateResultCount,omitempty"`
+	Alert                  *SearchStreamAlert `json:"alert,omitempty"`
+}
+
+// SearchStreamAlert is an alert shown to the user about the search, such as
+// when no repositories matched.
+type SearchStreamAlert struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// StreamSearch runs the search rawQuery, calling send with matches and
+// progress as they are found. send is never called concurrently. If send
+// returns an error, no further events are sent and the search is canceled.
+func StreamSearch(ctx context.Context, rawQuery string, send func(*SearchStreamEvent) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := &searchStreamer{
+		ctx:    ctx,
+		send:   send,
+		cancel: cancel,
+		start:  time.Now(),
+	}
+
+	if strings.HasPrefix(rawQuery, "!hier!") {
+		r, err := newSearcherResolver(strings.TrimPrefix(rawQuery, "!hier!"))
+		if err != nil {
+			return err
+		}
+		return s.streamHierarchical(ctx, r)
+	}
+
+	q, err := query.ParseAndCheck(rawQuery)
+	if err != nil {
+		log15.Debug("search stream failed to parse", "query", rawQuery, "error", err)
+		return err
+	}
+	if q.Hierarchical {
+		r, err := newBooleanSearcherResolver(q)
+		if err != nil {
+			return err
+		}
+		return s.streamHierarchical(ctx, r)
+	}
+	r := &searchResolver{
+		query:  q,
+		stream: s.stream,
+	}
+	res, err := r.doResults(ctx, "")
+	if err != nil {
+		return s.error(err)
+	}
+	return s.done(res)
+}
+
+// streamHierarchical runs the hierarchical search r, streaming its results.
+func (s *searchStreamer) streamHierarchical(ctx context.Context, r *searcherResolver) error {
+	res, err := r.streamResults(ctx, s)
+	if err != nil {
+		return s.error(err)
+	}
+	return s.done(res)
+}
+
+// send forwards partial results to r.stream if set.
+func (r *searchResolver) send(results []*searchResultResolver, common *searchResultsCommon) {
+	if r.stream != nil {
+		r.stream(results, common)
+	}
+}
+
+// searchStreamer aggregates partial results into events for StreamSearch.
+type searchStreamer struct {
+	ctx    context.Context
+	send   func(*SearchStreamEvent) error
+	cancel context.CancelFunc
+	start  time.Time
+
+	mu         sync.Mutex
+	common     searchResultsCommon
+	matchCount int
+	err        error // first error returned by send
+}
+
+func (s *searchStreamer) stream(results []*searchResultResolver, common *searchResultsCommon) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if common != nil {
+		// resultCount is accounted for by matchCount, since not every
+		// search reports it.
+		delta := *common
+		delta.resultCount = 0
+		s.common.update(delta)
+	}
+
+	if len(results) > 0 {
+		matches := make([]*SearchStreamMatch, 0, len(results))
+		for _, r := range results {
+			s.matchCount += int(r.resultCount())
+			if m := toSearchStreamMatch(s.ctx, r); m != nil {
+				matches = append(matches, m)
+			}
+		}
+		s.sendLocked(&SearchStreamEvent{Matches: matches})
+	}
+
+	s.sendLocked(&SearchStreamEvent{Progress: s.progressLocked(&s.common)})
+}
+
+// done sends the final progress event for the search results res.
+func (s *searchStreamer) done(res *searchResultsResolver) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// res contains the authoritative status of the search, but the match
+	// count reflects what was actually sent.
+	p := s.progressLocked(&res.searchResultsCommon)
+	p.Done = true
+	if len(res.repos) == 0 {
+		// Not every search code path reports the repositories it matched.
+		p.Repositories = len(s.common.repos)
+	}
+	p.LimitHit = p.LimitHit || res.LimitHit()
+	p.ApproximateResultCount = res.ApproximateResultCount()
+	if res.alert != nil {
+		p.Alert = &SearchStreamAlert{
+			Title:       res.alert.title,
+			Description: res.alert.description,
+		}
+	}
+	s.sendLocked(&SearchStreamEvent{Progress: p})
+	return s.err
+}
+
+// error returns the error which caused the search to fail. If the search
+// failed because the client went away, the send error is returned instead.
+func (s *searchStreamer) error(err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	return err
+}
+
+func (s *searchStreamer) sendLocked(ev *SearchStreamEvent) {
+	if s.err != nil {
+		return
+	}
+	if err := s.send(ev); err != nil {
+		s.err = err
+		s.cancel()
+	}
+}
+
+func (s *searchStreamer) progressLocked(common *searchResultsCommon) *SearchStreamProgress {
+	stats := common.stats()
+	return &SearchStreamProgress{
+		MatchCount:          s.matchCount,
+		Repositories:        len(common.repos),
+		RepositoryStatus:    stats.StatusCounts(),
+		LimitHit:            common.limitHit,
+		IndexUnavailable:    common.indexUnavailable,
+		ElapsedMilliseconds: int64(time.Since(s.start) / time.Millisecond),
+	}
+}
+
+// stats returns the status of every repository in c as a search.Stats. This
+// lets the streaming API account for progress the same way as results from
+// the pkg/search searchers (see toSearchResultsCommon for the inverse).
+func (c *searchResultsCommon) stats() *search.Stats {
+	indexed := make(map[api.RepoName]struct{}, len(c.indexed))
+	for _, r := range c.indexed {
+		indexed[r.Name] = struct{}{}
+	}
+
+	stats := &search.Stats{
+		MatchCount: int(c.resultCount),
+	}
+	add := func(repos []*types.Repo, status search.RepositoryStatusType) {
+		for _, r := range repos {
+			source := backend.SourceSearcher
+			if _, ok := indexed[r.Name]; ok {
+				source = backend.SourceZoekt
+			}
+			st := status
+			if _, ok := c.partial[r.Name]; ok && st == search.RepositoryStatusSearched {
+				st = search.RepositoryStatusLimitHit
+			}
+			stats.Status = append(stats.Status, search.RepositoryStatus{
+				Repository: search.Repository{Name: r.Name},
+				Source:     source,
+				Status:     st,
+			})
+		}
+	}
+	add(c.searched, search.RepositoryStatusSearched)
+	add(c.cloning, search.RepositoryStatusCloning)
+	add(c.missing, search.RepositoryStatusMissing)
+	add(c.timedout, search.RepositoryStatusTimedOut)
+
+	if c.indexUnavailable {
+		stats.Unavailable = append(stats.Unavailable, backend.SourceZoekt)
+	}
+	return stats
+}
+
+// toSearchStreamMatch converts a search result into its streaming form.
+func toSearchStreamMatch(ctx context.Context, r *searchResultResolver) *SearchStreamMatch {
+	switch {
+	case r.fileMatch != nil:
+		fm := r.fileMatch
+		m := &SearchStreamMatch{
+			Type:       "file",
+			Repository: string(fm.repo.Name),
+			Commit:     string(fm.commitID),
+			Path:       fm.JPath,
+			LimitHit:   fm.JLimitHit,
+		}
+		if fm.inputRev != nil {
+			m.Revision = *fm.inputRev
+		}
+		for _, lm := range fm.JLineMatches {
+			m.LineMatches = append(m.LineMatches, &SearchStreamLineMatch{
+				Preview:          lm.JPreview,
+				LineNumber:       lm.JLineNumber,
+				OffsetAndLengths: lm.JOffsetAndLengths,
+			})
+		}
+		for _, mr := range fm.JMatchRanges {
+			m.MatchRanges = append(m.MatchRanges, &SearchStreamRange{
+				Start: SearchStreamPosition{Line: mr.JStart.JLine, Character: mr.JStart.JColumn},
+				End:   SearchStreamPosition{Line: mr.JEnd.JLine, Character: mr.JEnd.JColumn},
+			})
+		}
+		for _, sym := range fm.symbols {
+			m.Symbols = append(m.Symbols, &SearchStreamSymbol{
+				Name:          sym.symbol.Name,
+				ContainerName: sym.symbol.ContainerName,
+				Kind:          sym.Kind(),
+				Language:      sym.language,
+				URL:           sym.URL(ctx),
+			})
+		}
+		if len(m.LineMatches) == 0 && len(m.Symbols) > 0 {
+			m.Type = "symbol"
+		}
+		return m
+
+	case r.repo != nil:
+		return &SearchStreamMatch{
+			Type:       "repo",
+			Repository: string(r.repo.repo.Name),
+			URL:        r.repo.URL(),
+		}
+
+	case r.diff != nil:
+		c := r.diff
+		m := &SearchStreamMatch{
+			Type:       "commit",
+			Repository: string(c.commit.repo.repo.Name),
+			Commit:     string(c.commit.oid),
+			URL:        c.url,
+			Label:      c.label,
+			Detail:     c.detail,
+		}
+		if c.messagePreview != nil {
+			m.MessagePreview = c.messagePreview.value
+		}
+		if c.diffPreview != nil {
+			m.DiffPreview = c.diffPreview.value
+		}
+		return m
+
+	default:
+		return nil
+	}
+}
+
+// streamResults is like Results, but sends partial results to s as they are
+// found by the underlying search.StreamSearcher.
+func (r *searcherResolver) streamResults(ctx context.Context, s *searchStreamer) (*searchResultsResolver, error) {
+	sCtx, q, opts, err := r.prepare(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every repository in opts is being searched.
+	repos := make([]*types.Repo, 0, len(opts.Repositories))
+	for _, name := range opts.Repositories {
+		repo, err := sCtx.GetRepo(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	s.stream(nil, &searchResultsCommon{repos: repos})
+
+	var (
+		mu      sync.Mutex
+		sendErr error
+	)
+	collector := &search.Collector{}
+	err = search.StreamSearch(ctx, r.Searcher, q, opts, search.SenderFunc(func(result *search.Result) {
+		collector.Send(result)
+
+		results, err := toSearchResultResolvers(ctx, sCtx, result)
+		if err == nil {
+			var common *searchResultsCommon
+			common, err = toSearchResultsCommon(ctx, sCtx, opts, result)
+			if err == nil {
+				s.stream(results, common)
+				return
+			}
+		}
+		mu.Lock()
+		if sendErr == nil {
+			sendErr = err
+		}
+		mu.Unlock()
+	}))
+	if err == nil {
+		err = sendErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return r.toResultsResolver(ctx, sCtx, opts, collector.Result(), s.start)
+}
@@ -0,0 +1,49 @@
+package graphqlbackend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/search"
+)
+
+func TestSearchResultsCommon_stats(t *testing.T) {
+	repo := func(id api.RepoID, name string) *types.Repo {
+		return &types.Repo{ID: id, Name: api.RepoName(name)}
+	}
+	a, b, c, d, e := repo(1, "a"), repo(2, "b"), repo(3, "c"), repo(4, "d"), repo(5, "e")
+	common := &searchResultsCommon{
+		repos:            []*types.Repo{a, b, c, d, e},
+		searched:         []*types.Repo{a, b},
+		indexed:          []*types.Repo{a},
+		cloning:          []*types.Repo{c},
+		missing:          []*types.Repo{d},
+		timedout:         []*types.Repo{e},
+		partial:          map[api.RepoName]struct{}{"b": {}},
+		resultCount:      3,
+		indexUnavailable: true,
+	}
+
+	stats := common.stats()
+	if stats.MatchCount != 3 {
+		t.Errorf("got MatchCount %d, want 3", stats.MatchCount)
+	}
+	wantCounts := map[search.RepositoryStatusType]int{
+		search.RepositoryStatusSearched: 1,
+		search.RepositoryStatusLimitHit: 1,
+		search.RepositoryStatusCloning:  1,
+		search.RepositoryStatusMissing:  1,
+		search.RepositoryStatusTimedOut: 1,
+	}
+	if got := stats.StatusCounts(); !reflect.DeepEqual(got, wantCounts) {
+		t.Errorf("got status counts %v, want %v", got, wantCounts)
+	}
+	if got := stats.Status[0].Source; got != "textindexed" {
+		t.Errorf("got source %q for indexed repo, want textindexed", got)
+	}
+	if len(stats.Unavailable) != 1 {
+		t.Errorf("expected indexed search to be unavailable, got %v", stats.Unavailable)
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/google/zoekt"
 	zoektquery "github.com/google/zoekt/query"
+	"github.com/sourcegraph/go-lsp"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
@@ -53,9 +54,11 @@ var (
 
 // fileMatchResolver is a resolver for the GraphQL type `FileMatch`
 type fileMatchResolver struct {
-	JPath        string       `json:"Path"`
-	JLineMatches []*lineMatch `json:"LineMatches"`
-	JLimitHit    bool         `json:"LimitHit"`
+	JPath        string        `json:"Path"`
+	JLineMatches []*lineMatch  `json:"LineMatches"`
+	JMatchRanges []*matchRange `json:"MatchRanges"`
+	JPatch       string        `json:"Patch"`
+	JLimitHit    bool          `json:"LimitHit"`
 	symbols      []*symbolResolver
 	uri          string
 	repo         *types.Repo
@@ -64,6 +67,9 @@ type fileMatchResolver struct {
 	// preserve the original revision specifier from the user instead of navigating them to the
 	// absolute commit ID when they select a result.
 	inputRev *string
+	// rank is the position of the match when ordered by relevance (see
+	// rankFileMatches), starting at 1, or 0 if it was not ranked.
+	rank int
 }
 
 func (fm *fileMatchResolver) Key() string {
@@ -101,6 +107,14 @@ func (fm *fileMatchResolver) LineMatches() []*lineMatch {
 	return fm.JLineMatches
 }
 
+func (fm *fileMatchResolver) MatchRanges() []*rangeResolver {
+	r := make([]*rangeResolver, len(fm.JMatchRanges))
+	for i, mr := range fm.JMatchRanges {
+		r[i] = &rangeResolver{mr.lspRange()}
+	}
+	return r
+}
+
 func (fm *fileMatchResolver) LimitHit() bool {
 	return fm.JLimitHit
 }
@@ -133,6 +147,29 @@ func (lm *lineMatch) LimitHit() bool {
 	return lm.JLimitHit
 }
 
+// matchRange is the range of a match in a file. Unlike a lineMatch, it may
+// span multiple lines. It is the struct used by searcher to send the range
+// of each match.
+type matchRange struct {
+	JStart matchLocation `json:"Start"`
+	JEnd   matchLocation `json:"End"`
+}
+
+// matchLocation is a position in a file. Line and Column are 0-based, and
+// Column is measured in characters (not bytes).
+type matchLocation struct {
+	JOffset int32 `json:"Offset"`
+	JLine   int32 `json:"Line"`
+	JColumn int32 `json:"Column"`
+}
+
+func (r *matchRange) lspRange() lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: int(r.JStart.JLine), Character: int(r.JStart.JColumn)},
+		End:   lsp.Position{Line: int(r.JEnd.JLine), Character: int(r.JEnd.JColumn)},
+	}
+}
+
 // textSearch searches repo@commit with p.
 // Note: the returned matches do not set fileMatch.uri
 func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
@@ -181,6 +218,13 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 	if p.IsCaseSensitive {
 		q.Set("IsCaseSensitive", "true")
 	}
+	if p.IsStructuralPat {
+		q.Set("IsStructuralPat", "true")
+	}
+	if p.IsReplace {
+		q.Set("IsReplace", "true")
+		q.Set("Replacement", p.Replacement)
+	}
 	if p.PathPatternsAreRegExps {
 		q.Set("PathPatternsAreRegExps", "true")
 	}
@@ -510,6 +554,7 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 			limitHit = true
 		}
 		lines := make([]*lineMatch, 0, len(file.LineMatches))
+		var ranges []*matchRange
 		for _, l := range file.LineMatches {
 			if !l.FileName {
 				if len(l.LineFragments) > maxLineFragmentMatches {
@@ -520,6 +565,13 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 					offset := utf8.RuneCount(l.Line[:m.LineOffset])
 					length := utf8.RuneCount(l.Line[m.LineOffset : m.LineOffset+m.MatchLength])
 					offsets[k] = [2]int32{int32(offset), int32(length)}
+
+					// Zoekt matches never span lines.
+					line := int32(l.LineNumber - 1)
+					ranges = append(ranges, &matchRange{
+						JStart: matchLocation{JOffset: int32(m.Offset), JLine: line, JColumn: int32(offset)},
+						JEnd:   matchLocation{JOffset: int32(int(m.Offset) + m.MatchLength), JLine: line, JColumn: int32(offset + length)},
+					})
 				}
 				lines = append(lines, &lineMatch{
 					JPreview:          string(l.Line),
@@ -532,6 +584,7 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 		matches[i] = &fileMatchResolver{
 			JPath:        file.FileName,
 			JLineMatches: lines,
+			JMatchRanges: ranges,
 			JLimitHit:    fileLimitHit,
 			uri:          fileMatchURI(repo.Name, "", file.FileName),
 			repo:         repo,
@@ -673,6 +726,18 @@ var mockSearchFilesInRepos func(args *search.Args) ([]*fileMatchResolver, *searc
 
 // searchFilesInRepos searches a set of repos for a pattern.
 func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatchResolver, common *searchResultsCommon, err error) {
+	return searchFilesInReposStream(ctx, args, nil)
+}
+
+// fileMatchStream is called with the matches and status of each set of
+// repositories as soon as they have been searched. The searchResultsCommon
+// only describes those repositories.
+type fileMatchStream func([]*fileMatchResolver, *searchResultsCommon)
+
+// searchFilesInReposStream is like searchFilesInRepos, but additionally
+// sends partial results to stream if it is non-nil. The results sent to
+// stream are not limited to FileMatchLimit.
+func searchFilesInReposStream(ctx context.Context, args *search.Args, stream fileMatchStream) (res []*fileMatchResolver, common *searchResultsCommon, err error) {
 	if mockSearchFilesInRepos != nil {
 		return mockSearchFilesInRepos(args)
 	}
@@ -683,6 +748,10 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		tr.Finish()
 	}()
 
+	// We stop searching once we have found enough matches, but still want
+	// to rank them.
+	rankCtx := ctx
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -735,6 +804,15 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		}
 	}
 
+	if args.Pattern.IsStructuralPat || args.Pattern.IsReplace || args.Pattern.MatchesNewline() {
+		// Zoekt can't evaluate structural templates or replacements, or
+		// report matches that span lines, so use searcher for indexed repos
+		// too.
+		tr.LazyPrintf("structural, replace or multiline search, using searcher for %d indexed repos", len(zoektRepos))
+		searcherRepos = append(searcherRepos, zoektRepos...)
+		zoektRepos = nil
+	}
+
 	var (
 		wg                sync.WaitGroup
 		mu                sync.Mutex
@@ -799,22 +877,26 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 				tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.String("searchErr", searchErr.Error()), otlog.Bool("timeout", errcode.IsTimeout(searchErr)), otlog.Bool("temporary", errcode.IsTemporary(searchErr)))
 			}
 			mu.Lock()
-			defer mu.Unlock()
+			// repoCommon is the status of just this repository.
+			repoCommon := searchResultsCommon{partial: make(map[api.RepoName]struct{})}
 			if ctx.Err() == nil {
-				common.searched = append(common.searched, repoRev.Repo)
+				repoCommon.searched = append(repoCommon.searched, repoRev.Repo)
 			}
 			if repoLimitHit {
 				// We did not return all results in this repository.
-				common.partial[repoRev.Repo.Name] = struct{}{}
+				repoCommon.partial[repoRev.Repo.Name] = struct{}{}
 			}
 			// non-diff search reports timeout through searchErr, so pass false for timedOut
-			if fatalErr := handleRepoSearchResult(common, repoRev, repoLimitHit, false, searchErr); fatalErr != nil {
+			fatalErr := handleRepoSearchResult(&repoCommon, repoRev, repoLimitHit, false, searchErr)
+			common.update(repoCommon)
+			if fatalErr != nil {
 				if ctx.Err() == context.Canceled {
 					// Our request has been canceled (either because another one of searcherRepos
 					// had a fatal error, or otherwise), so we can just ignore these results. We
 					// handle this here, not in handleRepoSearchResult, because different callers of
 					// handleRepoSearchResult (for different result types) currently all need to
 					// handle cancellations differently.
+					mu.Unlock()
 					return
 				}
 				err = errors.Wrapf(searchErr, "failed to search %s", repoRev.String())
@@ -822,6 +904,15 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 				cancel()
 			}
 			addMatches(matches)
+			batch := streamBatch(stream, matches)
+			mu.Unlock()
+
+			// Stream outside of mu, so that a slow client doesn't block the
+			// other searches.
+			if batch != nil {
+				repoCommon.resultCount = int32(len(batch))
+				stream(batch, &repoCommon)
+			}
 		}(*repoRev)
 	}
 
@@ -831,29 +922,38 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		defer wg.Done()
 		matches, limitHit, reposLimitHit, searchErr := zoektSearchHEAD(ctx, args.Pattern, zoektRepos, args.UseFullDeadline)
 		mu.Lock()
-		defer mu.Unlock()
+		// indexedCommon is the status of just the indexed repositories.
+		indexedCommon := searchResultsCommon{partial: make(map[api.RepoName]struct{})}
 		if ctx.Err() == nil {
 			for _, repo := range zoektRepos {
-				common.searched = append(common.searched, repo.Repo)
-				common.indexed = append(common.indexed, repo.Repo)
+				indexedCommon.searched = append(indexedCommon.searched, repo.Repo)
+				indexedCommon.indexed = append(indexedCommon.indexed, repo.Repo)
 			}
 			for repo := range reposLimitHit {
 				// Repos that aren't included in the result set due to exceeded limits are partially searched
 				// for dynamic filter purposes. Note, reposLimitHit may include repos that did not have any results
 				// returned in the original result set, because indexed search has `limitHit` for the
 				// entire search rather than per repo as in non-indexed search.
-				common.partial[api.RepoName(repo)] = struct{}{}
+				indexedCommon.partial[api.RepoName(repo)] = struct{}{}
 			}
 		}
 		if limitHit {
-			common.limitHit = true
+			indexedCommon.limitHit = true
 		}
+		common.update(indexedCommon)
 		if searchErr != nil && err == nil && !overLimitCanceled {
 			err = searchErr
 			tr.LazyPrintf("cancel indexed search due to error: %v", err)
 			cancel()
 		}
 		addMatches(matches)
+		batch := streamBatch(stream, matches)
+		mu.Unlock()
+
+		if batch != nil {
+			indexedCommon.resultCount = int32(len(batch))
+			stream(batch, &indexedCommon)
+		}
 	}()
 
 	wg.Wait()
@@ -861,10 +961,33 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		return nil, common, err
 	}
 
+	// Rank all matches before trimming them to the limit, so that the most
+	// relevant ones are kept.
+	var all []*fileMatchResolver
+	for _, matches := range unflattened {
+		all = append(all, matches...)
+	}
+	if rankFileMatches(rankCtx, all) {
+		if limit := int(args.Pattern.FileMatchLimit); len(all) > limit {
+			all = all[:limit]
+		}
+		return all, common, nil
+	}
+
 	flattened := flattenFileMatches(unflattened, int(args.Pattern.FileMatchLimit))
 	return flattened, common, nil
 }
 
+// streamBatch returns a copy of matches to send to stream, or nil if stream is
+// nil. The caller must hold the lock that guards matches, and call stream with
+// the copy after releasing it.
+func streamBatch(stream fileMatchStream, matches []*fileMatchResolver) []*fileMatchResolver {
+	if stream == nil {
+		return nil
+	}
+	return append([]*fileMatchResolver{}, matches...)
+}
+
 func flattenFileMatches(unflattened [][]*fileMatchResolver, fileMatchLimit int) []*fileMatchResolver {
 	// Return early so we don't have to worry about empty lists in later
 	// calculations.
@@ -2,6 +2,7 @@ package graphqlbackend
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
 	"testing"
@@ -15,6 +16,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	pkgsearch "github.com/sourcegraph/sourcegraph/pkg/search"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
 )
@@ -158,6 +160,8 @@ func TestSearchFilesInRepos(t *testing.T) {
 		}
 	}
 	defer func() { mockSearchFilesInRepo = nil }()
+	defer func(orig func() pkgsearch.Ranker) { fileMatchRanker = orig }(fileMatchRanker)
+	fileMatchRanker = func() pkgsearch.Ranker { return nil }
 
 	q, err := query.ParseAndCheck("foo")
 	if err != nil {
@@ -205,6 +209,60 @@ func TestSearchFilesInRepos(t *testing.T) {
 	}
 }
 
+func TestSearchFilesInRepos_ranked(t *testing.T) {
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
+		var paths []string
+		switch repo.Name {
+		case "foo/one":
+			paths = []string{"vendor/a/b/c.go", "main.go"}
+		case "foo/two":
+			paths = []string{"a/b/c.go"}
+		}
+		for _, p := range paths {
+			matches = append(matches, &fileMatchResolver{
+				JPath: p,
+				uri:   "git://" + string(repo.Name) + "?" + rev + "#" + p,
+				repo:  repo,
+			})
+		}
+		return matches, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+	defer func(orig func() pkgsearch.Ranker) { fileMatchRanker = orig }(fileMatchRanker)
+	fileMatchRanker = func() pkgsearch.Ranker {
+		return &pkgsearch.ScoreRanker{Signals: []pkgsearch.WeightedSignal{
+			{Name: "vendored", Weight: 4, Signal: pkgsearch.VendoredSignal},
+			{Name: "depth", Weight: 1, Signal: pkgsearch.PathDepthSignal},
+		}}
+	}
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &search.Args{
+		Pattern: &search.PatternInfo{
+			FileMatchLimit: 2,
+			Pattern:        "foo",
+		},
+		Repos: makeRepositoryRevisions("foo/one", "foo/two"),
+		Query: q,
+	}
+	results, _, err := searchFilesInRepos(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The vendored file is the least relevant, so it is trimmed.
+	var got []string
+	for _, fm := range results {
+		got = append(got, fmt.Sprintf("%d %s/%s", fm.rank, fm.repo.Name, fm.JPath))
+	}
+	if want := []string{"1 foo/one/main.go", "2 foo/two/a/b/c.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func makeRepositoryRevisions(repos ...string) []*search.RepositoryRevisions {
 	r := make([]*search.RepositoryRevisions, len(repos))
 	for i, repospec := range repos {
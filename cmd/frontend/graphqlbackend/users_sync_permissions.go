@@ -0,0 +1,33 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/permsync"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+)
+
+func (*schemaResolver) SyncUserPermissions(ctx context.Context, args *struct {
+	User graphql.ID
+}) (*EmptyResponse, error) {
+	userID, err := UnmarshalUserID(args.User)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🚨 SECURITY: Only the user and site admins are allowed to sync the user's permissions.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if enabled, _, _ := conf.PermissionsBackgroundSync(); !enabled {
+		return nil, errors.New("background sync of repository permissions is not enabled (permissions.backgroundSync in the site configuration)")
+	}
+	if err := permsync.SyncUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
@@ -20,7 +20,9 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/app/pkg/updatecheck"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/cli/loghandlers"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions/mailreply"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/permsync"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/siteid"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
@@ -168,6 +170,8 @@ func Main() error {
 	}
 
 	goroutine.Go(mailreply.StartWorker)
+	goroutine.Go(discussions.StartWebhookWorker)
+	goroutine.Go(permsync.StartWorker)
 	go updatecheck.Start()
 	if hooks.AfterDBInit != nil {
 		hooks.AfterDBInit()
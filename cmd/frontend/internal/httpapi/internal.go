@@ -16,6 +16,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
@@ -39,6 +40,20 @@ func serveReposGetByName(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+// serveReposGetByExternalRepo returns the repository with the requested
+// external repository, or null if there is none.
+func serveReposGetByExternalRepo(w http.ResponseWriter, r *http.Request) error {
+	var spec api.ExternalRepoSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		return errors.Wrap(err, "Decode")
+	}
+	repo, err := db.Repos.GetByExternalRepo(r.Context(), spec)
+	if err != nil {
+		return errors.Wrap(err, "Repos.GetByExternalRepo")
+	}
+	return json.NewEncoder(w).Encode(repo)
+}
+
 func serveReposCreateIfNotExists(w http.ResponseWriter, r *http.Request) error {
 	var repo api.RepoCreateOrUpdateRequest
 	err := json.NewDecoder(r.Body).Decode(&repo)
@@ -156,6 +171,20 @@ func serveExternalServicesList(w http.ResponseWriter, r *http.Request) error {
 	return json.NewEncoder(w).Encode(services)
 }
 
+// serveExternalServicesSetSyncStatus records the outcome of the last sync of an external service
+// by repo-updater.
+func serveExternalServicesSetSyncStatus(w http.ResponseWriter, r *http.Request) error {
+	var status api.ExternalServiceSyncStatus
+	if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+		return err
+	}
+	if err := db.ExternalServices.SetSyncStatus(r.Context(), &status); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
 func serveReposInventoryUncached(w http.ResponseWriter, r *http.Request) error {
 	var req api.ReposGetInventoryUncachedRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -256,6 +285,28 @@ func serveReposListEnabled(w http.ResponseWriter, r *http.Request) error {
 	return json.NewEncoder(w).Encode(names)
 }
 
+// serveReposListReadable returns the repositories among those requested which
+// the given user can read. It checks the permissions as that user, not as the
+// internal actor the request is made as.
+func serveReposListReadable(w http.ResponseWriter, r *http.Request) error {
+	var req api.ReposListReadableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return errors.Wrap(err, "Decode")
+	}
+	names := make([]api.RepoName, 0, len(req.Repos))
+	if req.UserID != 0 && len(req.Repos) > 0 {
+		ctx := actor.WithActor(r.Context(), actor.FromUser(req.UserID))
+		repos, err := db.Repos.List(ctx, db.ReposListOptions{Names: req.Repos, Enabled: true})
+		if err != nil {
+			return errors.Wrap(err, "Repos.List")
+		}
+		for _, repo := range repos {
+			names = append(names, repo.Name)
+		}
+	}
+	return json.NewEncoder(w).Encode(names)
+}
+
 func serveSavedQueriesListAll(w http.ResponseWriter, r *http.Request) error {
 	// List settings for all users, orgs, etc.
 	settings, err := db.Settings.ListAll(r.Context())
@@ -311,6 +362,8 @@ func serveSavedQueriesSetInfo(w http.ResponseWriter, r *http.Request) error {
 		LastExecuted: info.LastExecuted,
 		LatestResult: info.LatestResult,
 		ExecDuration: info.ExecDuration,
+
+		ResultFingerprints: info.ResultFingerprints,
 	})
 	if err != nil {
 		return errors.Wrap(err, "SavedQueries.Set")
@@ -335,6 +388,29 @@ func serveSavedQueriesDeleteInfo(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func serveSavedQueriesRecordRun(w http.ResponseWriter, r *http.Request) error {
+	var run *api.SavedQueryRun
+	err := json.NewDecoder(r.Body).Decode(&run)
+	if err != nil {
+		return errors.Wrap(err, "Decode")
+	}
+	err = db.SavedQueryRuns.Create(r.Context(), &db.SavedQueryRun{
+		Spec:         run.Spec,
+		Query:        run.Query,
+		StartedAt:    run.StartedAt,
+		ExecDuration: run.ExecDuration,
+		ResultCount:  run.ResultCount,
+		Error:        run.Error,
+		Notified:     run.Notified,
+	})
+	if err != nil {
+		return errors.Wrap(err, "SavedQueryRuns.Create")
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+	return nil
+}
+
 func serveSettingsGetForSubject(w http.ResponseWriter, r *http.Request) error {
 	var subject api.SettingsSubject
 	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
@@ -11,14 +11,17 @@ const (
 
 	Registry = "registry"
 
-	RepoShield  = "repo.shield"
-	RepoRefresh = "repo.refresh"
-	Telemetry   = "telemetry"
+	RepoShield   = "repo.shield"
+	RepoRefresh  = "repo.refresh"
+	SearchStream = "search.stream"
+	Telemetry    = "telemetry"
+	Webhooks     = "webhooks"
 
 	SavedQueriesListAll    = "internal.saved-queries.list-all"
 	SavedQueriesGetInfo    = "internal.saved-queries.get-info"
 	SavedQueriesSetInfo    = "internal.saved-queries.set-info"
 	SavedQueriesDeleteInfo = "internal.saved-queries.delete-info"
+	SavedQueriesRecordRun  = "internal.saved-queries.record-run"
 	SettingsGetForSubject  = "internal.settings.get-for-subject"
 	OrgsListUsers          = "internal.orgs.list-users"
 	OrgsGetByName          = "internal.orgs.get-by-name"
@@ -35,15 +38,18 @@ const (
 	GitUploadPack          = "internal.git.upload-pack"
 	PhabricatorRepoCreate  = "internal.phabricator.repo.create"
 	ReposCreateIfNotExists = "internal.repos.create-if-not-exists"
+	ReposGetByExternalRepo = "internal.repos.get-by-external-repo"
 	ReposGetByName         = "internal.repos.get-by-name"
 	ReposInventoryUncached = "internal.repos.inventory-uncached"
 	ReposInventory         = "internal.repos.inventory"
 	ReposList              = "internal.repos.list"
 	ReposListEnabled       = "internal.repos.list-enabled"
+	ReposListReadable      = "internal.repos.list-readable"
 	ReposUpdateMetadata    = "internal.repos.update-metadata"
 	Configuration          = "internal.configuration"
 	ExternalServiceConfigs = "internal.external-services.configs"
 	ExternalServicesList   = "internal.external-services.list"
+	ExternalServicesStatus = "internal.external-services.sync-status"
 )
 
 // New creates a new API router with route URL pattern definitions but
@@ -59,6 +65,9 @@ func New(base *mux.Router) *mux.Router {
 	addGraphQLRoute(base)
 	addTelemetryRoute(base)
 
+	base.Path("/search/stream").Methods("GET").Name(SearchStream)
+	base.Path("/webhooks/{Kind:github|gitlab|bitbucket-server}").Methods("POST").Name(Webhooks)
+
 	// repo contains routes that are NOT specific to a revision. In these routes, the URL may not contain a revspec after the repo (that is, no "github.com/foo/bar@myrevspec").
 	repoPath := `/repos/` + routevar.Repo
 
@@ -83,6 +92,7 @@ func NewInternal(base *mux.Router) *mux.Router {
 	base.Path("/saved-queries/get-info").Methods("POST").Name(SavedQueriesGetInfo)
 	base.Path("/saved-queries/set-info").Methods("POST").Name(SavedQueriesSetInfo)
 	base.Path("/saved-queries/delete-info").Methods("POST").Name(SavedQueriesDeleteInfo)
+	base.Path("/saved-queries/record-run").Methods("POST").Name(SavedQueriesRecordRun)
 	base.Path("/settings/get-for-subject").Methods("POST").Name(SettingsGetForSubject)
 	base.Path("/orgs/list-users").Methods("POST").Name(OrgsListUsers)
 	base.Path("/orgs/get-by-name").Methods("POST").Name(OrgsGetByName)
@@ -100,11 +110,14 @@ func NewInternal(base *mux.Router) *mux.Router {
 	base.Path("/phabricator/repo-create").Methods("POST").Name(PhabricatorRepoCreate)
 	base.Path("/external-services/configs").Methods("POST").Name(ExternalServiceConfigs)
 	base.Path("/external-services/list").Methods("POST").Name(ExternalServicesList)
+	base.Path("/external-services/sync-status").Methods("POST").Name(ExternalServicesStatus)
 	base.Path("/repos/create-if-not-exists").Methods("POST").Name(ReposCreateIfNotExists)
+	base.Path("/repos/get-by-external-repo").Methods("POST").Name(ReposGetByExternalRepo)
 	base.Path("/repos/inventory-uncached").Methods("POST").Name(ReposInventoryUncached)
 	base.Path("/repos/inventory").Methods("POST").Name(ReposInventory)
 	base.Path("/repos/list").Methods("POST").Name(ReposList)
 	base.Path("/repos/list-enabled").Methods("POST").Name(ReposListEnabled)
+	base.Path("/repos/list-readable").Methods("POST").Name(ReposListReadable)
 	base.Path("/repos/update-metadata").Methods("POST").Name(ReposUpdateMetadata)
 	base.Path("/repos/{RepoName:.*}").Methods("POST").Name(ReposGetByName)
 	base.Path("/configuration").Methods("POST").Name(Configuration)
@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+)
+
+// serveSearchStream streams search results for the query in the "q" URL
+// parameter as Server-Sent Events. It sends "matches" events containing a
+// JSON array of matches, "progress" events containing the status of the
+// repositories searched so far, and finishes with a "done" event containing
+// the final progress. If the search fails an "error" event is sent instead.
+func serveSearchStream(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		return errors.New("search query (q) is required")
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("http flushing not supported")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // disable buffering by nginx
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	err := graphqlbackend.StreamSearch(r.Context(), q, func(ev *graphqlbackend.SearchStreamEvent) error {
+		var err error
+		switch {
+		case ev.Matches != nil:
+			err = writeEvent(w, "matches", ev.Matches)
+		case ev.Progress != nil && ev.Progress.Done:
+			err = writeEvent(w, "done", ev.Progress)
+		case ev.Progress != nil:
+			err = writeEvent(w, "progress", ev.Progress)
+		}
+		if err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil && r.Context().Err() == nil {
+		// We have already written the response header, so we can only
+		// report errors as an event.
+		_ = writeEvent(w, "error", struct {
+			Message string `json:"message"`
+		}{Message: err.Error()})
+		flusher.Flush()
+	}
+	return nil
+}
+
+// writeEvent writes a single Server-Sent Event named event with the JSON
+// encoding of v as its data.
+func writeEvent(w io.Writer, event string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "event: %s\n", event)
+	// JSON never contains raw newlines, so the data fits on a single data
+	// line.
+	buf.WriteString("data: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,21 @@
+package httpapi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteEvent(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeEvent(&buf, "progress", map[string]interface{}{
+		"done":    false,
+		"message": "line1\nline2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "event: progress\ndata: {\"done\":false,\"message\":\"line1\\nline2\"}\n\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
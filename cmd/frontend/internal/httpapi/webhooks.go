@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/sourcegraph/sourcegraph/pkg/env"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
+)
+
+// webhooksHandler forwards webhook deliveries from code hosts to repo-updater,
+// which validates them and enqueues updates of the repositories they refer to.
+//
+// 🚨 SECURITY: This handler is accessible to anonymous users. repo-updater
+// rejects deliveries that aren't signed with a configured webhook secret.
+var webhooksHandler = &httputil.ReverseProxy{
+	Director: func(req *http.Request) {
+		u, err := url.Parse(repoupdater.DefaultClient.URL)
+		if err != nil {
+			log.Printf("webhooks proxy: invalid repo-updater URL %q: %s", repoupdater.DefaultClient.URL, err)
+			return
+		}
+		req.URL.Scheme = u.Scheme
+		req.URL.Host = u.Host
+		req.URL.Path = "/webhooks/" + mux.Vars(req)["Kind"]
+		req.URL.RawQuery = ""
+		req.Host = u.Host
+		req.Header.Del("Cookie")
+		req.Header.Del("Authorization")
+	},
+	ErrorLog: log.New(env.DebugOut, "webhooks proxy: ", log.LstdFlags),
+}
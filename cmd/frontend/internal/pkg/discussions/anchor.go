@@ -0,0 +1,268 @@
+package discussions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// Anchor is the location of the lines that a thread's selection refers to in
+// some commit.
+type Anchor struct {
+	// Path is the path of the file which contains the lines.
+	Path string
+
+	// Lines is the range of the lines in the file.
+	Lines LineRange
+
+	// Moved is whether the lines were moved (to another place in the file, or
+	// to another file), as opposed to staying in place while the lines around
+	// them changed.
+	Moved bool
+}
+
+// anchorCache caches the anchors of thread selections by thread and commit.
+// They never change, because both the revision of the thread and the commit
+// are immutable, but expire so that anchors of old commits don't accumulate.
+var anchorCache = rcache.NewWithTTL("discussions_anchor", 7*24*3600) // 1 week
+
+// TrackAnchor returns the location of the thread target's selection in the
+// given commit, by mapping it through the changes between the target's
+// revision and the commit. It returns nil if the selected lines were deleted.
+//
+// The target must have a path, a revision and a selection.
+func TrackAnchor(ctx context.Context, repo gitserver.Repo, tr *types.DiscussionThreadTargetRepo, commit api.CommitID) (*Anchor, error) {
+	if tr.Path == nil || tr.Revision == nil || !tr.HasSelection() {
+		return nil, errors.New("thread target must have a path, revision and selection")
+	}
+	if !git.IsAbsoluteRevision(string(commit)) {
+		return nil, fmt.Errorf("non-absolute commit ID: %q", commit)
+	}
+
+	cacheKey := fmt.Sprintf("%d:%s", tr.ThreadID, commit)
+	if b, ok := anchorCache.Get(cacheKey); ok {
+		var anchor *Anchor
+		if err := json.Unmarshal(b, &anchor); err == nil {
+			return anchor, nil
+		}
+	}
+
+	selection := LineRange{StartLine: int(*tr.StartLine), EndLine: int(*tr.EndLine)}
+	anchor := &Anchor{Path: *tr.Path, Lines: selection}
+	if base := api.CommitID(*tr.Revision); base != commit {
+		// Diffing every file (to detect renames and moved lines) is expensive
+		// in large repositories, so we only do it if the diff of the thread's
+		// file is not enough.
+		fileDiffs, err := git.DiffFiles(ctx, repo, base, commit, []string{*tr.Path})
+		if err != nil {
+			return nil, err
+		}
+		if needsFullDiff(fileDiffs, *tr.Path, selection) {
+			fileDiffs, err = git.DiffFiles(ctx, repo, base, commit, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		anchor = MapAnchor(fileDiffs, *tr.Path, selection)
+	}
+
+	if b, err := json.Marshal(anchor); err == nil {
+		anchorCache.Set(cacheKey, b)
+	}
+	return anchor, nil
+}
+
+// MapAnchor maps the selected lines of the file at path through the changes
+// described by fileDiffs. It returns nil if the lines were deleted.
+//
+// If the selected lines were all removed, but the exact same lines (ignoring
+// indentation) were added in exactly one other place, they are considered to
+// be moved there. If lines of the selection were changed, the selection
+// extends to the lines that replaced them.
+func MapAnchor(fileDiffs []*git.FileDiff, path string, selection LineRange) *Anchor {
+	var fileDiff *git.FileDiff
+	for _, fd := range fileDiffs {
+		if fd.OrigName == path {
+			fileDiff = fd
+			break
+		}
+	}
+	if fileDiff == nil {
+		// The file did not change.
+		return &Anchor{Path: path, Lines: selection}
+	}
+
+	// An empty selection anchors the position before its start line.
+	empty := selection.EndLine <= selection.StartLine
+	if empty {
+		selection.EndLine = selection.StartLine + 1
+	}
+
+	removed, replaced := removedLines(fileDiff, selection)
+	if len(removed) == selection.EndLine-selection.StartLine {
+		if anchor := findMovedLines(fileDiffs, removed); anchor != nil {
+			if empty {
+				anchor.Lines.EndLine = anchor.Lines.StartLine
+			}
+			return anchor
+		}
+		if !replaced {
+			return nil // all selected lines were deleted
+		}
+	}
+	if fileDiff.NewName == "" {
+		return nil // the file was deleted
+	}
+
+	anchor := &Anchor{
+		Path: fileDiff.NewName,
+		Lines: LineRange{
+			StartLine: mapLineBoundary(fileDiff.Hunks, selection.StartLine, true),
+			EndLine:   mapLineBoundary(fileDiff.Hunks, selection.EndLine, false),
+		},
+	}
+	if empty {
+		anchor.Lines.EndLine = anchor.Lines.StartLine
+	}
+	return anchor
+}
+
+// needsFullDiff reports whether MapAnchor needs the changes to every file to
+// map the selection, given fileDiffs of only the file at path. That is the
+// case if all selected lines were removed, because they may have been moved to
+// another file, or if the file was deleted, which is how a diff of only the
+// file reports a rename.
+func needsFullDiff(fileDiffs []*git.FileDiff, path string, selection LineRange) bool {
+	for _, fd := range fileDiffs {
+		if fd.OrigName != path {
+			continue
+		}
+		if fd.NewName == "" {
+			return true
+		}
+		if selection.EndLine <= selection.StartLine {
+			selection.EndLine = selection.StartLine + 1
+		}
+		removed, _ := removedLines(fd, selection)
+		return len(removed) == selection.EndLine-selection.StartLine
+	}
+	return false // the file did not change
+}
+
+// removedLines returns the lines of the (non-empty) selection which were
+// removed, and whether any of them were replaced with other lines.
+func removedLines(fileDiff *git.FileDiff, selection LineRange) (removed []string, replaced bool) {
+	for _, h := range fileDiff.Hunks {
+		start, _ := diffHunkStarts(h)
+		for i := range h.Removed {
+			if line := start + i; line >= selection.StartLine && line < selection.EndLine {
+				removed = append(removed, h.Removed[i])
+				replaced = replaced || h.NewLines > 0
+			}
+		}
+	}
+	return removed, replaced
+}
+
+// diffHunkStarts returns the zero-based line numbers in the original and the
+// new file at which the hunk's lines were removed and added.
+func diffHunkStarts(h *git.DiffHunk) (origStart, newStart int) {
+	// Hunk headers refer to the line after which lines were added or removed
+	// if there are none.
+	origStart, newStart = h.OrigStartLine, h.NewStartLine
+	if h.OrigLines > 0 {
+		origStart--
+	}
+	if h.NewLines > 0 {
+		newStart--
+	}
+	return origStart, newStart
+}
+
+// mapLineBoundary maps the zero-based line boundary (before line) of the
+// original file to the new file. If the line was removed, start selects
+// whether the boundary moves to the start or the end of the lines which
+// replaced it. Lines added exactly at the boundary are outside of the
+// selection that line starts (if start) or ends.
+func mapLineBoundary(hunks []*git.DiffHunk, line int, start bool) int {
+	delta := 0
+	for _, h := range hunks {
+		origStart, newStart := diffHunkStarts(h)
+		origEnd := origStart + h.OrigLines
+		switch {
+		case origEnd < line || (origEnd == line && (h.OrigLines > 0 || start)):
+			// The hunk is before the boundary.
+			delta += h.NewLines - h.OrigLines
+		case origStart < line:
+			// The boundary is in the removed lines.
+			if start {
+				return newStart
+			}
+			return newStart + h.NewLines
+		default:
+			// This and the following hunks are after the boundary.
+			return line + delta
+		}
+	}
+	return line + delta
+}
+
+// findMovedLines returns the location where the given lines were added, if
+// they were added in exactly one place. Indentation is ignored, and lines
+// which are all blank are never considered to be moved.
+func findMovedLines(fileDiffs []*git.FileDiff, lines []string) *Anchor {
+	blank := true
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			blank = false
+			break
+		}
+	}
+	if blank {
+		return nil
+	}
+
+	var found *Anchor
+	for _, fd := range fileDiffs {
+		if fd.NewName == "" {
+			continue
+		}
+		for _, h := range fd.Hunks {
+			_, newStart := diffHunkStarts(h)
+			for i := 0; i+len(lines) <= len(h.Added); i++ {
+				if !equalIgnoringIndentation(h.Added[i:i+len(lines)], lines) {
+					continue
+				}
+				if found != nil {
+					return nil // ambiguous
+				}
+				found = &Anchor{
+					Path:  fd.NewName,
+					Lines: LineRange{StartLine: newStart + i, EndLine: newStart + i + len(lines)},
+					Moved: true,
+				}
+			}
+		}
+	}
+	return found
+}
+
+func equalIgnoringIndentation(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strings.TrimSpace(a[i]) != strings.TrimSpace(b[i]) {
+			return false
+		}
+	}
+	return true
+}
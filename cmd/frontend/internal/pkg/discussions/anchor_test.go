@@ -0,0 +1,166 @@
+package discussions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestMapAnchor(t *testing.T) {
+	// f.go originally has the lines "1" to "10". The new version adds "0" at
+	// the top, changes "4" to "four", removes "6" and adds "new" at the end.
+	fDiff := &git.FileDiff{
+		OrigName: "f.go",
+		NewName:  "f.go",
+		Hunks: []*git.DiffHunk{
+			{OrigStartLine: 0, OrigLines: 0, NewStartLine: 1, NewLines: 1, Added: []string{"0"}},
+			{OrigStartLine: 4, OrigLines: 1, NewStartLine: 5, NewLines: 1, Removed: []string{"4"}, Added: []string{"four"}},
+			{OrigStartLine: 6, OrigLines: 1, NewStartLine: 6, NewLines: 0, Removed: []string{"6"}},
+			{OrigStartLine: 10, OrigLines: 0, NewStartLine: 11, NewLines: 1, Added: []string{"new"}},
+		},
+	}
+	// The function in lines 3 to 5 of a.go is moved (and indented) to lines 11
+	// to 13 of b.go, which is renamed from c.go.
+	moveDiffs := []*git.FileDiff{
+		{
+			OrigName: "a.go",
+			NewName:  "a.go",
+			Hunks: []*git.DiffHunk{
+				{OrigStartLine: 3, OrigLines: 3, NewStartLine: 2, NewLines: 0, Removed: []string{"func foo() {", "\treturn", "}"}},
+			},
+		},
+		{
+			OrigName: "c.go",
+			NewName:  "b.go",
+			Hunks: []*git.DiffHunk{
+				{OrigStartLine: 10, OrigLines: 0, NewStartLine: 11, NewLines: 3, Added: []string{"\tfunc foo() {", "\t\treturn", "\t}"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		fileDiffs []*git.FileDiff
+		path      string
+		selection LineRange
+		want      *Anchor
+	}{
+		{
+			name:      "unchanged_file",
+			fileDiffs: []*git.FileDiff{fDiff},
+			path:      "g.go",
+			selection: LineRange{StartLine: 1, EndLine: 3},
+			want:      &Anchor{Path: "g.go", Lines: LineRange{StartLine: 1, EndLine: 3}},
+		},
+		{
+			name:      "added_lines_before",
+			fileDiffs: []*git.FileDiff{fDiff},
+			path:      "f.go",
+			selection: LineRange{StartLine: 0, EndLine: 2},
+			want:      &Anchor{Path: "f.go", Lines: LineRange{StartLine: 1, EndLine: 3}},
+		},
+		{
+			name:      "changed_line",
+			fileDiffs: []*git.FileDiff{fDiff},
+			path:      "f.go",
+			selection: LineRange{StartLine: 3, EndLine: 4},
+			want:      &Anchor{Path: "f.go", Lines: LineRange{StartLine: 4, EndLine: 5}},
+		},
+		{
+			name:      "deleted_line",
+			fileDiffs: []*git.FileDiff{fDiff},
+			path:      "f.go",
+			selection: LineRange{StartLine: 5, EndLine: 6},
+			want:      nil,
+		},
+		{
+			name:      "deleted_line_in_selection",
+			fileDiffs: []*git.FileDiff{fDiff},
+			path:      "f.go",
+			selection: LineRange{StartLine: 4, EndLine: 7},
+			want:      &Anchor{Path: "f.go", Lines: LineRange{StartLine: 5, EndLine: 7}},
+		},
+		{
+			name:      "added_lines_after",
+			fileDiffs: []*git.FileDiff{fDiff},
+			path:      "f.go",
+			selection: LineRange{StartLine: 9, EndLine: 10},
+			want:      &Anchor{Path: "f.go", Lines: LineRange{StartLine: 9, EndLine: 10}},
+		},
+		{
+			name:      "empty_selection",
+			fileDiffs: []*git.FileDiff{fDiff},
+			path:      "f.go",
+			selection: LineRange{StartLine: 2, EndLine: 2},
+			want:      &Anchor{Path: "f.go", Lines: LineRange{StartLine: 3, EndLine: 3}},
+		},
+		{
+			name:      "renamed_file",
+			fileDiffs: []*git.FileDiff{{OrigName: "old.go", NewName: "new.go"}},
+			path:      "old.go",
+			selection: LineRange{StartLine: 1, EndLine: 2},
+			want:      &Anchor{Path: "new.go", Lines: LineRange{StartLine: 1, EndLine: 2}},
+		},
+		{
+			name:      "deleted_file",
+			fileDiffs: []*git.FileDiff{{OrigName: "f.go", Hunks: []*git.DiffHunk{{OrigStartLine: 1, OrigLines: 2, NewStartLine: 0, NewLines: 0, Removed: []string{"a", "b"}}}}},
+			path:      "f.go",
+			selection: LineRange{StartLine: 1, EndLine: 2},
+			want:      nil,
+		},
+		{
+			name:      "moved_to_other_file",
+			fileDiffs: moveDiffs,
+			path:      "a.go",
+			selection: LineRange{StartLine: 2, EndLine: 5},
+			want:      &Anchor{Path: "b.go", Lines: LineRange{StartLine: 10, EndLine: 13}, Moved: true},
+		},
+		{
+			name:      "partially_moved",
+			fileDiffs: moveDiffs,
+			path:      "a.go",
+			selection: LineRange{StartLine: 1, EndLine: 5},
+			want:      &Anchor{Path: "a.go", Lines: LineRange{StartLine: 1, EndLine: 2}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := MapAnchor(test.fileDiffs, test.path, test.selection)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNeedsFullDiff(t *testing.T) {
+	fDiff := &git.FileDiff{
+		OrigName: "f.go",
+		NewName:  "f.go",
+		Hunks: []*git.DiffHunk{
+			{OrigStartLine: 4, OrigLines: 2, NewStartLine: 3, NewLines: 0, Removed: []string{"4", "5"}},
+		},
+	}
+	deleted := &git.FileDiff{OrigName: "f.go", Hunks: []*git.DiffHunk{{OrigStartLine: 1, OrigLines: 1, Removed: []string{"1"}}}}
+
+	tests := []struct {
+		name      string
+		fileDiffs []*git.FileDiff
+		selection LineRange
+		want      bool
+	}{
+		{name: "unchanged_file", selection: LineRange{StartLine: 3, EndLine: 5}},
+		{name: "removed_lines", fileDiffs: []*git.FileDiff{fDiff}, selection: LineRange{StartLine: 3, EndLine: 5}, want: true},
+		{name: "removed_empty_selection", fileDiffs: []*git.FileDiff{fDiff}, selection: LineRange{StartLine: 3, EndLine: 3}, want: true},
+		{name: "partially_removed_lines", fileDiffs: []*git.FileDiff{fDiff}, selection: LineRange{StartLine: 2, EndLine: 5}},
+		{name: "deleted_or_renamed_file", fileDiffs: []*git.FileDiff{deleted}, selection: LineRange{StartLine: 0, EndLine: 1}, want: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := needsFullDiff(test.fileDiffs, "f.go", test.selection); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
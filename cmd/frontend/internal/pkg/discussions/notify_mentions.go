@@ -48,6 +48,47 @@ func NotifyNewComment(updatedThread *types.DiscussionThread, newComment *types.D
 	})
 }
 
+// NotifyAssigned should be invoked after users have been assigned to a
+// discussion thread, in order to notify them. The thread's first comment is
+// included in the notification.
+//
+// It returns immediately and does not block.
+func NotifyAssigned(thread *types.DiscussionThread, assignerUserID int32, assigneeUserIDs []int32) {
+	if len(assigneeUserIDs) == 0 {
+		return
+	}
+	goroutine.Go(func() {
+		ctx := context.Background()
+		comments, err := db.DiscussionComments.List(ctx, &db.DiscussionCommentsListOptions{
+			LimitOffset: &db.LimitOffset{
+				Limit: 1,
+			},
+			ThreadID: &thread.ID,
+		})
+		if err != nil || len(comments) == 0 {
+			log15.Error("discussions: determining first comment of thread", "thread", thread.ID, "error", err)
+			return
+		}
+		n := &notifier{
+			typ:               assignedNotification,
+			eventAuthorUserID: assignerUserID,
+			thread:            thread,
+			comment:           comments[0],
+			template:          assignedEmailTemplate,
+		}
+		for _, userID := range assigneeUserIDs {
+			user, err := db.Users.GetByID(ctx, userID)
+			if err != nil {
+				log15.Error("discussions: GetByID", "error", err)
+				continue
+			}
+			if err := n.notifyUsername(ctx, user.Username); err != nil {
+				log15.Error("discussions: notifyUsername", "error", err)
+			}
+		}
+	})
+}
+
 func notifyMentions(n *notifier) {
 	goroutine.Go(func() {
 		ctx := context.Background()
@@ -60,6 +101,9 @@ func notifyMentions(n *notifier) {
 				log15.Error("discussions: notifyUsername", "error", err)
 			}
 		}
+		if err := n.notifyWebhooks(ctx); err != nil {
+			log15.Error("discussions: notifyWebhooks", "error", err)
+		}
 	})
 }
 
@@ -68,6 +112,7 @@ type notificationType int
 const (
 	newThreadNotification  notificationType = iota
 	newCommentNotification notificationType = iota
+	assignedNotification   notificationType = iota
 )
 
 type notifier struct {
@@ -85,6 +130,7 @@ type notifier struct {
 //
 // 	1. If you were previously mentioned in the thread, you are subscribed.
 // 	2. If you previously authored a comment, you are subscribed.
+// 	3. If you are assigned to the thread, you are subscribed.
 //
 func (n *notifier) subscribers(ctx context.Context) ([]string, error) {
 	comments, err := db.DiscussionComments.List(ctx, &db.DiscussionCommentsListOptions{
@@ -106,6 +152,16 @@ func (n *notifier) subscribers(ctx context.Context) ([]string, error) {
 			subscribers = append(subscribers, mention)
 		}
 	}
+	for _, assigneeUserID := range n.thread.AssigneeUserIDs {
+		assignee, err := db.Users.GetByID(ctx, assigneeUserID)
+		if err != nil {
+			return nil, errors.Wrap(err, "Assignee: GetByID")
+		}
+		if _, ok := set[assignee.Username]; !ok {
+			set[assignee.Username] = struct{}{}
+			subscribers = append(subscribers, assignee.Username)
+		}
+	}
 	for _, comment := range comments {
 		commentAuthor, err := db.Users.GetByID(ctx, comment.AuthorUserID)
 		if err != nil {
@@ -168,8 +224,12 @@ func (n *notifier) notifyUsername(ctx context.Context, username string) error {
 		msgID := func(commentID int64) string {
 			return fmt.Sprintf("%s+%d.%d@%s", emailParts[0], n.thread.ID, commentID, emailParts[1])
 		}
-		id := msgID(n.comment.ID)
-		messageID = &id
+		if n.typ != assignedNotification {
+			// Assignment notifications are about an existing comment, whose
+			// message ID was already used.
+			id := msgID(n.comment.ID)
+			messageID = &id
+		}
 
 		// Get a list of prior comments in the thread and generate the
 		// references list. This makes e.g. Gmail understand that this email is
@@ -184,7 +244,7 @@ func (n *notifier) notifyUsername(ctx context.Context, username string) error {
 			return errors.Wrap(err, "DiscussionComments.List")
 		}
 		for _, comment := range comments {
-			if comment.ID == n.comment.ID {
+			if comment.ID == n.comment.ID && messageID != nil {
 				continue
 			}
 			references = append(references, msgID(comment.ID))
@@ -242,9 +302,16 @@ func (n *notifier) notifyUsername(ctx context.Context, username string) error {
 	if err != nil {
 		return errors.Wrap(err, "CommentAuthor: GetByID")
 	}
-	fromName := commentAuthor.DisplayName
+	eventAuthor := commentAuthor
+	if n.eventAuthorUserID != commentAuthor.ID {
+		eventAuthor, err = db.Users.GetByID(ctx, n.eventAuthorUserID)
+		if err != nil {
+			return errors.Wrap(err, "EventAuthor: GetByID")
+		}
+	}
+	fromName := eventAuthor.DisplayName
 	if fromName == "" {
-		fromName = commentAuthor.Username
+		fromName = eventAuthor.Username
 	}
 
 	commentContentsHTML, err := markdown.Render(n.comment.Contents, nil)
@@ -268,6 +335,11 @@ func (n *notifier) notifyUsername(ctx context.Context, username string) error {
 			UniqueValue           string
 			CanReply              bool
 
+			// Assigned is whether the recipient was assigned to the thread by
+			// EventAuthorUsername.
+			Assigned            bool
+			EventAuthorUsername string
+
 			// These fields may be empty strings depending on the type of comment..
 			RepoName        string
 			FileName        string
@@ -282,6 +354,9 @@ func (n *notifier) notifyUsername(ctx context.Context, username string) error {
 			UniqueValue:           fmt.Sprint(n.comment.ID),
 			CanReply:              conf.CanReadEmail(),
 
+			Assigned:            n.typ == assignedNotification,
+			EventAuthorUsername: eventAuthor.Username,
+
 			RepoName:        repoShortName,
 			FileName:        fileName,
 			CodeContextText: codeContextText,
@@ -299,6 +374,9 @@ var (
 `
 
 	sharedCommentTextTemplate = `
+{{- if .Assigned -}}
+	{{- "@" -}}{{- .EventAuthorUsername -}}{{- " assigned you to this discussion. " -}}
+{{- end -}}
 {{- "@" -}}{{- .CommentAuthorUsername -}}{{- " commented" -}}
 	{{- with .FileName -}}{{- " on " -}}{{- . -}}{{- end -}}
 	{{- ":\n" -}}
@@ -335,6 +413,7 @@ var (
 	"description": "View this discussion on Sourcegraph"
 }
 </script>
+{{if .Assigned}}<p><strong>@{{.EventAuthorUsername}}</strong> assigned you to this discussion.</p>{{end}}
 <p><strong>@{{.CommentAuthorUsername}}</strong> commented{{with .FileName}} on <strong>{{.}}</strong>{{end}}:</p>
 {{.CommentContentsHTML}}
 {{with .CodeContextHTML}}
@@ -361,4 +440,10 @@ var (
 		Text:    sharedCommentTextTemplate,
 		HTML:    sharedCommentHTMLTemplate,
 	})
+
+	assignedEmailTemplate = txemail.MustValidate(txtypes.Templates{
+		Subject: sharedCommentSubjectTemplate,
+		Text:    sharedCommentTextTemplate,
+		HTML:    sharedCommentHTMLTemplate,
+	})
 )
@@ -0,0 +1,547 @@
+package discussions
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions/mentions"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	"github.com/sourcegraph/sourcegraph/pkg/slack"
+	"github.com/sourcegraph/sourcegraph/schema"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// webhookSubscription is a subscription to notifications of discussion
+// activity (from the notifications.discussions setting), and the event to
+// notify it of.
+type webhookSubscription struct {
+	*schema.DiscussionsNotificationSubscription
+	event types.DiscussionNotificationEvent
+
+	// userID or orgID is the settings subject that the subscription is from.
+	userID, orgID *int32
+}
+
+// webhookSubscriptions returns the subscriptions to notify of the event.
+// Subscriptions in user settings are notified of activity in the threads that
+// the user is subscribed to, or of the user being mentioned. Subscriptions in
+// organization settings are notified of activity by the organization's
+// members. Each URL is notified at most once.
+//
+// 🚨 SECURITY: Notifications include the contents of the thread, so a
+// subscription is only notified if its user (or every member of its
+// organization) can read the thread's repository.
+func (n *notifier) webhookSubscriptions(ctx context.Context) ([]*webhookSubscription, error) {
+	var event types.DiscussionNotificationEvent
+	switch n.typ {
+	case newThreadNotification:
+		event = types.DiscussionNotificationEventThreadCreated
+	case newCommentNotification:
+		event = types.DiscussionNotificationEventCommentAdded
+	default:
+		return nil, nil
+	}
+
+	var (
+		subscriptions []*webhookSubscription
+		seenURLs      = make(map[string]struct{})
+	)
+	add := func(subject api.SettingsSubject, events ...types.DiscussionNotificationEvent) error {
+		settings, err := backend.Configuration.GetForSubject(ctx, subject)
+		if err != nil {
+			return err
+		}
+		if len(settings.NotificationsDiscussions) == 0 {
+			return nil
+		}
+		if ok, err := n.subjectCanReadTarget(ctx, subject); err != nil || !ok {
+			return err
+		}
+		for _, s := range settings.NotificationsDiscussions {
+			if _, seen := seenURLs[s.Url]; seen {
+				continue
+			}
+			if event, ok := subscribedEvent(s, events); ok {
+				seenURLs[s.Url] = struct{}{}
+				subscriptions = append(subscriptions, &webhookSubscription{
+					DiscussionsNotificationSubscription: s,
+					event:                               event,
+					userID:                              subject.User,
+					orgID:                               subject.Org,
+				})
+			}
+		}
+		return nil
+	}
+
+	mentioned := make(map[string]struct{})
+	for _, mention := range mentions.Parse(n.comment.Contents) {
+		mentioned[mention] = struct{}{}
+	}
+	if n.typ == newThreadNotification {
+		for _, mention := range mentions.Parse(n.thread.Title) {
+			mentioned[mention] = struct{}{}
+		}
+	}
+	subscribers, err := n.subscribers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, username := range subscribers {
+		user, err := db.Users.GetByUsername(ctx, username)
+		if errcode.IsNotFound(err) {
+			continue // a mention of a user that does not exist
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "GetByUsername")
+		}
+		if user.ID == n.eventAuthorUserID {
+			// Do not send notifications to the user who created the event.
+			continue
+		}
+		events := []types.DiscussionNotificationEvent{event}
+		if _, ok := mentioned[username]; ok {
+			events = append([]types.DiscussionNotificationEvent{types.DiscussionNotificationEventMention}, events...)
+		}
+		if err := add(api.SettingsSubject{User: &user.ID}, events...); err != nil {
+			return nil, errors.Wrap(err, "user settings")
+		}
+	}
+
+	memberships, err := db.OrgMembers.GetByUserID(ctx, n.eventAuthorUserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "OrgMembers.GetByUserID")
+	}
+	for _, m := range memberships {
+		orgID := m.OrgID
+		if err := add(api.SettingsSubject{Org: &orgID}, event); err != nil {
+			return nil, errors.Wrap(err, "org settings")
+		}
+	}
+	return subscriptions, nil
+}
+
+// subjectCanReadTarget reports whether the user, or every member of the
+// organization, can read the repository that the thread is on.
+func (n *notifier) subjectCanReadTarget(ctx context.Context, subject api.SettingsSubject) (bool, error) {
+	if n.thread.TargetRepo == nil {
+		return true, nil
+	}
+
+	var userIDs []int32
+	switch {
+	case subject.User != nil:
+		userIDs = []int32{*subject.User}
+	case subject.Org != nil:
+		members, err := db.OrgMembers.GetByOrgID(ctx, *subject.Org)
+		if err != nil {
+			return false, errors.Wrap(err, "OrgMembers.GetByOrgID")
+		}
+		for _, m := range members {
+			userIDs = append(userIDs, m.UserID)
+		}
+	}
+	if len(userIDs) == 0 {
+		return false, nil
+	}
+	for _, userID := range userIDs {
+		_, err := db.Repos.Get(actor.WithActor(ctx, actor.FromUser(userID)), n.thread.TargetRepo.RepoID)
+		if errcode.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.Wrap(err, "db.Repos.Get")
+		}
+	}
+	return true, nil
+}
+
+// subscribedEvent returns the first of the events that the subscription is
+// subscribed to.
+func subscribedEvent(s *schema.DiscussionsNotificationSubscription, events []types.DiscussionNotificationEvent) (types.DiscussionNotificationEvent, bool) {
+	for _, event := range events {
+		if len(s.Events) == 0 {
+			return event, true
+		}
+		for _, e := range s.Events {
+			if types.DiscussionNotificationEvent(e) == event {
+				return event, true
+			}
+		}
+	}
+	return "", false
+}
+
+// webhookEvent describes an event of discussion activity. It is the body of
+// requests to `webhook` subscriptions.
+type webhookEvent struct {
+	Event   types.DiscussionNotificationEvent `json:"event"`
+	Thread  webhookEventThread                `json:"thread"`
+	Comment webhookEventComment               `json:"comment"`
+}
+
+type webhookEventThread struct {
+	ID         int64  `json:"id"`
+	Title      string `json:"title"`
+	Repository string `json:"repository,omitempty"`
+	Path       string `json:"path,omitempty"`
+}
+
+type webhookEventComment struct {
+	ID        int64     `json:"id"`
+	Author    string    `json:"author"` // the username of the comment's author
+	Contents  string    `json:"contents"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// notifyWebhooks queues the notifications of the event for delivery to the
+// chat services and webhooks that are subscribed to it.
+func (n *notifier) notifyWebhooks(ctx context.Context) error {
+	subscriptions, err := n.webhookSubscriptions(ctx)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+
+	url, err := URLToInlineComment(ctx, n.thread, n.comment)
+	if err != nil {
+		return errors.Wrap(err, "URLToInlineComment")
+	}
+	if url == nil {
+		return nil // can't generate a link to this thread target type
+	}
+	author, err := db.Users.GetByID(ctx, n.comment.AuthorUserID)
+	if err != nil {
+		return errors.Wrap(err, "CommentAuthor: GetByID")
+	}
+	e := &webhookEvent{
+		Thread: webhookEventThread{
+			ID:    n.thread.ID,
+			Title: n.thread.Title,
+		},
+		Comment: webhookEventComment{
+			ID:        n.comment.ID,
+			Author:    author.Username,
+			Contents:  n.comment.Contents,
+			CreatedAt: n.comment.CreatedAt,
+		},
+	}
+	if n.thread.TargetRepo != nil {
+		repo, err := db.Repos.Get(ctx, n.thread.TargetRepo.RepoID)
+		if err != nil {
+			return errors.Wrap(err, "db.Repos.Get")
+		}
+		e.Thread.Repository = string(repo.Name)
+		if n.thread.TargetRepo.Path != nil {
+			e.Thread.Path = *n.thread.TargetRepo.Path
+		}
+	}
+
+	for _, s := range subscriptions {
+		e.Event = s.event
+		u := *url
+		q := u.Query()
+		q.Set("utm_source", s.Type)
+		u.RawQuery = q.Encode()
+		e.Comment.URL = u.String()
+		body, signature, err := webhookRequestBody(s.DiscussionsNotificationSubscription, e)
+		if err != nil {
+			return err
+		}
+		_, err = db.DiscussionNotificationDeliveries.Create(ctx, &types.DiscussionNotificationDelivery{
+			Event:      s.event,
+			ThreadID:   n.thread.ID,
+			CommentID:  n.comment.ID,
+			UserID:     s.userID,
+			OrgID:      s.orgID,
+			TargetType: s.Type,
+			URL:        s.Url,
+			Body:       string(body),
+			Signature:  signature,
+		})
+		if err != nil {
+			return errors.Wrap(err, "DiscussionNotificationDeliveries.Create")
+		}
+	}
+	return nil
+}
+
+// webhookRequestBody returns the body of the request that notifies the
+// subscription of the event, in the format of the subscription's type. For
+// `webhook` subscriptions with a secret, it also returns the signature of the
+// body.
+func webhookRequestBody(s *schema.DiscussionsNotificationSubscription, e *webhookEvent) (body []byte, signature string, err error) {
+	var summary string
+	switch e.Event {
+	case types.DiscussionNotificationEventThreadCreated:
+		summary = fmt.Sprintf("@%s started a discussion", e.Comment.Author)
+	case types.DiscussionNotificationEventCommentAdded:
+		summary = fmt.Sprintf("@%s commented", e.Comment.Author)
+	case types.DiscussionNotificationEventMention:
+		summary = fmt.Sprintf("@%s mentioned you", e.Comment.Author)
+	}
+	if e.Thread.Path != "" {
+		summary += " on " + e.Thread.Path
+	}
+	title := e.Thread.Title
+	if e.Thread.Repository != "" {
+		title = "[" + e.Thread.Repository + "] " + title
+	}
+
+	var v interface{}
+	switch s.Type {
+	case "slack":
+		v = &slack.Payload{
+			Username:    "discussions-bot",
+			IconEmoji:   ":speech_balloon:",
+			UnfurlLinks: false,
+			UnfurlMedia: false,
+			Text:        summary,
+			Attachments: []*slack.Attachment{
+				{
+					Fallback:   fmt.Sprintf("%s: %s", summary, title),
+					Color:      "good",
+					Title:      title,
+					TitleLink:  e.Comment.URL,
+					Text:       e.Comment.Contents,
+					MarkdownIn: []string{"text"},
+					Timestamp:  e.Comment.CreatedAt.Unix(),
+				},
+			},
+		}
+	case "microsoftTeams":
+		v = &teamsMessageCard{
+			Type:    "MessageCard",
+			Context: "https://schema.org/extensions",
+			Summary: summary,
+			Title:   title,
+			Text:    fmt.Sprintf("**%s:**\n\n%s", summary, e.Comment.Contents),
+			PotentialAction: []*teamsAction{
+				{
+					Type:    "OpenUri",
+					Name:    "View discussion",
+					Targets: []teamsTarget{{OS: "default", URI: e.Comment.URL}},
+				},
+			},
+		}
+	case "webhook":
+		v = e
+	default:
+		return nil, "", fmt.Errorf("unknown notification subscription type: %q", s.Type)
+	}
+	body, err = json.Marshal(v)
+	if err != nil {
+		return nil, "", err
+	}
+	if s.Type == "webhook" && s.Secret != "" {
+		signature = webhookSignature(s.Secret, body)
+	}
+	return body, signature, nil
+}
+
+// webhookSignature returns the value of the X-Sourcegraph-Signature header of
+// a request with the given body.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// teamsMessageCard is a Microsoft Teams message card, defined at:
+// https://docs.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+type teamsMessageCard struct {
+	Type            string         `json:"@type"`
+	Context         string         `json:"@context"`
+	Summary         string         `json:"summary"`
+	Title           string         `json:"title"`
+	Text            string         `json:"text"`
+	PotentialAction []*teamsAction `json:"potentialAction,omitempty"`
+}
+
+type teamsAction struct {
+	Type    string        `json:"@type"`
+	Name    string        `json:"name"`
+	Targets []teamsTarget `json:"targets"`
+}
+
+type teamsTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
+
+var (
+	// webhookRetryDelays are the delays before retrying to deliver a
+	// notification after each failed attempt. Delivery is given up after the
+	// last one.
+	webhookRetryDelays = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+
+	// webhookDeliveryLogRetention is how long the log of notifications is kept.
+	webhookDeliveryLogRetention = 30 * 24 * time.Hour
+)
+
+// StartWebhookWorker should be invoked only after the DB has been
+// initialized. It starts the background worker which is responsible for
+// delivering notifications of discussion activity to chat services and
+// webhooks.
+//
+// It should be invoked in a separate goroutine.
+func StartWebhookWorker() {
+	// Only one frontend instance should ever run this worker, so we use a
+	// distributed lock to guarantee this. If the frontend with the lock
+	// acquired dies, it will be released after 1 minute.
+	for {
+		ctx, release, ok := rcache.TryAcquireMutex(context.Background(), "discussionsWebhookWorker")
+		if !ok {
+			// Failed to acquire the mutex. Wait before trying again.
+			time.Sleep(30 * time.Second)
+			continue
+		}
+
+		// Acquired the mutex, perform work under it.
+		log15.Debug("discussions: webhook worker running")
+		var lastCleanup time.Time
+		for ctx.Err() == nil {
+			if time.Since(lastCleanup) > time.Hour {
+				if err := db.DiscussionNotificationDeliveries.DeleteCreatedBefore(ctx, time.Now().Add(-webhookDeliveryLogRetention)); err != nil {
+					log15.Error("discussions: deleting old notification deliveries", "error", err)
+				}
+				lastCleanup = time.Now()
+			}
+			if err := deliverWebhooks(ctx); err != nil {
+				log15.Error("discussions: delivering notifications", "error", err)
+			}
+			time.Sleep(5 * time.Second)
+		}
+		log15.Debug("discussions: webhook worker stopped", "ctx", ctx.Err())
+		release()
+	}
+}
+
+// deliverWebhooks attempts to deliver the notifications that are due, and
+// schedules the retries of those that fail.
+func deliverWebhooks(ctx context.Context) error {
+	deliveries, err := db.DiscussionNotificationDeliveries.ListDue(ctx, 100)
+	if err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		deliveryErr := postWebhook(ctx, d)
+		var nextAttemptAt *time.Time
+		if deliveryErr != nil && int(d.Attempts) < len(webhookRetryDelays) {
+			t := time.Now().Add(webhookRetryDelays[d.Attempts])
+			nextAttemptAt = &t
+		}
+		if err := db.DiscussionNotificationDeliveries.RecordAttempt(ctx, d.ID, deliveryErr, nextAttemptAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webhookClient is the HTTP client that delivers notifications. Any user can
+// configure the URLs that notifications are sent to, so it refuses to connect
+// to internal addresses (see checkWebhookAddr).
+var webhookClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: checkWebhookAddr,
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// internalNets are the networks that notifications are never delivered to:
+// loopback, private, shared, link-local and unspecified addresses.
+var internalNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// checkWebhookAddr is called with the resolved address before each connection
+// made by webhookClient. It refuses internal addresses, so that users can't
+// make the frontend send requests to services on its network. Checking the
+// resolved address (instead of the host in the URL) also covers redirects and
+// host names that resolve to internal addresses.
+func checkWebhookAddr(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid IP address %q", host)
+	}
+	for _, n := range internalNets {
+		if n.Contains(ip) {
+			return fmt.Errorf("refusing to deliver notification to internal address %s", ip)
+		}
+	}
+	return nil
+}
+
+// postWebhook sends the notification's request.
+func postWebhook(ctx context.Context, d *types.DiscussionNotificationDelivery) error {
+	req, err := http.NewRequest("POST", d.URL, bytes.NewReader([]byte(d.Body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.TargetType == "webhook" {
+		req.Header.Set("X-Sourcegraph-Event", string(d.Event))
+		req.Header.Set("X-Sourcegraph-Delivery", strconv.FormatInt(d.ID, 10))
+		if d.Signature != "" {
+			req.Header.Set("X-Sourcegraph-Signature", d.Signature)
+		}
+	}
+
+	resp, err := webhookClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected HTTP response status %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
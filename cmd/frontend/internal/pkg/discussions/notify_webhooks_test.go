@@ -0,0 +1,186 @@
+package discussions
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestWebhookRequestBody(t *testing.T) {
+	e := &webhookEvent{
+		Event: types.DiscussionNotificationEventMention,
+		Thread: webhookEventThread{
+			ID:         1,
+			Title:      "Fix this",
+			Repository: "github.com/foo/bar",
+			Path:       "mux.go",
+		},
+		Comment: webhookEventComment{
+			ID:        2,
+			Author:    "alice",
+			Contents:  "@bob what do you think?",
+			URL:       "https://sourcegraph.example.com/github.com/foo/bar/-/blob/mux.go?utm_source=webhook#tab=discussions",
+			CreatedAt: time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	tests := []struct {
+		subscription  schema.DiscussionsNotificationSubscription
+		wantBody      string
+		wantSignature string
+	}{
+		{
+			subscription: schema.DiscussionsNotificationSubscription{Type: "slack", Url: "https://hooks.slack.com/services/x"},
+			wantBody:     `{"username":"discussions-bot","icon_emoji":":speech_balloon:","text":"@alice mentioned you on mux.go","attachments":[{"color":"good","fallback":"@alice mentioned you on mux.go: [github.com/foo/bar] Fix this","fields":null,"footer":"","mrkdwn_in":["text"],"thumb_url":"","text":"@bob what do you think?","ts":1538352000,"title":"[github.com/foo/bar] Fix this","title_link":"https://sourcegraph.example.com/github.com/foo/bar/-/blob/mux.go?utm_source=webhook#tab=discussions"}]}`,
+		},
+		{
+			subscription: schema.DiscussionsNotificationSubscription{Type: "microsoftTeams", Url: "https://outlook.office.com/webhook/x"},
+			wantBody:     `{"@type":"MessageCard","@context":"https://schema.org/extensions","summary":"@alice mentioned you on mux.go","title":"[github.com/foo/bar] Fix this","text":"**@alice mentioned you on mux.go:**\n\n@bob what do you think?","potentialAction":[{"@type":"OpenUri","name":"View discussion","targets":[{"os":"default","uri":"https://sourcegraph.example.com/github.com/foo/bar/-/blob/mux.go?utm_source=webhook#tab=discussions"}]}]}`,
+		},
+		{
+			subscription: schema.DiscussionsNotificationSubscription{Type: "webhook", Url: "https://example.com", Secret: "s3cr3t"},
+			wantBody:     `{"event":"mention","thread":{"id":1,"title":"Fix this","repository":"github.com/foo/bar","path":"mux.go"},"comment":{"id":2,"author":"alice","contents":"@bob what do you think?","url":"https://sourcegraph.example.com/github.com/foo/bar/-/blob/mux.go?utm_source=webhook#tab=discussions","createdAt":"2018-10-01T00:00:00Z"}}`,
+			// echo -n "$BODY" | openssl dgst -sha256 -hmac s3cr3t
+			wantSignature: "sha256=9d2928893a58a5c4a17d1dc3d305601d5f2a2b3513c4eddaa98ac3a91652ab12",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.subscription.Type, func(t *testing.T) {
+			body, signature, err := webhookRequestBody(&test.subscription, e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != test.wantBody {
+				t.Errorf("got body %s, want %s", body, test.wantBody)
+			}
+			if signature != test.wantSignature {
+				t.Errorf("got signature %q, want %q", signature, test.wantSignature)
+			}
+		})
+	}
+}
+
+func TestSubscribedEvent(t *testing.T) {
+	mentionOrComment := []types.DiscussionNotificationEvent{types.DiscussionNotificationEventMention, types.DiscussionNotificationEventCommentAdded}
+	tests := []struct {
+		events    []string
+		wantEvent types.DiscussionNotificationEvent
+		wantOK    bool
+	}{
+		{events: nil, wantEvent: types.DiscussionNotificationEventMention, wantOK: true},
+		{events: []string{"commentAdded"}, wantEvent: types.DiscussionNotificationEventCommentAdded, wantOK: true},
+		{events: []string{"commentAdded", "mention"}, wantEvent: types.DiscussionNotificationEventMention, wantOK: true},
+		{events: []string{"threadCreated"}, wantOK: false},
+	}
+	for _, test := range tests {
+		event, ok := subscribedEvent(&schema.DiscussionsNotificationSubscription{Events: test.events}, mentionOrComment)
+		if event != test.wantEvent || ok != test.wantOK {
+			t.Errorf("events %q: got (%q, %v), want (%q, %v)", test.events, event, ok, test.wantEvent, test.wantOK)
+		}
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	var (
+		status     = http.StatusOK
+		gotHeaders http.Header
+		gotBody    string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header
+		b, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(status)
+		w.Write([]byte("oops"))
+	}))
+	defer ts.Close()
+
+	// The test server listens on a loopback address, which webhookClient
+	// refuses to connect to.
+	defer func(c *http.Client) { webhookClient = c }(webhookClient)
+	webhookClient = ts.Client()
+
+	d := &types.DiscussionNotificationDelivery{
+		ID:         3,
+		Event:      types.DiscussionNotificationEventCommentAdded,
+		TargetType: "webhook",
+		URL:        ts.URL,
+		Body:       `{"event":"commentAdded"}`,
+		Signature:  "sha256=abc",
+	}
+	if err := postWebhook(context.Background(), d); err != nil {
+		t.Fatal(err)
+	}
+	if gotBody != d.Body {
+		t.Errorf("got body %q, want %q", gotBody, d.Body)
+	}
+	wantHeaders := map[string]string{
+		"Content-Type":            "application/json",
+		"X-Sourcegraph-Event":     "commentAdded",
+		"X-Sourcegraph-Delivery":  "3",
+		"X-Sourcegraph-Signature": "sha256=abc",
+	}
+	for name, want := range wantHeaders {
+		if got := gotHeaders.Get(name); got != want {
+			t.Errorf("got header %s %q, want %q", name, got, want)
+		}
+	}
+
+	status = http.StatusInternalServerError
+	err := postWebhook(context.Background(), d)
+	if want := "unexpected HTTP response status 500: oops"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
+func TestPostWebhook_internalAddr(t *testing.T) {
+	var called bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer ts.Close()
+
+	d := &types.DiscussionNotificationDelivery{
+		ID:         3,
+		Event:      types.DiscussionNotificationEventCommentAdded,
+		TargetType: "webhook",
+		URL:        ts.URL,
+		Body:       `{"event":"commentAdded"}`,
+	}
+	err := postWebhook(context.Background(), d)
+	if err == nil || !strings.Contains(err.Error(), "refusing to deliver notification to internal address 127.0.0.1") {
+		t.Errorf("got error %v, want internal address to be refused", err)
+	}
+	if called {
+		t.Error("webhook on internal address was called")
+	}
+}
+
+func TestCheckWebhookAddr(t *testing.T) {
+	tests := map[string]bool{
+		"127.0.0.1:80":       false,
+		"10.1.2.3:443":       false,
+		"172.20.0.1:443":     false,
+		"192.168.1.1:443":    false,
+		"169.254.169.254:80": false,
+		"0.0.0.0:80":         false,
+		"[::1]:80":           false,
+		"[fd00::1]:443":      false,
+		"[fe80::1]:443":      false,
+		"8.8.8.8:443":        true,
+		"[2001:db8::1]:443":  true,
+	}
+	for address, wantOK := range tests {
+		err := checkWebhookAddr("tcp", address, nil)
+		if ok := err == nil; ok != wantOK {
+			t.Errorf("%s: got error %v, want allowed %v", address, err, wantOK)
+		}
+	}
+}
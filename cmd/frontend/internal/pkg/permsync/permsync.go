@@ -0,0 +1,336 @@
+// Package permsync syncs repository permissions from the authz providers to the database in the
+// background, so that checking the repository permissions of a user does not require calls to the
+// code hosts at request time (see db.UserPermissions).
+package permsync
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/usagestats"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+const (
+	// maxUsersPerRound is the maximum number of users whose permissions are synced before the
+	// worker looks for the users that are due again, so that newly active users don't have to
+	// wait for a long backlog of inactive users.
+	maxUsersPerRound = 100
+
+	// repoPageSize is the number of repositories whose permissions are requested from an authz
+	// provider at once.
+	repoPageSize = 500
+)
+
+var (
+	syncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "src",
+		Subsystem: "authz",
+		Name:      "perms_sync_duration_seconds",
+		Help:      "Time spent syncing the repository permissions of a user from all authz providers.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 14),
+	}, []string{"success"})
+	syncLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "src",
+		Subsystem: "authz",
+		Name:      "perms_sync_lag_seconds",
+		Help:      "Age of the stored repository permissions of a user when they were replaced by newly synced permissions.",
+		Buckets:   prometheus.ExponentialBuckets(60, 2, 12),
+	})
+	dueUsersGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "src",
+		Subsystem: "authz",
+		Name:      "perms_sync_due_users",
+		Help:      "Number of users whose repository permissions are due to be synced in the current round (at most 100).",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(syncDuration)
+	prometheus.MustRegister(syncLag)
+	prometheus.MustRegister(dueUsersGauge)
+
+	conf.ContributeValidator(func(c conf.Unified) (problems []string) {
+		if c.PermissionsBackgroundSync == nil {
+			return nil
+		}
+		for field, v := range map[string]string{
+			"userInterval": c.PermissionsBackgroundSync.UserInterval,
+			"maxStaleness": c.PermissionsBackgroundSync.MaxStaleness,
+		} {
+			if v == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+				problems = append(problems, fmt.Sprintf("permissions.backgroundSync.%s must be a positive duration (such as \"1h\"), got %q", field, v))
+			}
+		}
+		return problems
+	})
+}
+
+// StartWorker should be invoked only after the DB has been initialized. It starts the background
+// worker which keeps the stored repository permissions of users up to date while
+// "permissions.backgroundSync" is enabled in the site configuration.
+//
+// It should be invoked in a separate goroutine.
+func StartWorker() {
+	// Only one frontend instance should ever run this worker, so we use a distributed lock to
+	// guarantee this. If the frontend with the lock acquired dies, it will be released after 1
+	// minute.
+	for {
+		ctx, release, ok := rcache.TryAcquireMutex(context.Background(), "permissionsBackgroundSync")
+		if !ok {
+			// Failed to acquire the mutex. Wait before trying again.
+			time.Sleep(30 * time.Second)
+			continue
+		}
+
+		// Acquired the mutex, perform work under it.
+		log15.Debug("permsync: worker running")
+		workForever(ctx)
+		log15.Debug("permsync: worker stopped", "ctx", ctx.Err())
+		release()
+	}
+}
+
+func workForever(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := syncRound(ctx)
+		if err != nil {
+			log15.Error("permsync: sync round failed", "error", err)
+		}
+		if n < maxUsersPerRound {
+			// Nothing else is due right now.
+			select {
+			case <-time.After(time.Minute):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+// syncRound syncs the permissions of the users that are due and returns how many users it synced.
+func syncRound(ctx context.Context) (int, error) {
+	enabled, userInterval, maxStaleness := conf.PermissionsBackgroundSync()
+	if _, providers := authz.GetProviders(); !enabled || len(providers) == 0 {
+		dueUsersGauge.Set(0)
+		return 0, nil
+	}
+
+	userIDs, err := dueUsers(ctx, time.Now(), userInterval, maxStaleness)
+	if err != nil {
+		return 0, err
+	}
+	dueUsersGauge.Set(float64(len(userIDs)))
+
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if err := SyncUser(ctx, userID); err != nil {
+			log15.Warn("permsync: failed to sync repository permissions of user", "userID", userID, "error", err)
+		}
+	}
+	return len(userIDs), nil
+}
+
+// mockListUsersToday mocks usagestats.ListUsersToday in tests.
+var mockListUsersToday func() (*usagestats.ActiveUsers, error)
+
+// dueUsers returns up to maxUsersPerRound users whose permissions should be synced now. Users who
+// were active today come first, if their permissions are older than userInterval. They are followed
+// by all other users whose permissions are older than half of maxStaleness, so that their
+// permissions are still fresh enough to be used when they become active again.
+func dueUsers(ctx context.Context, now time.Time, userInterval, maxStaleness time.Duration) ([]int32, error) {
+	listUsersToday := usagestats.ListUsersToday
+	if mockListUsersToday != nil {
+		listUsersToday = mockListUsersToday
+	}
+	active, err := listUsersToday()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing active users")
+	}
+
+	var userIDs []int32
+	seen := make(map[int32]bool)
+	for _, s := range active.Registered {
+		if len(userIDs) == maxUsersPerRound {
+			return userIDs, nil
+		}
+		id, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			continue
+		}
+		userID := int32(id)
+		perms, err := db.UserPermissions.List(ctx, userID, authz.Read, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(perms) == 0 || now.Sub(oldest(perms)) > userInterval {
+			userIDs = append(userIDs, userID)
+			seen[userID] = true
+		}
+	}
+
+	stale, err := db.UserPermissions.ListStaleUsers(ctx, authz.Read, now.Add(-maxStaleness/2), maxUsersPerRound)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range stale {
+		if len(userIDs) == maxUsersPerRound {
+			break
+		}
+		if !seen[userID] {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+func oldest(perms []*db.UserRepoPermissions) time.Time {
+	t := perms[0].UpdatedAt
+	for _, p := range perms[1:] {
+		if p.UpdatedAt.Before(t) {
+			t = p.UpdatedAt
+		}
+	}
+	return t
+}
+
+// SyncUser syncs the repository permissions of the user from all authz providers and stores them
+// in the database, regardless of when they were last synced.
+func SyncUser(ctx context.Context, userID int32) (err error) {
+	start := time.Now()
+	defer func() {
+		syncDuration.WithLabelValues(strconv.FormatBool(err == nil)).Observe(time.Since(start).Seconds())
+	}()
+
+	user, err := db.Users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, providers := authz.GetProviders()
+	accts, err := db.ExternalAccounts.List(ctx, db.ExternalAccountsListOptions{UserID: userID})
+	if err != nil {
+		return err
+	}
+	previous, err := db.UserPermissions.List(ctx, userID, authz.Read, nil)
+	if err != nil {
+		return err
+	}
+
+	// An error from one provider must not keep the permissions from the other providers from
+	// being synced, so errors are collected and returned at the end.
+	var errs *multierror.Error
+	for _, p := range providers {
+		acct, err := providerAccount(ctx, user, accts, p)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "fetching account of user from authz provider %s", p.ServiceID()))
+			continue
+		}
+		repoIDs, err := readableRepos(ctx, p, acct)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "listing repository permissions of user from authz provider %s", p.ServiceID()))
+			continue
+		}
+
+		perms := &db.UserRepoPermissions{
+			UserID:      userID,
+			Perm:        authz.Read,
+			ServiceType: p.ServiceType(),
+			ServiceID:   p.ServiceID(),
+			RepoIDs:     repoIDs,
+		}
+		if err := db.UserPermissions.Upsert(ctx, perms); err != nil {
+			return err
+		}
+		for _, prev := range previous {
+			if prev.ServiceType == perms.ServiceType && prev.ServiceID == perms.ServiceID {
+				syncLag.Observe(perms.UpdatedAt.Sub(prev.UpdatedAt).Seconds())
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// providerAccount returns the external account of the user that the authz provider uses to check
+// their permissions, fetching it from the provider (and associating it with the user) if the user
+// has none yet. It returns a nil account if the provider does not know the user.
+func providerAccount(ctx context.Context, user *types.User, accts []*extsvc.ExternalAccount, p authz.Provider) (*extsvc.ExternalAccount, error) {
+	for _, acct := range accts {
+		if acct.ServiceID == p.ServiceID() && acct.ServiceType == p.ServiceType() {
+			return acct, nil
+		}
+	}
+
+	acct, err := p.FetchAccount(ctx, user, accts)
+	if err != nil || acct == nil {
+		return nil, err
+	}
+	if err := db.ExternalAccounts.AssociateUserAndSave(ctx, user.ID, acct.ExternalAccountSpec, acct.ExternalAccountData); err != nil {
+		return nil, err
+	}
+	return acct, nil
+}
+
+// readableRepos returns the IDs of all repositories owned by the authz provider that the external
+// account can read.
+func readableRepos(ctx context.Context, p authz.Provider, acct *extsvc.ExternalAccount) ([]api.RepoID, error) {
+	// 🚨 SECURITY: List all repositories as an internal actor, so that they are not filtered by the
+	// permissions being synced here.
+	ctx = actor.WithActor(ctx, &actor.Actor{Internal: true})
+
+	var (
+		repoIDs []api.RepoID
+		afterID api.RepoID
+	)
+	for {
+		// Page by ID instead of by offset, so that repositories added or removed meanwhile don't
+		// cause other repositories to be skipped or listed twice.
+		repos, err := db.Repos.List(ctx, db.ReposListOptions{
+			Enabled:     true,
+			Disabled:    true,
+			AfterID:     afterID,
+			LimitOffset: &db.LimitOffset{Limit: repoPageSize},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(repos) == 0 {
+			return repoIDs, nil
+		}
+		afterID = repos[len(repos)-1].ID
+
+		mine, _ := p.Repos(ctx, authz.ToRepos(repos))
+		if len(mine) > 0 {
+			perms, err := p.RepoPerms(ctx, acct, mine)
+			if err != nil {
+				return nil, err
+			}
+			for _, repo := range repos {
+				if perms[repo.Name][authz.Read] {
+					repoIDs = append(repoIDs, repo.ID)
+				}
+			}
+		}
+
+		if len(repos) < repoPageSize {
+			return repoIDs, nil
+		}
+	}
+}
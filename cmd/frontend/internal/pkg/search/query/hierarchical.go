@@ -0,0 +1,120 @@
+package query
+
+import (
+	"fmt"
+	"regexp/syntax"
+
+	querysyntax "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/syntax"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/types"
+	searchquery "github.com/sourcegraph/sourcegraph/pkg/search/query"
+)
+
+// HierarchicalQuery converts the query, including its boolean structure, to a
+// query that can be evaluated by the hierarchical search backends (searcher
+// and Zoekt).
+//
+// Only the default, file, repo and case fields are supported. repo: must
+// apply to the whole query, which the typechecker ensures.
+func (q *Query) HierarchicalQuery() (searchquery.Q, error) {
+	caseSensitive := q.IsCaseSensitive()
+
+	var convert func(n *querysyntax.Node) (searchquery.Q, error)
+	convert = func(n *querysyntax.Node) (searchquery.Q, error) {
+		switch n.Op {
+		case querysyntax.OpNone:
+			return q.hierarchicalExpr(n.Expr, caseSensitive)
+		case querysyntax.OpNot:
+			child, err := convert(n.Operands[0])
+			if err != nil {
+				return nil, err
+			}
+			return &searchquery.Not{Child: child}, nil
+		}
+
+		children := make([]searchquery.Q, 0, len(n.Operands))
+		for _, o := range n.Operands {
+			child, err := convert(o)
+			if err != nil {
+				return nil, err
+			}
+			if child != nil {
+				children = append(children, child)
+			}
+		}
+		if n.Op == querysyntax.OpOr {
+			return searchquery.NewOr(children...), nil
+		}
+		return searchquery.NewAnd(children...), nil
+	}
+
+	if q.Syntax.Tree == nil {
+		return &searchquery.Const{Value: true}, nil
+	}
+	hq, err := convert(q.Syntax.Tree)
+	if err != nil {
+		return nil, err
+	}
+	return searchquery.Simplify(hq), nil
+}
+
+// hierarchicalExpr converts a single term. It returns nil for terms (such as
+// case:) that only affect how the other terms are matched.
+func (q *Query) hierarchicalExpr(expr *querysyntax.Expr, caseSensitive bool) (searchquery.Q, error) {
+	field, value, ok := q.Lookup(expr)
+	if !ok {
+		return nil, fmt.Errorf("unchecked query term %q", expr)
+	}
+
+	var hq searchquery.Q
+	switch field {
+	case FieldDefault, FieldFile:
+		atom, err := hierarchicalPattern(value, field == FieldFile, caseSensitive)
+		if err != nil {
+			return nil, err
+		}
+		hq = atom
+	case FieldRepo:
+		hq = &searchquery.Repo{Pattern: value.Regexp.String()}
+	case FieldCase:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("field %q is not supported in queries with OR or NOT", field)
+	}
+
+	if value.Not() {
+		hq = &searchquery.Not{Child: hq}
+	}
+	return hq, nil
+}
+
+// hierarchicalPattern converts a pattern value to a substring or regexp atom
+// matching file names (if fileName) or file contents.
+func hierarchicalPattern(value *types.Value, fileName, caseSensitive bool) (searchquery.Q, error) {
+	if value.String != nil {
+		return &searchquery.Substring{
+			Pattern:       *value.String,
+			CaseSensitive: caseSensitive,
+			FileName:      fileName,
+			Content:       !fileName,
+		}, nil
+	}
+
+	r, err := syntax.Parse(value.Regexp.String(), syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	if r.Op == syntax.OpLiteral {
+		return &searchquery.Substring{
+			Pattern:       string(r.Rune),
+			CaseSensitive: caseSensitive,
+			FileName:      fileName,
+			Content:       !fileName,
+		}, nil
+	}
+	return &searchquery.Regexp{
+		Regexp:        r,
+		CaseSensitive: caseSensitive,
+		FileName:      fileName,
+		Content:       !fileName,
+	}, nil
+}
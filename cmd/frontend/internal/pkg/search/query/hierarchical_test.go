@@ -0,0 +1,53 @@
+package query
+
+import "testing"
+
+func TestQuery_HierarchicalQuery(t *testing.T) {
+	tests := map[string]string{
+		"foo OR bar":                    `(or content_substr:"foo" content_substr:"bar")`,
+		"foo NOT bar":                   `(and content_substr:"foo" (not content_substr:"bar"))`,
+		"foo -bar":                      `(and content_substr:"foo" (not content_substr:"bar"))`,
+		"repo:a (foo OR file:b.*)":      `(and repo:a (or content_substr:"foo" file_regex:"(?-s:b.*)"))`,
+		`NOT ("foo" file:\.go$) OR bar`: `(or (not (and content_substr:"foo" file_regex:"(?-m:\\.go$)")) content_substr:"bar")`,
+		"Foo OR bar":                    `(or case_content_substr:"Foo" case_content_substr:"bar")`,
+		"case:no (Foo OR bar)":          `(or content_substr:"Foo" content_substr:"bar")`,
+		"-file:test (foo OR bar)":       `(and (not file_substr:"test") (or content_substr:"foo" content_substr:"bar"))`,
+	}
+	for input, want := range tests {
+		t.Run(input, func(t *testing.T) {
+			q, err := ParseAndCheck(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !q.Hierarchical {
+				t.Fatal("got Hierarchical == false, want true")
+			}
+			hq, err := q.HierarchicalQuery()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hq.String(); got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestQuery_HierarchicalQuery_unsupported(t *testing.T) {
+	tests := map[string]string{
+		"repo:a OR b":      `type error at character 0: field "repo" may not be used within OR or NOT; it must apply to the whole query`,
+		"NOT (a lang:go)":  `type error at character 7: field "lang" may not be used within OR or NOT; it must apply to the whole query`,
+		"lang:go (a OR b)": `field "lang" is not supported in queries with OR or NOT`,
+	}
+	for input, want := range tests {
+		t.Run(input, func(t *testing.T) {
+			q, err := ParseAndCheck(input)
+			if err == nil {
+				_, err = q.HierarchicalQuery()
+			}
+			if err == nil || err.Error() != want {
+				t.Errorf("got err == %v, want %q", err, want)
+			}
+		})
+	}
+}
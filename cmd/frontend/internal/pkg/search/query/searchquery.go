@@ -3,6 +3,8 @@
 package query
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/syntax"
@@ -21,6 +23,11 @@ const (
 	FieldLang      = "lang"
 	FieldType      = "type"
 
+	// FieldPatternType selects how search terms are interpreted: as regular
+	// expressions (patternType:regexp, the default) or as a structural search
+	// template (patternType:structural).
+	FieldPatternType = "patternType"
+
 	// For diff and commit search only:
 	FieldBefore    = "before"
 	FieldAfter     = "after"
@@ -41,16 +48,18 @@ var (
 
 	conf = types.Config{
 		FieldTypes: map[string]types.FieldType{
-			FieldDefault:   {Literal: types.RegexpType, Quoted: types.StringType},
+			FieldDefault:   {Literal: types.RegexpType, Quoted: types.StringType, Boolean: true},
 			FieldCase:      {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldRepo:      regexpNegatableFieldType,
 			FieldRepoGroup: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
-			FieldFile:      regexpNegatableFieldType,
+			FieldFile:      {Literal: types.RegexpType, Quoted: types.RegexpType, Negatable: true, Boolean: true},
 			FieldFork:      {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldArchived:  {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldLang:      {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
 			FieldType:      stringFieldType,
 
+			FieldPatternType: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+
 			FieldBefore:    stringFieldType,
 			FieldAfter:     stringFieldType,
 			FieldAuthor:    regexpNegatableFieldType,
@@ -95,11 +104,68 @@ func parseAndCheck(conf *types.Config, input string) (*Query, error) {
 	if err != nil {
 		return nil, err
 	}
+	structural := isStructural(syntaxQuery)
+	if structural {
+		conf = structuralConfig(conf)
+	}
 	checkedQuery, err := conf.Check(syntaxQuery)
 	if err != nil {
 		return nil, err
 	}
-	return &Query{conf: conf, Query: checkedQuery}, nil
+	if structural && checkedQuery.Hierarchical {
+		return nil, errors.New("patternType:structural may not be used with OR or NOT")
+	}
+	if structural {
+		for _, v := range checkedQuery.Fields[FieldDefault] {
+			if v.Regexp != nil {
+				return nil, fmt.Errorf("patternType:structural may not be used with regexp pattern /%s/", v.Regexp)
+			}
+		}
+	}
+	q := &Query{conf: conf, Query: checkedQuery}
+	if _, ok := conf.FieldTypes[FieldPatternType]; ok {
+		switch patternType, _ := q.StringValue(FieldPatternType); patternType {
+		case "", "regexp", "structural":
+		default:
+			return nil, fmt.Errorf("invalid patternType:%q (valid values are: regexp, structural)", patternType)
+		}
+	}
+	return q, nil
+}
+
+// isStructural reports whether q has patternType:structural.
+func isStructural(q *syntax.Query) bool {
+	for _, expr := range q.Expr {
+		if expr.Field == FieldPatternType && !expr.Not && strings.Trim(expr.Value, `"'`) == "structural" {
+			return true
+		}
+	}
+	return false
+}
+
+// structuralConfig returns a copy of conf in which search terms are strings
+// instead of regular expressions, since structural search templates (such as
+// foo(:[args])) are generally not valid regular expressions.
+func structuralConfig(conf *types.Config) *types.Config {
+	fieldTypes := make(map[string]types.FieldType, len(conf.FieldTypes))
+	for field, typ := range conf.FieldTypes {
+		fieldTypes[field] = typ
+	}
+	if typ, ok := fieldTypes[FieldDefault]; ok {
+		typ.Literal = types.StringType
+		fieldTypes[FieldDefault] = typ
+	}
+	return &types.Config{FieldTypes: fieldTypes, FieldAliases: conf.FieldAliases}
+}
+
+// IsStructural reports whether the query's search terms are a structural
+// search template (patternType:structural).
+func (q *Query) IsStructural() bool {
+	if _, ok := q.conf.FieldTypes[FieldPatternType]; !ok {
+		return false
+	}
+	patternType, _ := q.StringValue(FieldPatternType)
+	return patternType == "structural"
 }
 
 // BoolValue returns the last boolean value (yes/no) for the field. For example, if the query is
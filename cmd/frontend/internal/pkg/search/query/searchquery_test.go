@@ -140,3 +140,34 @@ func checkPanic(t *testing.T, msg string, f func()) {
 	}()
 	f()
 }
+
+func TestParseAndCheck_structural(t *testing.T) {
+	q, err := ParseAndCheck("patternType:structural foo(:[args]) file:\\.go$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !q.IsStructural() {
+		t.Error("got IsStructural() == false, want true")
+	}
+	values := q.Values(FieldDefault)
+	if len(values) != 1 || values[0].String == nil || *values[0].String != "foo(:[args])" {
+		t.Errorf("got search terms %v, want the string foo(:[args])", values)
+	}
+	if values, _ := q.RegexpPatterns(FieldFile); !reflect.DeepEqual(values, []string{`\.go$`}) {
+		t.Errorf("got file patterns %q, want regexp", values)
+	}
+
+	q, err = ParseAndCheck("foo(:[args])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.IsStructural() {
+		t.Error("got IsStructural() == true, want false")
+	}
+
+	for _, input := range []string{"patternType:bogus foo", "patternType:structural foo OR bar", "patternType:structural /foo/"} {
+		if _, err := ParseAndCheck(input); err == nil {
+			t.Errorf("%q: got err == nil, want error", input)
+		}
+	}
+}
@@ -27,20 +27,34 @@ type context struct {
 //
 // BNF-ish query syntax:
 //
-//   exprList  := {exprSign} | exprSign (sep exprSign)*
+//   query     := {orExpr}
+//   orExpr    := andExpr ("OR" andExpr)*
+//   andExpr   := unaryExpr ({"AND"} unaryExpr)*
+//   unaryExpr := "NOT" unaryExpr | {"-"} "(" orExpr ")" | exprSign
 //   exprSign  := {"-"} expr
 //   expr      := fieldExpr | lit | quoted | pattern
 //   fieldExpr := lit ":" value
 //   value     := lit | quoted
+//
+// Terms are separated by sep, and adjacent terms are implicitly ANDed. NOT
+// binds tighter than AND, which binds tighter than OR.
 func Parse(input string) (*Query, error) {
 	tokens := Scan(input)
 	p := parser{tokens: tokens}
 	ctx := context{field: ""}
-	exprs, err := p.parseExprList(ctx)
+	p.skipSep()
+	if p.peek().Type == TokenEOF {
+		return &Query{Input: input}, nil
+	}
+	tree, err := p.parseOr(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return &Query{Expr: exprs, Input: input}, nil
+	p.skipSep()
+	if tok := p.peek(); tok.Type != TokenEOF {
+		return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want expr", tok.Type)}
+	}
+	return &Query{Expr: tree.exprs(), Tree: tree, Input: input}, nil
 }
 
 // peek returns the next token without consuming it. Peeking beyond the end of
@@ -68,30 +82,129 @@ func (p *parser) next() Token {
 	return Token{Type: TokenEOF}
 }
 
-// exprList := {exprSign} | exprSign (sep exprSign)*
-func (p *parser) parseExprList(ctx context) (exprList []*Expr, err error) {
-	if p.peek().Type == TokenEOF {
-		return nil, nil
+// skipSep consumes any separators at the cursor.
+func (p *parser) skipSep() {
+	for p.peek().Type == TokenSep {
+		p.next()
 	}
+}
 
+// orExpr := andExpr ("OR" andExpr)*
+func (p *parser) parseOr(ctx context) (*Node, error) {
+	node, err := p.parseAnd(ctx)
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Node{node}
 	for {
-		tok := p.peek()
-		if tok.Type == TokenEOF {
+		p.skipSep()
+		if p.peek().Type != TokenOr {
 			break
 		}
-		if tok.Type == TokenSep {
+		p.next()
+		p.skipSep()
+		operand, err := p.parseAnd(ctx)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return node, nil
+	}
+	return &Node{Pos: node.Pos, Op: OpOr, Operands: operands}, nil
+}
+
+// andExpr := unaryExpr ({"AND"} unaryExpr)*
+func (p *parser) parseAnd(ctx context) (*Node, error) {
+	node, err := p.parseUnary(ctx)
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Node{node}
+loop:
+	for {
+		p.skipSep()
+		switch p.peek().Type {
+		case TokenEOF, TokenRParen, TokenOr:
+			break loop
+		case TokenAnd:
 			p.next()
-			continue
+			p.skipSep()
+		}
+		operand, err := p.parseUnary(ctx)
+		if err != nil {
+			return nil, err
 		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return node, nil
+	}
+	return &Node{Pos: node.Pos, Op: OpAnd, Operands: operands}, nil
+}
 
-		expr, err := p.parseExprSign(ctx)
+// unaryExpr := "NOT" unaryExpr | {"-"} "(" orExpr ")" | exprSign
+func (p *parser) parseUnary(ctx context) (*Node, error) {
+	tok := p.peek()
+	switch tok.Type {
+	case TokenNot:
+		p.next()
+		p.skipSep()
+		operand, err := p.parseUnary(ctx)
 		if err != nil {
 			return nil, err
 		}
-		exprList = append(exprList, expr)
+		return negate(tok.Pos, operand), nil
+	case TokenLParen:
+		return p.parseGroup(ctx)
+	case TokenMinus:
+		if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].Type == TokenLParen {
+			p.next()
+			operand, err := p.parseGroup(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return negate(tok.Pos, operand), nil
+		}
+	}
+
+	expr, err := p.parseExprSign(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return &Node{Pos: expr.Pos, Expr: expr}, nil
+}
 
-	return exprList, nil
+// parseGroup parses "(" orExpr ")".
+func (p *parser) parseGroup(ctx context) (*Node, error) {
+	lparen := p.next()
+	p.skipSep()
+	if tok := p.peek(); tok.Type == TokenRParen {
+		return nil, &ParseError{Pos: tok.Pos, Msg: "got TokenRParen, want expr (empty parentheses)"}
+	}
+	node, err := p.parseOr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSep()
+	if tok := p.next(); tok.Type != TokenRParen {
+		return nil, &ParseError{Pos: lparen.Pos, Msg: "unclosed parenthesis"}
+	}
+	return node, nil
+}
+
+// negate returns the negation of node. A negated single term is represented
+// as a negated Expr (-term), so that it is handled like any other negated term.
+func negate(pos int, node *Node) *Node {
+	if node.Op == OpNone {
+		node.Expr.Not = !node.Expr.Not
+		return node
+	}
+	if node.Op == OpNot {
+		return node.Operands[0]
+	}
+	return &Node{Pos: pos, Op: OpNot, Operands: []*Node{node}}
 }
 
 // exprSign := {"-"} expr
@@ -129,15 +242,23 @@ func (p *parser) parseExpr(ctx context) (*Expr, error) {
 			valueTok := p.next()
 			switch valueTok.Type {
 			case TokenLiteral, TokenQuoted:
-				if tok3 := p.next(); tok3.Type != TokenSep && tok3.Type != TokenEOF {
+				if tok3 := p.next(); tok3.Type == TokenRParen {
+					p.backup()
+				} else if tok3.Type != TokenSep && tok3.Type != TokenEOF {
 					return nil, &ParseError{Pos: tok3.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok3.Type)}
 				}
 				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: valueTok.Value, ValueType: valueTok.Type}, nil
+			case TokenRParen:
+				p.backup()
+				fallthrough
 			case TokenSep, TokenEOF:
 				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: "", ValueType: TokenLiteral}, nil
 			default:
 				return nil, &ParseError{Pos: valueTok.Pos, Msg: fmt.Sprintf("got %s, want value", valueTok.Type)}
 			}
+		case TokenRParen:
+			p.backup()
+			fallthrough
 		case TokenSep, TokenEOF:
 			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		default:
@@ -146,6 +267,9 @@ func (p *parser) parseExpr(ctx context) (*Expr, error) {
 	case TokenQuoted, TokenPattern:
 		tok2 := p.next()
 		switch tok2.Type {
+		case TokenRParen:
+			p.backup()
+			fallthrough
 		case TokenSep, TokenEOF:
 			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		default:
@@ -111,3 +111,60 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_boolean(t *testing.T) {
+	tests := map[string]struct {
+		wantTree    string
+		wantBoolean bool
+		wantErr     *ParseError
+	}{
+		"a b":               {wantTree: "a b"},
+		"a AND b":           {wantTree: "a b"},
+		"(a b)":             {wantTree: "a b"},
+		"a OR b":            {wantTree: "a OR b", wantBoolean: true},
+		"a or b":            {wantTree: "a or b"},
+		"a b OR c":          {wantTree: "(a b) OR c", wantBoolean: true},
+		"a (b OR c)":        {wantTree: "a (b OR c)", wantBoolean: true},
+		"a AND (b OR c) d":  {wantTree: "a (b OR c) d", wantBoolean: true},
+		"NOT a":             {wantTree: "-a"},
+		"NOT -a":            {wantTree: "a"},
+		"a NOT file:b":      {wantTree: "a -file:b"},
+		"NOT (a b)":         {wantTree: "NOT (a b)", wantBoolean: true},
+		"-(a OR b)":         {wantTree: "NOT (a OR b)", wantBoolean: true},
+		"NOT NOT (a b)":     {wantTree: "a b"},
+		"(a OR b) (c OR d)": {wantTree: "(a OR b) (c OR d)", wantBoolean: true},
+		"((a|b) OR c)":      {wantTree: "(a|b) OR c", wantBoolean: true},
+		"(repo:a f:b)":      {wantTree: "repo:a f:b"},
+		"(a OR /b c/)":      {wantTree: "a OR /b c/", wantBoolean: true},
+		"(a":                {wantErr: &ParseError{Pos: 0, Msg: "unclosed parenthesis"}},
+		"(a OR (b c)":       {wantErr: &ParseError{Pos: 0, Msg: "unclosed parenthesis"}},
+		"( )":               {wantErr: &ParseError{Pos: 2, Msg: "got TokenRParen, want expr (empty parentheses)"}},
+		"OR a":              {wantErr: &ParseError{Pos: 0, Msg: "got TokenOr, want expr"}},
+		"a OR":              {wantErr: &ParseError{Pos: 4, Msg: "got TokenEOF, want expr"}},
+		"a AND OR b":        {wantErr: &ParseError{Pos: 6, Msg: "got TokenOr, want expr"}},
+		"a NOT":             {wantErr: &ParseError{Pos: 5, Msg: "got TokenEOF, want expr"}},
+		"(a) b)":            {wantTree: "(a) b)"},
+		"(a b))":            {wantTree: "(a b) )"},
+	}
+	for input, test := range tests {
+		t.Run(input, func(t *testing.T) {
+			query, err := Parse(input)
+			if err != nil && test.wantErr == nil {
+				t.Fatal(err)
+			} else if err == nil && test.wantErr != nil {
+				t.Fatalf("got err == nil, want %q", test.wantErr)
+			} else if test.wantErr != nil && !reflect.DeepEqual(err, test.wantErr) {
+				t.Fatalf("got err == %q, want %q", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tree := query.Tree.String(); tree != test.wantTree {
+				t.Errorf("tree: %s\ngot  %s\nwant %s", input, tree, test.wantTree)
+			}
+			if boolean := query.HasBooleanOperators(); boolean != test.wantBoolean {
+				t.Errorf("HasBooleanOperators: %s\ngot  %v\nwant %v", input, boolean, test.wantBoolean)
+			}
+		})
+	}
+}
@@ -8,7 +8,91 @@ import (
 // A Query contains the parse tree of a query.
 type Query struct {
 	Input string  // the original input query string
-	Expr  []*Expr // expressions in this query
+	Expr  []*Expr // expressions in this query, in order of appearance
+	Tree  *Node   // the query's boolean structure (nil for an empty query)
+}
+
+// HasBooleanOperators reports whether the query uses OR or NOT (applied to a
+// group). Queries without them are equivalent to the AND of all of q.Expr.
+func (q *Query) HasBooleanOperators() bool {
+	var found bool
+	q.Tree.walk(func(n *Node) {
+		if n.Op == OpOr || n.Op == OpNot {
+			found = true
+		}
+	})
+	return found
+}
+
+// Operator is a boolean operator in a query.
+type Operator int
+
+// All Operator values.
+const (
+	OpNone Operator = iota // a leaf node holding an Expr
+	OpAnd
+	OpOr
+	OpNot
+)
+
+// A Node is a node in the boolean structure of a query. Leaf nodes hold a
+// single Expr; all other nodes apply Op to their Operands.
+//
+// NOT applied directly to a single term is represented as a negated Expr (the
+// same as -term), not as an OpNot node.
+type Node struct {
+	Pos      int      // the starting character position of the node
+	Op       Operator // the operator, or OpNone for a leaf
+	Expr     *Expr    // the expression (only for leaf nodes)
+	Operands []*Node  // the operands (only for non-leaf nodes)
+}
+
+func (n *Node) String() string {
+	switch n.Op {
+	case OpNone:
+		return n.Expr.String()
+	case OpNot:
+		return "NOT " + n.Operands[0].group()
+	}
+	sep := " "
+	if n.Op == OpOr {
+		sep = " OR "
+	}
+	s := make([]string, len(n.Operands))
+	for i, o := range n.Operands {
+		s[i] = o.group()
+	}
+	return strings.Join(s, sep)
+}
+
+// group returns the string representation of n, parenthesized if it is an
+// AND or OR of multiple operands.
+func (n *Node) group() string {
+	if n.Op == OpAnd || n.Op == OpOr {
+		return "(" + n.String() + ")"
+	}
+	return n.String()
+}
+
+// walk calls f for n and each of its descendants, in depth-first order.
+func (n *Node) walk(f func(*Node)) {
+	if n == nil {
+		return
+	}
+	f(n)
+	for _, o := range n.Operands {
+		o.walk(f)
+	}
+}
+
+// exprs returns the Exprs of all leaf nodes under n, in order.
+func (n *Node) exprs() (exprs []*Expr) {
+	n.walk(func(n *Node) {
+		if n.Op == OpNone {
+			exprs = append(exprs, n.Expr)
+		}
+	})
+	return exprs
 }
 
 // An Expr describes an expression in a query.
@@ -22,8 +22,26 @@ const (
 	TokenColon
 	TokenMinus
 	TokenSep // separator (like a semicolon)
+	TokenLParen
+	TokenRParen
+	TokenAnd
+	TokenOr
+	TokenNot
 )
 
+// keywords are the boolean operators. They are only recognized when written
+// in uppercase, so that "and", "or" and "not" remain ordinary search terms.
+var keywords = map[string]TokenType{
+	"AND": TokenAnd,
+	"OR":  TokenOr,
+	"NOT": TokenNot,
+}
+
+// camelCaseFields are the field names that contain uppercase letters. They are
+// matched explicitly, so that other words with uppercase letters followed by a
+// ':' (such as "someMap:" in a pasted code snippet) remain literals.
+var camelCaseFields = []string{"patternType"}
+
 var singleCharTokens = map[rune]TokenType{
 	':': TokenColon,
 	'-': TokenMinus,
@@ -54,6 +72,8 @@ type scanner struct {
 	pos     int
 	prevPos int
 	start   int
+
+	depth int // number of currently open TokenLParen
 }
 
 func (s *scanner) next() rune {
@@ -88,6 +108,17 @@ func (s *scanner) emit(typ TokenType) {
 	s.start = s.pos
 }
 
+// emitLiteral emits the pending text as a TokenLiteral, or as a keyword token
+// if it is a boolean operator that is not the value of a field.
+func (s *scanner) emitLiteral() {
+	isValue := len(s.tokens) > 0 && s.tokens[len(s.tokens)-1].Type == TokenColon
+	if typ, ok := keywords[s.input[s.start:s.pos]]; ok && !isValue {
+		s.emit(typ)
+		return
+	}
+	s.emit(TokenLiteral)
+}
+
 func (s *scanner) emitError(msg string) {
 	s.tokens = append(s.tokens, Token{
 		Type:  TokenError,
@@ -112,6 +143,19 @@ func scanDefault(s *scanner) stateFn {
 			return scanDefault
 		}
 
+		if r == '(' && opensGroup(s.input[s.pos:]) {
+			s.next()
+			s.depth++
+			s.emit(TokenLParen)
+			return scanDefault
+		}
+		if r == ')' && s.depth > 0 {
+			s.next()
+			s.depth--
+			s.emit(TokenRParen)
+			return scanDefault
+		}
+
 		if r == '"' || r == '\'' {
 			return scanQuoted
 		}
@@ -125,6 +169,16 @@ func scanDefault(s *scanner) stateFn {
 }
 
 func scanText(s *scanner) stateFn {
+	for _, field := range camelCaseFields {
+		if strings.HasPrefix(s.input[s.pos:], field+":") {
+			s.pos += len(field)
+			s.emit(TokenLiteral)
+			s.next()
+			s.emit(TokenColon)
+			return scanValue
+		}
+	}
+
 	// Characters that may come before a ':' (TokenColon) in a TokenLiteral.
 	preColonChars := "abcdefghijklmnopqrstuvwxyz0123456789"
 
@@ -133,7 +187,7 @@ func scanText(s *scanner) stateFn {
 			break
 		}
 		r := s.next()
-		if unicode.IsSpace(r) {
+		if unicode.IsSpace(r) || (r == ')' && s.depth > 0) {
 			s.backup()
 			break
 		}
@@ -146,11 +200,12 @@ func scanText(s *scanner) stateFn {
 			return scanValue
 		}
 		if !strings.ContainsRune(preColonChars, r) {
+			s.pos = s.start
 			return scanLiteral
 		}
 	}
 
-	s.emit(TokenLiteral)
+	s.emitLiteral()
 	return scanDefault
 }
 
@@ -168,7 +223,12 @@ func scanValue(s *scanner) stateFn {
 	return scanLiteral
 }
 
+// scanLiteral scans a literal up to the next whitespace. Inside a group, a
+// ')' that does not close a '(' within the literal itself ends the literal, so
+// that "(a OR b(c))" scans as "(", "a", "OR", "b(c)", ")".
 func scanLiteral(s *scanner) stateFn {
+	balance := 0
+	escaped := false
 	for {
 		if s.eof() {
 			break
@@ -178,12 +238,58 @@ func scanLiteral(s *scanner) stateFn {
 			s.backup()
 			break
 		}
+		if escaped {
+			escaped = false
+			continue
+		}
+		switch r {
+		case '\\':
+			escaped = true
+		case '(':
+			balance++
+		case ')':
+			if balance == 0 && s.depth > 0 {
+				s.backup()
+				s.emitLiteral()
+				return scanDefault
+			}
+			balance--
+		}
 	}
 
-	s.emit(TokenLiteral)
+	s.emitLiteral()
 	return scanDefault
 }
 
+// opensGroup reports whether the '(' at the start of word (which extends to
+// the next whitespace) opens a group rather than being part of a regexp such
+// as "(a|b)c". A '(' opens a group if it is not closed within the same word.
+func opensGroup(word string) bool {
+	balance := 0
+	escaped := false
+	for _, r := range word {
+		if unicode.IsSpace(r) {
+			break
+		}
+		if escaped {
+			escaped = false
+			continue
+		}
+		switch r {
+		case '\\':
+			escaped = true
+		case '(':
+			balance++
+		case ')':
+			balance--
+			if balance == 0 {
+				return false
+			}
+		}
+	}
+	return balance > 0
+}
+
 func scanQuoted(s *scanner) stateFn {
 	q := s.next()
 	escaped := false
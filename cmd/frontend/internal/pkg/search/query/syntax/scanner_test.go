@@ -29,6 +29,8 @@ func TestScanner(t *testing.T) {
 		"^a":       {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"^a"}},
 		"^a .b":    {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenLiteral}, wantValues: []string{"^a", " ", ".b"}},
 		"a:b c:d":  {wantTypes: []TokenType{TokenLiteral, TokenColon, TokenLiteral, TokenSep, TokenLiteral, TokenColon, TokenLiteral}, wantValues: []string{"a", ":", "b", " ", "c", ":", "d"}},
+		"aB:c":     {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"aB:c"}},
+		"Ab:c":     {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"Ab:c"}},
 		"a:b:c":    {wantTypes: []TokenType{TokenLiteral, TokenColon, TokenLiteral}, wantValues: []string{"a", ":", "b:c"}},
 		`a:""`:     {wantTypes: []TokenType{TokenLiteral, TokenColon, TokenQuoted}},
 		`a:"b"`:    {wantTypes: []TokenType{TokenLiteral, TokenColon, TokenQuoted}, wantValues: []string{"a", ":", `"b"`}},
@@ -67,6 +69,20 @@ func TestScanner(t *testing.T) {
 		"a /b/ c":  {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern, TokenSep, TokenLiteral}, wantValues: []string{"a", " ", "b", " ", "c"}},
 		"a /b c":   {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern}, wantValues: []string{"a", " ", "b c"}},
 		"a /b c/":  {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenPattern}, wantValues: []string{"a", " ", "b c"}},
+		"a OR b":   {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenOr, TokenSep, TokenLiteral}, wantValues: []string{"a", " ", "OR", " ", "b"}},
+		"a or b":   {wantTypes: []TokenType{TokenLiteral, TokenSep, TokenLiteral, TokenSep, TokenLiteral}, wantValues: []string{"a", " ", "or", " ", "b"}},
+		"AND NOT":  {wantTypes: []TokenType{TokenAnd, TokenSep, TokenNot}, wantValues: []string{"AND", " ", "NOT"}},
+		"a:OR":     {wantTypes: []TokenType{TokenLiteral, TokenColon, TokenLiteral}, wantValues: []string{"a", ":", "OR"}},
+		"(a)":      {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"(a)"}},
+		"(a|b)c":   {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"(a|b)c"}},
+		"(a b)":    {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", " ", "b", ")"}},
+		"((a) b)":  {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "(a)", " ", "b", ")"}},
+		"(a b(c))": {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", " ", "b(c)", ")"}},
+		`(a \)b)`:  {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", " ", `\)b`, ")"}},
+		`(a "b")`:  {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenQuoted, TokenRParen}, wantValues: []string{"(", "a", " ", `"b"`, ")"}},
+		"(a c:d)":  {wantTypes: []TokenType{TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenColon, TokenLiteral, TokenRParen}, wantValues: []string{"(", "a", " ", "c", ":", "d", ")"}},
+		"-(a b)":   {wantTypes: []TokenType{TokenMinus, TokenLParen, TokenLiteral, TokenSep, TokenLiteral, TokenRParen}, wantValues: []string{"-", "(", "a", " ", "b", ")"}},
+		"a)":       {wantTypes: []TokenType{TokenLiteral}, wantValues: []string{"a)"}},
 	}
 	for input, test := range tests {
 		t.Run(input, func(t *testing.T) {
@@ -89,6 +105,23 @@ func TestScanner(t *testing.T) {
 	}
 }
 
+func TestScanner_camelCase(t *testing.T) {
+	tests := map[string][]string{
+		// Known camelCase fields.
+		"patternType:structural": {"patternType", ":", "structural", ""},
+		"-patternType:regexp":    {"-", "patternType", ":", "regexp", ""},
+		// Other camelCase words followed by a ':' are literals.
+		"fooBar:x": {"fooBar:x", ""},
+		"someMap:": {"someMap:", ""},
+		"TODO:":    {"TODO:", ""},
+	}
+	for input, want := range tests {
+		if got := tokenValues(Scan(input)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %q, want %q", input, got, want)
+		}
+	}
+}
+
 func tokenTypes(tokens []Token) []TokenType {
 	types := make([]TokenType, len(tokens))
 	for i, t := range tokens {
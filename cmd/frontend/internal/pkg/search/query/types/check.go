@@ -32,6 +32,7 @@ type FieldType struct {
 	Quoted    ValueType // interpret literal tokens as being of this type
 	Singular  bool      // whether the field may only be used 0 or 1 times
 	Negatable bool      // whether the field can be matched negated (i.e., -field:value)
+	Boolean   bool      // whether the field may be used within OR and NOT (and then negated, even if not Negatable)
 
 	// FeatureFlagEnabled returns true if this field is enabled.
 	// The field is always enabled if this is nil.
@@ -44,8 +45,10 @@ func (c *Config) Check(query *syntax.Query) (*Query, error) {
 		Syntax: query,
 		Fields: map[string][]*Value{},
 	}
+	boolean := c.isHierarchical(query)
+	checkedQuery.Hierarchical = boolean
 	for _, expr := range query.Expr {
-		field, fieldType, value, err := c.checkExpr(expr)
+		field, fieldType, value, err := c.checkExpr(expr, boolean)
 		if err != nil {
 			return nil, err
 		}
@@ -54,10 +57,60 @@ func (c *Config) Check(query *syntax.Query) (*Query, error) {
 		}
 		checkedQuery.Fields[field] = append(checkedQuery.Fields[field], value)
 	}
+	if boolean {
+		if err := c.checkBoolean(query.Tree, false); err != nil {
+			return nil, err
+		}
+	}
 	return &checkedQuery, nil
 }
 
-func (c *Config) resolveField(field string, not bool) (resolvedField string, typ FieldType, err error) {
+// isHierarchical reports whether query must be evaluated with its boolean
+// structure: it uses OR or NOT groups, or negates a field that only supports
+// negation in that form.
+func (c *Config) isHierarchical(query *syntax.Query) bool {
+	if query.HasBooleanOperators() {
+		return true
+	}
+	for _, expr := range query.Expr {
+		if !expr.Not {
+			continue
+		}
+		if _, typ, err := c.resolveField(expr.Field, false, false); err == nil && !typ.Negatable && typ.Boolean {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBoolean checks that only Boolean fields are used within OR and NOT
+// groups. Other fields (such as repo:) apply to the whole query, so they may
+// only be ANDed with it at the top level.
+func (c *Config) checkBoolean(node *syntax.Node, nested bool) error {
+	switch node.Op {
+	case syntax.OpNone:
+		field, typ, err := c.resolveField(node.Expr.Field, false, true)
+		if err != nil {
+			return &TypeError{Pos: node.Pos, Err: err}
+		}
+		if nested && !typ.Boolean {
+			return &TypeError{Pos: node.Pos, Err: fmt.Errorf("field %q may not be used within OR or NOT; it must apply to the whole query", field)}
+		}
+		return nil
+	case syntax.OpAnd:
+		// AND does not change whether its operands are nested.
+	default:
+		nested = true
+	}
+	for _, operand := range node.Operands {
+		if err := c.checkBoolean(operand, nested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) resolveField(field string, not, boolean bool) (resolvedField string, typ FieldType, err error) {
 	// Resolve field alias, if any.
 	if resolvedField, ok := c.FieldAliases[field]; ok {
 		field = resolvedField
@@ -74,7 +127,7 @@ func (c *Config) resolveField(field string, not bool) (resolvedField string, typ
 		err = fmt.Errorf("unrecognized field %q; the feature flag for this field is not enabled", field)
 		return
 	}
-	if not && !typ.Negatable {
+	if not && !typ.Negatable && !(boolean && typ.Boolean) {
 		if field == "" {
 			err = errors.New("negated terms (-term) are not yet supported")
 		} else {
@@ -85,9 +138,9 @@ func (c *Config) resolveField(field string, not bool) (resolvedField string, typ
 	return field, typ, nil
 }
 
-func (c *Config) checkExpr(expr *syntax.Expr) (field string, fieldType FieldType, value *Value, err error) {
+func (c *Config) checkExpr(expr *syntax.Expr, boolean bool) (field string, fieldType FieldType, value *Value, err error) {
 	// Resolve field name.
-	resolvedField, fieldType, err := c.resolveField(expr.Field, expr.Not)
+	resolvedField, fieldType, err := c.resolveField(expr.Field, expr.Not, boolean)
 	if err != nil {
 		return "", FieldType{}, nil, &TypeError{Pos: expr.Pos, Err: err}
 	}
@@ -35,6 +35,11 @@ func TestCheck(t *testing.T) {
 				Literal: RegexpType,
 				Quoted:  StringType,
 			},
+			"t": {
+				Literal: RegexpType,
+				Quoted:  StringType,
+				Boolean: true,
+			},
 			"r": {
 				Literal:   RegexpType,
 				Quoted:    RegexpType,
@@ -92,14 +97,28 @@ func TestCheck(t *testing.T) {
 				"b": {{Value: true}},
 			},
 		},
-		`-a`:         {wantErr: &TypeError{Pos: 1, Err: errors.New(`negated terms (-term) are not yet supported`)}},
-		`-b:yes`:     {wantErr: &TypeError{Pos: 1, Err: errors.New(`field "b" does not support negation`)}},
-		"b:yes b:no": {wantErr: &TypeError{Pos: 6, Err: errors.New(`field "b" may not be used more than once`)}},
-		`/a\x/`:      {wantErr: &TypeError{Pos: 1, Err: errors.New("error parsing regexp: invalid escape sequence: `\\x`")}},
-		`"\z"`:       {wantErr: &TypeError{Pos: 0, Err: errors.New(`invalid quoted string: "\z"`)}},
-		"b:z":        {wantErr: &TypeError{Pos: 0, Err: errors.New(`invalid boolean "z"`)}},
-		`b:"z"`:      {wantErr: &TypeError{Pos: 0, Err: errors.New(`invalid boolean "z"`)}},
-		"z:a":        {wantErr: &TypeError{Pos: 0, Err: errors.New(`unrecognized field "z"`)}},
+		"a OR -b": {wantErr: &TypeError{Pos: 6, Err: errors.New(`negated terms (-term) are not yet supported`)}},
+		"t:a OR -t:b": {want: map[string][]value{"t": {
+			{Value: regexp.MustCompile("a")},
+			{Not: true, Value: regexp.MustCompile("b")},
+		}}},
+		"-t:a": {want: map[string][]value{"t": {{Not: true, Value: regexp.MustCompile("a")}}}},
+		"r:a NOT (t:b t:c)": {
+			want: map[string][]value{
+				"t": {{Value: regexp.MustCompile("b")}, {Value: regexp.MustCompile("c")}},
+				"r": {{Value: regexp.MustCompile("a")}},
+			},
+		},
+		"t:a OR r:b":     {wantErr: &TypeError{Pos: 7, Err: errors.New(`field "r" may not be used within OR or NOT; it must apply to the whole query`)}},
+		"NOT (t:a -r:b)": {wantErr: &TypeError{Pos: 10, Err: errors.New(`field "r" may not be used within OR or NOT; it must apply to the whole query`)}},
+		`-a`:             {wantErr: &TypeError{Pos: 1, Err: errors.New(`negated terms (-term) are not yet supported`)}},
+		`-b:yes`:         {wantErr: &TypeError{Pos: 1, Err: errors.New(`field "b" does not support negation`)}},
+		"b:yes b:no":     {wantErr: &TypeError{Pos: 6, Err: errors.New(`field "b" may not be used more than once`)}},
+		`/a\x/`:          {wantErr: &TypeError{Pos: 1, Err: errors.New("error parsing regexp: invalid escape sequence: `\\x`")}},
+		`"\z"`:           {wantErr: &TypeError{Pos: 0, Err: errors.New(`invalid quoted string: "\z"`)}},
+		"b:z":            {wantErr: &TypeError{Pos: 0, Err: errors.New(`invalid boolean "z"`)}},
+		`b:"z"`:          {wantErr: &TypeError{Pos: 0, Err: errors.New(`invalid boolean "z"`)}},
+		"z:a":            {wantErr: &TypeError{Pos: 0, Err: errors.New(`unrecognized field "z"`)}},
 	}
 	for input, test := range tests {
 		t.Run(input, func(t *testing.T) {
@@ -10,6 +10,23 @@ import (
 type Query struct {
 	Syntax *syntax.Query       // the query syntax
 	Fields map[string][]*Value // map of field name -> values
+
+	// Hierarchical is whether the query uses boolean operators (OR, NOT or
+	// negated Boolean fields) and must be evaluated using Syntax.Tree.
+	Hierarchical bool
+}
+
+// Lookup returns the field name and checked value of expr, which must be one
+// of q.Syntax.Expr.
+func (q *Query) Lookup(expr *syntax.Expr) (field string, value *Value, ok bool) {
+	for field, values := range q.Fields {
+		for _, v := range values {
+			if v.syntax == expr {
+				return field, v, true
+			}
+		}
+	}
+	return "", nil, false
 }
 
 // ValueType is the set of types of values in queries.
@@ -2,9 +2,11 @@
 package search
 
 import (
+	"errors"
 	"regexp/syntax"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/search/multiline"
 )
 
 // PatternInfo is the struct used by vscode pass on search queries. Keep it in
@@ -14,6 +16,9 @@ type PatternInfo struct {
 	IsRegExp        bool
 	IsWordMatch     bool
 	IsCaseSensitive bool
+	IsStructuralPat bool
+	IsReplace       bool
+	Replacement     string
 	FileMatchLimit  int32
 
 	// We do not support IsMultiline
@@ -33,8 +38,24 @@ func (p *PatternInfo) IsEmpty() bool {
 	return p.Pattern == "" && p.ExcludePattern == "" && len(p.IncludePatterns) == 0 && p.IncludePattern == ""
 }
 
+// MatchesNewline returns true if Pattern is a regular expression that
+// explicitly matches a newline, such as `foo\nbar` or `(?s)foo.*bar` (see
+// multiline.MatchesNewline). Searcher matches such patterns against whole
+// files, so their matches may span lines.
+func (p *PatternInfo) MatchesNewline() bool {
+	if !p.IsRegExp || p.IsStructuralPat {
+		return false
+	}
+	matches, err := multiline.MatchesNewline(p.Pattern)
+	return err == nil && matches
+}
+
 // Validate returns a non-nil error if PatternInfo is not valid.
 func (p *PatternInfo) Validate() error {
+	if p.IsReplace && p.IsStructuralPat {
+		return errors.New("replacements are not supported in structural search")
+	}
+
 	if p.IsRegExp {
 		if _, err := syntax.Parse(p.Pattern, syntax.Perl); err != nil {
 			return err
@@ -17,8 +17,24 @@ type DiscussionThread struct {
 	ArchivedAt   *time.Time
 	UpdatedAt    time.Time
 	DeletedAt    *time.Time
+	Status       DiscussionThreadStatus
+
+	// AssigneeUserIDs are the users assigned to the thread, from the
+	// discussion_threads_assignees table.
+	AssigneeUserIDs []int32
+	Labels          []string
 }
 
+// DiscussionThreadStatus is the status of a discussion thread.
+type DiscussionThreadStatus string
+
+// The valid discussion_threads.status values.
+const (
+	DiscussionThreadStatusOpen     DiscussionThreadStatus = "open"
+	DiscussionThreadStatusResolved DiscussionThreadStatus = "resolved"
+	DiscussionThreadStatusWontFix  DiscussionThreadStatus = "wontfix"
+)
+
 // DiscussionThreadTargetRepo mirrors the underlying discussion_threads_target_repo field types exactly.
 // It intentionally does not try to e.g. alleviate null fields.
 type DiscussionThreadTargetRepo struct {
@@ -56,3 +72,40 @@ type DiscussionComment struct {
 	DeletedAt    *time.Time
 	Reports      []string
 }
+
+// DiscussionNotificationDelivery mirrors the underlying
+// discussion_notification_deliveries field types exactly. It intentionally does
+// not try to e.g. alleviate null fields.
+type DiscussionNotificationDelivery struct {
+	ID        int64
+	Event     DiscussionNotificationEvent
+	ThreadID  int64
+	CommentID int64
+
+	// UserID or OrgID is the settings subject whose subscription the
+	// notification is for.
+	UserID *int32
+	OrgID  *int32
+
+	TargetType string // "slack", "microsoftTeams" or "webhook"
+	URL        string
+	Body       string // the JSON request body
+	Signature  string // the X-Sourcegraph-Signature header of webhook requests, if any
+
+	Attempts      int32
+	LastError     *string
+	NextAttemptAt *time.Time // nil if the notification was delivered or will not be retried
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time
+}
+
+// DiscussionNotificationEvent is the kind of discussion activity that a
+// notification is about.
+type DiscussionNotificationEvent string
+
+// The valid discussion_notification_deliveries.event values.
+const (
+	DiscussionNotificationEventThreadCreated DiscussionNotificationEvent = "threadCreated"
+	DiscussionNotificationEventCommentAdded  DiscussionNotificationEvent = "commentAdded"
+	DiscussionNotificationEventMention       DiscussionNotificationEvent = "mention"
+)
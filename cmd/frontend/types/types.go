@@ -29,6 +29,8 @@ type Repo struct {
 	Enabled bool
 	// Fork is whether this repository is a fork of another repository.
 	Fork bool
+	// Archived is whether this repository has been archived on its code host.
+	Archived bool
 	// CreatedAt is when this repository was created on Sourcegraph.
 	CreatedAt time.Time
 	// UpdatedAt is when this repository's metadata was last updated on Sourcegraph.
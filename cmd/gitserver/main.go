@@ -2,12 +2,14 @@
 package main // import "github.com/sourcegraph/sourcegraph/cmd/gitserver"
 
 import (
+	"context"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -18,16 +20,40 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
 	"github.com/sourcegraph/sourcegraph/pkg/debugserver"
 	"github.com/sourcegraph/sourcegraph/pkg/env"
+	gitserverclient "github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/tracer"
 )
 
 const janitorInterval = 24 * time.Hour
 
+// rebalanceInterval is how often we transfer repositories owned by other
+// gitservers to their owner.
+const rebalanceInterval = 10 * time.Minute
+
+// freeUpSpaceInterval is how often we check whether we need to evict
+// repositories to free up disk space.
+const freeUpSpaceInterval = time.Minute
+
 var (
 	reposDir          = env.Get("SRC_REPOS_DIR", "/data/repos", "Root dir containing repos.")
 	runRepoCleanup, _ = strconv.ParseBool(env.Get("SRC_RUN_REPO_CLEANUP", "", "Periodically remove inactive repositories."))
+	gitserverAddr     = env.Get("GITSERVER_ADDR", "", "Address of this gitserver in the list of gitservers. Defaults to the address whose host is the hostname.")
+	wantPctFree       = env.Get("SRC_REPOS_DESIRED_PERCENT_FREE", "10", "Target percentage of free space on disk. The least recently used repositories are evicted when free space falls below it. 0 disables evictions.")
 )
 
+// gitserverAddrsReady is non-zero once the list of gitservers has been
+// discovered.
+var gitserverAddrsReady int32
+
+// gitserverAddrs returns the addresses of every gitserver, or nil if they
+// have not been discovered yet.
+func gitserverAddrs(ctx context.Context) []string {
+	if atomic.LoadInt32(&gitserverAddrsReady) == 0 {
+		return nil
+	}
+	return gitserverclient.DefaultClient.Addrs(ctx)
+}
+
 func main() {
 	env.Lock()
 	env.HandleHelpFlag()
@@ -40,9 +66,17 @@ func main() {
 		log.Fatalf("failed to create SRC_REPOS_DIR: %s", err)
 	}
 
+	desiredPercentFree, err := strconv.Atoi(wantPctFree)
+	if err != nil || desiredPercentFree < 0 || desiredPercentFree > 100 {
+		log.Fatalf("git-server: SRC_REPOS_DESIRED_PERCENT_FREE must be a percentage between 0 and 100, got %q", wantPctFree)
+	}
+
 	gitserver := server.Server{
 		ReposDir:                reposDir,
 		DeleteStaleRepositories: runRepoCleanup,
+		DesiredPercentFree:      desiredPercentFree,
+		Addrs:                   gitserverAddrs,
+		Addr:                    gitserverAddr,
 	}
 	gitserver.RegisterMetrics()
 
@@ -66,6 +100,26 @@ func main() {
 		}
 	}()
 
+	go func() {
+		for {
+			if err := gitserver.FreeUpSpace(); err != nil {
+				log15.Error("git-server: failed to free up disk space", "error", err)
+			}
+			time.Sleep(freeUpSpaceInterval)
+		}
+	}()
+
+	go func() {
+		// Discovering the gitservers may block until the frontend is
+		// available, so we only take part in rebalancing once it is done.
+		gitserverclient.DefaultClient.Addrs(context.Background())
+		atomic.StoreInt32(&gitserverAddrsReady, 1)
+		for {
+			gitserver.Rebalance()
+			time.Sleep(rebalanceInterval)
+		}
+	}()
+
 	port := "3178"
 	host := ""
 	if env.InsecureDev {
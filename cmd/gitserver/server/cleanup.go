@@ -243,15 +243,27 @@ func (s *Server) removeRepoDirectory(dir string) error {
 
 	// Everything after this point is just cleanup, so any error that occurs
 	// should not be returned, just logged.
+	s.removeEmptyParents(dir)
 
-	// Cleanup empty parent directories. We just attempt to remove and if we
-	// have a failure we assume it's due to the directory having other
-	// children. If we checked first we could race with someone else adding a
-	// new clone.
+	// Delete the atomically renamed dir. We do this last since if it fails we
+	// will rely on a janitor job to clean up for us.
+	if err := os.RemoveAll(filepath.Join(tmp, "repo")); err != nil {
+		log15.Warn("failed to cleanup after removing dir", "dir", dir, "error", err)
+	}
+
+	return nil
+}
+
+// removeEmptyParents removes the empty parent directories of dir, up to
+// s.ReposDir. Errors are only logged.
+func (s *Server) removeEmptyParents(dir string) {
+	// We just attempt to remove and if we have a failure we assume it's due
+	// to the directory having other children. If we checked first we could
+	// race with someone else adding a new clone.
 	rootInfo, err := os.Stat(s.ReposDir)
 	if err != nil {
 		log15.Warn("Failed to stat ReposDir", "error", err)
-		return nil
+		return
 	}
 	current := dir
 	for {
@@ -269,7 +281,7 @@ func (s *Server) removeRepoDirectory(dir string) error {
 		}
 		if err != nil {
 			log15.Warn("failed to stat parent directory", "dir", current, "error", err)
-			return nil
+			return
 		}
 		if os.SameFile(rootInfo, info) {
 			// Stop, we are at the parent.
@@ -281,14 +293,6 @@ func (s *Server) removeRepoDirectory(dir string) error {
 			break
 		}
 	}
-
-	// Delete the atomically renamed dir. We do this last since if it fails we
-	// will rely on a janitor job to clean up for us.
-	if err := os.RemoveAll(filepath.Join(tmp, "repo")); err != nil {
-		log15.Warn("failed to cleanup after removing dir", "dir", dir, "error", err)
-	}
-
-	return nil
 }
 
 // cleanTmpFiles tries to remove tmp_pack_* files from .git/objects/pack.
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// gitCloneOptionsEntry is an entry of the gitCloneOptions site configuration
+// with its compiled pattern.
+type gitCloneOptionsEntry struct {
+	pattern *regexp.Regexp
+	opt     *schema.GitCloneOptions
+}
+
+var (
+	// compiledGitCloneOptions is the gitCloneOptions site configuration,
+	// compiled whenever the configuration changes.
+	compiledGitCloneOptions     atomic.Value
+	compiledGitCloneOptionsOnce sync.Once
+)
+
+// getGitCloneOptions returns the compiled gitCloneOptions site
+// configuration. It is a variable so that tests can mock it.
+var getGitCloneOptions = func() []gitCloneOptionsEntry {
+	compiledGitCloneOptionsOnce.Do(func() {
+		conf.Watch(func() {
+			compiledGitCloneOptions.Store(compileGitCloneOptions(conf.Get().GitCloneOptions))
+		})
+	})
+	return compiledGitCloneOptions.Load().([]gitCloneOptionsEntry)
+}
+
+// compileGitCloneOptions compiles the patterns of opts, skipping entries with
+// an invalid pattern.
+func compileGitCloneOptions(opts []*schema.GitCloneOptions) []gitCloneOptionsEntry {
+	entries := make([]gitCloneOptionsEntry, 0, len(opts))
+	for _, opt := range opts {
+		re, err := regexp.Compile(opt.Pattern)
+		if err != nil {
+			log15.Error("Ignoring gitCloneOptions entry with invalid pattern.", "pattern", opt.Pattern, "error", err)
+			continue
+		}
+		entries = append(entries, gitCloneOptionsEntry{pattern: re, opt: opt})
+	}
+	return entries
+}
+
+// gitCloneOptions returns the partial and shallow clone options of repo, or
+// nil if it is cloned in full.
+func gitCloneOptions(repo api.RepoName) *schema.GitCloneOptions {
+	for _, e := range getGitCloneOptions() {
+		if e.pattern.MatchString(string(repo)) {
+			return e.opt
+		}
+	}
+	return nil
+}
+
+// cloneArgs returns the arguments for `git clone` which make a partial or
+// shallow clone according to opt.
+func cloneArgs(opt *schema.GitCloneOptions) []string {
+	if opt == nil {
+		return nil
+	}
+	var args []string
+	if opt.Filter != "" {
+		args = append(args, "--filter="+opt.Filter)
+	}
+	if opt.Depth > 0 {
+		// --depth implies --single-branch, but we want all branches.
+		args = append(args, "--depth="+strconv.Itoa(opt.Depth), "--no-single-branch")
+	}
+	return args
+}
+
+// fetchArgs returns the remote and the extra arguments for fetching updates
+// into the repository in dir, which was cloned from url.
+//
+// Git only allows to fetch into a partial clone from its promisor remote
+// (origin), and then applies the filter of the clone. The history of a shallow
+// clone is kept at the configured depth, so that fetches do not pull in the
+// full history of new branches.
+func fetchArgs(dir, url string, opt *schema.GitCloneOptions) (remote string, args []string) {
+	remote = url
+	if isPartialClone(dir) {
+		remote = "origin"
+	}
+	if isShallowClone(dir) && opt != nil && opt.Depth > 0 {
+		args = append(args, "--depth="+strconv.Itoa(opt.Depth))
+	}
+	return remote, args
+}
+
+// fetchCommit fetches the commit into the shallow clone in dir, for commits
+// which are older than the history of its branches and tags. Code hosts
+// generally allow fetching any commit which is reachable from a ref.
+func (s *Server) fetchCommit(ctx context.Context, repo api.RepoName, url string, commit string, dir string) error {
+	remote, args := fetchArgs(dir, url, gitCloneOptions(repo))
+	if remote == "" {
+		remote = "origin"
+	}
+	if len(args) == 0 {
+		args = []string{"--depth=1"}
+	}
+	cmd := exec.CommandContext(ctx, "git", append(append([]string{"fetch"}, args...), remote, commit)...)
+	cmd.Dir = dir
+	if output, err := s.runWithRemoteOpts(ctx, cmd, nil); err != nil {
+		return errors.Wrapf(err, "failed to fetch commit %s. Output: %s", commit, newURLRedactor(url).redact(string(output)))
+	}
+	return nil
+}
+
+// gitDir returns the GIT_DIR of the repository in dir.
+func gitDir(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); os.IsNotExist(err) {
+		return filepath.Join(dir, ".git")
+	}
+	return dir
+}
+
+// isShallowClone reports whether the repository in dir is a shallow clone,
+// whose history is truncated.
+func isShallowClone(dir string) bool {
+	_, err := os.Stat(filepath.Join(gitDir(dir), "shallow"))
+	return err == nil
+}
+
+// partialCloneConfig matches the settings that Git adds to the config of a
+// partial clone: extensions.partialClone in older versions of Git, and
+// remote.<name>.promisor in newer ones. Config keys are case-insensitive.
+var partialCloneConfig = regexp.MustCompile(`(?im)^\s*(partialclone\s*=|promisor\s*=\s*true\s*$)`)
+
+// isPartialClone reports whether the repository in dir is a partial clone,
+// whose missing objects are fetched from its remote on demand. We read the
+// config file instead of running `git config`, because this is called for
+// every exec request.
+func isPartialClone(dir string) bool {
+	config, err := ioutil.ReadFile(filepath.Join(gitDir(dir), "config"))
+	if err != nil {
+		return false
+	}
+	return partialCloneConfig.Match(config)
+}
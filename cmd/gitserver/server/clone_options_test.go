@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGitCloneOptions(t *testing.T) {
+	orig := getGitCloneOptions
+	defer func() { getGitCloneOptions = orig }()
+	opts := compileGitCloneOptions([]*schema.GitCloneOptions{
+		{Pattern: "("}, // invalid, ignored
+		{Pattern: `^github\.com/foo/monorepo$`, Filter: "blob:none"},
+		{Pattern: `^github\.com/foo/`, Depth: 10},
+	})
+	getGitCloneOptions = func() []gitCloneOptionsEntry { return opts }
+
+	tests := map[api.RepoName][]string{
+		"github.com/foo/monorepo": {"--filter=blob:none"},
+		"github.com/foo/bar":      {"--depth=10", "--no-single-branch"},
+		"github.com/baz/qux":      nil,
+	}
+	for repo, want := range tests {
+		if got := cloneArgs(gitCloneOptions(repo)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got clone args %q, want %q", repo, got, want)
+		}
+	}
+}
+
+func TestPartialShallowClone(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitserver-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@a.com", "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@a.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %s (output: %s)", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	src := filepath.Join(root, "src")
+	run(root, "init", src)
+	run(src, "config", "uploadpack.allowFilter", "true")
+	for _, name := range []string{"a", "b", "c"} {
+		if err := ioutil.WriteFile(filepath.Join(src, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+		run(src, "add", name)
+		run(src, "commit", "-m", name)
+	}
+	first := run(src, "rev-list", "--max-parents=0", "HEAD")
+
+	full := filepath.Join(root, "full")
+	run(root, "clone", "--mirror", "file://"+src, filepath.Join(full, ".git"))
+	if isPartialClone(full) || isShallowClone(full) {
+		t.Error("expected full clone not to be partial or shallow")
+	}
+
+	opt := &schema.GitCloneOptions{Pattern: ".*", Filter: "blob:none", Depth: 1}
+	orig := getGitCloneOptions
+	defer func() { getGitCloneOptions = orig }()
+	opts := compileGitCloneOptions([]*schema.GitCloneOptions{opt})
+	getGitCloneOptions = func() []gitCloneOptionsEntry { return opts }
+
+	dir := filepath.Join(root, "partial")
+	args := append([]string{"clone", "--mirror"}, cloneArgs(opt)...)
+	run(root, append(args, "file://"+src, filepath.Join(dir, ".git"))...)
+	if !isPartialClone(dir) {
+		t.Error("expected partial clone")
+	}
+	if !isShallowClone(dir) {
+		t.Error("expected shallow clone")
+	}
+	if remote, args := fetchArgs(dir, "file://"+src, opt); remote != "origin" || !reflect.DeepEqual(args, []string{"--depth=1"}) {
+		t.Errorf("got fetch remote %q and args %q, want origin and --depth=1", remote, args)
+	}
+
+	// Missing blobs are fetched on demand.
+	if got := run(dir, "show", "HEAD:a"); got != "a" {
+		t.Errorf("got contents %q, want %q", got, "a")
+	}
+
+	// Commits older than the shallow history are fetched individually.
+	s := &Server{ReposDir: root}
+	if err := s.fetchCommit(context.Background(), "partial", "file://"+src, first, dir); err != nil {
+		t.Fatal(err)
+	}
+	run(dir, "rev-parse", first+"^{commit}")
+}
@@ -0,0 +1,261 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// lastAccessFile is the name of the file in a GIT_DIR whose mtime is the last
+// time the repository was used by an exec request (which includes archives).
+const lastAccessFile = "sg_lastaccess"
+
+// accessRecordInterval is how often we update the last access time of a
+// repository which is used continuously.
+const accessRecordInterval = time.Minute
+
+// evictedFileName is the name of the file in ReposDir which records the
+// repositories evicted to free up disk space, so that they are not cloned
+// again by background updates after a restart.
+const evictedFileName = ".evicted.json"
+
+var reposEvicted = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "repos_evicted",
+	Help:      "number of repos removed to free up disk space",
+})
+
+func init() {
+	prometheus.MustRegister(reposEvicted)
+}
+
+// recordAccess records that the repository in dir is being used, for
+// choosing which repositories to evict when the disk is (almost) full.
+func (s *Server) recordAccess(repo api.RepoName, dir string) {
+	now := time.Now()
+	s.accessMu.Lock()
+	if s.accessed == nil {
+		s.accessed = make(map[api.RepoName]time.Time)
+	}
+	if now.Sub(s.accessed[repo]) < accessRecordInterval {
+		s.accessMu.Unlock()
+		return
+	}
+	s.accessed[repo] = now
+	s.accessMu.Unlock()
+
+	path := filepath.Join(gitDir(dir), lastAccessFile)
+	err := os.Chtimes(path, now, now)
+	if os.IsNotExist(err) {
+		err = ioutil.WriteFile(path, nil, 0600)
+	}
+	if err != nil {
+		log15.Warn("failed to record repository access", "repo", repo, "error", err)
+	}
+}
+
+// lastAccess returns the last time the repository with the given GIT_DIR was
+// used. If it has not been used since it was cloned (or since we started to
+// record accesses), it is the last time it was updated.
+func lastAccess(gitDir string) (time.Time, error) {
+	fi, err := os.Stat(filepath.Join(gitDir, lastAccessFile))
+	if os.IsNotExist(err) {
+		fi, err = os.Stat(filepath.Join(gitDir, "HEAD"))
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// diskFree returns the free and total bytes of the file system of dir. It is
+// a variable so that tests can mock it.
+var diskFree = func(dir string) (free, total uint64, err error) {
+	var fs syscall.Statfs_t
+	if err := syscall.Statfs(dir, &fs); err != nil {
+		return 0, 0, err
+	}
+	return fs.Bavail * uint64(fs.Bsize), fs.Blocks * uint64(fs.Bsize), nil
+}
+
+// FreeUpSpace evicts the least recently used repositories until at least
+// s.DesiredPercentFree percent of the disk of s.ReposDir is free. Evicted
+// repositories are cloned again when they are used, but not by background
+// updates (see handleRepoUpdate).
+func (s *Server) FreeUpSpace() error {
+	if s.DesiredPercentFree <= 0 {
+		return nil
+	}
+
+	free, total, err := diskFree(s.ReposDir)
+	if err != nil {
+		return errors.Wrap(err, "failed to determine free disk space")
+	}
+	desired := total / 100 * uint64(s.DesiredPercentFree)
+	if free >= desired {
+		return nil
+	}
+	needed := int64(desired - free)
+	log15.Warn("disk space is low, evicting least recently used repos", "free", free, "desired", desired)
+
+	type repoAccess struct {
+		gitDir     string
+		lastAccess time.Time
+	}
+	var repos []repoAccess
+	err = filepath.Walk(s.ReposDir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if s.ignorePath(path) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !fi.IsDir() || fi.Name() != ".git" {
+			return nil
+		}
+		t, err := lastAccess(path)
+		if err != nil {
+			log15.Warn("failed to determine last access of repo", "repo", path, "error", err)
+			return filepath.SkipDir
+		}
+		repos = append(repos, repoAccess{gitDir: path, lastAccess: t})
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].lastAccess.Before(repos[j].lastAccess) })
+
+	var evicted []api.RepoName
+	for _, r := range repos {
+		if needed <= 0 {
+			break
+		}
+		dir := filepath.Dir(r.gitDir)
+		repo := protocol.NormalizeRepo(api.RepoName(strings.TrimPrefix(dir, s.ReposDir+"/")))
+
+		// Don't evict repositories which are being cloned, updated or
+		// transferred, or which commands are running in.
+		lock, ok := s.locker.TryAcquire(dir, "evicting")
+		if !ok {
+			continue
+		}
+		unlock, ok := s.tryLockRepo(repo)
+		if !ok {
+			lock.Release()
+			continue
+		}
+		size := dirSize(r.gitDir)
+		err := s.removeRepoDirectory(r.gitDir)
+		unlock()
+		lock.Release()
+		if err != nil {
+			log15.Error("failed to evict repo", "repo", repo, "error", err)
+			continue
+		}
+		log15.Info("evicted repo", "repo", repo, "lastAccess", r.lastAccess, "size", size)
+		reposEvicted.Inc()
+		evicted = append(evicted, repo)
+		needed -= size
+	}
+	if needed > 0 {
+		log15.Error("failed to free up enough disk space by evicting repos", "missing", needed)
+	}
+	return s.setEvicted(evicted, true)
+}
+
+// dirSize returns the total size in bytes of the files in dir.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err == nil && !fi.IsDir() {
+			size += fi.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// loadEvicted reads the set of evicted repositories, if it has not been read
+// yet. s.evictedMu must be held.
+func (s *Server) loadEvicted() {
+	if s.evicted != nil {
+		return
+	}
+	s.evicted = make(map[api.RepoName]time.Time)
+	b, err := ioutil.ReadFile(filepath.Join(s.ReposDir, evictedFileName))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log15.Warn("failed to read evicted repos", "error", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(b, &s.evicted); err != nil {
+		log15.Warn("failed to read evicted repos", "error", err)
+	}
+}
+
+// isEvicted reports whether repo was evicted to free up disk space, and has
+// not been cloned again since.
+func (s *Server) isEvicted(repo api.RepoName) bool {
+	s.evictedMu.Lock()
+	defer s.evictedMu.Unlock()
+	s.loadEvicted()
+	_, ok := s.evicted[protocol.NormalizeRepo(repo)]
+	return ok
+}
+
+// setEvicted records whether the repos are evicted, and persists the set of
+// evicted repositories.
+func (s *Server) setEvicted(repos []api.RepoName, evicted bool) error {
+	s.evictedMu.Lock()
+	defer s.evictedMu.Unlock()
+	s.loadEvicted()
+
+	changed := false
+	for _, repo := range repos {
+		repo = protocol.NormalizeRepo(repo)
+		if _, ok := s.evicted[repo]; ok == evicted {
+			continue
+		}
+		if evicted {
+			s.evicted[repo] = time.Now()
+		} else {
+			delete(s.evicted, repo)
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	b, err := json.Marshal(s.evicted)
+	if err != nil {
+		return err
+	}
+	tmp, err := s.tempDir("evicted-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	tmpFile := filepath.Join(tmp, evictedFileName)
+	if err := ioutil.WriteFile(tmpFile, b, 0600); err != nil {
+		return errors.Wrap(err, "failed to write evicted repos")
+	}
+	return os.Rename(tmpFile, filepath.Join(s.ReposDir, evictedFileName))
+}
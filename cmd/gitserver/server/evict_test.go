@@ -0,0 +1,98 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestFreeUpSpace(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitserver-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, repo := range []string{testRepoA, testRepoB, testRepoC} {
+		if err := exec.Command("git", "--bare", "init", filepath.Join(root, repo, ".git")).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &Server{ReposDir: root, DesiredPercentFree: 10}
+	s.Handler() // Handler as a side-effect sets up Server
+
+	// B was used least recently, C was never used (so its HEAD counts).
+	now := time.Now()
+	s.recordAccess(testRepoA, filepath.Join(root, testRepoA))
+	s.recordAccess(testRepoB, filepath.Join(root, testRepoB))
+	if err := os.Chtimes(filepath.Join(root, testRepoB, ".git", lastAccessFile), now.Add(-2*time.Hour), now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(root, testRepoC, ".git", "HEAD"), now.Add(-time.Hour), now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	// We are 1 byte short of the desired free space.
+	orig := diskFree
+	defer func() { diskFree = orig }()
+	diskFree = func(string) (uint64, uint64, error) { return 99, 1000, nil }
+
+	if err := s.FreeUpSpace(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, testRepoB)); !os.IsNotExist(err) {
+		t.Error("expected least recently used repoB to be evicted")
+	}
+	for _, repo := range []string{testRepoA, testRepoC} {
+		if !repoCloned(filepath.Join(root, repo)) {
+			t.Errorf("expected %s not to be evicted", repo)
+		}
+	}
+
+	// The evicted repos survive a restart.
+	s = &Server{ReposDir: root}
+	if !s.isEvicted(testRepoB) {
+		t.Error("expected repoB to be recorded as evicted")
+	}
+	if s.isEvicted(testRepoA) {
+		t.Error("expected repoA not to be recorded as evicted")
+	}
+	if err := s.setEvicted([]api.RepoName{testRepoB}, false); err != nil {
+		t.Fatal(err)
+	}
+	if s := (&Server{ReposDir: root}); s.isEvicted(testRepoB) {
+		t.Error("expected repoB not to be recorded as evicted after it is cloned again")
+	}
+
+	// Nothing is evicted when there is enough free space.
+	diskFree = func(string) (uint64, uint64, error) { return 100, 1000, nil }
+	if err := s.FreeUpSpace(); err != nil {
+		t.Fatal(err)
+	}
+	if !repoCloned(filepath.Join(root, testRepoC)) {
+		t.Error("expected repoC not to be evicted")
+	}
+
+	// Repos which commands are running in are skipped.
+	s = &Server{ReposDir: root, DesiredPercentFree: 10}
+	s.Handler()
+	diskFree = func(string) (uint64, uint64, error) { return 99, 1000, nil }
+	unlock := s.rlockRepo(testRepoC)
+	err = s.FreeUpSpace()
+	unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !repoCloned(filepath.Join(root, testRepoC)) {
+		t.Error("expected repoC which is in use not to be evicted")
+	}
+	if repoCloned(filepath.Join(root, testRepoA)) {
+		t.Error("expected repoA to be evicted instead of repoC")
+	}
+}
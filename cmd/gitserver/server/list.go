@@ -47,54 +47,8 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 		}
 
 	case query("cloned"):
-		err := filepath.Walk(s.ReposDir, func(path string, info os.FileInfo, err error) error {
-			if ctx.Err() != nil {
-				return ctx.Err()
-			}
-
-			if s.ignorePath(path) {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
-			}
-
-			if err != nil {
-				return nil
-			}
-
-			// We only care about directories
-			if !info.IsDir() {
-				return nil
-			}
-
-			// New style git directory layout
-			if filepath.Base(path) == ".git" {
-				name, err := filepath.Rel(s.ReposDir, filepath.Dir(path))
-				if err != nil {
-					return err
-				}
-				repos = append(repos, name)
-				return filepath.SkipDir
-			}
-
-			// For old-style directory layouts we need to do an extra extra
-			// stat to check if this is a repo.
-			if _, err := os.Stat(filepath.Join(path, "HEAD")); os.IsNotExist(err) {
-				// HEAD doesn't exist, so keep recursing
-				return nil
-			} else if err != nil {
-				return err
-			}
-
-			// path is an old style git repo since it contains HEAD
-			name, err := filepath.Rel(s.ReposDir, path)
-			if err != nil {
-				return err
-			}
-			repos = append(repos, name)
-			return filepath.SkipDir
-		})
+		var err error
+		repos, err = s.listCloned(ctx)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -157,3 +111,57 @@ func listGitoliteRepos(ctx context.Context, gconf *schema.GitoliteConnection) ([
 
 	return repos, nil
 }
+
+// listCloned returns the names of the repositories cloned on this gitserver.
+func (s *Server) listCloned(ctx context.Context) ([]string, error) {
+	repos := make([]string, 0)
+	err := filepath.Walk(s.ReposDir, func(path string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if s.ignorePath(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if err != nil {
+			return nil
+		}
+
+		// We only care about directories
+		if !info.IsDir() {
+			return nil
+		}
+
+		// New style git directory layout
+		if filepath.Base(path) == ".git" {
+			name, err := filepath.Rel(s.ReposDir, filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			repos = append(repos, name)
+			return filepath.SkipDir
+		}
+
+		// For old-style directory layouts we need to do an extra extra
+		// stat to check if this is a repo.
+		if _, err := os.Stat(filepath.Join(path, "HEAD")); os.IsNotExist(err) {
+			// HEAD doesn't exist, so keep recursing
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// path is an old style git repo since it contains HEAD
+		name, err := filepath.Rel(s.ReposDir, path)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, name)
+		return filepath.SkipDir
+	})
+	return repos, err
+}
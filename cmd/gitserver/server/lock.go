@@ -3,6 +3,7 @@ package server
 import (
 	"path/filepath"
 	"sync"
+	"time"
 )
 
 // RepositoryLocker provides locks for doing operations to a repository
@@ -109,3 +110,100 @@ func (l *RepositoryLock) Release() {
 	}
 	l.locker.mu.Unlock()
 }
+
+// repoLockMaxWriterWait is how long a writer waits for a repoRWLock before new
+// readers have to wait for it.
+const repoLockMaxWriterWait = time.Minute
+
+// repoRWLock is the lock of the clone of a repository (see Server.lockRepo).
+// It is like sync.RWMutex, except that it also has TryLock and that a writer
+// waiting for the lock does not block new readers until it has waited for
+// maxWriterWait. Fetches hold the lock for reading for as long as they talk
+// to the code host, and a writer (such as a delete) waiting for a slow fetch
+// must not stall every command in the repository until the fetch is done, but
+// on a busy repository it must not wait forever either.
+type repoRWLock struct {
+	maxWriterWait time.Duration
+
+	mu   sync.Mutex
+	cond sync.Cond // signaled when the lock is released
+
+	readers  int  // the number of readers holding the lock
+	writer   bool // whether a writer holds the lock
+	starving int  // the number of writers which waited longer than maxWriterWait
+
+	refs int // the number of holders and waiters, protected by Server.repoLocksMu
+}
+
+func newRepoRWLock(maxWriterWait time.Duration) *repoRWLock {
+	l := &repoRWLock{maxWriterWait: maxWriterWait}
+	l.cond.L = &l.mu
+	return l
+}
+
+// RLock locks l for reading. It blocks while a writer holds the lock or has
+// waited for it for longer than maxWriterWait.
+func (l *repoRWLock) RLock() {
+	l.mu.Lock()
+	for l.writer || l.starving > 0 {
+		l.cond.Wait()
+	}
+	l.readers++
+	l.mu.Unlock()
+}
+
+// RUnlock undoes a single RLock call.
+func (l *repoRWLock) RUnlock() {
+	l.mu.Lock()
+	l.readers--
+	if l.readers == 0 {
+		l.cond.Broadcast()
+	}
+	l.mu.Unlock()
+}
+
+// Lock locks l for writing. It blocks until no reader or writer holds the
+// lock. Readers may keep acquiring the lock while it waits, until it has
+// waited for maxWriterWait.
+func (l *repoRWLock) Lock() {
+	l.mu.Lock()
+	acquired, starving := false, false
+	t := time.AfterFunc(l.maxWriterWait, func() {
+		l.mu.Lock()
+		if !acquired {
+			starving = true
+			l.starving++
+		}
+		l.mu.Unlock()
+	})
+	for l.writer || l.readers > 0 {
+		l.cond.Wait()
+	}
+	acquired = true
+	if starving {
+		l.starving--
+	}
+	l.writer = true
+	l.mu.Unlock()
+	t.Stop()
+}
+
+// TryLock locks l for writing if no reader or writer holds the lock, and
+// reports whether it did.
+func (l *repoRWLock) TryLock() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.writer || l.readers > 0 {
+		return false
+	}
+	l.writer = true
+	return true
+}
+
+// Unlock unlocks l for writing.
+func (l *repoRWLock) Unlock() {
+	l.mu.Lock()
+	l.writer = false
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
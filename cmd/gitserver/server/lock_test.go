@@ -0,0 +1,117 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRepoRWLock(t *testing.T) {
+	l := newRepoRWLock(time.Hour)
+
+	// A long running reader, such as a fetch.
+	l.RLock()
+	if l.TryLock() {
+		t.Fatal("expected TryLock to fail while a reader holds the lock")
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		l.Lock()
+		close(locked)
+	}()
+
+	// A writer waiting for the lock must not block new readers.
+	rlocked := make(chan struct{})
+	go func() {
+		l.RLock()
+		l.RUnlock()
+		close(rlocked)
+	}()
+	select {
+	case <-rlocked:
+	case <-locked:
+		t.Fatal("writer acquired the lock while a reader holds it")
+	case <-time.After(5 * time.Second):
+		t.Fatal("reader blocked by a waiting writer")
+	}
+
+	l.RUnlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer did not acquire the lock after the readers released it")
+	}
+	if l.TryLock() {
+		t.Fatal("expected TryLock to fail while a writer holds the lock")
+	}
+	l.Unlock()
+	if !l.TryLock() {
+		t.Fatal("expected TryLock to succeed when the lock is free")
+	}
+	l.Unlock()
+}
+
+func TestRepoRWLock_writerStarvation(t *testing.T) {
+	l := newRepoRWLock(100 * time.Millisecond)
+
+	// Readers keep holding the lock.
+	l.RLock()
+	locked := make(chan struct{})
+	go func() {
+		l.Lock()
+		close(locked)
+	}()
+
+	// Once the writer has waited for maxWriterWait, new readers wait for it.
+	time.Sleep(500 * time.Millisecond)
+	rlocked := make(chan struct{})
+	go func() {
+		l.RLock()
+		close(rlocked)
+	}()
+	select {
+	case <-rlocked:
+		t.Fatal("reader acquired the lock while a writer waited for longer than maxWriterWait")
+	case <-locked:
+		t.Fatal("writer acquired the lock while a reader holds it")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	l.RUnlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer did not acquire the lock after the readers released it")
+	}
+	l.Unlock()
+	select {
+	case <-rlocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reader did not acquire the lock after the writer released it")
+	}
+	l.RUnlock()
+}
+
+func TestServer_repoLocks(t *testing.T) {
+	s := &Server{}
+	unlock := s.rlockRepo("github.com/foo/bar")
+	unlockWrite, ok := s.tryLockRepo("github.com/Foo/Bar")
+	if ok {
+		t.Fatal("expected tryLockRepo to fail while the repository is locked for reading")
+	}
+	if unlockWrite != nil {
+		t.Fatal("expected no unlock func when tryLockRepo fails")
+	}
+	if len(s.repoLocks) != 1 {
+		t.Fatalf("got %d repo locks, want 1", len(s.repoLocks))
+	}
+	unlock()
+	if len(s.repoLocks) != 0 {
+		t.Errorf("got %d repo locks after unlocking, want 0", len(s.repoLocks))
+	}
+
+	s.lockRepo("github.com/foo/bar")()
+	if len(s.repoLocks) != 0 {
+		t.Errorf("got %d repo locks after unlocking, want 0", len(s.repoLocks))
+	}
+}
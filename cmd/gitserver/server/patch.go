@@ -31,6 +31,7 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 		repoGitDir = filepath.Join(s.ReposDir, repo)
 		if _, err := os.Stat(repoGitDir); os.IsNotExist(err) {
 			http.Error(w, "gitserver: repo does not exist - "+err.Error(), http.StatusInternalServerError)
+			return
 		}
 	}
 
@@ -172,7 +173,13 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	cmd = exec.CommandContext(ctx, "git", "update-ref", req.TargetRef, cmtHash)
+	updateRefArgs := []string{"update-ref", req.TargetRef, cmtHash}
+	if req.NoOverwrite {
+		// The zero OID as the old value makes update-ref fail if the ref
+		// exists.
+		updateRefArgs = append(updateRefArgs, "0000000000000000000000000000000000000000")
+	}
+	cmd = exec.CommandContext(ctx, "git", updateRefArgs...)
 	cmd.Dir = repoGitDir
 
 	if out, err = run(cmd); err != nil {
@@ -182,7 +189,10 @@ func (s *Server) handleCreateCommitFromPatch(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	sendResp(w, "refs/"+ref)
+	if !strings.HasPrefix(ref, "refs/") {
+		ref = "refs/" + ref
+	}
+	sendResp(w, ref)
 }
 
 func sendResp(w http.ResponseWriter, commitID string) {
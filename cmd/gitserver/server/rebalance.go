@@ -0,0 +1,492 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"golang.org/x/net/context/ctxhttp"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// This file implements rebalancing repositories between gitservers. Each
+// repository is owned by one gitserver (see conf.GitSharding). When the
+// list of gitservers changes, the owner of some repositories changes. Rather
+// than recloning those repositories from the code host, the gitserver which
+// has a repository transfers it to the new owner as a git bundle.
+//
+// Until a repository has been transferred, the new owner redirects requests
+// for it to the gitserver which has it (see protocol.LocationHeader).
+
+// remoteURLHeader is the header on /receive-repo requests with the
+// repository's Git remote URL.
+const remoteURLHeader = "X-Gitserver-Remote-Url"
+
+// holderCacheTTL is how long we remember which gitserver has a repository
+// that we own but do not have.
+const holderCacheTTL = 30 * time.Second
+
+// findHolderTimeout is how long we wait for other gitservers to tell us if
+// they have a repository.
+const findHolderTimeout = 2 * time.Second
+
+type holder struct {
+	addr string // "" if no other gitserver has the repository
+	at   time.Time
+}
+
+// selfAddr returns the address of this gitserver in addrs, or "" if it is
+// unknown.
+func (s *Server) selfAddr(addrs []string) string {
+	if s.Addr != "" {
+		return s.Addr
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return ""
+	}
+	for _, addr := range addrs {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		// In Kubernetes the address of the pod gitserver-0 is
+		// gitserver-0.gitserver:3178.
+		if host == hostname || strings.HasPrefix(host, hostname+".") {
+			return addr
+		}
+	}
+	return ""
+}
+
+// rebalanceAddrs returns the addresses of every gitserver and the address of
+// this one. It returns ok == false if this gitserver does not take part in
+// rebalancing.
+func (s *Server) rebalanceAddrs(ctx context.Context) (addrs []string, self string, ok bool) {
+	if s.Addrs == nil {
+		return nil, "", false
+	}
+	addrs = s.Addrs(ctx)
+	self = s.selfAddr(addrs)
+	return addrs, self, self != "" && len(addrs) > 0
+}
+
+// replicasOf returns the addresses in addrs of the gitservers which keep a
+// copy of repo. The first is its owner.
+func replicasOf(repo api.RepoName, addrs []string) []string {
+	return conf.GitSharding().AddrsForRepo(repo, addrs, conf.GitReplicationFactor())
+}
+
+func containsAddr(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// handleMisdirected responds with http.StatusMisdirectedRequest if this
+// gitserver does not have repo, and another gitserver should handle requests
+// for it. It returns true if it responded.
+//
+// If we are one of the replicas of repo but another gitserver still has it,
+// we tell the client to send requests for repo there until it has been
+// transferred to us. If we are not a replica, we tell the client to send
+// requests to the owner.
+func (s *Server) handleMisdirected(ctx context.Context, w http.ResponseWriter, repo api.RepoName, dir string) bool {
+	if repoCloned(dir) {
+		return false
+	}
+	addrs, self, ok := s.rebalanceAddrs(ctx)
+	if !ok {
+		return false
+	}
+
+	var location string
+	if containsAddr(replicasOf(repo, addrs), self) {
+		location = s.findHolder(ctx, repo, addrs, self)
+		if location == "" {
+			// No one has the repository, so we clone it as usual.
+			return false
+		}
+	}
+
+	w.Header().Set(protocol.LocationHeader, location)
+	http.Error(w, "repository is not on this gitserver", http.StatusMisdirectedRequest)
+	return true
+}
+
+// findHolder returns the address of the gitserver in addrs (other than self)
+// which has repo, or "" if there is none.
+func (s *Server) findHolder(ctx context.Context, repo api.RepoName, addrs []string, self string) string {
+	s.holdersMu.Lock()
+	h, ok := s.holders[repo]
+	s.holdersMu.Unlock()
+	if ok && time.Since(h.at) < holderCacheTTL {
+		return h.addr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, findHolderTimeout)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		found = make(chan string, len(addrs))
+	)
+	for _, addr := range addrs {
+		if addr == self {
+			continue
+		}
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			cloned, err := isRepoClonedOn(ctx, addr, repo)
+			if err != nil {
+				log15.Warn("failed to check if repository is on gitserver", "repo", repo, "gitserver", addr, "error", err)
+				return
+			}
+			if cloned {
+				found <- addr
+			}
+		}(addr)
+	}
+	wg.Wait()
+	close(found)
+
+	h = holder{addr: <-found, at: time.Now()}
+	s.holdersMu.Lock()
+	if s.holders == nil {
+		s.holders = map[api.RepoName]holder{}
+	}
+	s.holders[repo] = h
+	s.holdersMu.Unlock()
+	return h.addr
+}
+
+// isRepoClonedOn returns whether the gitserver at addr has repo.
+func isRepoClonedOn(ctx context.Context, addr string, repo api.RepoName) (bool, error) {
+	body, err := json.Marshal(&protocol.IsRepoClonedRequest{Repo: repo, Local: true})
+	if err != nil {
+		return false, err
+	}
+	resp, err := ctxhttp.Post(ctx, nil, "http://"+addr+"/is-repo-cloned", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// setTransferStatus records the progress of transferring repo. An empty
+// progress message marks the transfer as done.
+func (s *Server) setTransferStatus(repo api.RepoName, progress string) {
+	s.transfersMu.Lock()
+	defer s.transfersMu.Unlock()
+	if progress == "" {
+		delete(s.transfers, repo)
+		return
+	}
+	if s.transfers == nil {
+		s.transfers = map[api.RepoName]string{}
+	}
+	s.transfers[repo] = progress
+}
+
+// transferStatus returns the progress of transferring repo, and whether it
+// is being transferred.
+func (s *Server) transferStatus(repo api.RepoName) (progress string, inProgress bool) {
+	s.transfersMu.Lock()
+	defer s.transfersMu.Unlock()
+	progress, inProgress = s.transfers[repo]
+	return progress, inProgress
+}
+
+// Rebalance transfers the repositories on this gitserver which it is not a
+// replica of to their owner. It does nothing if this gitserver does
+// not take part in rebalancing (see Server.Addrs).
+func (s *Server) Rebalance() {
+	ctx, cancel := s.serverContext()
+	defer cancel()
+
+	addrs, self, ok := s.rebalanceAddrs(ctx)
+	if !ok {
+		return
+	}
+
+	names, err := s.listCloned(ctx)
+	if err != nil {
+		log15.Error("rebalance: failed to list repositories", "error", err)
+		return
+	}
+
+	type move struct {
+		repo  api.RepoName
+		owner string
+	}
+	var moves []move
+	for _, name := range names {
+		repo := protocol.NormalizeRepo(api.RepoName(name))
+		if replicas := replicasOf(repo, addrs); !containsAddr(replicas, self) {
+			moves = append(moves, move{repo: repo, owner: replicas[0]})
+		}
+	}
+	rebalanceReposPending.Set(float64(len(moves)))
+	if len(moves) == 0 {
+		return
+	}
+	log15.Info("rebalance: transferring repositories owned by other gitservers", "count", len(moves))
+
+	for i, m := range moves {
+		if ctx.Err() != nil {
+			return
+		}
+
+		start := time.Now()
+		err := s.transferRepo(ctx, m.repo, m.owner)
+		rebalanceTransferDuration.WithLabelValues("sent").Observe(time.Since(start).Seconds())
+		if err != nil {
+			rebalanceTransferErrors.WithLabelValues("sent").Inc()
+			log15.Error("rebalance: failed to transfer repository", "repo", m.repo, "to", m.owner, "error", err)
+		} else {
+			rebalanceReposTransferred.WithLabelValues("sent").Inc()
+			log15.Info("rebalance: transferred repository", "repo", m.repo, "to", m.owner, "duration", time.Since(start))
+		}
+		rebalanceReposPending.Set(float64(len(moves) - i - 1))
+	}
+}
+
+// transferRepo sends repo to the gitserver at dst as a git bundle, and then
+// removes it from this gitserver.
+func (s *Server) transferRepo(ctx context.Context, repo api.RepoName, dst string) error {
+	dir := filepath.Join(s.ReposDir, string(repo))
+
+	// Commands may keep running in the repository while we send it, so we
+	// only hold the lock for reading until we delete it.
+	unlock := s.rlockRepo(repo)
+	if !repoCloned(dir) {
+		unlock()
+		return nil
+	}
+	s.setTransferStatus(repo, "transferring to "+dst)
+	defer s.setTransferStatus(repo, "")
+	err := s.sendRepo(ctx, repo, dir, dst)
+	unlock()
+	if err != nil {
+		return err
+	}
+
+	defer s.lockRepo(repo)()
+	if !repoCloned(dir) {
+		// The repository was deleted or moved while we sent it.
+		return nil
+	}
+	if addrs, self, ok := s.rebalanceAddrs(ctx); !ok || containsAddr(replicasOf(repo, addrs), self) {
+		// The gitservers changed while we sent the repository, and we need
+		// it again.
+		return nil
+	}
+
+	// The owner has the repository now, so requests for it are redirected
+	// there (see handleMisdirected) and we can remove our copy.
+	return s.deleteRepo(repo)
+}
+
+// sendRepo sends the clone of repo in dir to the gitserver at dst. The caller
+// must hold the lock of the clone for reading (see rlockRepo).
+func (s *Server) sendRepo(ctx context.Context, repo api.RepoName, dir, dst string) error {
+	remoteURL, err := repoRemoteURL(ctx, dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to get remote URL")
+	}
+
+	if isShallowClone(dir) || isPartialClone(dir) {
+		// A shallow or partial clone can't be bundled, because it lacks
+		// objects, so the owner clones the repository from its remote
+		// instead (with the same gitCloneOptions).
+		return updateReplica(ctx, dst, repo, remoteURL)
+	}
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "bundle", "create", "-", "--all")
+	cmd.Dir = dir
+	cmd.Stdout = pw
+	cmd.Stderr = &stderr
+	go func() {
+		err := cmd.Run()
+		if err != nil {
+			err = errors.Wrapf(err, "git bundle failed. Output: %s", stderr.String())
+		}
+		pw.CloseWithError(err)
+	}()
+
+	req, err := http.NewRequest("POST", "http://"+dst+"/receive-repo?repo="+url.QueryEscape(string(repo)), pr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-git-bundle")
+	req.Header.Set(remoteURLHeader, remoteURL)
+	resp, err := ctxhttp.Do(ctx, nil, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return errors.Errorf("receive-repo: http status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// handleReceiveRepo receives a repository transferred from another
+// gitserver. The request body is a git bundle of the repository.
+func (s *Server) handleReceiveRepo(w http.ResponseWriter, r *http.Request) {
+	repo := protocol.NormalizeRepo(api.RepoName(r.URL.Query().Get("repo")))
+	if repo == "" {
+		http.Error(w, "repo missing", http.StatusBadRequest)
+		return
+	}
+	remoteURL := r.Header.Get(remoteURLHeader)
+	if remoteURL == "" {
+		http.Error(w, remoteURLHeader+" header missing", http.StatusBadRequest)
+		return
+	}
+
+	dir := filepath.Join(s.ReposDir, string(repo))
+	if repoCloned(dir) {
+		// We already have the repository (for example because we were asked
+		// for it before it was transferred), so the sender can remove its
+		// copy.
+		return
+	}
+
+	lock, ok := s.locker.TryAcquire(dir, "receiving repository from another gitserver")
+	if !ok {
+		http.Error(w, "repository is being cloned", http.StatusConflict)
+		return
+	}
+	defer lock.Release()
+
+	s.setTransferStatus(repo, "receiving from "+r.RemoteAddr)
+	defer s.setTransferStatus(repo, "")
+
+	start := time.Now()
+	err := s.receiveRepo(r.Context(), dir, remoteURL, r.Body)
+	rebalanceTransferDuration.WithLabelValues("received").Observe(time.Since(start).Seconds())
+	if err != nil {
+		rebalanceTransferErrors.WithLabelValues("received").Inc()
+		log15.Error("rebalance: failed to receive repository", "repo", repo, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rebalanceReposTransferred.WithLabelValues("received").Inc()
+	log15.Info("rebalance: received repository", "repo", repo, "duration", time.Since(start))
+}
+
+// receiveRepo clones the repository in bundle to dir, using remoteURL as
+// its remote. Like cloneRepo, it clones to a temporary directory first.
+func (s *Server) receiveRepo(ctx context.Context, dir, remoteURL string, bundle io.Reader) error {
+	ctx, cancel, err := s.acquireCloneLimiter(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	tmp, err := s.tempDir("receive-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	bundlePath := filepath.Join(tmp, "repo.bundle")
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, bundle)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to read bundle")
+	}
+
+	tmpPath := filepath.Join(tmp, ".git")
+	cmd := exec.CommandContext(ctx, "git", "clone", "--mirror", bundlePath, tmpPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "clone from bundle failed. Output: %s", string(output))
+	}
+	cmd = exec.CommandContext(ctx, "git", "remote", "set-url", "origin", remoteURL)
+	cmd.Dir = tmpPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "failed to set remote URL. Output: %s", newURLRedactor(remoteURL).redact(string(output)))
+	}
+
+	if err := setLastChanged(tmpPath); err != nil {
+		return errors.Wrapf(err, "failed to update last changed time")
+	}
+	if err := setGitAttributes(tmpPath); err != nil {
+		return err
+	}
+
+	dstPath := filepath.Join(dir, ".git")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, dstPath)
+}
+
+var (
+	rebalanceReposPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "src",
+		Subsystem: "gitserver",
+		Name:      "rebalance_repos_pending",
+		Help:      "number of repos on this gitserver waiting to be transferred to their owner.",
+	})
+	rebalanceReposTransferred = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "src",
+		Subsystem: "gitserver",
+		Name:      "rebalance_repos_transferred",
+		Help:      "number of repos transferred between gitservers.",
+	}, []string{"direction"})
+	rebalanceTransferErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "src",
+		Subsystem: "gitserver",
+		Name:      "rebalance_transfer_errors",
+		Help:      "number of failed repo transfers between gitservers.",
+	}, []string{"direction"})
+	rebalanceTransferDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "src",
+		Subsystem: "gitserver",
+		Name:      "rebalance_transfer_duration_seconds",
+		Help:      "time taken to transfer a repo between gitservers.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"direction"})
+)
+
+func init() {
+	prometheus.MustRegister(rebalanceReposPending)
+	prometheus.MustRegister(rebalanceReposTransferred)
+	prometheus.MustRegister(rebalanceTransferErrors)
+	prometheus.MustRegister(rebalanceTransferDuration)
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+func TestRebalance(t *testing.T) {
+	remote, cleanup := tmpDir(t)
+	defer cleanup()
+	runGit(t, remote, "init", ".")
+	runGit(t, remote, "commit", "--allow-empty", "-m", "hello")
+	wantCommit := runGit(t, remote, "rev-parse", "HEAD")
+
+	// Start two gitservers. old has every repository, new owns some of them.
+	servers, addrs, cleanupServers := newTestGitservers(t, 2)
+	defer cleanupServers()
+	oldS, oldAddr := servers[0], addrs[0]
+	newS, newAddr := servers[1], addrs[1]
+
+	// Find a repository owned by each gitserver.
+	var moved, stays api.RepoName
+	for i := 0; moved == "" || stays == ""; i++ {
+		repo := api.RepoName(fmt.Sprintf("example.com/repo%d", i))
+		if conf.GitSharding().AddrForRepo(repo, addrs) == newAddr {
+			moved = repo
+		} else {
+			stays = repo
+		}
+	}
+	for _, repo := range []api.RepoName{moved, stays} {
+		if _, err := oldS.cloneRepo(context.Background(), repo, remote, &cloneOptions{Block: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	isRepoCloned := func(addr string, repo api.RepoName) (status int, location string) {
+		t.Helper()
+		body, _ := json.Marshal(&protocol.IsRepoClonedRequest{Repo: repo})
+		resp, err := http.Post("http://"+addr+"/is-repo-cloned", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode, resp.Header.Get(protocol.LocationHeader)
+	}
+
+	// Before rebalancing, the owner redirects requests for moved to the
+	// gitserver which has it, which serves them.
+	if status, location := isRepoCloned(newAddr, moved); status != http.StatusMisdirectedRequest || location != oldAddr {
+		t.Errorf("new gitserver before rebalance: got status %d location %q, want %d %q", status, location, http.StatusMisdirectedRequest, oldAddr)
+	}
+	if status, _ := isRepoCloned(oldAddr, moved); status != http.StatusOK {
+		t.Errorf("old gitserver before rebalance: got status %d, want %d", status, http.StatusOK)
+	}
+
+	oldS.Rebalance()
+
+	if status, _ := isRepoCloned(newAddr, moved); status != http.StatusOK {
+		t.Errorf("new gitserver after rebalance: got status %d, want %d", status, http.StatusOK)
+	}
+	// The old gitserver does not own moved, so it sends clients to the owner.
+	if status, location := isRepoCloned(oldAddr, moved); status != http.StatusMisdirectedRequest || location != "" {
+		t.Errorf("old gitserver after rebalance: got status %d location %q, want %d %q", status, location, http.StatusMisdirectedRequest, "")
+	}
+	if status, _ := isRepoCloned(oldAddr, stays); status != http.StatusOK {
+		t.Errorf("old gitserver kept repository it owns: got status %d, want %d", status, http.StatusOK)
+	}
+
+	dir := filepath.Join(newS.ReposDir, string(moved))
+	if got := runGit(t, dir, "rev-parse", "HEAD"); got != wantCommit {
+		t.Errorf("transferred repository HEAD: got %q, want %q", got, wantCommit)
+	}
+	if got := runGit(t, dir, "remote", "get-url", "origin"); got != remote {
+		t.Errorf("transferred repository remote: got %q, want %q", got, remote)
+	}
+}
+
+// newTestGitservers starts n gitservers which know about each other.
+func newTestGitservers(t *testing.T, n int) (servers []*Server, addrs []string, cleanup func()) {
+	var cleanups []func()
+	for i := 0; i < n; i++ {
+		reposDir, cleanupDir := tmpDir(t)
+		s := &Server{
+			ReposDir: reposDir,
+			Addrs:    func(context.Context) []string { return addrs },
+		}
+		ts := httptest.NewServer(s.Handler())
+		u, _ := url.Parse(ts.URL)
+		s.Addr = u.Host
+		servers = append(servers, s)
+		addrs = append(addrs, u.Host)
+		cleanups = append(cleanups, func() {
+			ts.Close()
+			s.Stop()
+			cleanupDir()
+		})
+	}
+	return servers, addrs, func() {
+		for _, f := range cleanups {
+			f()
+		}
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	c.Env = []string{
+		"GIT_COMMITTER_NAME=a",
+		"GIT_COMMITTER_EMAIL=a@a.com",
+		"GIT_AUTHOR_NAME=a",
+		"GIT_AUTHOR_EMAIL=a@a.com",
+	}
+	b, err := c.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, b)
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"golang.org/x/net/context/ctxhttp"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// handleRepoRename moves the clone of a repository which was renamed or
+// transferred on its code host to the directory of its new name, so that it
+// does not have to be cloned again. If another gitserver owns the new name,
+// the clone is then transferred to it (see rebalance.go).
+func (s *Server) handleRepoRename(w http.ResponseWriter, r *http.Request) {
+	var req protocol.RepoRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.From == "" || req.To == "" {
+		http.Error(w, "from and to must be set", http.StatusBadRequest)
+		return
+	}
+
+	dir := filepath.Join(s.ReposDir, string(protocol.NormalizeRepo(req.From)))
+	if !req.Replica && s.handleMisdirected(r.Context(), w, req.From, dir) {
+		return
+	}
+
+	if err := s.renameRepo(req.From, req.To); err != nil {
+		log15.Error("failed to rename repository", "from", req.From, "to", req.To, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log15.Info("renamed repository", "from", req.From, "to", req.To)
+
+	if !req.Replica {
+		s.renameReplicas(r.Context(), req)
+		s.handOffRenamed(r.Context(), req.To)
+	}
+}
+
+// handOffRenamed transfers our clone of the renamed repository to the owner of
+// its new name, if that is another gitserver. Failures are only logged,
+// because the owner redirects requests for the repository here until
+// Rebalance transfers it.
+func (s *Server) handOffRenamed(ctx context.Context, repo api.RepoName) {
+	repo = protocol.NormalizeRepo(repo)
+	addrs, self, ok := s.rebalanceAddrs(ctx)
+	if !ok {
+		return
+	}
+	replicas := replicasOf(repo, addrs)
+	if containsAddr(replicas, self) {
+		return
+	}
+	if err := s.transferRepo(ctx, repo, replicas[0]); err != nil {
+		log15.Warn("rename: failed to transfer repository to the owner of its new name", "repo", repo, "to", replicas[0], "error", err)
+	}
+}
+
+// renameRepo moves our clone of the repository from to the directory of to.
+// It is not an error if we have no clone of from.
+func (s *Server) renameRepo(from, to api.RepoName) error {
+	from, to = protocol.NormalizeRepo(from), protocol.NormalizeRepo(to)
+	if from == to {
+		return nil
+	}
+	src := filepath.Join(s.ReposDir, string(from))
+	dst := filepath.Join(s.ReposDir, string(to))
+	if !repoCloned(src) {
+		return nil
+	}
+
+	srcLock, ok := s.locker.TryAcquire(src, "renaming to "+string(to))
+	if !ok {
+		return errors.New("repository is being cloned")
+	}
+	defer srcLock.Release()
+	dstLock, ok := s.locker.TryAcquire(dst, "renaming from "+string(from))
+	if !ok {
+		return errors.New("repository with the new name is being cloned")
+	}
+	defer dstLock.Release()
+
+	// Wait for commands running in either clone to finish, and keep new ones
+	// out until the move is done. The locks are always taken in the same
+	// order, so that concurrent renames in opposite directions can't
+	// deadlock.
+	first, second := from, to
+	if second < first {
+		first, second = second, first
+	}
+	for _, repo := range []api.RepoName{first, second} {
+		defer s.lockRepo(repo)()
+	}
+
+	if repoCloned(dst) {
+		// The repository was already cloned under its new name, so our copy
+		// under the old name is obsolete.
+		return s.deleteRepo(from)
+	}
+
+	gitDir := filepath.Join(src, ".git")
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		// Old style, src is the GIT_DIR itself.
+		gitDir = src
+	} else if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(gitDir, filepath.Join(dst, ".git")); err != nil {
+		return err
+	}
+
+	// src may still contain the clones of other repositories (such as
+	// "github.com/foo/bar/baz" for "github.com/foo/bar"), so we only remove
+	// it if it is empty.
+	if gitDir != src && os.Remove(src) != nil {
+		return nil
+	}
+	s.removeEmptyParents(src)
+	return nil
+}
+
+// renameReplicas forwards a rename request to the other replicas of
+// req.From, if this gitserver is its owner (see conf.GitReplicationFactor).
+// Failures are only logged, because the replicas clone the repository under
+// its new name on demand.
+func (s *Server) renameReplicas(ctx context.Context, req protocol.RepoRenameRequest) {
+	addrs, self, ok := s.rebalanceAddrs(ctx)
+	if !ok {
+		return
+	}
+	replicas := replicasOf(req.From, addrs)
+	if len(replicas) < 2 || replicas[0] != self {
+		return
+	}
+
+	req.Replica = true
+	body, err := json.Marshal(&req)
+	if err != nil {
+		log15.Error("rename: failed to encode request", "error", err)
+		return
+	}
+	for _, addr := range replicas[1:] {
+		if err := renameReplica(ctx, addr, body); err != nil {
+			log15.Warn("rename: failed to rename repository on replica", "from", req.From, "to", req.To, "replica", addr, "error", err)
+		}
+	}
+}
+
+func renameReplica(ctx context.Context, addr string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, replicateTimeout)
+	defer cancel()
+
+	resp, err := ctxhttp.Post(ctx, nil, "http://"+addr+"/rename", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return errors.Errorf("rename: http status %d: %s", resp.StatusCode, string(b))
+	}
+	return nil
+}
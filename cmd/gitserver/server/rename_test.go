@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+)
+
+func TestRenameRepo(t *testing.T) {
+	root, err := ioutil.TempDir("", "gitserver-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	mkRepo := func(name string) {
+		if err := exec.Command("git", "--bare", "init", filepath.Join(root, name, ".git")).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+
+	mkRepo("github.com/old/a")
+	mkRepo("github.com/old/a/nested")
+	mkRepo("github.com/old/b")
+	mkRepo("github.com/new/b")
+
+	s := &Server{ReposDir: root}
+	s.Handler() // Handler as a side-effect sets up Server
+
+	// The clone is moved, but nested repositories stay in place.
+	if err := s.renameRepo("github.com/old/a", "github.com/new/a"); err != nil {
+		t.Fatal(err)
+	}
+	if !repoCloned(filepath.Join(root, "github.com/new/a")) {
+		t.Error("expected github.com/new/a to be cloned")
+	}
+	if exists("github.com/old/a/.git") {
+		t.Error("expected github.com/old/a to be removed")
+	}
+	if !repoCloned(filepath.Join(root, "github.com/old/a/nested")) {
+		t.Error("expected github.com/old/a/nested to be kept")
+	}
+
+	// If the new name is already cloned, the old clone is removed.
+	if err := s.renameRepo("github.com/old/b", "github.com/new/b"); err != nil {
+		t.Fatal(err)
+	}
+	if exists("github.com/old/b") {
+		t.Error("expected github.com/old/b to be removed")
+	}
+	if !repoCloned(filepath.Join(root, "github.com/new/b")) {
+		t.Error("expected github.com/new/b to be kept")
+	}
+
+	// Renaming a repository which is not cloned is a no-op.
+	if err := s.renameRepo("github.com/old/c", "github.com/new/c"); err != nil {
+		t.Fatal(err)
+	}
+	if exists("github.com/new/c") {
+		t.Error("expected github.com/new/c not to be created")
+	}
+}
+
+func TestRepoRename_handOff(t *testing.T) {
+	remote, cleanup := tmpDir(t)
+	defer cleanup()
+	runGit(t, remote, "init", ".")
+	runGit(t, remote, "commit", "--allow-empty", "-m", "hello")
+
+	servers, addrs, cleanupServers := newTestGitservers(t, 2)
+	defer cleanupServers()
+
+	// Find an old name owned by the first gitserver and a new name owned by
+	// the second.
+	var from, to api.RepoName
+	for i := 0; from == "" || to == ""; i++ {
+		repo := api.RepoName(fmt.Sprintf("example.com/repo%d", i))
+		if conf.GitSharding().AddrForRepo(repo, addrs) == addrs[0] {
+			if from == "" {
+				from = repo
+			}
+		} else if to == "" {
+			to = repo
+		}
+	}
+	if _, err := servers[0].cloneRepo(context.Background(), from, remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := json.Marshal(&protocol.RepoRenameRequest{From: from, To: to})
+	resp, err := http.Post("http://"+addrs[0]+"/rename", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("rename: got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// The clone is moved to the owner of the new name.
+	if repoCloned(filepath.Join(servers[0].ReposDir, string(to))) {
+		t.Error("expected the old owner to transfer the renamed repository")
+	}
+	if repoCloned(filepath.Join(servers[0].ReposDir, string(from))) {
+		t.Error("expected the old owner to remove the repository under its old name")
+	}
+	if !repoCloned(filepath.Join(servers[1].ReposDir, string(to))) {
+		t.Error("expected the owner of the new name to have the repository")
+	}
+}
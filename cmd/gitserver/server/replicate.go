@@ -0,0 +1,100 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"golang.org/x/net/context/ctxhttp"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// replicateTimeout is how long we wait for a replica to accept an update.
+// Replicas clone in the background, so this does not need to cover a clone.
+const replicateTimeout = 5 * time.Minute
+
+// replicate asks the other replicas of repo to update their copy, if this
+// gitserver is the owner of repo (see conf.GitReplicationFactor). url is the
+// Git remote URL of repo, or "" to use the remote of our copy. It returns
+// immediately; the replicas are updated in the background.
+func (s *Server) replicate(repo api.RepoName, url string) {
+	ctx, cancel := s.serverContext()
+	addrs, self, ok := s.rebalanceAddrs(ctx)
+	if !ok {
+		cancel()
+		return
+	}
+	replicas := replicasOf(repo, addrs)
+	if len(replicas) < 2 || replicas[0] != self {
+		cancel()
+		return
+	}
+
+	go func() {
+		defer cancel()
+		if url == "" {
+			var err error
+			url, err = repoRemoteURL(ctx, filepath.Join(s.ReposDir, string(protocol.NormalizeRepo(repo))))
+			if err != nil {
+				log15.Error("replicate: failed to get remote URL", "repo", repo, "error", err)
+				return
+			}
+		}
+		for _, addr := range replicas[1:] {
+			if err := updateReplica(ctx, addr, repo, url); err != nil {
+				replicateErrors.Inc()
+				log15.Warn("replicate: failed to update replica", "repo", repo, "replica", addr, "error", err)
+			}
+		}
+	}()
+}
+
+// updateReplica asks the gitserver at addr to update (or clone) its copy of
+// repo.
+func updateReplica(ctx context.Context, addr string, repo api.RepoName, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, replicateTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(&protocol.RepoUpdateRequest{Repo: repo, URL: url, Replica: true})
+	if err != nil {
+		return err
+	}
+	resp, err := ctxhttp.Post(ctx, nil, "http://"+addr+"/repo-update", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return errors.Errorf("repo-update: http status %d: %s", resp.StatusCode, string(b))
+	}
+	var info protocol.RepoUpdateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return err
+	}
+	if info.Error != "" {
+		return errors.New(info.Error)
+	}
+	return nil
+}
+
+var replicateErrors = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "replicate_errors",
+	Help:      "number of failed requests to update a replica of a repo.",
+})
+
+func init() {
+	prometheus.MustRegister(replicateErrors)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestReplicate(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{GitReplicationFactor: 2}})
+	defer conf.Mock(nil)
+
+	remote, cleanup := tmpDir(t)
+	defer cleanup()
+	runGit(t, remote, "init", ".")
+	runGit(t, remote, "commit", "--allow-empty", "-m", "hello")
+
+	servers, addrs, cleanupServers := newTestGitservers(t, 2)
+	defer cleanupServers()
+
+	// Find a repository owned by the first gitserver.
+	var repo api.RepoName
+	for i := 0; repo == ""; i++ {
+		r := api.RepoName(fmt.Sprintf("example.com/repo%d", i))
+		if conf.GitSharding().AddrForRepo(r, addrs) == addrs[0] {
+			repo = r
+		}
+	}
+	owner, replica := servers[0], servers[1]
+	replicaDir := filepath.Join(replica.ReposDir, string(repo))
+
+	// waitForHead waits until the replica's copy of repo is at commit.
+	waitForHead := func(commit string) {
+		t.Helper()
+		for i := 0; i < 500; i++ {
+			if repoCloned(replicaDir) {
+				if _, cloning := replica.locker.Status(replicaDir); !cloning && runGit(t, replicaDir, "rev-parse", "HEAD") == commit {
+					return
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("replica did not reach commit %s", commit)
+	}
+
+	// Cloning on the owner clones on the replica.
+	if _, err := owner.cloneRepo(context.Background(), repo, remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+	waitForHead(runGit(t, remote, "rev-parse", "HEAD"))
+
+	// Updating the owner updates the replica.
+	runGit(t, remote, "commit", "--allow-empty", "-m", "world")
+	if err := owner.doRepoUpdate(context.Background(), repo, ""); err != nil {
+		t.Fatal(err)
+	}
+	waitForHead(runGit(t, remote, "rev-parse", "HEAD"))
+}
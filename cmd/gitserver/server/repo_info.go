@@ -21,6 +21,9 @@ func (s *Server) handleRepoInfo(w http.ResponseWriter, r *http.Request) {
 	}
 	repo := protocol.NormalizeRepo(req.Repo)
 	dir := path.Join(s.ReposDir, string(repo))
+	if s.handleMisdirected(r.Context(), w, repo, dir) {
+		return
+	}
 
 	resp := protocol.RepoInfoResponse{
 		Cloned: repoCloned(dir),
@@ -39,6 +42,7 @@ func (s *Server) handleRepoInfo(w http.ResponseWriter, r *http.Request) {
 			resp.CloneInProgress = true
 			resp.CloneProgress = "This will never finish cloning"
 		}
+		resp.TransferProgress, resp.TransferInProgress = s.transferStatus(repo)
 	}
 	if resp.Cloned {
 		if mtime, err := repoLastFetched(dir); err != nil {
@@ -73,7 +77,10 @@ func (s *Server) handleRepoDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.deleteRepo(req.Repo); err != nil {
+	unlock := s.lockRepo(req.Repo)
+	err := s.deleteRepo(req.Repo)
+	unlock()
+	if err != nil {
 		log15.Error("failed to delete repository", "repo", req.Repo, "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -81,6 +88,8 @@ func (s *Server) handleRepoDelete(w http.ResponseWriter, r *http.Request) {
 	log15.Info("deleted repository", "repo", req.Repo)
 }
 
+// deleteRepo deletes the clone of repo. The caller must hold the lock of the
+// clone for writing (see lockRepo).
 func (s *Server) deleteRepo(repo api.RepoName) error {
 	repo = protocol.NormalizeRepo(repo)
 	dir := filepath.Join(s.ReposDir, string(repo))
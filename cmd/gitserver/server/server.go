@@ -112,6 +112,22 @@ type Server struct {
 	// Janitor job runs.
 	DeleteStaleRepositories bool
 
+	// DesiredPercentFree is the percentage of the disk of ReposDir that
+	// FreeUpSpace keeps free by evicting the least recently used
+	// repositories. If 0, repositories are never evicted.
+	DesiredPercentFree int
+
+	// Addrs returns the addresses of every gitserver. Each repository is
+	// owned by one of them (see conf.GitSharding), and copied to
+	// conf.GitReplicationFactor of them. If Addrs is nil, this gitserver does
+	// not take part in rebalancing or replicating repositories between
+	// gitservers.
+	Addrs func(ctx context.Context) []string
+
+	// Addr is the address of this gitserver. If empty, it is the address
+	// returned by Addrs whose host is the hostname of this machine.
+	Addr string
+
 	// skipCloneForTests is set by tests to avoid clones.
 	skipCloneForTests bool
 
@@ -134,6 +150,33 @@ type Server struct {
 
 	repoUpdateLocksMu sync.Mutex // protects the map below and also updates to locks.once
 	repoUpdateLocks   map[api.RepoName]*locks
+
+	repoLocksMu sync.Mutex // protects repoLocks
+	// repoLocks are the locks of the clone of each repository which is in
+	// use. Use s.rlockRepo, s.lockRepo and s.tryLockRepo instead of using it
+	// directly.
+	repoLocks map[api.RepoName]*repoRWLock
+
+	transfersMu sync.Mutex // protects transfers
+	// transfers is the progress of each repository being transferred to or
+	// from this gitserver.
+	transfers map[api.RepoName]string
+
+	holdersMu sync.Mutex // protects holders
+	// holders caches which gitserver has the repositories we are a replica
+	// of but do not have yet.
+	holders map[api.RepoName]holder
+
+	accessMu sync.Mutex // protects accessed
+	// accessed is the last time we recorded an access of each repository
+	// (see recordAccess).
+	accessed map[api.RepoName]time.Time
+
+	evictedMu sync.Mutex // protects evicted
+	// evicted is the set of repositories evicted to free up disk space (see
+	// FreeUpSpace). Use s.isEvicted and s.setEvicted instead of using it
+	// directly.
+	evicted map[api.RepoName]time.Time
 }
 
 type locks struct {
@@ -141,6 +184,74 @@ type locks struct {
 	mu   *sync.Mutex // prevents updates running in parallel
 }
 
+// rlockRepo locks the clone of repo for reading, and returns a func which
+// unlocks it. Running git commands in the clone (exec and fetch) holds the
+// lock for reading, and deleting or moving the clone holds it for writing (see
+// lockRepo), so that commands don't see a half-deleted repository.
+func (s *Server) rlockRepo(repo api.RepoName) (unlock func()) {
+	l := s.acquireRepoLock(repo)
+	l.RLock()
+	return func() {
+		l.RUnlock()
+		s.releaseRepoLock(repo, l)
+	}
+}
+
+// lockRepo locks the clone of repo for writing, and returns a func which
+// unlocks it.
+func (s *Server) lockRepo(repo api.RepoName) (unlock func()) {
+	l := s.acquireRepoLock(repo)
+	l.Lock()
+	return func() {
+		l.Unlock()
+		s.releaseRepoLock(repo, l)
+	}
+}
+
+// tryLockRepo is like lockRepo, except that it does not wait for the lock. It
+// reports whether it locked the clone.
+func (s *Server) tryLockRepo(repo api.RepoName) (unlock func(), ok bool) {
+	l := s.acquireRepoLock(repo)
+	if !l.TryLock() {
+		s.releaseRepoLock(repo, l)
+		return nil, false
+	}
+	return func() {
+		l.Unlock()
+		s.releaseRepoLock(repo, l)
+	}, true
+}
+
+// acquireRepoLock returns the lock of the clone of repo. The caller must call
+// releaseRepoLock once it no longer holds or waits for the lock.
+func (s *Server) acquireRepoLock(repo api.RepoName) *repoRWLock {
+	repo = protocol.NormalizeRepo(repo)
+	s.repoLocksMu.Lock()
+	defer s.repoLocksMu.Unlock()
+	if s.repoLocks == nil {
+		s.repoLocks = make(map[api.RepoName]*repoRWLock)
+	}
+	l, ok := s.repoLocks[repo]
+	if !ok {
+		l = newRepoRWLock(repoLockMaxWriterWait)
+		s.repoLocks[repo] = l
+	}
+	l.refs++
+	return l
+}
+
+// releaseRepoLock releases a lock returned by acquireRepoLock, and forgets it
+// once nobody uses it anymore.
+func (s *Server) releaseRepoLock(repo api.RepoName, l *repoRWLock) {
+	repo = protocol.NormalizeRepo(repo)
+	s.repoLocksMu.Lock()
+	defer s.repoLocksMu.Unlock()
+	l.refs--
+	if l.refs == 0 {
+		delete(s.repoLocks, repo)
+	}
+}
+
 // shortGitCommandTimeout returns the timeout for git commands that should not
 // take a long time. Some commands such as "git archive" are allowed more time
 // than "git rev-parse", so this will return an appropriate timeout given the
@@ -225,10 +336,12 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/is-repo-cloned", s.handleIsRepoCloned)
 	mux.HandleFunc("/repo", s.handleRepoInfo)
 	mux.HandleFunc("/delete", s.handleRepoDelete)
+	mux.HandleFunc("/rename", s.handleRepoRename)
 	mux.HandleFunc("/repo-update", s.handleRepoUpdate)
 	mux.HandleFunc("/upload-pack", s.handleUploadPack)
 	mux.HandleFunc("/getGitolitePhabricatorMetadata", s.handleGetGitolitePhabricatorMetadata)
 	mux.HandleFunc("/create-commit-from-patch", s.handleCreateCommitFromPatch)
+	mux.HandleFunc("/receive-repo", s.handleReceiveRepo)
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -369,6 +482,9 @@ func (s *Server) handleIsRepoCloned(w http.ResponseWriter, r *http.Request) {
 	}
 	req.Repo = protocol.NormalizeRepo(req.Repo)
 	dir := path.Join(s.ReposDir, string(req.Repo))
+	if !req.Local && s.handleMisdirected(r.Context(), w, req.Repo, dir) {
+		return
+	}
 	if repoCloned(dir) {
 		w.WriteHeader(http.StatusOK)
 	} else {
@@ -389,6 +505,9 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 	var resp protocol.RepoUpdateResponse
 	req.Repo = protocol.NormalizeRepo(req.Repo)
 	dir := path.Join(s.ReposDir, string(req.Repo))
+	if !req.Replica && s.handleMisdirected(r.Context(), w, req.Repo, dir) {
+		return
+	}
 
 	// despite the existence of a context on the request, we don't want to
 	// cancel the git commands partway through if the request terminates.
@@ -397,7 +516,12 @@ func (s *Server) handleRepoUpdate(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel2 := context.WithTimeout(ctx, longGitCommandTimeout)
 	defer cancel2()
 	resp.QueueCap, resp.QueueLen = s.queryCloneLimiter()
-	if !repoCloned(dir) && !s.skipCloneForTests {
+	if !repoCloned(dir) && !req.Replica && s.isEvicted(req.Repo) {
+		// The repo was evicted to free up disk space. We only clone it
+		// again when it is used, so that background updates do not fill up
+		// the disk again.
+		resp.Evicted = true
+	} else if !repoCloned(dir) && !s.skipCloneForTests {
 		// optimistically, we assume that our cloning attempt might
 		// succeed.
 		resp.CloneInProgress = true
@@ -548,6 +672,10 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 	}
 
 	dir := path.Join(s.ReposDir, string(req.Repo))
+	if s.handleMisdirected(ctx, w, req.Repo, dir) {
+		status = "misdirected"
+		return
+	}
 	cloneProgress, cloneInProgress := s.locker.Status(dir)
 	if strings.ToLower(string(req.Repo)) == "github.com/sourcegraphtest/alwayscloningtest" {
 		cloneInProgress = true
@@ -586,6 +714,8 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.recordAccess(req.Repo, dir)
+
 	didUpdate := s.ensureRevision(ctx, req.Repo, req.URL, req.EnsureRevision, dir)
 	if didUpdate {
 		ensureRevisionStatus = "fetched"
@@ -593,6 +723,15 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 		ensureRevisionStatus = "noop"
 	}
 
+	defer s.rlockRepo(req.Repo)()
+	if !repoCloned(dir) {
+		// The clone was deleted or moved while we waited for the lock.
+		status = "repo-not-found"
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
+		return
+	}
+
 	w.Header().Set("Trailer", "X-Exec-Error")
 	w.Header().Add("Trailer", "X-Exec-Exit-Status")
 	w.Header().Add("Trailer", "X-Exec-Stderr")
@@ -620,6 +759,14 @@ func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
 	cmd.Dir = dir
 	cmd.Stdout = stdoutW
 	cmd.Stderr = stderrW
+	if isPartialClone(dir) {
+		// Git fetches the objects which are missing from a partial clone
+		// (such as file contents for git show, archive and blame) from
+		// the remote on demand, so the command must not prompt for
+		// credentials.
+		cmd.Env = os.Environ()
+		configureRemoteOpts(cmd)
+	}
 
 	var err error
 	exitStatus, err = runCommand(ctx, cmd)
@@ -751,7 +898,8 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 		defer os.RemoveAll(tmpPath)
 		tmpPath = filepath.Join(tmpPath, ".git")
 
-		cmd := exec.CommandContext(ctx, "git", "clone", "--mirror", "--progress", url, tmpPath)
+		args := append([]string{"clone", "--mirror", "--progress"}, cloneArgs(gitCloneOptions(repo))...)
+		cmd := exec.CommandContext(ctx, "git", append(args, url, tmpPath)...)
 		log15.Info("cloning repo", "repo", repo, "tmp", tmpPath, "dst", dstPath)
 
 		pr, pw := io.Pipe()
@@ -789,6 +937,10 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 
 		log15.Info("repo cloned", "repo", repo)
 		repoClonedCounter.Inc()
+		if err := s.setEvicted([]api.RepoName{repo}, false); err != nil {
+			log15.Warn("failed to record that evicted repo was cloned again", "repo", repo, "error", err)
+		}
+		s.replicate(repo, url)
 
 		return nil
 	}
@@ -1007,6 +1159,9 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string
 			s.repoUpdateLocksMu.Unlock()
 
 			err = s.doRepoUpdate2(repo, url)
+			if err == nil {
+				s.replicate(repo, url)
+			}
 		})
 	}()
 
@@ -1163,6 +1318,8 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 	repo = protocol.NormalizeRepo(repo)
 	dir := path.Join(s.ReposDir, string(repo))
 
+	defer s.rlockRepo(repo)()
+
 	// If URL is not set, we can also use the last known working URL (set as the remote origin).
 	var urlIsGitRemote bool
 	if url == "" {
@@ -1197,7 +1354,9 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 		}
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "fetch", "--prune", url, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+refs/pull/*:refs/pull/*")
+	remote, args := fetchArgs(dir, url, gitCloneOptions(repo))
+	args = append(append([]string{"fetch", "--prune"}, args...), remote, "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*", "+refs/pull/*:refs/pull/*")
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = dir
 
 	// drop temporary pack files after a fetch. this function won't
@@ -1269,16 +1428,29 @@ func (s *Server) ensureRevision(ctx context.Context, repo api.RepoName, url, rev
 	}
 	// rev-parse on an OID does not check if the commit actually exists, so it
 	// is always works. So we append ^0 to force the check
+	var commit string
 	if git.IsAbsoluteRevision(rev) {
+		commit = rev
 		rev = rev + "^0"
 	}
-	cmd := exec.Command("git", "rev-parse", rev, "--")
-	cmd.Dir = repoDir
-	if err := cmd.Run(); err == nil {
+	revExists := func() bool {
+		cmd := exec.Command("git", "rev-parse", rev, "--")
+		cmd.Dir = repoDir
+		return cmd.Run() == nil
+	}
+	if revExists() {
 		return false
 	}
 	// Revision not found, update before returning.
 	s.doRepoUpdate(ctx, repo, url)
+
+	// An update of a shallow clone only fetches the recent history of its
+	// branches and tags, so older commits are fetched individually.
+	if commit != "" && isShallowClone(repoDir) && !revExists() {
+		if err := s.fetchCommit(ctx, repo, url, commit, repoDir); err != nil {
+			log15.Warn("failed to fetch commit into shallow clone", "repo", repo, "commit", commit, "error", err)
+		}
+	}
 	return true
 }
 
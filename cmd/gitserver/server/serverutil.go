@@ -22,23 +22,7 @@ import (
 // runWithRemoteOpts runs the command after applying the remote options.
 // If progress is not nil, all output is written to it in a separate goroutine.
 func (s *Server) runWithRemoteOpts(ctx context.Context, cmd *exec.Cmd, progress io.Writer) ([]byte, error) {
-	cmd.Env = append(cmd.Env, "GIT_ASKPASS=true") // disable password prompt
-
-	// Suppress asking to add SSH host key to known_hosts (which will hang because
-	// the command is non-interactive).
-	//
-	// And set a timeout to avoid indefinite hangs if the server is unreachable.
-	cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes -o ConnectTimeout=30")
-
-	extraArgs := []string{
-		// Unset credential helper because the command is non-interactive.
-		"-c", "credential.helper=",
-
-		// Use Git wire protocol version 2.
-		// https://opensource.googleblog.com/2018/05/introducing-git-protocol-version-2.html
-		"-c", "protocol.version=2",
-	}
-	cmd.Args = append(cmd.Args[:1], append(extraArgs, cmd.Args[1:]...)...)
+	configureRemoteOpts(cmd)
 
 	var b interface {
 		Bytes() []byte
@@ -68,6 +52,28 @@ func (s *Server) runWithRemoteOpts(ctx context.Context, cmd *exec.Cmd, progress
 	return b.Bytes(), err
 }
 
+// configureRemoteOpts applies the remote options to a git command which talks
+// to a remote, so that it does not prompt for credentials or hang.
+func configureRemoteOpts(cmd *exec.Cmd) {
+	cmd.Env = append(cmd.Env, "GIT_ASKPASS=true") // disable password prompt
+
+	// Suppress asking to add SSH host key to known_hosts (which will hang because
+	// the command is non-interactive).
+	//
+	// And set a timeout to avoid indefinite hangs if the server is unreachable.
+	cmd.Env = append(cmd.Env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes -o ConnectTimeout=30")
+
+	extraArgs := []string{
+		// Unset credential helper because the command is non-interactive.
+		"-c", "credential.helper=",
+
+		// Use Git wire protocol version 2.
+		// https://opensource.googleblog.com/2018/05/introducing-git-protocol-version-2.html
+		"-c", "protocol.version=2",
+	}
+	cmd.Args = append(cmd.Args[:1], append(extraArgs, cmd.Args[1:]...)...)
+}
+
 // repoCloned checks if dir or `${dir}/.git` is a valid GIT_DIR.
 var repoCloned = func(dir string) bool {
 	if _, err := os.Stat(filepath.Join(dir, "HEAD")); !os.IsNotExist(err) {
@@ -17,6 +17,11 @@ func (s *Server) handleUploadPack(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dir := path.Join(s.ReposDir, string(repo))
+	if s.handleMisdirected(r.Context(), w, repo, dir) {
+		return
+	}
+
 	if r.Header.Get("Content-Type") != "application/x-git-upload-pack-request" {
 		http.Error(w, "Unexpected Content-Type", http.StatusBadRequest)
 		return
@@ -34,8 +39,12 @@ func (s *Server) handleUploadPack(w http.ResponseWriter, r *http.Request) {
 	}
 	defer body.Close()
 
+	if repoCloned(dir) {
+		s.recordAccess(repo, dir)
+	}
+
 	cmd := exec.CommandContext(r.Context(), "git", "upload-pack", "--stateless-rpc", ".")
-	cmd.Dir = path.Join(s.ReposDir, string(repo))
+	cmd.Dir = dir
 	cmd.Stdout = w
 	cmd.Stdin = body
 	if err := cmd.Run(); err != nil {
@@ -232,3 +232,22 @@ func serveTestNotification(w http.ResponseWriter, r *http.Request) {
 
 	log15.Info("saved query test notification sent", "spec", args.Spec, "key", key)
 }
+
+func serveRunSavedQuery(w http.ResponseWriter, r *http.Request) {
+	allSavedQueries.mu.Lock()
+	defer allSavedQueries.mu.Unlock()
+
+	var args *queryrunnerapi.RunSavedQueryArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		writeError(w, errors.Wrap(err, "decoding JSON arguments"))
+		return
+	}
+
+	key := savedQueryIDSpecKey(args.Spec)
+	if _, ok := allSavedQueries.allSavedQueries[key]; !ok {
+		writeError(w, fmt.Errorf("no saved search found with key %q", key))
+		return
+	}
+	executor.runNow(key)
+	log15.Info("saved query run requested", "spec", args.Spec, "key", key)
+}
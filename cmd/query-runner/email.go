@@ -35,6 +35,34 @@ func (n *notifier) emailNotify(ctx context.Context) {
 		defer cancel()
 
 		for _, recipient := range n.recipients {
+			if !recipient.email {
+				continue
+			}
+			added, err := readableMatches(ctx, recipient, n.added)
+			if err != nil {
+				log15.Error("Failed to check which new results the email notification recipient can read.", "userID", recipient.spec.userID, "error", err)
+				continue
+			}
+			if len(added) == 0 {
+				continue
+			}
+
+			plural := ""
+			if len(added) != 1 {
+				plural = "s"
+			}
+			var matches []emailMatch
+			for i, m := range added {
+				if i == maxNotificationMatches {
+					break
+				}
+				matches = append(matches, emailMatch{
+					Title:   m.title(),
+					Preview: m.Preview,
+					URL:     matchURL(m, utmSourceEmail),
+				})
+			}
+
 			ownership := "the" // example: "new search results have been found for {{.Ownership}} saved search"
 			if n.spec.Subject.User != nil && *n.spec.Subject.User == recipient.spec.userID {
 				ownership = "your"
@@ -43,24 +71,26 @@ func (n *notifier) emailNotify(ctx context.Context) {
 				ownership = "your organization's"
 			}
 
-			plural := ""
-			if n.results.Data.Search.Results.ApproximateResultCount != "1" {
-				plural = "s"
-			}
 			if err := sendEmail(ctx, recipient.spec.userID, "results", newSearchResultsEmailTemplates, struct {
-				URL                    string
-				Description            string
-				Query                  string
-				ApproximateResultCount string
-				Ownership              string
-				PluralResults          string
+				URL           string
+				Description   string
+				Query         string
+				ResultCount   int
+				Ownership     string
+				PluralResults string
+				Matches       []emailMatch
+				MoreCount     int
+				RemovedCount  int
 			}{
-				URL:                    searchURL(n.newQuery, utmSourceEmail),
-				Description:            n.query.Description,
-				Query:                  n.query.Query,
-				ApproximateResultCount: n.results.Data.Search.Results.ApproximateResultCount,
-				Ownership:              ownership,
-				PluralResults:          plural,
+				URL:           searchURL(n.query.Query, utmSourceEmail),
+				Description:   n.query.Description,
+				Query:         n.query.Query,
+				ResultCount:   len(added),
+				Ownership:     ownership,
+				PluralResults: plural,
+				Matches:       matches,
+				MoreCount:     len(added) - len(matches),
+				RemovedCount:  n.removed,
 			}); err != nil {
 				log15.Error("Failed to send email notification for new saved search results.", "userID", recipient.spec.userID, "error", err)
 			}
@@ -68,21 +98,47 @@ func (n *notifier) emailNotify(ctx context.Context) {
 	}()
 }
 
+// emailMatch is a new match of a saved search, as listed in the email.
+type emailMatch struct {
+	Title   string
+	Preview string
+	URL     string
+}
+
 var newSearchResultsEmailTemplates = txemail.MustValidate(txtypes.Templates{
-	Subject: `[{{.ApproximateResultCount}} new result{{.PluralResults}}] {{.Description}}`,
+	Subject: `[{{.ResultCount}} new result{{.PluralResults}}] {{.Description}}`,
 	Text: `
-{{.ApproximateResultCount}} new search result{{.PluralResults}} found for {{.Ownership}} saved search:
+{{.ResultCount}} new search result{{.PluralResults}} found for {{.Ownership}} saved search:
 
   "{{.Description}}"
-
-View the new result{{.PluralResults}} on Sourcegraph: {{.URL}}
+{{range .Matches}}
+* {{.Title}}{{if .Preview}}
+    {{.Preview}}{{end}}
+  {{.URL}}
+{{end}}{{if .MoreCount}}
+...and {{.MoreCount}} more.
+{{end}}{{if .RemovedCount}}
+{{.RemovedCount}} previous result(s) no longer match.
+{{end}}
+View all results on Sourcegraph: {{.URL}}
 `,
 	HTML: `
-<strong>{{.ApproximateResultCount}}</strong> new search result{{.PluralResults}} found for {{.Ownership}} saved search:
+<strong>{{.ResultCount}}</strong> new search result{{.PluralResults}} found for {{.Ownership}} saved search:
 
 <p style="padding-left: 16px">&quot;{{.Description}}&quot;</p>
 
-<p><a href="{{.URL}}">View the new result{{.PluralResults}} on Sourcegraph</a></p>
+<ul>
+{{range .Matches}}
+	<li>
+		<a href="{{.URL}}">{{.Title}}</a>
+		{{if .Preview}}<br><code>{{.Preview}}</code>{{end}}
+	</li>
+{{end}}
+</ul>
+{{if .MoreCount}}<p>...and {{.MoreCount}} more.</p>{{end}}
+{{if .RemovedCount}}<p>{{.RemovedCount}} previous result(s) no longer match.</p>{{end}}
+
+<p><a href="{{.URL}}">View all results on Sourcegraph</a></p>
 `,
 })
 
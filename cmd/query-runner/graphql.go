@@ -5,10 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/url"
-	"runtime"
-	"time"
 
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 
@@ -34,63 +31,33 @@ const gqlSearchQuery = `query Search(
 			results {
 				__typename
 				... on FileMatch {
-					resource
-					limitHit
+					repository {
+						name
+					}
+					file {
+						path
+						url
+					}
 					lineMatches {
 						preview
 						lineNumber
-						offsetAndLengths
 					}
 				}
 				... on CommitSearchResult {
-					refs {
-						name
-						displayName
-						prefix
-						repository {
-							name
-						}
-					}
-					sourceRefs {
-						name
-						displayName
-						prefix
-						repository {
-							name
-						}
-					}
-					messagePreview {
-						value
-						highlights {
-							line
-							character
-							length
-						}
-					}
-					diffPreview {
-						value
-						highlights {
-							line
-							character
-							length
-						}
-					}
 					commit {
 						repository {
 							name
 						}
 						oid
 						abbreviatedOID
-						author {
-							person {
-								displayName
-								avatarURL
-							}
-							date
-						}
-						message
+						subject
+						url
 					}
 				}
+				... on Repository {
+					name
+					url
+				}
 			}
 			alert {
 				title
@@ -113,15 +80,51 @@ type gqlSearchResponse struct {
 		Search struct {
 			Results struct {
 				ApproximateResultCount string
+				LimitHit               bool
 				Cloning                []*api.Repo
 				Timedout               []*api.Repo
-				Results                []interface{}
+				Results                []*gqlSearchResult
 			}
 		}
 	}
 	Errors []interface{}
 }
 
+// incomplete reports whether the search results may be missing matches,
+// because the result limit was hit or some repositories were cloning or timed
+// out.
+func (r *gqlSearchResponse) incomplete() bool {
+	results := r.Data.Search.Results
+	return results.LimitHit || len(results.Cloning) > 0 || len(results.Timedout) > 0
+}
+
+// gqlSearchResult is a search result, which is a FileMatch, CommitSearchResult
+// or Repository (only the fields of its type are set).
+type gqlSearchResult struct {
+	Typename string `json:"__typename"`
+
+	// FileMatch fields.
+	Repository  *struct{ Name string }
+	File        *struct{ Path, URL string }
+	LineMatches []*struct {
+		Preview    string
+		LineNumber int32 // zero-based
+	}
+
+	// CommitSearchResult fields.
+	Commit *struct {
+		Repository     struct{ Name string }
+		OID            string
+		AbbreviatedOID string
+		Subject        string
+		URL            string
+	}
+
+	// Repository fields.
+	Name string
+	URL  string
+}
+
 func search(ctx context.Context, query string) (*gqlSearchResponse, error) {
 	var buf bytes.Buffer
 	err := json.NewEncoder(&buf).Encode(graphQLQuery{
@@ -162,38 +165,3 @@ func gqlURL(queryName string) (string, error) {
 	u.RawQuery = queryName
 	return u.String(), nil
 }
-
-// extractTime extracts the time from the given search result.
-func extractTime(result interface{}) (t *time.Time, err error) {
-	// Use recover because we assume the data structure here a lot, for less
-	// error checking.
-	defer func() {
-		if r := recover(); r != nil {
-			// Same as net/http
-			const size = 64 << 10
-			buf := make([]byte, size)
-			buf = buf[:runtime.Stack(buf, false)]
-			log.Printf("failed to extract time from search result: %v\n%s", r, buf)
-		}
-		err = fmt.Errorf("failed to extract time from search result")
-	}()
-
-	m := result.(map[string]interface{})
-	typeName := m["__typename"].(string)
-	switch typeName {
-	case "CommitSearchResult":
-		commit := m["commit"].(map[string]interface{})
-		author := commit["author"].(map[string]interface{})
-		date := author["date"].(string)
-
-		// For now, our graphql API commit authorship date is in Go default time format.
-		goTimeFormat := "2006-01-02 15:04:05.999999999 -0700 MST"
-		t, err := time.Parse(date, goTimeFormat)
-		if err != nil {
-			return nil, err
-		}
-		return &t, nil
-	default:
-		return nil, fmt.Errorf("unexpected result __typename %q", typeName)
-	}
-}
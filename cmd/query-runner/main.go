@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -26,7 +27,7 @@ import (
 )
 
 var (
-	forceRunInterval = env.Get("FORCE_RUN_INTERVAL", "", "Force an interval to run saved queries at, instead of assuming query execution time * 30 (query that takes 2s to run, runs every 60s)")
+	forceRunInterval = env.Get("FORCE_RUN_INTERVAL", "", "Force the minimum interval between runs of saved queries, instead of assuming query execution time * 30 (query that takes 2s to run, runs at most every 60s)")
 )
 
 const port = "3183"
@@ -49,6 +50,7 @@ func main() {
 	http.HandleFunc(queryrunnerapi.PathSavedQueryWasCreatedOrUpdated, serveSavedQueryWasCreatedOrUpdated)
 	http.HandleFunc(queryrunnerapi.PathSavedQueryWasDeleted, serveSavedQueryWasDeleted)
 	http.HandleFunc(queryrunnerapi.PathTestNotification, serveTestNotification)
+	http.HandleFunc(queryrunnerapi.PathRunSavedQuery, serveRunSavedQuery)
 
 	ctx := context.Background()
 
@@ -86,6 +88,37 @@ var executor = &executorT{}
 
 type executorT struct {
 	forceRunInterval *time.Duration
+
+	mu     sync.Mutex
+	forced map[string]bool // keys of saved queries to run on the next pass, regardless of their schedule
+}
+
+// runNow runs the saved query with the given key (see savedQueryIDSpecKey) as
+// soon as possible, regardless of its schedule. Requested runs are still
+// subject to the minimum run interval (see isDue).
+func (e *executorT) runNow(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.forced == nil {
+		e.forced = map[string]bool{}
+	}
+	e.forced[key] = true
+}
+
+// isForced reports whether a run of the saved query with the given key was
+// requested.
+func (e *executorT) isForced(key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.forced[key]
+}
+
+// clearForced clears the request to run the saved query with the given key,
+// once it runs.
+func (e *executorT) clearForced(key string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.forced, key)
 }
 
 func (e *executorT) run(ctx context.Context) error {
@@ -110,8 +143,8 @@ func (e *executorT) run(ctx context.Context) error {
 	for {
 		allSavedQueries := allSavedQueries.get()
 		start := time.Now()
-		for _, query := range allSavedQueries {
-			err := e.runQuery(ctx, query.Spec, query.Config)
+		for key, query := range allSavedQueries {
+			err := e.runQuery(ctx, key, query.Spec, query.Config)
 			if err != nil {
 				log15.Error("executor: failed to run query", "error", err, "query_description", query.Config.Description)
 			}
@@ -127,79 +160,90 @@ func (e *executorT) run(ctx context.Context) error {
 	}
 }
 
-// runQuery runs the given query if an appropriate amount of time has elapsed
-// since it last ran.
-func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery) error {
-	if !query.Notify && !query.NotifySlack {
+// runQuery runs the given query if its schedule (or, by default, an
+// appropriate amount of time having elapsed since it last ran) says so, or if
+// a run of it was requested (see runNow).
+func (e *executorT) runQuery(ctx context.Context, key string, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery) error {
+	force := e.isForced(key)
+	notifying := query.Notify || query.NotifySlack
+	if !notifying && !force {
 		// No need to run this query because there will be nobody to notify.
 		return nil
 	}
-	if !strings.Contains(query.Query, "type:diff") && !strings.Contains(query.Query, "type:commit") {
-		// TODO(slimsag): we temporarily do not support non-commit search
-		// queries, since those do not support the after:"time" operator.
-		return nil
-	}
 
 	info, err := api.InternalClient.SavedQueriesGetInfo(ctx, query.Query)
 	if err != nil {
 		return errors.Wrap(err, "SavedQueriesGetInfo")
 	}
 
-	// If the saved query was executed recently in the past, then skip it to
-	// avoid putting too much pressure on searcher/gitserver.
-	if info != nil {
-		// We assume a run interval of 30x that which it takes to execute the
-		// query. For example, a query which takes 2s to execute will run (2s*30)
-		// every minute.
-		//
-		// Additionally, in case queries run very quickly (e.g. our after:
-		// queries with no results often return in ~15ms), we impose a minimum
-		// run interval of 10s.
-		runInterval := info.ExecDuration * 30
-		if runInterval < 10*time.Second {
-			runInterval = 10 * time.Second
-		}
-		if e.forceRunInterval != nil {
-			runInterval = *e.forceRunInterval
-		}
-		if time.Since(info.LastExecuted) < runInterval {
-			return nil // too early to run the query
-		}
-	}
-
-	// Construct a new query which finds search results introduced after the
-	// last time we queried.
-	var latestKnownResult time.Time
-	if info != nil {
-		latestKnownResult = info.LatestResult
-	} else {
-		// We've never executed this search query before, so use the current
-		// time. We'll most certainly find nothing, which is okay.
-		latestKnownResult = time.Now()
-	}
-	afterTime := latestKnownResult.UTC().Format(time.RFC3339)
-	newQuery := strings.Join([]string{query.Query, fmt.Sprintf(`after:"%s"`, afterTime)}, " ")
-	if debugPretendSavedQueryResultsExist {
-		debugPretendSavedQueryResultsExist = false
-		newQuery = query.Query
+	if info != nil && !e.isDue(info, query, force) {
+		return nil // too early to run the query
 	}
+	e.clearForced(key)
 
 	// Perform the search and mark the saved query as having been executed in
 	// the database. We do this regardless of whether or not the search query
 	// fails in order to avoid e.g. failed saved queries from executing
 	// constantly and potentially causing harm to the system. We'll retry at
 	// our normal interval, regardless of errors.
+	newQuery, windowed := searchQuery(query.Query, info)
+	startedAt := time.Now()
 	v, execDuration, searchErr := performSearch(ctx, newQuery)
-	if err := api.InternalClient.SavedQueriesSetInfo(ctx, &api.SavedQueryInfo{
+	newInfo := &api.SavedQueryInfo{
 		Query:        query.Query,
 		LastExecuted: time.Now(),
-		LatestResult: latestResultTime(info, v, searchErr),
 		ExecDuration: execDuration,
-	}); err != nil {
+	}
+	var (
+		matches []*searchMatch
+		added   []*searchMatch
+		removed int
+	)
+	if info != nil {
+		newInfo.LatestResult = info.LatestResult
+		newInfo.ResultFingerprints = info.ResultFingerprints
+	} else {
+		newInfo.LatestResult = newInfo.LastExecuted
+	}
+	if searchErr == nil {
+		// Compare the matches against those of the last run. If we've never
+		// recorded the matches of this query before, only record them: every
+		// match would be "new" otherwise.
+		var prevFingerprints []string
+		if info != nil {
+			prevFingerprints = info.ResultFingerprints
+		}
+		if v.incomplete() {
+			log15.Warn("executor: comparing incomplete search results", "query", newQuery, "limitHit", v.Data.Search.Results.LimitHit, "cloning", len(v.Data.Search.Results.Cloning), "timedout", len(v.Data.Search.Results.Timedout))
+		}
+		matches, added, removed, newInfo.ResultFingerprints = compareResults(prevFingerprints, v, windowed)
+		if prevFingerprints == nil {
+			added, removed = nil, 0
+		}
+		if debugPretendSavedQueryResultsExist {
+			debugPretendSavedQueryResultsExist = false
+			added = matches
+		}
+		if len(added) > 0 {
+			newInfo.LatestResult = time.Now()
+		}
+	}
+	if err := api.InternalClient.SavedQueriesSetInfo(ctx, newInfo); err != nil {
 		return errors.Wrap(err, "SavedQueriesSetInfo")
 	}
 
+	run := &api.SavedQueryRun{
+		Spec:         spec,
+		Query:        query.Query,
+		StartedAt:    startedAt,
+		ExecDuration: execDuration,
+	}
 	if searchErr != nil {
+		msg := searchErr.Error()
+		run.Error = &msg
+		if err := api.InternalClient.SavedQueriesRecordRun(ctx, run); err != nil {
+			log15.Error("executor: failed to record run", "error", err)
+		}
 		return searchErr
 	}
 
@@ -207,13 +251,97 @@ func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, que
 	// that we don't block other search queries from running in sequence (which
 	// is done intentionally, to ensure no overloading of searcher/gitserver).
 	go func() {
-		if err := notify(context.Background(), spec, query, newQuery, v); err != nil {
-			log15.Error("executor: failed to send notifications", "error", err)
+		ctx := context.Background()
+		if notifying && len(added) > 0 {
+			if err := notify(ctx, spec, query, added, removed); err != nil {
+				log15.Error("executor: failed to send notifications", "error", err)
+			} else {
+				run.Notified = true
+			}
+		}
+		if count, ok, err := readableMatchCount(ctx, spec, matches); err != nil {
+			log15.Error("executor: failed to count readable matches", "error", err)
+		} else if ok {
+			run.ResultCount = &count
+		}
+		if err := api.InternalClient.SavedQueriesRecordRun(ctx, run); err != nil {
+			log15.Error("executor: failed to record run", "error", err)
 		}
 	}()
 	return nil
 }
 
+// isDue reports whether it is time to run the query again, given the info of
+// its last execution and whether a run of it was requested.
+func (e *executorT) isDue(info *api.SavedQueryInfo, query api.ConfigSavedQuery, force bool) bool {
+	// If the saved query was executed recently in the past, then skip it to
+	// avoid putting too much pressure on searcher/gitserver. This applies to
+	// scheduled and requested runs too, so neither a schedule nor users
+	// requesting runs can make a query run more often.
+	//
+	// We assume a run interval of 30x that which it takes to execute the
+	// query. For example, a query which takes 2s to execute will run (2s*30)
+	// every minute.
+	//
+	// Additionally, in case queries run very quickly (e.g. queries with no
+	// results often return in ~15ms), we impose a minimum run interval of
+	// 10s.
+	runInterval := info.ExecDuration * 30
+	if runInterval < 10*time.Second {
+		runInterval = 10 * time.Second
+	}
+	if e.forceRunInterval != nil {
+		runInterval = *e.forceRunInterval
+	}
+	if time.Since(info.LastExecuted) < runInterval {
+		return false
+	}
+	if force {
+		return true
+	}
+
+	if query.Schedule != "" {
+		if s := cachedParseSchedule(query.Schedule); s != nil {
+			next := s.next(info.LastExecuted)
+			return !next.IsZero() && !time.Now().Before(next)
+		}
+		// Fall back to the default interval for invalid schedules.
+	}
+	return true
+}
+
+// savedQueryMaxResults is the result limit of the searches of saved queries
+// which don't specify one with "count:". The default limit of searches is too
+// low to find the new matches of most queries.
+const savedQueryMaxResults = 1000
+
+// searchQuery returns the query to search for the new results of the saved
+// query, given the info of its last execution. Commit and diff searches only
+// look for commits after the latest known result, so their results are a
+// window of the query's matches (windowed is true).
+func searchQuery(query string, info *api.SavedQueryInfo) (newQuery string, windowed bool) {
+	newQuery = query
+	if !strings.Contains(query, "count:") {
+		newQuery += fmt.Sprintf(" count:%d", savedQueryMaxResults)
+	}
+	if !strings.Contains(query, "type:diff") && !strings.Contains(query, "type:commit") {
+		return newQuery, false
+	}
+
+	// Construct a new query which finds search results introduced after the
+	// last time we found new results.
+	var latestKnownResult time.Time
+	if info != nil {
+		latestKnownResult = info.LatestResult
+	} else {
+		// We've never executed this search query before, so use the current
+		// time. We'll most certainly find nothing, which is okay.
+		latestKnownResult = time.Now()
+	}
+	afterTime := latestKnownResult.UTC().Format(time.RFC3339)
+	return newQuery + fmt.Sprintf(` after:"%s"`, afterTime), true
+}
+
 func performSearch(ctx context.Context, query string) (v *gqlSearchResponse, execDuration time.Duration, err error) {
 	attempts := 0
 	for {
@@ -246,33 +374,14 @@ func performSearch(ctx context.Context, query string) (v *gqlSearchResponse, exe
 	}
 }
 
-func latestResultTime(prevInfo *api.SavedQueryInfo, v *gqlSearchResponse, searchErr error) time.Time {
-	if searchErr != nil || len(v.Data.Search.Results.Results) == 0 {
-		// Error performing the search, or there were no results. Assume the
-		// previous info's result time.
-		if prevInfo != nil {
-			return prevInfo.LatestResult
-		}
-		return time.Now()
-	}
-
-	// Results are ordered chronologically, so first result is the latest.
-	t, err := extractTime(v.Data.Search.Results.Results[0])
-	if err != nil {
-		// Error already logged by extractTime.
-		return time.Now()
-	}
-	return *t
-}
-
 var externalURL *url.URL
 
 // notify handles sending notifications for new search results.
-func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery, newQuery string, results *gqlSearchResponse) error {
-	if len(results.Data.Search.Results.Results) == 0 {
+func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery, added []*searchMatch, removed int) error {
+	if len(added) == 0 {
 		return nil
 	}
-	log15.Info("sending notifications", "new_results", len(results.Data.Search.Results.Results), "description", query.Description)
+	log15.Info("sending notifications", "new_results", len(added), "removed_results", removed, "description", query.Description)
 
 	// Determine which users to notify.
 	recipients, err := getNotificationRecipients(ctx, spec, query)
@@ -284,8 +393,8 @@ func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSave
 	n := &notifier{
 		spec:       spec,
 		query:      query,
-		newQuery:   newQuery,
-		results:    results,
+		added:      added,
+		removed:    removed,
 		recipients: recipients,
 	}
 
@@ -298,37 +407,67 @@ func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSave
 type notifier struct {
 	spec       api.SavedQueryIDSpec
 	query      api.ConfigSavedQuery
-	newQuery   string
-	results    *gqlSearchResponse
+	added      []*searchMatch // the matches that are new since the last run
+	removed    int            // the number of matches that no longer exist
 	recipients recipients
 }
 
+// maxNotificationMatches is the maximum number of new matches listed in a
+// notification. The notification links to the search results for the rest.
+const maxNotificationMatches = 10
+
 const (
 	utmSourceEmail = "saved-search-email"
 	utmSourceSlack = "saved-search-slack"
 )
 
 func searchURL(query, utmSource string) string {
+	u := absoluteURL("search", utmSource)
+	if u == nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set("q", query)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// absoluteURL returns the absolute URL of the given URL (relative to the
+// external URL), with the utm_source query parameter set. It returns nil if the
+// external URL could not be determined.
+func absoluteURL(relativeURL, utmSource string) *url.URL {
 	if externalURL == nil {
 		// Determine the external URL.
 		externalURLStr, err := api.InternalClient.ExternalURL(context.Background())
 		if err != nil {
 			log15.Error("failed to get ExternalURL", err)
-			return ""
+			return nil
 		}
 		externalURL, err = url.Parse(externalURLStr)
 		if err != nil {
 			log15.Error("failed to parse ExternalURL", err)
-			return ""
+			return nil
 		}
 	}
 
-	// Construct URL to the search query.
-	u := externalURL.ResolveReference(&url.URL{Path: "search"})
+	ref, err := url.Parse(strings.TrimPrefix(relativeURL, "/"))
+	if err != nil {
+		log15.Error("failed to parse URL", "url", relativeURL, "error", err)
+		return nil
+	}
+	u := externalURL.ResolveReference(ref)
 	q := u.Query()
-	q.Set("q", query)
 	q.Set("utm_source", utmSource)
 	u.RawQuery = q.Encode()
+	return u
+}
+
+// matchURL returns the absolute URL to the match.
+func matchURL(m *searchMatch, utmSource string) string {
+	u := absoluteURL(m.URL, utmSource)
+	if u == nil {
+		return ""
+	}
 	return u.String()
 }
 
@@ -77,6 +77,78 @@ func getNotificationRecipients(ctx context.Context, spec api.SavedQueryIDSpec, q
 	return recipients, nil
 }
 
+// readableMatchCount returns the number of matches which the owner of the saved
+// query (every member of it, for an org) can read. The run history of a saved
+// query shows this count instead of the number of all matches, which would
+// reveal matches in repositories the owner cannot read. ok is false for saved
+// queries in global settings, which every user can see.
+func readableMatchCount(ctx context.Context, spec api.SavedQueryIDSpec, matches []*searchMatch) (count int32, ok bool, err error) {
+	var owner recipient
+	switch {
+	case spec.Subject.User != nil:
+		owner.spec.userID = *spec.Subject.User
+	case spec.Subject.Org != nil:
+		owner.spec.orgID = *spec.Subject.Org
+	default:
+		return 0, false, nil
+	}
+	readable, err := readableMatches(ctx, &owner, matches)
+	if err != nil {
+		return 0, false, err
+	}
+	return int32(len(readable)), true, nil
+}
+
+// readableMatches returns the matches which the recipient may see. The search
+// runs as the internal actor, which can read every repository, so matches in
+// repositories that the recipient cannot read must not be sent to them. A
+// message posted to an org's Slack webhook only includes matches in
+// repositories that every org member can read.
+func readableMatches(ctx context.Context, r *recipient, matches []*searchMatch) ([]*searchMatch, error) {
+	userIDs := []int32{r.spec.userID}
+	if r.spec.userID == 0 {
+		var err error
+		userIDs, err = api.InternalClient.OrgsListUsers(ctx, r.spec.orgID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(userIDs) == 0 || len(matches) == 0 {
+		return nil, nil
+	}
+
+	var repos []api.RepoName
+	seen := map[api.RepoName]bool{}
+	for _, m := range matches {
+		if name := api.RepoName(m.Repo); !seen[name] {
+			seen[name] = true
+			repos = append(repos, name)
+		}
+	}
+	for _, userID := range userIDs {
+		readable, err := api.InternalClient.ReposListReadable(ctx, userID, repos)
+		if err != nil {
+			return nil, err
+		}
+		repos = readable
+		if len(repos) == 0 {
+			return nil, nil
+		}
+	}
+
+	readable := make(map[string]bool, len(repos))
+	for _, name := range repos {
+		readable[string(name)] = true
+	}
+	var filtered []*searchMatch
+	for _, m := range matches {
+		if readable[m.Repo] {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered, nil
+}
+
 type recipients []*recipient
 
 // add adds the new recipient, merging it into an existing slice element if one already exists for
@@ -141,3 +141,82 @@ func TestDiffNotificationRecipients(t *testing.T) {
 		})
 	}
 }
+
+func TestReadableMatches(t *testing.T) {
+	ctx := context.Background()
+
+	readable := map[int32][]api.RepoName{
+		1: {"public", "private-a"},
+		2: {"public", "private-b"},
+	}
+	api.MockReposListReadable = func(userID int32, repos []api.RepoName) ([]api.RepoName, error) {
+		var names []api.RepoName
+		for _, repo := range repos {
+			for _, name := range readable[userID] {
+				if repo == name {
+					names = append(names, repo)
+				}
+			}
+		}
+		return names, nil
+	}
+	defer func() { api.MockReposListReadable = nil }()
+	api.MockOrgsListUsers = func(orgID int32) (users []int32, err error) {
+		return []int32{1, 2}, nil
+	}
+	defer func() { api.MockOrgsListUsers = nil }()
+
+	matches := []*searchMatch{
+		{Repo: "public", Path: "a.go"},
+		{Repo: "private-a", Path: "a.go"},
+		{Repo: "private-b", Path: "b.go"},
+	}
+	tests := map[string]struct {
+		recipient *recipient
+		want      []*searchMatch
+	}{
+		"user": {
+			recipient: &recipient{spec: recipientSpec{userID: 1}, email: true},
+			want:      matches[:2],
+		},
+		"org": {
+			recipient: &recipient{spec: recipientSpec{orgID: 3}, slack: true},
+			want:      matches[:1],
+		},
+		"unknown user": {
+			recipient: &recipient{spec: recipientSpec{userID: 4}, email: true},
+			want:      nil,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := readableMatches(ctx, test.recipient, matches)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	userID, orgID := int32(1), int32(3)
+	counts := map[string]struct {
+		subject   api.SettingsSubject
+		wantCount int32
+		wantOK    bool
+	}{
+		"user owner":   {subject: api.SettingsSubject{User: &userID}, wantCount: 2, wantOK: true},
+		"org owner":    {subject: api.SettingsSubject{Org: &orgID}, wantCount: 1, wantOK: true},
+		"global owner": {subject: api.SettingsSubject{Site: true}},
+	}
+	for name, test := range counts {
+		count, ok, err := readableMatchCount(ctx, api.SavedQueryIDSpec{Subject: test.subject}, matches)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != test.wantCount || ok != test.wantOK {
+			t.Errorf("%s: got count %d (ok %v), want %d (ok %v)", name, count, ok, test.wantCount, test.wantOK)
+		}
+	}
+}
@@ -39,6 +39,7 @@ const (
 	PathSavedQueryWasCreatedOrUpdated = "/saved-query-was-created-or-updated"
 	PathSavedQueryWasDeleted          = "/saved-query-was-deleted"
 	PathTestNotification              = "/test-notification"
+	PathRunSavedQuery                 = "/run-saved-query"
 )
 
 type client struct {
@@ -86,6 +87,15 @@ func (c *client) TestNotification(ctx context.Context, spec api.SavedQueryIDSpec
 	return c.post(PathTestNotification, &TestNotificationArgs{Spec: spec})
 }
 
+type RunSavedQueryArgs struct {
+	Spec api.SavedQueryIDSpec
+}
+
+// RunSavedQuery is called to run a saved search as soon as possible, regardless of its schedule.
+func (c *client) RunSavedQuery(ctx context.Context, spec api.SavedQueryIDSpec) error {
+	return c.post(PathRunSavedQuery, &RunSavedQueryArgs{Spec: spec})
+}
+
 func (c *client) post(path string, data interface{}) error {
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(data); err != nil {
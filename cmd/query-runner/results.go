@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// searchMatch is a single match of a saved search query: a line in a file, a
+// commit, or a repository.
+type searchMatch struct {
+	Repo    string
+	Path    string // empty for commit and repository matches
+	Line    int32  // 1-based, or 0 if not a line match
+	Preview string // the matching line, or the commit subject
+	Commit  string // the full commit ID, for commit matches
+	URL     string // relative to the external URL
+}
+
+// fingerprint returns a short hash identifying the match across runs of the
+// query. It intentionally does not include the line number, so that a match
+// that merely moved (e.g. because lines were added above it) is not reported as
+// new.
+func (m *searchMatch) fingerprint() string {
+	h := sha256.Sum256([]byte(strings.Join([]string{m.Repo, m.Path, m.Commit, m.Preview}, "\x00")))
+	return hex.EncodeToString(h[:16])
+}
+
+// title returns a short human-readable description of where the match is.
+func (m *searchMatch) title() string {
+	switch {
+	case m.Path != "" && m.Line != 0:
+		return fmt.Sprintf("%s/%s:%d", m.Repo, m.Path, m.Line)
+	case m.Path != "":
+		return fmt.Sprintf("%s/%s", m.Repo, m.Path)
+	case m.Commit != "":
+		commit := m.Commit
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		return fmt.Sprintf("%s@%s", m.Repo, commit)
+	default:
+		return m.Repo
+	}
+}
+
+// matchesFromResults flattens the search results into individual matches. A
+// file match yields one match per matching line.
+func matchesFromResults(results []*gqlSearchResult) []*searchMatch {
+	var matches []*searchMatch
+	for _, r := range results {
+		switch r.Typename {
+		case "FileMatch":
+			if r.Repository == nil || r.File == nil {
+				continue
+			}
+			if len(r.LineMatches) == 0 {
+				// A match on the file path only.
+				matches = append(matches, &searchMatch{Repo: r.Repository.Name, Path: r.File.Path, URL: r.File.URL})
+				continue
+			}
+			for _, lm := range r.LineMatches {
+				line := lm.LineNumber + 1
+				matches = append(matches, &searchMatch{
+					Repo:    r.Repository.Name,
+					Path:    r.File.Path,
+					Line:    line,
+					Preview: strings.TrimSpace(lm.Preview),
+					URL:     fmt.Sprintf("%s#L%d", r.File.URL, line),
+				})
+			}
+		case "CommitSearchResult":
+			if r.Commit == nil {
+				continue
+			}
+			matches = append(matches, &searchMatch{
+				Repo:    r.Commit.Repository.Name,
+				Preview: r.Commit.Subject,
+				Commit:  r.Commit.OID,
+				URL:     r.Commit.URL,
+			})
+		case "Repository":
+			matches = append(matches, &searchMatch{Repo: r.Name, URL: r.URL})
+		}
+	}
+	return matches
+}
+
+// diffMatches compares the matches of the latest run of a query against the
+// fingerprints recorded for the previous run. It returns the matches that are
+// new, the number of previous matches that no longer exist, and the
+// fingerprints to record for the latest run.
+func diffMatches(prevFingerprints []string, matches []*searchMatch) (added []*searchMatch, removed int, fingerprints []string) {
+	// Use counts rather than a set, because identical lines can occur more
+	// than once in a file.
+	prev := make(map[string]int, len(prevFingerprints))
+	for _, fp := range prevFingerprints {
+		prev[fp]++
+	}
+
+	fingerprints = make([]string, 0, len(matches))
+	for _, m := range matches {
+		fp := m.fingerprint()
+		fingerprints = append(fingerprints, fp)
+		if prev[fp] > 0 {
+			prev[fp]--
+			continue
+		}
+		added = append(added, m)
+	}
+	for _, n := range prev {
+		removed += n
+	}
+	return added, removed, fingerprints
+}
+
+// compareResults compares the results of the latest run of a query against the
+// fingerprints recorded for the previous run (see diffMatches).
+//
+// If the results are a window of the query's matches (see searchQuery) or are
+// incomplete, matches missing from them may still exist, so they are not
+// counted as removed. The fingerprints of the matches missing from incomplete
+// results are kept, so that they are not reported as new once they are found
+// again. Those missing from a window are not, because the window only moves
+// forward.
+func compareResults(prevFingerprints []string, v *gqlSearchResponse, windowed bool) (matches, added []*searchMatch, removed int, fingerprints []string) {
+	matches = matchesFromResults(v.Data.Search.Results.Results)
+	added, removed, fingerprints = diffMatches(prevFingerprints, matches)
+	if !windowed && !v.incomplete() {
+		return matches, added, removed, fingerprints
+	}
+	if !windowed {
+		found := make(map[string]int, len(fingerprints))
+		for _, fp := range fingerprints {
+			found[fp]++
+		}
+		for _, fp := range prevFingerprints {
+			if found[fp] > 0 {
+				found[fp]--
+				continue
+			}
+			fingerprints = append(fingerprints, fp)
+		}
+	}
+	return matches, added, 0, fingerprints
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestMatchesFromResults(t *testing.T) {
+	fileMatch := &gqlSearchResult{Typename: "FileMatch"}
+	fileMatch.Repository = &struct{ Name string }{Name: "r"}
+	fileMatch.File = &struct{ Path, URL string }{Path: "a.go", URL: "/r/-/blob/a.go"}
+	fileMatch.LineMatches = []*struct {
+		Preview    string
+		LineNumber int32
+	}{{Preview: "  foo()", LineNumber: 4}}
+
+	commitMatch := &gqlSearchResult{Typename: "CommitSearchResult"}
+	commitMatch.Commit = &struct {
+		Repository     struct{ Name string }
+		OID            string
+		AbbreviatedOID string
+		Subject        string
+		URL            string
+	}{Repository: struct{ Name string }{Name: "r"}, OID: "0123456789abcdef", Subject: "Fix foo", URL: "/r/-/commit/0123456789abcdef"}
+
+	repoMatch := &gqlSearchResult{Typename: "Repository", Name: "r", URL: "/r"}
+
+	matches := matchesFromResults([]*gqlSearchResult{fileMatch, commitMatch, repoMatch})
+	want := []*searchMatch{
+		{Repo: "r", Path: "a.go", Line: 5, Preview: "foo()", URL: "/r/-/blob/a.go#L5"},
+		{Repo: "r", Preview: "Fix foo", Commit: "0123456789abcdef", URL: "/r/-/commit/0123456789abcdef"},
+		{Repo: "r", URL: "/r"},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Fatalf("got %+v, want %+v", matches, want)
+	}
+
+	var titles []string
+	for _, m := range matches {
+		titles = append(titles, m.title())
+	}
+	if want := []string{"r/a.go:5", "r@0123456", "r"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("got titles %q, want %q", titles, want)
+	}
+}
+
+func TestDiffMatches(t *testing.T) {
+	a := &searchMatch{Repo: "r", Path: "a.go", Line: 1, Preview: "foo()"}
+	aMoved := &searchMatch{Repo: "r", Path: "a.go", Line: 7, Preview: "foo()"}
+	b := &searchMatch{Repo: "r", Path: "b.go", Line: 1, Preview: "foo()"}
+	c := &searchMatch{Repo: "r", Path: "c.go", Line: 1, Preview: "foo()"}
+
+	_, _, prev := diffMatches(nil, []*searchMatch{a, b})
+
+	tests := map[string]struct {
+		matches     []*searchMatch
+		wantAdded   []*searchMatch
+		wantRemoved int
+	}{
+		"unchanged": {matches: []*searchMatch{a, b}},
+		"moved":     {matches: []*searchMatch{aMoved, b}},
+		"added":     {matches: []*searchMatch{a, b, c}, wantAdded: []*searchMatch{c}},
+		"removed":   {matches: []*searchMatch{b}, wantRemoved: 1},
+		"replaced":  {matches: []*searchMatch{a, c}, wantAdded: []*searchMatch{c}, wantRemoved: 1},
+		"duplicate": {matches: []*searchMatch{a, b, aMoved}, wantAdded: []*searchMatch{aMoved}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			added, removed, fingerprints := diffMatches(prev, test.matches)
+			if !reflect.DeepEqual(added, test.wantAdded) {
+				t.Errorf("got added %+v, want %+v", added, test.wantAdded)
+			}
+			if removed != test.wantRemoved {
+				t.Errorf("got removed %d, want %d", removed, test.wantRemoved)
+			}
+			if len(fingerprints) != len(test.matches) {
+				t.Errorf("got %d fingerprints, want %d", len(fingerprints), len(test.matches))
+			}
+		})
+	}
+}
+
+func TestSearchResponseIncomplete(t *testing.T) {
+	var v gqlSearchResponse
+	if v.incomplete() {
+		t.Error("got incomplete, want complete")
+	}
+	v.Data.Search.Results.LimitHit = true
+	if !v.incomplete() {
+		t.Error("limit hit: got complete, want incomplete")
+	}
+	v.Data.Search.Results.LimitHit = false
+	v.Data.Search.Results.Timedout = []*api.Repo{{Name: "r"}}
+	if !v.incomplete() {
+		t.Error("timed out: got complete, want incomplete")
+	}
+}
+
+func TestCompareResults(t *testing.T) {
+	fileMatch := func(path string) *gqlSearchResult {
+		r := &gqlSearchResult{Typename: "FileMatch"}
+		r.Repository = &struct{ Name string }{Name: "r"}
+		r.File = &struct{ Path, URL string }{Path: path, URL: "/r/-/blob/" + path}
+		return r
+	}
+	v := &gqlSearchResponse{}
+	v.Data.Search.Results.Results = []*gqlSearchResult{fileMatch("a.go"), fileMatch("b.go")}
+	_, _, _, prev := compareResults(nil, v, false)
+
+	tests := map[string]struct {
+		limitHit         bool
+		windowed         bool
+		wantRemoved      int
+		wantFingerprints int
+	}{
+		"complete":  {wantRemoved: 1, wantFingerprints: 2},
+		"limit hit": {limitHit: true, wantFingerprints: 3},
+		"windowed":  {windowed: true, wantFingerprints: 2},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// b.go is missing from the results and c.go is new.
+			v := &gqlSearchResponse{}
+			v.Data.Search.Results.LimitHit = test.limitHit
+			v.Data.Search.Results.Results = []*gqlSearchResult{fileMatch("a.go"), fileMatch("c.go")}
+			matches, added, removed, fingerprints := compareResults(prev, v, test.windowed)
+			if len(matches) != 2 {
+				t.Errorf("got %d matches, want 2", len(matches))
+			}
+			if len(added) != 1 || added[0].Path != "c.go" {
+				t.Errorf("got added %+v, want c.go", added)
+			}
+			if removed != test.wantRemoved {
+				t.Errorf("got removed %d, want %d", removed, test.wantRemoved)
+			}
+			if len(fingerprints) != test.wantFingerprints {
+				t.Errorf("got %d fingerprints, want %d", len(fingerprints), test.wantFingerprints)
+			}
+		})
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	latest := time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)
+	info := &api.SavedQueryInfo{LatestResult: latest}
+	tests := []struct {
+		query        string
+		wantQuery    string
+		wantWindowed bool
+	}{
+		{query: "foo", wantQuery: "foo count:1000"},
+		{query: "foo count:10", wantQuery: "foo count:10"},
+		{query: "foo type:diff", wantQuery: `foo type:diff count:1000 after:"2019-01-02T03:04:05Z"`, wantWindowed: true},
+		{query: "foo type:commit count:10", wantQuery: `foo type:commit count:10 after:"2019-01-02T03:04:05Z"`, wantWindowed: true},
+	}
+	for _, test := range tests {
+		query, windowed := searchQuery(test.query, info)
+		if query != test.wantQuery || windowed != test.wantWindowed {
+			t.Errorf("%q: got (%q, %v), want (%q, %v)", test.query, query, windowed, test.wantQuery, test.wantWindowed)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// schedule is a parsed cron expression, which specifies when a saved query is
+// run. All times are in UTC.
+type schedule struct {
+	minute, hour, dom, month, dow uint64 // bit sets of the matching values
+
+	// domStar and dowStar are whether the day of month and day of week fields
+	// are unrestricted. If both are restricted, a day matches if either of them
+	// matches (as in cron).
+	domStar, dowStar bool
+}
+
+var scheduleShorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// parseSchedule parses a cron expression with the five standard fields (minute,
+// hour, day of month, month and day of week), or one of the shorthands
+// @hourly, @daily, @weekly and @monthly.
+func parseSchedule(expr string) (*schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if s, ok := scheduleShorthands[expr]; ok {
+		expr = s
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	var (
+		s   schedule
+		err error
+	)
+	for _, f := range []struct {
+		name     string
+		field    string
+		min, max uint
+		bits     *uint64
+	}{
+		{"minute", fields[0], 0, 59, &s.minute},
+		{"hour", fields[1], 0, 23, &s.hour},
+		{"day of month", fields[2], 1, 31, &s.dom},
+		{"month", fields[3], 1, 12, &s.month},
+		{"day of week", fields[4], 0, 7, &s.dow},
+	} {
+		if *f.bits, err = parseScheduleField(f.field, f.min, f.max); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %s: %s", expr, f.name, err)
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 << 0 // 7 is also Sunday
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return &s, nil
+}
+
+var parsedSchedules = struct {
+	sync.Mutex
+	m map[string]*schedule
+}{m: map[string]*schedule{}}
+
+// cachedParseSchedule is like parseSchedule, but caches the result. It returns
+// nil for invalid expressions, whose error is logged only once.
+func cachedParseSchedule(expr string) *schedule {
+	parsedSchedules.Lock()
+	defer parsedSchedules.Unlock()
+	s, ok := parsedSchedules.m[expr]
+	if !ok {
+		var err error
+		s, err = parseSchedule(expr)
+		if err != nil {
+			log15.Warn("executor: invalid saved query schedule (using the default interval)", "error", err)
+		}
+		parsedSchedules.m[expr] = s
+	}
+	return s
+}
+
+// parseScheduleField parses a comma-separated list of values, ranges ("a-b")
+// and "*", each optionally with a step ("/n").
+func parseScheduleField(field string, min, max uint) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		rangeExpr, step := part, uint(1)
+		if i := strings.Index(part, "/"); i != -1 {
+			rangeExpr = part[:i]
+			n, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || n == 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = uint(n)
+		}
+
+		var lo, hi uint
+		switch {
+		case rangeExpr == "*":
+			lo, hi = min, max
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			if lo, err = parseScheduleValue(bounds[0], min, max); err != nil {
+				return 0, err
+			}
+			if hi, err = parseScheduleValue(bounds[1], min, max); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rangeExpr)
+			}
+		default:
+			if lo, err = parseScheduleValue(rangeExpr, min, max); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if step != 1 {
+				hi = max // "a/n" means "a-max/n"
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func parseScheduleValue(s string, min, max uint) (uint, error) {
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil || uint(v) < min || uint(v) > max {
+		return 0, fmt.Errorf("value %q is not in the range %d-%d", s, min, max)
+	}
+	return uint(v), nil
+}
+
+// next returns the first time after t that matches the schedule, or the zero
+// time if there is none within the next 5 years (e.g. "0 0 30 2 *").
+func (s *schedule) next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestSchedule(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2018, 10, 3, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "* * * * *", want: time.Date(2018, 10, 3, 10, 18, 0, 0, time.UTC)},
+		{expr: "*/30 * * * *", want: time.Date(2018, 10, 3, 10, 30, 0, 0, time.UTC)},
+		{expr: "@hourly", want: time.Date(2018, 10, 3, 11, 0, 0, 0, time.UTC)},
+		{expr: "@daily", want: time.Date(2018, 10, 4, 0, 0, 0, 0, time.UTC)},
+		{expr: "@weekly", want: time.Date(2018, 10, 7, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", want: time.Date(2018, 10, 7, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", want: time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 9 * * 1-5", want: time.Date(2018, 10, 4, 9, 0, 0, 0, time.UTC)},
+		{expr: "15,45 10 * * *", want: time.Date(2018, 10, 3, 10, 45, 0, 0, time.UTC)},
+		{expr: "0 12 1 1 *", want: time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC)},
+		// Either the day of month or the day of week matches.
+		{expr: "0 0 5 * 4", want: time.Date(2018, 10, 4, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", want: time.Time{}},
+	}
+	for _, test := range tests {
+		s, err := parseSchedule(test.expr)
+		if err != nil {
+			t.Errorf("%q: %s", test.expr, err)
+			continue
+		}
+		if got := s.next(now); !got.Equal(test.want) {
+			t.Errorf("%q: got next %s, want %s", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestParseSchedule_invalid(t *testing.T) {
+	for _, expr := range []string{"", "@yearly", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := parseSchedule(expr); err == nil {
+			t.Errorf("%q: got no error", expr)
+		}
+	}
+}
+
+func TestExecutor_isDue(t *testing.T) {
+	e := &executorT{}
+	hourly := api.ConfigSavedQuery{Schedule: "@hourly"}
+	minutely := api.ConfigSavedQuery{Schedule: "* * * * *"}
+	ago := func(d time.Duration) *api.SavedQueryInfo {
+		return &api.SavedQueryInfo{LastExecuted: time.Now().Add(-d), ExecDuration: 10 * time.Second}
+	}
+
+	tests := []struct {
+		name  string
+		info  *api.SavedQueryInfo
+		query api.ConfigSavedQuery
+		force bool
+		want  bool
+	}{
+		{name: "default interval elapsed", info: ago(6 * time.Minute), want: true},
+		{name: "default interval not elapsed", info: ago(4 * time.Minute), want: false},
+		{name: "schedule due", info: ago(61 * time.Minute), query: hourly, want: true},
+		{name: "schedule not due", info: ago(time.Second), query: hourly, want: false},
+		{name: "schedule more often than the minimum interval", info: ago(2 * time.Minute), query: minutely, want: false},
+		{name: "forced", info: ago(6 * time.Minute), query: hourly, force: true, want: true},
+		{name: "forced more often than the minimum interval", info: ago(time.Minute), query: hourly, force: true, want: false},
+	}
+	for _, test := range tests {
+		if got := e.isDue(test.info, test.query, test.force); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"strings"
 
 	log15 "gopkg.in/inconshreveable/log15.v2"
 
@@ -11,23 +13,54 @@ import (
 )
 
 func (n *notifier) slackNotify(ctx context.Context) {
+	for _, recipient := range n.recipients {
+		if !recipient.slack {
+			continue
+		}
+		added, err := readableMatches(ctx, recipient, n.added)
+		if err != nil {
+			log15.Error("Failed to check which new results the Slack notification recipient can read.", "recipient", recipient, "error", err)
+			continue
+		}
+		if len(added) == 0 {
+			continue
+		}
+		text := n.slackText(added)
+		if err := slackNotify(ctx, recipient, text); err != nil {
+			log15.Error("Failed to post Slack notification message.", "recipient", recipient, "text", text, "error", err)
+		}
+	}
+	logEvent("", "SavedSearchSlackNotificationSent", "results")
+}
+
+// slackText returns the text of the Slack message listing the new matches.
+func (n *notifier) slackText(added []*searchMatch) string {
 	plural := ""
-	if n.results.Data.Search.Results.ApproximateResultCount != "1" {
+	if len(added) != 1 {
 		plural = "s"
 	}
 
-	text := fmt.Sprintf(`*%s* new result%s found for saved search <%s|"%s">`,
-		n.results.Data.Search.Results.ApproximateResultCount,
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `*%d* new result%s found for saved search <%s|"%s">`,
+		len(added),
 		plural,
-		searchURL(n.newQuery, utmSourceSlack),
-		n.query.Description,
+		searchURL(n.query.Query, utmSourceSlack),
+		slackEscape(n.query.Description),
 	)
-	for _, recipient := range n.recipients {
-		if err := slackNotify(ctx, recipient, text); err != nil {
-			log15.Error("Failed to post Slack notification message.", "recipient", recipient, "text", text, "error", err)
+	for i, m := range added {
+		if i == maxNotificationMatches {
+			fmt.Fprintf(&buf, "\n...and %d more", len(added)-i)
+			break
+		}
+		fmt.Fprintf(&buf, "\n• <%s|%s>", matchURL(m, utmSourceSlack), slackEscape(m.title()))
+		if m.Preview != "" {
+			fmt.Fprintf(&buf, " `%s`", slackEscape(strings.Replace(m.Preview, "`", "'", -1)))
 		}
 	}
-	logEvent("", "SavedSearchSlackNotificationSent", "results")
+	if n.removed > 0 {
+		fmt.Fprintf(&buf, "\n_%d previous result(s) no longer match_", n.removed)
+	}
+	return buf.String()
 }
 
 func slackNotifySubscribed(ctx context.Context, recipient *recipient, query api.SavedQuerySpecAndConfig) error {
@@ -77,3 +110,11 @@ func slackNotify(ctx context.Context, recipient *recipient, text string) error {
 	client := slack.New(settings.NotificationsSlack.WebhookURL, true)
 	return slack.Post(payload, client.WebhookURL)
 }
+
+// slackEscape escapes the characters that have a special meaning in Slack
+// message text.
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
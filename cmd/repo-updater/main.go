@@ -107,6 +107,14 @@ func main() {
 	go repos.SyncBitbucketServerConnections(ctx)
 	go repos.RunBitbucketServerRepositorySyncWorker(ctx)
 
+	// Bitbucket Cloud connections and repos syncing threads
+	go repos.SyncBitbucketCloudConnections(ctx)
+	go repos.RunBitbucketCloudRepositorySyncWorker(ctx)
+
+	// Gitea connections and repos syncing threads
+	go repos.SyncGiteaConnections(ctx)
+	go repos.RunGiteaRepositorySyncWorker(ctx)
+
 	// Start other repos syncer syncing thread
 	go func() { log.Fatal(syncer.Run(ctx, repos.GetUpdateInterval())) }()
 
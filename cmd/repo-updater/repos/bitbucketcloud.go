@@ -0,0 +1,372 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/atomicvalue"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/time/rate"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+var bitbucketCloudConnections = func() *atomicvalue.Value {
+	c := atomicvalue.New()
+	c.Set(func() interface{} {
+		return []*bitbucketCloudConnection{}
+	})
+	return c
+}()
+
+// SyncBitbucketCloudConnections periodically syncs connections from
+// the Frontend API.
+func SyncBitbucketCloudConnections(ctx context.Context) {
+	t := time.NewTicker(configWatchInterval)
+	var lastConfig []*schema.BitbucketCloudConnection
+	for range t.C {
+		config, err := conf.BitbucketCloudConfigs(ctx)
+		if err != nil {
+			log15.Error("unable to fetch Bitbucket Cloud configs", "err", err)
+			continue
+		}
+
+		if reflect.DeepEqual(config, lastConfig) {
+			continue
+		}
+		lastConfig = config
+
+		var conns []*bitbucketCloudConnection
+		for _, c := range config {
+			conn, err := newBitbucketCloudConnection(c)
+			if err != nil {
+				log15.Error("Error processing configured Bitbucket Cloud connection. Skipping it.", "url", c.Url, "error", err)
+				continue
+			}
+			conns = append(conns, conn)
+		}
+
+		bitbucketCloudConnections.Set(func() interface{} {
+			return conns
+		})
+
+		bitbucketCloudWorker.restart()
+	}
+}
+
+// getBitbucketCloudConnection returns the Bitbucket Cloud connection (config + API client) that is
+// responsible for the repository specified by the args.
+func getBitbucketCloudConnection(args protocol.RepoLookupArgs) (*bitbucketCloudConnection, error) {
+	conns := bitbucketCloudConnections.Get().([]*bitbucketCloudConnection)
+	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == bitbucketcloud.ServiceType {
+		// Look up by external repository spec.
+		for _, conn := range conns {
+			if args.ExternalRepo.ServiceID == conn.baseURL.String() {
+				return conn, nil
+			}
+		}
+		return nil, errors.Errorf("no configured Bitbucket Cloud connection with URL: %q", args.ExternalRepo.ServiceID)
+	}
+
+	if args.Repo != "" {
+		// Look up by repository name.
+		repo := strings.ToLower(string(args.Repo))
+		for _, conn := range conns {
+			if strings.HasPrefix(repo, conn.baseURL.Hostname()+"/") {
+				return conn, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// GetBitbucketCloudRepository queries a configured Bitbucket Cloud connection endpoint for
+// information about the specified repository.
+//
+// If args.Repo refers to a repository that is not known to be on a configured Bitbucket Cloud
+// connection's host, it returns authoritative == false.
+func GetBitbucketCloudRepository(ctx context.Context, args protocol.RepoLookupArgs) (repo *protocol.RepoInfo, authoritative bool, err error) {
+	conn, err := getBitbucketCloudConnection(args)
+	if err != nil {
+		return nil, true, err // refers to a Bitbucket Cloud repo but the host is not configured
+	}
+	if conn == nil {
+		return nil, false, nil // refers to a non-Bitbucket Cloud repo
+	}
+
+	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == bitbucketcloud.ServiceType {
+		// Look up by external repository spec (the UUID), so that renamed and transferred
+		// repositories are found.
+		r, err := conn.client.Repo(ctx, "", args.ExternalRepo.ID)
+		if err == nil {
+			return conn.repoInfo(r), true, nil
+		}
+		if !errcode.IsNotFound(err) || args.Repo == "" {
+			return nil, true, err
+		}
+	}
+
+	if args.Repo != "" {
+		// Look up by repository name.
+		nameWithOwner := strings.TrimPrefix(strings.ToLower(string(args.Repo)), conn.baseURL.Hostname()+"/")
+		parts := strings.Split(nameWithOwner, "/")
+		if len(parts) != 2 {
+			return nil, true, errors.Errorf("malformed Bitbucket Cloud repository name: %q", args.Repo)
+		}
+		r, err := conn.client.Repo(ctx, parts[0], parts[1])
+		if err != nil {
+			return nil, true, err
+		}
+		return conn.repoInfo(r), true, nil
+	}
+
+	return nil, true, fmt.Errorf("unable to look up Bitbucket Cloud repository (%+v)", args)
+}
+
+var bitbucketCloudWorker = &worker{
+	work: func(ctx context.Context, shutdown chan struct{}) {
+		for _, c := range bitbucketCloudConnections.Get().([]*bitbucketCloudConnection) {
+			go func(c *bitbucketCloudConnection) {
+				for {
+					updateBitbucketCloudRepos(ctx, c)
+					bitbucketCloudUpdateTime.WithLabelValues(c.baseURL.String()).Set(float64(time.Now().Unix()))
+					select {
+					case <-shutdown:
+						return
+					case <-time.After(GetUpdateInterval()):
+					}
+				}
+			}(c)
+		}
+	},
+}
+
+// RunBitbucketCloudRepositorySyncWorker runs the worker that syncs repositories from configured
+// Bitbucket Cloud accounts to Sourcegraph.
+func RunBitbucketCloudRepositorySyncWorker(ctx context.Context) {
+	bitbucketCloudWorker.start(ctx)
+}
+
+// updateBitbucketCloudRepos ensures that all provided repositories exist in the repository table.
+func updateBitbucketCloudRepos(ctx context.Context, conn *bitbucketCloudConnection) {
+	repoChan := make(chan repoCreateOrUpdateRequest)
+	defer close(repoChan)
+	sourceID := conn.config.Username
+	if sourceID == "" {
+		sourceID = conn.config.OauthKey
+	}
+	go createEnableUpdateRepos(ctx, fmt.Sprintf("bitbucketcloud:%s", sourceID), nil, repoChan)
+	for r := range conn.listAllRepos(ctx, nil) {
+		ri := conn.repoInfo(r)
+		if ri.VCS.URL == "" {
+			continue
+		}
+		repoChan <- repoCreateOrUpdateRequest{
+			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
+				RepoName:     ri.Name,
+				ExternalRepo: ri.ExternalRepo,
+				Description:  ri.Description,
+				Fork:         ri.Fork,
+				Enabled:      conn.config.InitialRepositoryEnablement,
+			},
+			URL: ri.VCS.URL,
+		}
+	}
+}
+
+// Bitbucket Cloud allows 1,000 requests per hour to the repositories API for each user. We keep
+// a fifth of them for repository lookups by users and for other clients of the same account.
+const (
+	bitbucketCloudRateLimitRequestsPerHour  = 800
+	bitbucketCloudRateLimitMaxBurstRequests = 100
+)
+
+func newBitbucketCloudConnection(config *schema.BitbucketCloudConnection) (*bitbucketCloudConnection, error) {
+	rawBaseURL := config.Url
+	if rawBaseURL == "" {
+		rawBaseURL = "https://bitbucket.org"
+	}
+	baseURL, err := url.Parse(rawBaseURL)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = NormalizeBaseURL(baseURL)
+
+	rawAPIURL := config.ApiURL
+	if rawAPIURL == "" {
+		rawAPIURL = "https://api.bitbucket.org"
+	}
+	apiURL, err := url.Parse(rawAPIURL)
+	if err != nil {
+		return nil, err
+	}
+	apiURL = NormalizeBaseURL(apiURL)
+
+	transport, err := cachedTransportWithCertTrusted("")
+	if err != nil {
+		return nil, err
+	}
+
+	client := &bitbucketcloud.Client{
+		URL:         apiURL,
+		Username:    config.Username,
+		AppPassword: config.AppPassword,
+		HTTPClient: &http.Client{
+			Transport: bitbucketcloud.WithRequestCounter(transport),
+		},
+		RateLimit: rate.NewLimiter(rate.Every(time.Hour/bitbucketCloudRateLimitRequestsPerHour), bitbucketCloudRateLimitMaxBurstRequests),
+	}
+	if config.OauthKey != "" {
+		cc := clientcredentials.Config{
+			ClientID:     config.OauthKey,
+			ClientSecret: config.OauthSecret,
+			TokenURL:     bitbucketcloud.TokenURL,
+		}
+		client.TokenSource = cc.TokenSource(context.Background())
+	}
+
+	return &bitbucketCloudConnection{
+		config:  config,
+		baseURL: baseURL,
+		client:  client,
+	}, nil
+}
+
+type bitbucketCloudConnection struct {
+	config  *schema.BitbucketCloudConnection
+	baseURL *url.URL // normalized web URL, such as https://bitbucket.org/
+	client  *bitbucketcloud.Client
+}
+
+// repoInfo returns the protocol.RepoInfo of a Bitbucket Cloud repository.
+func (c *bitbucketCloudConnection) repoInfo(repo *bitbucketcloud.Repo) *protocol.RepoInfo {
+	root := repo.Links.HTML.Href
+	return &protocol.RepoInfo{
+		Name:         reposource.BitbucketCloudRepoName(c.config.RepositoryPathPattern, c.baseURL.Hostname(), repo.FullName),
+		ExternalRepo: bitbucketcloud.ExternalRepoSpec(repo, *c.baseURL),
+		Description:  repo.Description,
+		Fork:         repo.Parent != nil,
+		VCS: protocol.VCSInfo{
+			URL: c.authenticatedRemoteURL(repo),
+		},
+		Links: &protocol.RepoLinks{
+			Root:   root,
+			Tree:   root + "/src/{rev}/{path}",
+			Blob:   root + "/src/{rev}/{path}",
+			Commit: root + "/commits/{commit}",
+		},
+	}
+}
+
+// authenticatedRemoteURL returns the repository's Git remote URL with the configured app password
+// (or an OAuth access token) inserted in the URL userinfo. It returns the empty string for
+// Mercurial repositories.
+func (c *bitbucketCloudConnection) authenticatedRemoteURL(repo *bitbucketcloud.Repo) string {
+	if repo.SCM != "git" {
+		return ""
+	}
+
+	var cloneURL string
+	for _, l := range repo.Links.Clone {
+		if l.Name == "ssh" && c.config.GitURLType == "ssh" {
+			return l.Href // SSH authentication must be provided out-of-band
+		}
+		if l.Name == "https" {
+			cloneURL = l.Href
+		}
+	}
+	if cloneURL == "" {
+		return ""
+	}
+
+	if c.client.TokenSource == nil {
+		return setUserinfoBestEffort(cloneURL, c.config.Username, c.config.AppPassword)
+	}
+
+	// OAuth access tokens expire after a few hours, but the remote URL is refreshed on every
+	// sync.
+	tok, err := c.client.TokenSource.Token()
+	if err != nil {
+		log15.Warn("Error getting Bitbucket Cloud OAuth token for repository Git remote URL.", "url", cloneURL, "error", err)
+		return cloneURL
+	}
+	u, err := url.Parse(cloneURL)
+	if err != nil {
+		log15.Warn("Error adding authentication to Bitbucket Cloud repository Git remote URL.", "url", cloneURL, "error", err)
+		return cloneURL
+	}
+	u.User = url.UserPassword("x-token-auth", tok.AccessToken)
+	return u.String()
+}
+
+// listAllRepos lists the repositories of the connection, recording listing errors in status (which
+// may be nil).
+func (c *bitbucketCloudConnection) listAllRepos(ctx context.Context, status *syncStatus) <-chan *bitbucketcloud.Repo {
+	const perPage = 100 // max Bitbucket Cloud API pagelen parameter
+	ch := make(chan *bitbucketcloud.Repo, perPage)
+	go func() {
+		defer close(ch)
+
+		seen := make(map[string]bool)
+		send := func(r *bitbucketcloud.Repo) {
+			if !seen[r.UUID] {
+				seen[r.UUID] = true
+				ch <- r
+			}
+		}
+
+		for _, nameWithOwner := range c.config.Repos {
+			parts := strings.SplitN(nameWithOwner, "/", 2)
+			if len(parts) != 2 {
+				log15.Error("Skipping invalid Bitbucket Cloud repository", "repo", nameWithOwner)
+				status.listError(fmt.Errorf("invalid repository %q", nameWithOwner))
+				continue
+			}
+			r, err := c.client.Repo(ctx, parts[0], parts[1])
+			if err != nil {
+				log15.Error("Error getting Bitbucket Cloud repository", "repo", nameWithOwner, "error", err)
+				status.listError(errors.Wrapf(err, "getting repository %s", nameWithOwner))
+				continue
+			}
+			send(r)
+		}
+
+		teams := c.config.Teams
+		if len(teams) == 0 && len(c.config.Repos) == 0 {
+			teams = []string{""} // the repositories the user is a member of
+		}
+		for _, team := range teams {
+			page := &bitbucketcloud.PageToken{Pagelen: perPage}
+			for {
+				repos, next, err := c.client.Repos(ctx, page, team)
+				if err != nil {
+					log15.Error("Error listing Bitbucket Cloud repositories", "team", team, "error", err)
+					status.listError(errors.Wrapf(err, "listing repositories of team %q", team))
+					break
+				}
+				for _, r := range repos {
+					send(r)
+				}
+				if !next.HasMore() {
+					break
+				}
+				page = next
+			}
+		}
+	}()
+
+	return ch
+}
@@ -0,0 +1,140 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketcloud"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func testBitbucketCloudRepo(team, name, scm string) *bitbucketcloud.Repo {
+	r := &bitbucketcloud.Repo{
+		UUID:     "{" + team + "-" + name + "}",
+		FullName: team + "/" + name,
+		Slug:     name,
+		Name:     name,
+		SCM:      scm,
+	}
+	r.Links.HTML.Href = "https://bitbucket.org/" + r.FullName
+	r.Links.Clone = []bitbucketcloud.Link{
+		{Href: "https://alice@bitbucket.org/" + r.FullName + ".git", Name: "https"},
+		{Href: "git@bitbucket.org:" + r.FullName + ".git", Name: "ssh"},
+	}
+	return r
+}
+
+func TestBitbucketCloudConnection_listAllRepos(t *testing.T) {
+	teams := map[string][]*bitbucketcloud.Repo{
+		"a": {testBitbucketCloudRepo("a", "1", "git"), testBitbucketCloudRepo("a", "2", "git"), testBitbucketCloudRepo("a", "3", "hg")},
+		"b": {testBitbucketCloudRepo("b", "1", "git")},
+	}
+	member := []*bitbucketcloud.Repo{teams["a"][1], teams["b"][0]}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "alice" || pass != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var repos []*bitbucketcloud.Repo
+		switch r.URL.Path {
+		case "/2.0/repositories":
+			repos = member
+		case "/2.0/repositories/a", "/2.0/repositories/b":
+			repos = teams[r.URL.Path[len("/2.0/repositories/"):]]
+		case "/2.0/repositories/b/1":
+			json.NewEncoder(w).Encode(teams["b"][0])
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// Serve one repository per page.
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		resp := map[string]interface{}{"page": page, "pagelen": 1, "values": repos[page-1 : page]}
+		if page < len(repos) {
+			q := r.URL.Query()
+			q.Set("page", strconv.Itoa(page+1))
+			resp["next"] = srv.URL + r.URL.Path + "?" + q.Encode()
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	apiURL, _ := url.Parse(srv.URL + "/")
+	tests := []struct {
+		config schema.BitbucketCloudConnection
+		want   []string
+	}{
+		{
+			config: schema.BitbucketCloudConnection{},
+			want:   []string{"a/2", "b/1"},
+		},
+		{
+			config: schema.BitbucketCloudConnection{Repos: []string{"b/1"}, Teams: []string{"a", "b"}},
+			want:   []string{"b/1", "a/1", "a/2", "a/3"},
+		},
+		{
+			config: schema.BitbucketCloudConnection{Repos: []string{"b/1", "c/404"}},
+			want:   []string{"b/1"},
+		},
+	}
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			test.config.Username, test.config.AppPassword = "alice", "pw"
+			conn := &bitbucketCloudConnection{
+				config: &test.config,
+				client: &bitbucketcloud.Client{URL: apiURL, Username: "alice", AppPassword: "pw", HTTPClient: srv.Client()},
+			}
+
+			var got []string
+			for r := range conn.listAllRepos(context.Background(), nil) {
+				got = append(got, r.FullName)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBitbucketCloudConnection_authenticatedRemoteURL(t *testing.T) {
+	tests := []struct {
+		config schema.BitbucketCloudConnection
+		repo   *bitbucketcloud.Repo
+		want   string
+	}{
+		{
+			config: schema.BitbucketCloudConnection{Username: "alice", AppPassword: "pw"},
+			repo:   testBitbucketCloudRepo("a", "1", "git"),
+			want:   "https://alice:pw@bitbucket.org/a/1.git",
+		},
+		{
+			config: schema.BitbucketCloudConnection{Username: "alice", AppPassword: "pw", GitURLType: "ssh"},
+			repo:   testBitbucketCloudRepo("a", "1", "git"),
+			want:   "git@bitbucket.org:a/1.git",
+		},
+		{
+			config: schema.BitbucketCloudConnection{Username: "alice", AppPassword: "pw"},
+			repo:   testBitbucketCloudRepo("a", "1", "hg"),
+			want:   "",
+		},
+	}
+	for _, test := range tests {
+		conn := &bitbucketCloudConnection{config: &test.config, client: &bitbucketcloud.Client{}}
+		if got := conn.authenticatedRemoteURL(test.repo); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.config, got, test.want)
+		}
+	}
+}
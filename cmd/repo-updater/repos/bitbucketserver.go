@@ -262,8 +262,8 @@ func updateBitbucketServerRepos(ctx context.Context, conn *bitbucketServerConnec
 	if sourceID == "" {
 		sourceID = conn.config.Username
 	}
-	go createEnableUpdateRepos(ctx, fmt.Sprintf("bitbucket:%s", sourceID), repoChan)
-	for r := range conn.listAllRepos(ctx) {
+	go createEnableUpdateRepos(ctx, fmt.Sprintf("bitbucket:%s", sourceID), nil, repoChan)
+	for r := range conn.listAllRepos(ctx, nil) {
 		if r.State != "AVAILABLE" {
 			continue
 		}
@@ -344,7 +344,9 @@ type bitbucketServerConnection struct {
 	client *bitbucketserver.Client
 }
 
-func (c *bitbucketServerConnection) listAllRepos(ctx context.Context) <-chan *bitbucketserver.Repo {
+// listAllRepos lists the repositories of the connection, recording listing errors in status (which
+// may be nil).
+func (c *bitbucketServerConnection) listAllRepos(ctx context.Context, status *syncStatus) <-chan *bitbucketserver.Repo {
 	perPage := 100
 	ch := make(chan *bitbucketserver.Repo, perPage)
 	go func() {
@@ -354,6 +356,7 @@ func (c *bitbucketServerConnection) listAllRepos(ctx context.Context) <-chan *bi
 		repos, _, err := c.client.RecentRepos(ctx, &bitbucketserver.PageToken{Limit: perPage})
 		if err != nil {
 			log15.Warn("failed to list recent repos for Bitbucket Server", "url", c.client.URL, "error", err)
+			status.listError(errors.Wrap(err, "listing recent repositories"))
 		}
 		recent := map[int]bool{}
 		for _, r := range repos {
@@ -371,6 +374,7 @@ func (c *bitbucketServerConnection) listAllRepos(ctx context.Context) <-chan *bi
 			repos, page, err = c.client.Repos(ctx, page)
 			if err != nil {
 				log15.Error("failed when listing Bitbucket Server repos", "url", c.client.URL, "error", err)
+				status.listError(errors.Wrap(err, "listing repositories"))
 				return
 			}
 			for _, r := range repos {
@@ -0,0 +1,218 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// DryRun lists the repositories of the proposed configuration of an external service and
+// compares them with the repositories of its current configuration, without adding, updating or
+// removing any repositories. current is nil if the external service does not exist yet.
+//
+// Repositories are matched by their external repository (see api.ExternalRepoSpec), so that
+// changing how repositories are named (such as the repositoryPathPattern of a GitHub connection)
+// shows up as renames.
+func DryRun(ctx context.Context, current *api.ExternalService, proposed *api.ExternalService) (*protocol.ExternalServiceDryRunResult, error) {
+	if current != nil && current.Kind != proposed.Kind {
+		return nil, fmt.Errorf("can't change the kind of external service %d from %s to %s", current.ID, current.Kind, proposed.Kind)
+	}
+
+	var (
+		wg                  sync.WaitGroup
+		before, after       []*protocol.RepoInfo
+		beforeErr, afterErr error
+		beforeErrs          []string
+		afterErrs           []string
+	)
+	if current != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			before, beforeErrs, beforeErr = listExternalServiceRepos(ctx, current)
+		}()
+	}
+	after, afterErrs, afterErr = listExternalServiceRepos(ctx, proposed)
+	wg.Wait()
+	if afterErr != nil {
+		return nil, afterErr
+	}
+	if beforeErr != nil {
+		return nil, errors.Wrap(beforeErr, "current configuration")
+	}
+
+	res := diffRepos(before, after)
+	for _, err := range beforeErrs {
+		res.Errors = append(res.Errors, "current configuration: "+err)
+	}
+	res.Errors = append(res.Errors, afterErrs...)
+	return res, nil
+}
+
+// listExternalServiceRepos lists the repositories of the external service that a sync would add
+// or update, along with the errors encountered listing them.
+func listExternalServiceRepos(ctx context.Context, svc *api.ExternalService) ([]*protocol.RepoInfo, []string, error) {
+	// The status only collects the errors, it is never recorded.
+	status := &syncStatus{repos: map[api.RepoName]bool{}, complete: true}
+
+	var repos []*protocol.RepoInfo
+	switch svc.Kind {
+	case "GITHUB":
+		var c schema.GitHubConnection
+		if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+			return nil, nil, fmt.Errorf("config error: %s", err)
+		}
+		conn, err := newGitHubConnection(svc.ID, &c)
+		if err != nil {
+			return nil, nil, err
+		}
+		for repo := range conn.listAllRepositories(ctx, status) {
+			repos = append(repos, &protocol.RepoInfo{
+				Name:         githubRepositoryToRepoPath(conn, repo),
+				ExternalRepo: github.ExternalRepoSpec(repo, *conn.baseURL),
+			})
+		}
+
+	case "GITLAB":
+		var c schema.GitLabConnection
+		if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+			return nil, nil, fmt.Errorf("config error: %s", err)
+		}
+		conn, err := newGitLabConnection(svc.ID, &c)
+		if err != nil {
+			return nil, nil, err
+		}
+		for proj := range conn.listAllProjects(ctx, status) {
+			repos = append(repos, &protocol.RepoInfo{
+				Name:         gitlabProjectToRepoPath(conn, proj),
+				ExternalRepo: gitlab.ExternalRepoSpec(proj, *conn.baseURL),
+			})
+		}
+
+	case "BITBUCKETSERVER":
+		var c schema.BitbucketServerConnection
+		if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+			return nil, nil, fmt.Errorf("config error: %s", err)
+		}
+		conn, err := newBitbucketServerConnection(&c)
+		if err != nil {
+			return nil, nil, err
+		}
+		for repo := range conn.listAllRepos(ctx, status) {
+			if repo.State != "AVAILABLE" {
+				continue
+			}
+			if info := bitbucketServerRepoInfo(conn.config, repo); info != nil && info.VCS.URL != "" {
+				repos = append(repos, info)
+			}
+		}
+
+	case "BITBUCKETCLOUD":
+		var c schema.BitbucketCloudConnection
+		if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+			return nil, nil, fmt.Errorf("config error: %s", err)
+		}
+		conn, err := newBitbucketCloudConnection(&c)
+		if err != nil {
+			return nil, nil, err
+		}
+		for repo := range conn.listAllRepos(ctx, status) {
+			if info := conn.repoInfo(repo); info.VCS.URL != "" {
+				repos = append(repos, info)
+			}
+		}
+
+	case "GITEA":
+		var c schema.GiteaConnection
+		if err := jsonc.Unmarshal(svc.Config, &c); err != nil {
+			return nil, nil, fmt.Errorf("config error: %s", err)
+		}
+		conn, err := newGiteaConnection(&c)
+		if err != nil {
+			return nil, nil, err
+		}
+		for repo := range conn.listAllRepositories(ctx, status) {
+			repos = append(repos, conn.repoInfo(repo))
+		}
+
+	case "OTHER":
+		cloneURLs, err := otherExternalServiceCloneURLs(svc)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, u := range cloneURLs {
+			repos = append(repos, repoFromCloneURL(u))
+		}
+
+	default:
+		// AWSCODECOMMIT, GITOLITE and PHABRICATOR.
+		return nil, nil, fmt.Errorf("dry runs are not supported for external services of kind %s", svc.Kind)
+	}
+
+	if err := ctx.Err(); err != nil {
+		status.listError(errors.Wrap(err, "listing repositories did not complete"))
+	}
+	return repos, status.status.Errors, nil
+}
+
+// diffRepos compares the repositories that a sync of the current and the proposed configuration of
+// an external service would add or update.
+func diffRepos(before, after []*protocol.RepoInfo) *protocol.ExternalServiceDryRunResult {
+	type key struct {
+		spec api.ExternalRepoSpec
+		name api.RepoName // only used if there is no external repository
+	}
+	keyOf := func(repo *protocol.RepoInfo) key {
+		if repo.ExternalRepo != nil {
+			return key{spec: *repo.ExternalRepo}
+		}
+		return key{name: repo.Name}
+	}
+
+	beforeByKey := make(map[key]api.RepoName, len(before))
+	for _, repo := range before {
+		beforeByKey[keyOf(repo)] = repo.Name
+	}
+
+	res := protocol.ExternalServiceDryRunResult{
+		Added:   []api.RepoName{},
+		Removed: []api.RepoName{},
+		Renamed: []protocol.RepoRename{},
+	}
+	seen := make(map[key]bool, len(after))
+	for _, repo := range after {
+		k := keyOf(repo)
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		switch name, ok := beforeByKey[k]; {
+		case !ok:
+			res.Added = append(res.Added, repo.Name)
+		case name != repo.Name:
+			res.Renamed = append(res.Renamed, protocol.RepoRename{From: name, To: repo.Name})
+		default:
+			res.Unchanged++
+		}
+	}
+	for k, name := range beforeByKey {
+		if !seen[k] {
+			res.Removed = append(res.Removed, name)
+		}
+	}
+
+	sort.Slice(res.Added, func(i, j int) bool { return res.Added[i] < res.Added[j] })
+	sort.Slice(res.Removed, func(i, j int) bool { return res.Removed[i] < res.Removed[j] })
+	sort.Slice(res.Renamed, func(i, j int) bool { return res.Renamed[i].To < res.Renamed[j].To })
+	return &res
+}
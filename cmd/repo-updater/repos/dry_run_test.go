@@ -0,0 +1,111 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+)
+
+func TestDiffRepos(t *testing.T) {
+	repo := func(name api.RepoName, id string) *protocol.RepoInfo {
+		r := &protocol.RepoInfo{Name: name}
+		if id != "" {
+			r.ExternalRepo = &api.ExternalRepoSpec{ID: id, ServiceType: "github", ServiceID: "https://github.com/"}
+		}
+		return r
+	}
+
+	before := []*protocol.RepoInfo{
+		repo("github.com/a/removed", "1"),
+		repo("github.com/a/renamed", "2"),
+		repo("github.com/a/unchanged", "3"),
+		repo("example.com/no-external-repo", ""),
+	}
+	after := []*protocol.RepoInfo{
+		repo("github.com/a/unchanged", "3"),
+		repo("ghe/a/renamed", "2"),
+		repo("github.com/a/added", "4"),
+		repo("github.com/a/added", "4"), // listed twice, such as by two repositoryQuery values
+		repo("example.com/no-external-repo", ""),
+	}
+
+	want := &protocol.ExternalServiceDryRunResult{
+		Added:     []api.RepoName{"github.com/a/added"},
+		Removed:   []api.RepoName{"github.com/a/removed"},
+		Renamed:   []protocol.RepoRename{{From: "github.com/a/renamed", To: "ghe/a/renamed"}},
+		Unchanged: 2,
+	}
+	if have := diffRepos(before, after); !reflect.DeepEqual(have, want) {
+		t.Errorf("\nhave: %+v\nwant: %+v", have, want)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	current := &api.ExternalService{
+		ID:     1,
+		Kind:   "OTHER",
+		Config: `{"url": "https://git.example.com", "repos": ["a", "b"]}`,
+	}
+	proposed := &api.ExternalService{
+		ID:     1,
+		Kind:   "OTHER",
+		Config: `{"url": "https://git.example.com", "repos": ["b", "c"]}`,
+	}
+
+	have, err := DryRun(ctx, current, proposed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &protocol.ExternalServiceDryRunResult{
+		Added:     []api.RepoName{"git.example.com/c"},
+		Removed:   []api.RepoName{"git.example.com/a"},
+		Renamed:   []protocol.RepoRename{},
+		Unchanged: 1,
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("\nhave: %+v\nwant: %+v", have, want)
+	}
+
+	// A new external service has no current configuration.
+	have, err = DryRun(ctx, nil, proposed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []api.RepoName{"git.example.com/b", "git.example.com/c"}; !reflect.DeepEqual(have.Added, want) {
+		t.Errorf("have added %q, want %q", have.Added, want)
+	}
+
+	if _, err := DryRun(ctx, current, &api.ExternalService{ID: 1, Kind: "GITHUB"}); err == nil {
+		t.Error("expected changing the kind of an external service to fail")
+	}
+	if _, err := DryRun(ctx, nil, &api.ExternalService{Kind: "GITOLITE", Config: `{}`}); err == nil {
+		t.Error("expected a dry run of an unsupported kind to fail")
+	}
+}
+
+func TestDryRun_listError(t *testing.T) {
+	// A code host that rejects the token.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	proposed := &api.ExternalService{
+		Kind:   "GITEA",
+		Config: fmt.Sprintf(`{"url": %q, "token": "bad"}`, srv.URL),
+	}
+	have, err := DryRun(context.Background(), nil, proposed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(have.Errors) == 0 {
+		t.Error("expected the listing error to be reported")
+	}
+}
@@ -0,0 +1,336 @@
+package repos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/atomicvalue"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+var giteaConnections = func() *atomicvalue.Value {
+	c := atomicvalue.New()
+	c.Set(func() interface{} {
+		return []*giteaConnection{}
+	})
+	return c
+}()
+
+// SyncGiteaConnections periodically syncs connections from
+// the Frontend API.
+func SyncGiteaConnections(ctx context.Context) {
+	t := time.NewTicker(configWatchInterval)
+	var lastConfig []*schema.GiteaConnection
+	for range t.C {
+		config, err := conf.GiteaConfigs(ctx)
+		if err != nil {
+			log15.Error("unable to fetch Gitea configs", "err", err)
+			continue
+		}
+
+		if reflect.DeepEqual(config, lastConfig) {
+			continue
+		}
+		lastConfig = config
+
+		var conns []*giteaConnection
+		for _, c := range config {
+			conn, err := newGiteaConnection(c)
+			if err != nil {
+				log15.Error("Error processing configured Gitea connection. Skipping it.", "url", c.Url, "error", err)
+				continue
+			}
+			conns = append(conns, conn)
+		}
+
+		giteaConnections.Set(func() interface{} {
+			return conns
+		})
+
+		giteaRepositorySyncWorker.restart()
+	}
+}
+
+// getGiteaConnection returns the Gitea connection (config + API client) that is responsible for
+// the repository specified by the args.
+func getGiteaConnection(args protocol.RepoLookupArgs) (*giteaConnection, error) {
+	conns := giteaConnections.Get().([]*giteaConnection)
+	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == gitea.ServiceType {
+		// Look up by external repository spec.
+		for _, conn := range conns {
+			if args.ExternalRepo.ServiceID == conn.baseURL.String() {
+				return conn, nil
+			}
+		}
+		return nil, errors.Errorf("no configured Gitea connection with URL: %q", args.ExternalRepo.ServiceID)
+	}
+
+	if args.Repo != "" {
+		// Look up by repository name.
+		repo := strings.ToLower(string(args.Repo))
+		for _, conn := range conns {
+			if strings.HasPrefix(repo, conn.baseURL.Hostname()+"/") {
+				return conn, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// GetGiteaRepository queries a configured Gitea connection endpoint for information about the
+// specified repository.
+//
+// If args.Repo refers to a repository that is not known to be on a configured Gitea connection's
+// host, it returns authoritative == false.
+func GetGiteaRepository(ctx context.Context, args protocol.RepoLookupArgs) (repo *protocol.RepoInfo, authoritative bool, err error) {
+	conn, err := getGiteaConnection(args)
+	if err != nil {
+		return nil, true, err // refers to a Gitea repo but the host is not configured
+	}
+	if conn == nil {
+		return nil, false, nil // refers to a non-Gitea repo
+	}
+
+	if args.ExternalRepo != nil && args.ExternalRepo.ServiceType == gitea.ServiceType {
+		// Look up by external repository spec, so that renamed repositories are found.
+		id, err := strconv.ParseInt(args.ExternalRepo.ID, 10, 64)
+		if err != nil {
+			return nil, true, err
+		}
+		r, err := conn.client.GetRepositoryByID(ctx, id)
+		if err == nil {
+			return conn.repoInfo(r), true, nil
+		}
+		// Gogs can't look up repositories by ID, so fall back to the name.
+		if !errcode.IsNotFound(err) || args.Repo == "" {
+			return nil, true, err
+		}
+	}
+
+	if args.Repo != "" {
+		// Look up by repository name.
+		nameWithOwner := strings.TrimPrefix(strings.ToLower(string(args.Repo)), conn.baseURL.Hostname()+"/")
+		parts := strings.Split(nameWithOwner, "/")
+		if len(parts) != 2 {
+			return nil, true, errors.Errorf("malformed Gitea repository name: %q", args.Repo)
+		}
+		r, err := conn.client.GetRepository(ctx, parts[0], parts[1])
+		if err != nil {
+			return nil, true, err
+		}
+		return conn.repoInfo(r), true, nil
+	}
+
+	return nil, true, fmt.Errorf("unable to look up Gitea repository (%+v)", args)
+}
+
+var giteaRepositorySyncWorker = &worker{
+	work: func(ctx context.Context, shutdown chan struct{}) {
+		for _, c := range giteaConnections.Get().([]*giteaConnection) {
+			go func(c *giteaConnection) {
+				for {
+					updateGiteaRepositories(ctx, c)
+					giteaUpdateTime.WithLabelValues(c.baseURL.String()).Set(float64(time.Now().Unix()))
+					select {
+					case <-shutdown:
+						return
+					case <-time.After(GetUpdateInterval()):
+					}
+				}
+			}(c)
+		}
+	},
+}
+
+// RunGiteaRepositorySyncWorker runs the worker that syncs repositories from configured Gitea instances to
+// Sourcegraph.
+func RunGiteaRepositorySyncWorker(ctx context.Context) {
+	giteaRepositorySyncWorker.start(ctx)
+}
+
+// updateGiteaRepositories ensures that all provided repositories exist in the repository table.
+func updateGiteaRepositories(ctx context.Context, conn *giteaConnection) {
+	repoChan := make(chan repoCreateOrUpdateRequest)
+	defer close(repoChan)
+	go createEnableUpdateRepos(ctx, fmt.Sprintf("gitea:%s", conn.baseURL), nil, repoChan)
+	for r := range conn.listAllRepositories(ctx, nil) {
+		ri := conn.repoInfo(r)
+		repoChan <- repoCreateOrUpdateRequest{
+			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
+				RepoName:     ri.Name,
+				ExternalRepo: ri.ExternalRepo,
+				Description:  ri.Description,
+				Fork:         ri.Fork,
+				Archived:     ri.Archived,
+				Enabled:      conn.config.InitialRepositoryEnablement,
+			},
+			URL: ri.VCS.URL,
+		}
+	}
+}
+
+func newGiteaConnection(config *schema.GiteaConnection) (*giteaConnection, error) {
+	baseURL, err := url.Parse(config.Url)
+	if err != nil {
+		return nil, err
+	}
+	baseURL = NormalizeBaseURL(baseURL)
+
+	transport, err := cachedTransportWithCertTrusted(config.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return &giteaConnection{
+		config:  config,
+		baseURL: baseURL,
+		client: &gitea.Client{
+			URL:   baseURL,
+			Token: config.Token,
+			HTTPClient: &http.Client{
+				Transport: gitea.WithRequestCounter(transport),
+			},
+		},
+	}, nil
+}
+
+type giteaConnection struct {
+	config  *schema.GiteaConnection
+	baseURL *url.URL // normalized base URL (with trailing slash)
+	client  *gitea.Client
+}
+
+// repoInfo returns the protocol.RepoInfo of a Gitea repository.
+func (c *giteaConnection) repoInfo(repo *gitea.Repository) *protocol.RepoInfo {
+	return &protocol.RepoInfo{
+		Name:         reposource.GiteaRepoName(c.config.RepositoryPathPattern, c.baseURL.Hostname(), repo.FullName),
+		ExternalRepo: gitea.ExternalRepoSpec(repo, *c.baseURL),
+		Description:  repo.Description,
+		Fork:         repo.Fork,
+		Archived:     repo.Archived,
+		VCS: protocol.VCSInfo{
+			URL: c.authenticatedRemoteURL(repo),
+		},
+		Links: &protocol.RepoLinks{
+			Root: repo.HTMLURL,
+			// Gitea redirects /src/{rev} to the branch, tag or commit named
+			// rev. Gogs only supports this form.
+			Tree:   repo.HTMLURL + "/src/{rev}/{path}",
+			Blob:   repo.HTMLURL + "/src/{rev}/{path}",
+			Commit: repo.HTMLURL + "/commit/{commit}",
+		},
+	}
+}
+
+// authenticatedRemoteURL returns the repository's Git remote URL with the configured Gitea access
+// token inserted in the URL userinfo, for repositories needing authentication.
+func (c *giteaConnection) authenticatedRemoteURL(repo *gitea.Repository) string {
+	if c.config.GitURLType == "ssh" {
+		return repo.SSHURL // SSH authentication must be provided out-of-band
+	}
+	if c.config.Token == "" || !repo.Private {
+		return repo.CloneURL
+	}
+	// Gitea accepts the token as the password of any user.
+	return setUserinfoBestEffort(repo.CloneURL, "git", c.config.Token)
+}
+
+// listAllRepositories lists the repositories of the connection, recording listing errors in status
+// (which may be nil).
+func (c *giteaConnection) listAllRepositories(ctx context.Context, status *syncStatus) <-chan *gitea.Repository {
+	ch := make(chan *gitea.Repository, gitea.PerPage)
+	go func() {
+		defer close(ch)
+
+		seen := make(map[int64]bool)
+		send := func(r *gitea.Repository) {
+			if !seen[r.ID] {
+				seen[r.ID] = true
+				ch <- r
+			}
+		}
+
+		for _, nameWithOwner := range c.config.Repos {
+			parts := strings.SplitN(nameWithOwner, "/", 2)
+			if len(parts) != 2 {
+				log15.Error("Skipping invalid Gitea repository", "url", c.baseURL, "repo", nameWithOwner)
+				status.listError(fmt.Errorf("invalid repository %q", nameWithOwner))
+				continue
+			}
+			r, err := c.client.GetRepository(ctx, parts[0], parts[1])
+			if err != nil {
+				log15.Error("Error getting Gitea repository", "url", c.baseURL, "repo", nameWithOwner, "error", err)
+				status.listError(errors.Wrapf(err, "getting repository %s", nameWithOwner))
+				continue
+			}
+			send(r)
+		}
+
+		repositoryQuery := c.config.RepositoryQuery
+		if len(repositoryQuery) == 0 {
+			repositoryQuery = []string{"affiliated"}
+		}
+		for _, q := range repositoryQuery {
+			var list func(page int) ([]*gitea.Repository, error)
+			switch q {
+			case "none":
+				continue
+			case "affiliated":
+				list = func(page int) ([]*gitea.Repository, error) {
+					return c.client.ListUserRepositories(ctx, page)
+				}
+			case "public":
+				list = func(page int) ([]*gitea.Repository, error) {
+					return c.client.SearchRepositories(ctx, "", page)
+				}
+			default:
+				keyword := q
+				list = func(page int) ([]*gitea.Repository, error) {
+					return c.client.SearchRepositories(ctx, keyword, page)
+				}
+			}
+
+			// Stop at the first page without any repository we haven't seen
+			// for this query yet. This is an empty page past the end on
+			// Gitea, and the second page on Gogs, which ignores the page
+			// parameter of some endpoints and always returns everything.
+			queried := make(map[int64]bool)
+			for page := 1; ; page++ {
+				repos, err := list(page)
+				if err != nil {
+					log15.Error("Error listing Gitea repositories", "url", c.baseURL, "repositoryQuery", q, "page", page, "error", err)
+					status.listError(errors.Wrapf(err, "listing repositories for repositoryQuery %q", q))
+					break
+				}
+				fresh := 0
+				for _, r := range repos {
+					if !queried[r.ID] {
+						queried[r.ID] = true
+						fresh++
+					}
+					send(r)
+				}
+				if fresh == 0 {
+					break
+				}
+			}
+		}
+	}()
+
+	return ch
+}
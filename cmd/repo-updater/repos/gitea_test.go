@@ -0,0 +1,203 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitea"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// newTestGiteaConnection returns a connection to a fake Gitea server with the
+// given repositories. If gogs is true, the server behaves like Gogs: it
+// ignores the page parameter and can't look up repositories by ID.
+func newTestGiteaConnection(t *testing.T, config *schema.GiteaConnection, repos []*gitea.Repository, gogs bool) (*giteaConnection, func()) {
+	page := func(r *http.Request) []*gitea.Repository {
+		if gogs {
+			return repos
+		}
+		n, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		start, end := (n-1)*gitea.PerPage, n*gitea.PerPage
+		if start > len(repos) {
+			start = len(repos)
+		}
+		if end > len(repos) {
+			end = len(repos)
+		}
+		return repos[start:end]
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/user/repos", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token "+config.Token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(page(r))
+	})
+	mux.HandleFunc("/api/v1/repos/search", func(w http.ResponseWriter, r *http.Request) {
+		var data []*gitea.Repository
+		for _, repo := range page(r) {
+			if q := r.URL.Query().Get("q"); q == "" || q == repo.Name {
+				data = append(data, repo)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "data": data})
+	})
+	mux.HandleFunc("/api/v1/repos/", func(w http.ResponseWriter, r *http.Request) {
+		for _, repo := range repos {
+			if r.URL.Path == "/api/v1/repos/"+repo.FullName {
+				json.NewEncoder(w).Encode(repo)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v1/repositories/", func(w http.ResponseWriter, r *http.Request) {
+		for _, repo := range repos {
+			if !gogs && r.URL.Path == fmt.Sprintf("/api/v1/repositories/%d", repo.ID) {
+				json.NewEncoder(w).Encode(repo)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+
+	baseURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Url = srv.URL
+	return &giteaConnection{
+		config:  config,
+		baseURL: NormalizeBaseURL(baseURL),
+		client:  &gitea.Client{URL: NormalizeBaseURL(baseURL), Token: config.Token, HTTPClient: srv.Client()},
+	}, srv.Close
+}
+
+func testGiteaRepositories(n int) []*gitea.Repository {
+	var repos []*gitea.Repository
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("repo%d", i)
+		repos = append(repos, &gitea.Repository{
+			ID:       int64(i),
+			Name:     name,
+			FullName: "org/" + name,
+			HTMLURL:  "https://gitea.example.com/org/" + name,
+			CloneURL: "https://gitea.example.com/org/" + name + ".git",
+			Private:  i%2 == 0,
+		})
+	}
+	return repos
+}
+
+func TestGiteaConnection_listAllRepositories(t *testing.T) {
+	repos := testGiteaRepositories(gitea.PerPage + 10)
+
+	tests := []struct {
+		name   string
+		config schema.GiteaConnection
+		gogs   bool
+		want   []int64
+	}{
+		{
+			name:   "affiliated by default",
+			config: schema.GiteaConnection{Token: "t"},
+			want:   repoIDs(1, gitea.PerPage+10),
+		},
+		{
+			name:   "affiliated on Gogs",
+			config: schema.GiteaConnection{Token: "t"},
+			gogs:   true,
+			want:   repoIDs(1, gitea.PerPage+10),
+		},
+		{
+			name:   "repos and search are unioned",
+			config: schema.GiteaConnection{Repos: []string{"org/repo3", "org/missing"}, RepositoryQuery: []string{"repo7", "repo3"}},
+			want:   []int64{3, 7},
+		},
+		{
+			name:   "none",
+			config: schema.GiteaConnection{Repos: []string{"org/repo2"}, RepositoryQuery: []string{"none"}},
+			want:   []int64{2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn, done := newTestGiteaConnection(t, &test.config, repos, test.gogs)
+			defer done()
+
+			var got []int64
+			for r := range conn.listAllRepositories(context.Background(), nil) {
+				got = append(got, r.ID)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func repoIDs(from, to int) (ids []int64) {
+	for i := from; i <= to; i++ {
+		ids = append(ids, int64(i))
+	}
+	return ids
+}
+
+func TestGetGiteaRepository(t *testing.T) {
+	for _, gogs := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gogs=%v", gogs), func(t *testing.T) {
+			conn, done := newTestGiteaConnection(t, &schema.GiteaConnection{Token: "t"}, testGiteaRepositories(2), gogs)
+			defer done()
+
+			orig := giteaConnections.Get()
+			giteaConnections.Set(func() interface{} { return []*giteaConnection{conn} })
+			defer giteaConnections.Set(func() interface{} { return orig })
+
+			host := conn.baseURL.Hostname()
+			repo, authoritative, err := GetGiteaRepository(context.Background(), protocol.RepoLookupArgs{
+				Repo: api.RepoName(host + "/org/repo2"),
+				ExternalRepo: &api.ExternalRepoSpec{
+					ID:          "2",
+					ServiceType: gitea.ServiceType,
+					ServiceID:   conn.baseURL.String(),
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !authoritative {
+				t.Error("got authoritative == false")
+			}
+			want := &protocol.RepoInfo{
+				Name:         api.RepoName(host + "/org/repo2"),
+				ExternalRepo: &api.ExternalRepoSpec{ID: "2", ServiceType: gitea.ServiceType, ServiceID: conn.baseURL.String()},
+				VCS:          protocol.VCSInfo{URL: "https://git:t@gitea.example.com/org/repo2.git"},
+				Links: &protocol.RepoLinks{
+					Root:   "https://gitea.example.com/org/repo2",
+					Tree:   "https://gitea.example.com/org/repo2/src/{rev}/{path}",
+					Blob:   "https://gitea.example.com/org/repo2/src/{rev}/{path}",
+					Commit: "https://gitea.example.com/org/repo2/commit/{commit}",
+				},
+			}
+			if !reflect.DeepEqual(repo, want) {
+				t.Errorf("got %+v, want %+v", repo, want)
+			}
+
+			if _, authoritative, _ := GetGiteaRepository(context.Background(), protocol.RepoLookupArgs{Repo: "github.com/org/repo2"}); authoritative {
+				t.Error("got authoritative == true for a repository on another host")
+			}
+		})
+	}
+}
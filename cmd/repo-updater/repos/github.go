@@ -14,7 +14,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/atomicvalue"
-	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
@@ -36,8 +35,10 @@ var githubConnections = func() *atomicvalue.Value {
 func SyncGitHubConnections(ctx context.Context) {
 	t := time.NewTicker(configWatchInterval)
 	var lastGitHubConf []*schema.GitHubConnection
+	var lastIDs []int64
 	for range t.C {
-		githubConf, err := conf.GitHubConfigs(ctx)
+		var githubConf []*schema.GitHubConnection
+		ids, err := externalServiceConfigs(ctx, "GITHUB", &githubConf)
 		if err != nil {
 			log15.Error("unable to fetch GitHub configs", "err", err)
 			continue
@@ -59,18 +60,22 @@ func SyncGitHubConnections(ctx context.Context) {
 				Url:                         "https://github.com",
 				InitialRepositoryEnablement: true,
 			})
+			ids = append(ids, 0) // not an external service
 		}
 
-		if reflect.DeepEqual(githubConf, lastGitHubConf) {
+		if reflect.DeepEqual(githubConf, lastGitHubConf) && reflect.DeepEqual(ids, lastIDs) {
 			continue
 		}
-		lastGitHubConf = githubConf
+		lastGitHubConf, lastIDs = githubConf, ids
 
 		var conns []*githubConnection
-		for _, c := range githubConf {
-			conn, err := newGitHubConnection(c)
+		for i, c := range githubConf {
+			conn, err := newGitHubConnection(ids[i], c)
 			if err != nil {
 				log15.Error("Error processing configured GitHub connection. Skipping it.", "url", c.Url, "error", err)
+				status := newSyncStatus(ids[i])
+				status.listError(err)
+				status.record(ctx)
 				continue
 			}
 			conns = append(conns, conn)
@@ -282,6 +287,7 @@ var gitHubRepositorySyncWorker = &worker{
 					case <-shutdown:
 						return
 					case <-time.After(GetUpdateInterval()):
+					case <-syncRequested(c.externalServiceID):
 					}
 				}
 			}(c)
@@ -301,11 +307,20 @@ func githubRepositoryToRepoPath(conn *githubConnection, repo *github.Repository)
 
 // updateGitHubRepositories ensures that all provided repositories have been added and updated on Sourcegraph.
 func updateGitHubRepositories(ctx context.Context, conn *githubConnection) {
-	repos := conn.listAllRepositories(ctx)
+	status := newSyncStatus(conn.externalServiceID)
+	repos := conn.listAllRepositories(ctx, status)
 
 	repoChan := make(chan repoCreateOrUpdateRequest)
-	defer close(repoChan)
-	go createEnableUpdateRepos(ctx, fmt.Sprintf("github:%s", conn.config.Token), repoChan)
+	done := make(chan struct{})
+	go func() {
+		createEnableUpdateRepos(ctx, fmt.Sprintf("github:%s", conn.config.Token), status, repoChan)
+		close(done)
+	}()
+	defer func() {
+		close(repoChan)
+		<-done
+		status.record(ctx)
+	}()
 	for repo := range repos {
 		// log15.Debug("github sync: create/enable/update repo", "repo", repo.NameWithOwner)
 		repoChan <- repoCreateOrUpdateRequest{
@@ -322,7 +337,7 @@ func updateGitHubRepositories(ctx context.Context, conn *githubConnection) {
 	}
 }
 
-func newGitHubConnection(config *schema.GitHubConnection) (*githubConnection, error) {
+func newGitHubConnection(externalServiceID int64, config *schema.GitHubConnection) (*githubConnection, error) {
 	baseURL, err := url.Parse(config.Url)
 	if err != nil {
 		return nil, err
@@ -338,16 +353,21 @@ func newGitHubConnection(config *schema.GitHubConnection) (*githubConnection, er
 	}
 
 	return &githubConnection{
-		config:           config,
-		baseURL:          baseURL,
-		githubDotCom:     githubDotCom,
-		client:           github.NewClient(apiURL, config.Token, transport),
-		searchClient:     github.NewClient(apiURL, config.Token, transport),
-		originalHostname: originalHostname,
+		externalServiceID: externalServiceID,
+		config:            config,
+		baseURL:           baseURL,
+		githubDotCom:      githubDotCom,
+		client:            github.NewClient(apiURL, config.Token, transport),
+		searchClient:      github.NewClient(apiURL, config.Token, transport),
+		originalHostname:  originalHostname,
 	}, nil
 }
 
 type githubConnection struct {
+	// externalServiceID is the ID of the external service of the connection, or 0 for the implicit
+	// GitHub.com connection.
+	externalServiceID int64
+
 	config       *schema.GitHubConnection
 	githubDotCom bool
 	baseURL      *url.URL
@@ -381,7 +401,7 @@ func (c *githubConnection) authenticatedRemoteURL(repo *github.Repository) strin
 	return u.String()
 }
 
-func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *github.Repository {
+func (c *githubConnection) listAllRepositories(ctx context.Context, status *syncStatus) <-chan *github.Repository {
 	const first = 100 // max GitHub API "first" parameter
 	ch := make(chan *github.Repository, first)
 
@@ -412,6 +432,7 @@ func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *gith
 					repos, err := c.client.ListPublicRepositories(ctx, sinceRepoID)
 					if err != nil {
 						log15.Error("Error listing public repositories", "sinceRepoID", sinceRepoID, "error", err)
+						status.listError(errors.Wrap(err, "listing public repositories"))
 						return
 					}
 					if len(repos) == 0 {
@@ -434,6 +455,7 @@ func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *gith
 					repos, hasNextPage, rateLimitCost, err = c.client.ListViewerRepositories(ctx, "", page)
 					if err != nil {
 						log15.Error("Error listing viewer's affiliated GitHub repositories", "page", page, "error", err)
+						status.listError(errors.Wrap(err, "listing affiliated repositories"))
 						break
 					}
 					rateLimitRemaining, rateLimitReset, _ := c.client.RateLimit.Get()
@@ -465,6 +487,7 @@ func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *gith
 					repos, hasNextPage, rateLimitCost, err = c.searchClient.ListRepositoriesForSearch(ctx, repositoryQuery, page)
 					if err != nil {
 						log15.Error("Error listing GitHub repositories for search", "searchString", repositoryQuery, "page", page, "error", err)
+						status.listError(errors.Wrapf(err, "listing repositories for search %q", repositoryQuery))
 						break
 					}
 					rateLimitRemaining, rateLimitReset, _ := c.searchClient.RateLimit.Get()
@@ -487,11 +510,13 @@ func (c *githubConnection) listAllRepositories(ctx context.Context) <-chan *gith
 			owner, name, err := github.SplitRepositoryNameWithOwner(nameWithOwner)
 			if err != nil {
 				log15.Error("Invalid GitHub repository", "nameWithOwner", nameWithOwner)
+				status.listError(err)
 				continue
 			}
 			repo, err := c.client.GetRepository(ctx, owner, name)
 			if err != nil {
 				log15.Error("Error getting GitHub repository", "nameWithOwner", nameWithOwner, "error", err)
+				status.listError(errors.Wrapf(err, "getting repository %s", nameWithOwner))
 				continue
 			}
 			log15.Debug("github sync: GetRepository", "repo", repo.NameWithOwner)
@@ -12,7 +12,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/atomicvalue"
-	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitlab"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
@@ -33,8 +32,10 @@ var gitlabConnections = func() *atomicvalue.Value {
 func SyncGitLabConnections(ctx context.Context) {
 	t := time.NewTicker(configWatchInterval)
 	var lastConfig []*schema.GitLabConnection
+	var lastIDs []int64
 	for range t.C {
-		gitlabConf, err := conf.GitLabConfigs(ctx)
+		var gitlabConf []*schema.GitLabConnection
+		ids, err := externalServiceConfigs(ctx, "GITLAB", &gitlabConf)
 		if err != nil {
 			log15.Error("unable to fetch Gitlab configs", "err", err)
 			continue
@@ -56,18 +57,22 @@ func SyncGitLabConnections(ctx context.Context) {
 				Url:                         "https://gitlab.com",
 				InitialRepositoryEnablement: true,
 			})
+			ids = append(ids, 0) // not an external service
 		}
 
-		if reflect.DeepEqual(gitlabConf, lastConfig) {
+		if reflect.DeepEqual(gitlabConf, lastConfig) && reflect.DeepEqual(ids, lastIDs) {
 			continue
 		}
-		lastConfig = gitlabConf
+		lastConfig, lastIDs = gitlabConf, ids
 
 		var conns []*gitlabConnection
-		for _, c := range gitlabConf {
-			conn, err := newGitLabConnection(c)
+		for i, c := range gitlabConf {
+			conn, err := newGitLabConnection(ids[i], c)
 			if err != nil {
 				log15.Error("Error processing configured GitLab connection. Skipping it.", "url", c.Url, "error", err)
+				status := newSyncStatus(ids[i])
+				status.listError(err)
+				status.record(ctx)
 				continue
 			}
 			conns = append(conns, conn)
@@ -194,6 +199,7 @@ var gitLabRepositorySyncWorker = &worker{
 					case <-shutdown:
 						return
 					case <-time.After(GetUpdateInterval()):
+					case <-syncRequested(c.externalServiceID):
 					}
 				}
 			}(c)
@@ -213,11 +219,20 @@ func gitlabProjectToRepoPath(conn *gitlabConnection, proj *gitlab.Project) api.R
 
 // updateGitLabProjects ensures that all provided repositories exist in the repository table.
 func updateGitLabProjects(ctx context.Context, conn *gitlabConnection) {
-	projs := conn.listAllProjects(ctx)
+	status := newSyncStatus(conn.externalServiceID)
+	projs := conn.listAllProjects(ctx, status)
 
 	repoChan := make(chan repoCreateOrUpdateRequest)
-	defer close(repoChan)
-	go createEnableUpdateRepos(ctx, fmt.Sprintf("gitlab:%s", conn.config.Token), repoChan)
+	done := make(chan struct{})
+	go func() {
+		createEnableUpdateRepos(ctx, fmt.Sprintf("gitlab:%s", conn.config.Token), status, repoChan)
+		close(done)
+	}()
+	defer func() {
+		close(repoChan)
+		<-done
+		status.record(ctx)
+	}()
 	for proj := range projs {
 		repoChan <- repoCreateOrUpdateRequest{
 			RepoCreateOrUpdateRequest: api.RepoCreateOrUpdateRequest{
@@ -233,7 +248,7 @@ func updateGitLabProjects(ctx context.Context, conn *gitlabConnection) {
 	}
 }
 
-func newGitLabConnection(config *schema.GitLabConnection) (*gitlabConnection, error) {
+func newGitLabConnection(externalServiceID int64, config *schema.GitLabConnection) (*gitlabConnection, error) {
 	baseURL, err := url.Parse(config.Url)
 	if err != nil {
 		return nil, err
@@ -246,13 +261,18 @@ func newGitLabConnection(config *schema.GitLabConnection) (*gitlabConnection, er
 	}
 
 	return &gitlabConnection{
-		config:  config,
-		baseURL: baseURL,
-		client:  gitlab.NewClientProvider(baseURL, transport).GetPATClient(config.Token),
+		externalServiceID: externalServiceID,
+		config:            config,
+		baseURL:           baseURL,
+		client:            gitlab.NewClientProvider(baseURL, transport).GetPATClient(config.Token),
 	}, nil
 }
 
 type gitlabConnection struct {
+	// externalServiceID is the ID of the external service of the connection, or 0 for the implicit
+	// GitLab.com connection.
+	externalServiceID int64
+
 	config  *schema.GitLabConnection
 	baseURL *url.URL // URL with path /api/v4 (no trailing slash)
 	client  *gitlab.Client
@@ -277,7 +297,7 @@ func (c *gitlabConnection) authenticatedRemoteURL(proj *gitlab.Project) string {
 	return u.String()
 }
 
-func (c *gitlabConnection) listAllProjects(ctx context.Context) <-chan *gitlab.Project {
+func (c *gitlabConnection) listAllProjects(ctx context.Context, status *syncStatus) <-chan *gitlab.Project {
 	configProjectQuery := c.config.ProjectQuery
 	if len(configProjectQuery) == 0 {
 		configProjectQuery = []string{"?membership=true"}
@@ -306,6 +326,7 @@ func (c *gitlabConnection) listAllProjects(ctx context.Context) <-chan *gitlab.P
 			q, err := normalizeQuery(projectQuery)
 			if err != nil {
 				log15.Error("Skipping invalid GitLab projectQuery", "projectQuery", projectQuery, "error", err)
+				status.listError(errors.Wrapf(err, "invalid projectQuery %q", projectQuery))
 				continue
 			}
 			q.Set("per_page", strconv.Itoa(perPage))
@@ -315,6 +336,7 @@ func (c *gitlabConnection) listAllProjects(ctx context.Context) <-chan *gitlab.P
 				projects, nextPageURL, err := c.client.ListProjects(ctx, url)
 				if err != nil {
 					log15.Error("Error listing GitLab projects", "url", url, "error", err)
+					status.listError(errors.Wrapf(err, "listing projects for projectQuery %q", projectQuery))
 					continue projectsQueries
 				}
 				for _, p := range projects {
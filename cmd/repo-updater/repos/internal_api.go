@@ -13,6 +13,7 @@ import (
 // InternalAPI captures the internal API methods needed for syncing external services' repos.
 type InternalAPI interface {
 	ExternalServicesList(context.Context, api.ExternalServicesListRequest) ([]*api.ExternalService, error)
+	ExternalServicesSetSyncStatus(context.Context, *api.ExternalServiceSyncStatus) error
 	ReposCreateIfNotExists(context.Context, api.RepoCreateOrUpdateRequest) (*api.Repo, error)
 	ReposUpdateMetadata(ctx context.Context, repo api.RepoName, description string, fork, archived bool) error
 }
@@ -32,6 +33,13 @@ func (a *internalAPI) ExternalServicesList(ctx context.Context, opts api.Externa
 	return api.InternalClient.ExternalServicesList(ctx, opts)
 }
 
+// ExternalServicesSetSyncStatus records the outcome of the last sync of an external service.
+func (a *internalAPI) ExternalServicesSetSyncStatus(ctx context.Context, status *api.ExternalServiceSyncStatus) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return api.InternalClient.ExternalServicesSetSyncStatus(ctx, status)
+}
+
 // RepoCreateIfNotExists creates the given repo if it doesn't exist.
 func (a *internalAPI) ReposCreateIfNotExists(ctx context.Context, req api.RepoCreateOrUpdateRequest) (*api.Repo, error) {
 	ctx, cancel := context.WithTimeout(ctx, a.timeout)
@@ -53,17 +61,19 @@ func (a *internalAPI) ReposUpdateMetadata(ctx context.Context, repoName api.Repo
 // FakeInternalAPI implements the InternalAPI interface with the given in memory data.
 // It's safe for concurrent use.
 type FakeInternalAPI struct {
-	mu     sync.RWMutex
-	svcs   map[string][]*api.ExternalService
-	repos  map[api.RepoName]*api.Repo
-	repoID api.RepoID
+	mu       sync.RWMutex
+	svcs     map[string][]*api.ExternalService
+	statuses map[int64]*api.ExternalServiceSyncStatus
+	repos    map[api.RepoName]*api.Repo
+	repoID   api.RepoID
 }
 
 // NewFakeInternalAPI returns a new FakeInternalAPI initialised with the given data.
 func NewFakeInternalAPI(svcs []*api.ExternalService, repos []*api.Repo) *FakeInternalAPI {
 	fa := FakeInternalAPI{
-		svcs:  map[string][]*api.ExternalService{},
-		repos: map[api.RepoName]*api.Repo{},
+		svcs:     map[string][]*api.ExternalService{},
+		statuses: map[int64]*api.ExternalServiceSyncStatus{},
+		repos:    map[api.RepoName]*api.Repo{},
 	}
 
 	for _, svc := range svcs {
@@ -95,6 +105,26 @@ func (a *FakeInternalAPI) ExternalServicesList(
 	return svcs, nil
 }
 
+// ExternalServicesSetSyncStatus records the outcome of the last sync of an external service.
+func (a *FakeInternalAPI) ExternalServicesSetSyncStatus(
+	_ context.Context,
+	status *api.ExternalServiceSyncStatus,
+) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.statuses[status.ExternalServiceID] = status
+	return nil
+}
+
+// ExternalServiceSyncStatus returns the last recorded sync status of the external service
+// with the given ID, or nil if none was recorded.
+func (a *FakeInternalAPI) ExternalServiceSyncStatus(id int64) *api.ExternalServiceSyncStatus {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.statuses[id]
+}
+
 // ReposList returns the list of all repos in the API.
 func (a *FakeInternalAPI) ReposList() []*api.Repo {
 	a.mu.RLock()
@@ -30,12 +30,24 @@ var (
 		Name:      "time_last_phabricator_sync",
 		Help:      "The last time a comprehensive Phabricator sync finished",
 	}, []string{"id"})
+	bitbucketCloudUpdateTime = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "src",
+		Subsystem: "repoupdater",
+		Name:      "time_last_bitbucketcloud_sync",
+		Help:      "The last time a comprehensive Bitbucket Cloud sync finished",
+	}, []string{"id"})
 	bitbucketServerUpdateTime = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "src",
 		Subsystem: "repoupdater",
 		Name:      "time_last_bitbucketserver_sync",
 		Help:      "The last time a comprehensive Bitbucket Server sync finished",
 	}, []string{"id"})
+	giteaUpdateTime = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "src",
+		Subsystem: "repoupdater",
+		Name:      "time_last_gitea_sync",
+		Help:      "The last time a comprehensive Gitea sync finished",
+	}, []string{"id"})
 
 	// gitoliteUpdateTime is an ugly duckling as repo-updater just
 	// hits a HTTP endpoint on the frontend that returns after
@@ -113,6 +125,12 @@ var (
 		Name:      "sched_manual_fetch",
 		Help:      "Incremented each time the scheduler updates a repository due to user traffic.",
 	})
+	schedWebhookFetch = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "src",
+		Subsystem: "repoupdater",
+		Name:      "sched_webhook_fetch",
+		Help:      "Incremented each time the scheduler updates a repository due to a code host webhook.",
+	})
 	schedKnownRepos = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: "src",
 		Subsystem: "repoupdater",
@@ -125,4 +143,17 @@ var (
 		Name:      "sched_scale",
 		Help:      "The scheduler interval scale.",
 	})
+
+	webhookEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "src",
+		Subsystem: "repoupdater",
+		Name:      "webhook_events",
+		Help:      "Incremented each time a valid webhook event is received from a code host.",
+	}, []string{"kind", "event"})
+	webhookRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "src",
+		Subsystem: "repoupdater",
+		Name:      "webhook_rejected",
+		Help:      "Incremented each time a webhook delivery is rejected because of an invalid signature or payload.",
+	}, []string{"kind", "reason"})
 )
@@ -2,6 +2,7 @@ package repos
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -143,6 +144,9 @@ type SyncResult struct {
 	Synced []*protocol.RepoInfo
 	// Repos that failed to be synced.
 	Errors SyncErrors
+	// The outcome of the sync as recorded for the external service, which is nil if the
+	// external service has no ID.
+	Status *api.ExternalServiceSyncStatus
 }
 
 // SyncMany synchonizes the repos defined by all the given external services of kind "OTHER".
@@ -170,6 +174,9 @@ func (s *OtherReposSyncer) SyncMany(ctx context.Context, svcs ...*api.ExternalSe
 
 // Sync synchronizes the repositories of a single external service of kind "OTHER"
 func (s *OtherReposSyncer) Sync(ctx context.Context, svc *api.ExternalService) (res *SyncResult) {
+	status := newSyncStatus(svc.ID)
+	defer func() { res.Status = s.recordStatus(ctx, status, res) }()
+
 	defer func(began time.Time) {
 		id, now := strconv.FormatInt(svc.ID, 10), time.Now().UTC()
 		otherExternalServicesLastSync.WithLabelValues(id).Set(float64(now.Unix()))
@@ -194,6 +201,30 @@ func (s *OtherReposSyncer) Sync(ctx context.Context, svc *api.ExternalService) (
 	return s.store(ctx, svc, repos...)
 }
 
+// recordStatus records the outcome of syncing the repos of an external service from its
+// SyncResult.
+func (s *OtherReposSyncer) recordStatus(ctx context.Context, status *syncStatus, res *SyncResult) *api.ExternalServiceSyncStatus {
+	if status == nil {
+		return nil
+	}
+	for _, repo := range res.Synced {
+		status.synced(repo.Name)
+	}
+	for _, err := range res.Errors {
+		if err.Repo != nil {
+			status.repoError(err.Repo.Name, errors.New(err.Err))
+		} else {
+			status.listError(errors.New(err.Err))
+		}
+	}
+
+	st := status.finish()
+	if err := s.api.ExternalServicesSetSyncStatus(ctx, st); err != nil {
+		log15.Error("error recording external service sync status", "id", st.ExternalServiceID, "error", err)
+	}
+	return st
+}
+
 func repoFromCloneURL(u *url.URL) *protocol.RepoInfo {
 	repoURL := u.String()
 	repoName := otherRepoName(u)
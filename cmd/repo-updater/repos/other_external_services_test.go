@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
@@ -139,6 +140,11 @@ func TestOtherReposSyncer_syncAll(t *testing.T) {
 							Err:     "config error: failed to parse JSON: [CloseBraceExpected]",
 						},
 					},
+					Status: &api.ExternalServiceSyncStatus{
+						ExternalServiceID: 2,
+						Errors:            []string{"config error: failed to parse JSON: [CloseBraceExpected]"},
+						Incomplete:        true,
+					},
 				},
 			},
 		},
@@ -161,6 +167,10 @@ func TestOtherReposSyncer_syncAll(t *testing.T) {
 							Err: "invalid empty repo name",
 						},
 					},
+					Status: &api.ExternalServiceSyncStatus{
+						ExternalServiceID: 1,
+						Errors:            []string{"repo : invalid empty repo name"},
+					},
 				},
 			},
 		},
@@ -174,6 +184,19 @@ func TestOtherReposSyncer_syncAll(t *testing.T) {
 			results, err := NewOtherReposSyncer(fa, nil).syncAll(ctx)
 			after := fa.ReposList()
 
+			for _, res := range results {
+				if res.Status == nil {
+					continue
+				}
+				if have, want := fa.ExternalServiceSyncStatus(res.Service.ID), res.Status; have != want {
+					t.Errorf("recorded sync status %+v, want %+v", have, want)
+				}
+				if res.Status.StartedAt.IsZero() || res.Status.FinishedAt.Before(res.Status.StartedAt) {
+					t.Errorf("invalid sync status times %v - %v", res.Status.StartedAt, res.Status.FinishedAt)
+				}
+				res.Status.StartedAt, res.Status.FinishedAt = time.Time{}, time.Time{}
+			}
+
 			for _, exp := range []struct {
 				name       string
 				have, want interface{}
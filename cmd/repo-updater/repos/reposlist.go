@@ -873,6 +873,24 @@ func UpdateOnce(ctx context.Context, name api.RepoName, url string) {
 	repos.update(string(name), url)
 }
 
+// UpdateFromWebhook is like UpdateOnce, but only updates repositories which
+// are already known, for updates requested by code host webhooks. It reports
+// whether the update was scheduled. The URL of the known repository takes
+// precedence over url, which is derived from the webhook payload.
+func UpdateFromWebhook(ctx context.Context, name api.RepoName, url string) bool {
+	repos.mu.Lock()
+	defer repos.mu.Unlock()
+	repo, ok := repos.repos[string(name)]
+	if !ok {
+		return false
+	}
+	if repo.URL != "" {
+		url = repo.URL
+	}
+	repos.update(string(name), url)
+	return true
+}
+
 // QueueSnapshot represents the state of the various queues repo-updater
 // maintains. The fields are ordered by priority.
 func QueueSnapshot() *Snapshot {
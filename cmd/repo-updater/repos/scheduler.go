@@ -148,7 +148,11 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 					schedError.Inc()
 					log15.Warn("error requesting repo update", "uri", repo.Name, "err", err)
 				}
-				if resp != nil && resp.LastFetched != nil && resp.LastChanged != nil {
+				if resp != nil && resp.Evicted {
+					// gitserver evicted the repo to free up disk space, and only
+					// clones it again when it is used, so we check back rarely.
+					s.schedule.updateInterval(repo, maxDelay)
+				} else if resp != nil && resp.LastFetched != nil && resp.LastChanged != nil {
 					// This is the heuristic that is described in the updateScheduler documentation.
 					// Update that documentation if you update this logic.
 					interval := resp.LastFetched.Sub(*resp.LastChanged) / 2
@@ -231,6 +235,40 @@ func (s *updateScheduler) UpdateOnce(name api.RepoName, url string) {
 	s.updateQueue.enqueue(repo, priorityHigh)
 }
 
+// UpdateFromWebhook causes a single high priority update of the given
+// repository in response to a code host webhook, if it is a configured
+// repository. It reports whether the update was enqueued. Repositories which
+// are not configured yet are added by the next sync of their code host
+// connection, which checks the connection's settings. The URL of the
+// configured repository takes precedence over url, which is derived from the
+// webhook payload. Like UpdateOnce, it neither adds nor removes the repo from
+// the schedule.
+func (s *updateScheduler) UpdateFromWebhook(name api.RepoName, url string) bool {
+	var configured *configuredRepo2
+	s.mu.Lock()
+	for _, repos := range s.sourceRepos {
+		if r, ok := repos[name]; ok {
+			configured = r
+			break
+		}
+	}
+	s.mu.Unlock()
+	if configured == nil {
+		return false
+	}
+
+	repo := &configuredRepo2{
+		Name: name,
+		URL:  configured.URL,
+	}
+	if repo.URL == "" {
+		repo.URL = url
+	}
+	schedWebhookFetch.Inc()
+	s.updateQueue.enqueue(repo, priorityHigh)
+	return true
+}
+
 // DebugDump returns the state of the update scheduler for debugging.
 func (s *updateScheduler) DebugDump() interface{} {
 	data := struct {
@@ -1157,6 +1157,29 @@ func TestUpdateScheduler_runUpdateLoop(t *testing.T) {
 				return []chan struct{}{s.schedule.wakeup}
 			},
 		},
+		{
+			name:                   "evicted repo backs off",
+			gitMaxConcurrentClones: 1,
+			initialSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: time.Hour, Due: defaultTime.Add(time.Hour)},
+			},
+			initialQueue: []*repoUpdate{
+				{Repo: a, Seq: 1},
+			},
+			mockRequestRepoUpdates: []*mockRequestRepoUpdate{
+				{
+					repo: a,
+					resp: &gitserverprotocol.RepoUpdateResponse{Evicted: true},
+				},
+			},
+			finalSchedule: []*scheduledRepoUpdate{
+				{Repo: a, Interval: maxDelay, Due: defaultTime.Add(maxDelay)},
+			},
+			timeAfterFuncDelays: []time.Duration{maxDelay},
+			expectedNotifications: func(s *updateScheduler) []chan struct{} {
+				return []chan struct{}{s.schedule.wakeup}
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,144 @@
+package repos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// maxSyncErrors is the maximum number of errors recorded for a single sync of an external service,
+// so that a code host that is down does not produce an error for each of its repositories.
+const maxSyncErrors = 100
+
+// syncStatus collects the outcome of a single sync of the repositories of an external service,
+// which is recorded with the frontend so that site admins can see why repositories are missing.
+// It is safe for concurrent use.
+type syncStatus struct {
+	mu       sync.Mutex
+	status   api.ExternalServiceSyncStatus
+	repos    map[api.RepoName]bool
+	complete bool // false if listing the repositories failed, so that repos is incomplete
+}
+
+// newSyncStatus starts collecting the outcome of a sync of the external service with the given
+// ID. It returns nil if id is 0 (such as for the implicit GitHub.com connection), in which case all
+// methods are no-ops.
+func newSyncStatus(id int64) *syncStatus {
+	if id == 0 {
+		return nil
+	}
+	return &syncStatus{
+		status:   api.ExternalServiceSyncStatus{ExternalServiceID: id, StartedAt: time.Now().UTC()},
+		repos:    map[api.RepoName]bool{},
+		complete: true,
+	}
+}
+
+// synced records that the repository was synced.
+func (s *syncStatus) synced(repo api.RepoName) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.repos[repo] = true
+	s.mu.Unlock()
+}
+
+// repoError records that the repository failed to sync.
+func (s *syncStatus) repoError(repo api.RepoName, err error) {
+	s.addError(fmt.Sprintf("repo %s: %s", repo, err))
+}
+
+// listError records that listing (some of) the repositories of the external service failed.
+func (s *syncStatus) listError(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.complete = false
+	s.mu.Unlock()
+	s.addError(err.Error())
+}
+
+func (s *syncStatus) addError(msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch n := len(s.status.Errors); {
+	case n < maxSyncErrors:
+		s.status.Errors = append(s.status.Errors, msg)
+	case n == maxSyncErrors:
+		s.status.Errors = append(s.status.Errors, "(more errors omitted)")
+	}
+}
+
+// finish completes the sync status and returns it. The frontend computes the added, removed and
+// unchanged counts from the synced repositories when it records the status.
+func (s *syncStatus) finish() *api.ExternalServiceSyncStatus {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var repos []api.RepoName
+	for repo := range s.repos {
+		repos = append(repos, repo)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i] < repos[j] })
+	s.status.Repos = repos
+	s.status.Incomplete = !s.complete
+
+	s.status.FinishedAt = time.Now().UTC()
+	status := s.status
+	return &status
+}
+
+// record finishes the sync status and records it with the frontend. Failures are only logged.
+func (s *syncStatus) record(ctx context.Context) {
+	status := s.finish()
+	if status == nil {
+		return
+	}
+	if err := api.InternalClient.ExternalServicesSetSyncStatus(ctx, status); err != nil {
+		log15.Error("Error recording external service sync status.", "id", status.ExternalServiceID, "error", err)
+	}
+}
+
+// externalServiceConfigs decodes the configs of all external services of the given kind into
+// result, which must be a pointer to a slice of the kind's config type, and returns the ID of the
+// external service of each config.
+func externalServiceConfigs(ctx context.Context, kind string, result interface{}) ([]int64, error) {
+	svcs, err := api.InternalClient.ExternalServicesList(ctx, api.ExternalServicesListRequest{Kind: kind})
+	if err != nil {
+		return nil, err
+	}
+
+	// Like the frontend, we decode the jsonc configs into untyped values first and then move them
+	// into result with JSON marshaling.
+	ids := make([]int64, 0, len(svcs))
+	cfgs := make([]interface{}, 0, len(svcs))
+	for _, svc := range svcs {
+		var cfg interface{}
+		if err := jsonc.Unmarshal(svc.Config, &cfg); err != nil {
+			log15.Error("Ignoring external service config that has invalid JSON.", "id", svc.ID, "displayName", svc.DisplayName, "error", err)
+			continue
+		}
+		ids = append(ids, svc.ID)
+		cfgs = append(cfgs, cfg)
+	}
+	buf, err := json.Marshal(cfgs)
+	if err != nil {
+		return nil, err
+	}
+	return ids, json.Unmarshal(buf, result)
+}
@@ -0,0 +1,57 @@
+package repos
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestSyncStatus_finish(t *testing.T) {
+	status := newSyncStatus(1)
+	status.synced("b")
+	status.synced("a")
+	status.synced("b")
+	have := status.finish()
+	if want := []api.RepoName{"a", "b"}; !reflect.DeepEqual(have.Repos, want) {
+		t.Errorf("have repos %q, want %q", have.Repos, want)
+	}
+	if have.Incomplete {
+		t.Error("expected sync to be complete")
+	}
+
+	// Repos missing because listing failed must not be counted as removed.
+	status = newSyncStatus(1)
+	status.synced("d")
+	status.listError(errors.New("boom"))
+	have = status.finish()
+	if !have.Incomplete {
+		t.Error("failed sync: expected sync to be incomplete")
+	}
+	if want := []string{"boom"}; !reflect.DeepEqual(have.Errors, want) {
+		t.Errorf("failed sync: have errors %q, want %q", have.Errors, want)
+	}
+
+	// A nil status (for connections without an external service) is a no-op.
+	var none *syncStatus
+	none.synced("a")
+	none.repoError("a", errors.New("boom"))
+	if none.finish() != nil {
+		t.Error("expected nil status to finish as nil")
+	}
+}
+
+func TestSyncStatus_maxErrors(t *testing.T) {
+	status := newSyncStatus(1)
+	for i := 0; i < maxSyncErrors+10; i++ {
+		status.repoError("a", errors.New("boom"))
+	}
+	errs := status.finish().Errors
+	if len(errs) != maxSyncErrors+1 {
+		t.Fatalf("have %d errors, want %d", len(errs), maxSyncErrors+1)
+	}
+	if have, want := errs[maxSyncErrors], "(more errors omitted)"; have != want {
+		t.Errorf("have last error %q, want %q", have, want)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
@@ -67,8 +68,9 @@ type repoCreateOrUpdateRequest struct {
 // createEnableUpdateRepos receives requests on the provided channel. The
 // source argument should be a distinctive string identifying the configuration
 // being updated, so repo-updater can detect when repositories are dropped from
-// a given source.
-func createEnableUpdateRepos(ctx context.Context, source string, repoChan <-chan repoCreateOrUpdateRequest) {
+// a given source. The outcome of each request is recorded in status, which may
+// be nil.
+func createEnableUpdateRepos(ctx context.Context, source string, status *syncStatus, repoChan <-chan repoCreateOrUpdateRequest) {
 	c := conf.Get()
 	newList := make(sourceRepoList)
 	newScheduler := newSchedulerEnabled(c)
@@ -82,14 +84,17 @@ func createEnableUpdateRepos(ctx context.Context, source string, repoChan <-chan
 		createdRepo, err := api.InternalClient.ReposCreateIfNotExists(ctx, op.RepoCreateOrUpdateRequest)
 		if err != nil {
 			log15.Warn("Error creating or updating repository", "repo", op.RepoName, "error", err)
+			status.repoError(op.RepoName, err)
 			return
 		}
 
 		err = api.InternalClient.ReposUpdateMetadata(ctx, op.RepoName, op.Description, op.Fork, op.Archived)
 		if err != nil {
 			log15.Warn("Error updating repository metadata", "repo", op.RepoName, "error", err)
+			status.repoError(op.RepoName, err)
 			return
 		}
+		status.synced(createdRepo.Name)
 
 		if !newScheduler {
 			newList[string(createdRepo.Name)] = configuredRepo{url: op.URL, enabled: createdRepo.Enabled}
@@ -184,3 +189,35 @@ func (w *worker) start(ctx context.Context) {
 	w.context = ctx
 	go w.work(ctx, shutdown)
 }
+
+// syncRequests holds a channel for each external service on which an early
+// sync of the external service can be requested (see requestSync).
+var syncRequests = struct {
+	sync.Mutex
+	m map[int64]chan struct{}
+}{m: map[int64]chan struct{}{}}
+
+// syncRequested returns a channel which receives a value when a sync of the
+// external service is requested. Sync workers wait on it as well as for the
+// update interval.
+func syncRequested(externalServiceID int64) chan struct{} {
+	syncRequests.Lock()
+	defer syncRequests.Unlock()
+	ch, ok := syncRequests.m[externalServiceID]
+	if !ok {
+		// A request made while a sync is running is kept until it is done.
+		ch = make(chan struct{}, 1)
+		syncRequests.m[externalServiceID] = ch
+	}
+	return ch
+}
+
+// requestSync requests a sync of the external service without waiting for
+// the update interval to elapse. It is a variable so that tests can mock it.
+var requestSync = func(externalServiceID int64) {
+	select {
+	case syncRequested(externalServiceID) <- struct{}{}:
+	default:
+		// A sync is already requested.
+	}
+}
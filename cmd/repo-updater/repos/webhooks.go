@@ -0,0 +1,405 @@
+package repos
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitlab"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// maxWebhookPayloadSize is the largest webhook payload we accept. GitHub caps
+// payloads at 25 MB; the others send much smaller payloads.
+const maxWebhookPayloadSize = 25 << 20
+
+// errInvalidWebhookSignature is returned when a webhook delivery isn't signed
+// with the secret of any configured connection.
+var errInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// A webhookUpdate is a repository that must be updated because of a webhook
+// event.
+type webhookUpdate struct {
+	Name api.RepoName // empty if no repository must be fetched, such as for deleted repositories
+	URL  string       // the repository's Git remote URL, if it can be derived from the payload
+
+	// ExternalRepo is set for repositories which were renamed or transferred.
+	// We know them by their previous name, so they are looked up by their
+	// external repository instead, and only synced if we know them.
+	ExternalRepo *api.ExternalRepoSpec
+
+	// SyncExternalService is the ID of the code host connection to sync, for
+	// events which add, remove or rename repositories. Only the sync applies
+	// the connection's repos, repositoryQuery and exclude settings.
+	SyncExternalService int64
+}
+
+// A webhookParser validates the signature of a webhook delivery and returns
+// the event it describes and the repositories to update. Events which don't
+// change any repository return no updates.
+type webhookParser func(header http.Header, body []byte) (event string, updates []webhookUpdate, err error)
+
+// WebhookHandler returns the http.Handler that receives webhook deliveries from
+// code hosts. Each delivery enqueues a high priority update of the repository
+// it refers to, so pushes become searchable without waiting for the next
+// scheduled update. Scheduled updates continue as a fallback for missed
+// deliveries and code hosts without webhooks.
+//
+// Deliveries are only accepted when they are signed with the webhookSecret of
+// a configured connection.
+func WebhookHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/webhooks/github", &webhookHandler{kind: "github", parse: parseGitHubWebhook})
+	mux.Handle("/webhooks/gitlab", &webhookHandler{kind: "gitlab", parse: parseGitLabWebhook})
+	mux.Handle("/webhooks/bitbucket-server", &webhookHandler{kind: "bitbucket-server", parse: parseBitbucketServerWebhook})
+	return mux
+}
+
+type webhookHandler struct {
+	kind  string
+	parse webhookParser
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookPayloadSize))
+	if err != nil {
+		webhookRejected.WithLabelValues(h.kind, "payload").Inc()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event, updates, err := h.parse(r.Header, body)
+	if err == errInvalidWebhookSignature {
+		webhookRejected.WithLabelValues(h.kind, "signature").Inc()
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		webhookRejected.WithLabelValues(h.kind, "payload").Inc()
+		log15.Warn("invalid webhook payload", "kind", h.kind, "event", event, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	webhookEvents.WithLabelValues(h.kind, event).Inc()
+	for _, u := range updates {
+		if u.ExternalRepo != nil {
+			repo, err := api.InternalClient.ReposGetByExternalRepo(r.Context(), *u.ExternalRepo)
+			if err != nil {
+				log15.Warn("webhook: failed to look up renamed repo", "kind", h.kind, "event", event, "externalRepo", u.ExternalRepo, "error", err)
+				continue
+			}
+			if repo == nil {
+				log15.Debug("webhook: ignoring unknown renamed repo", "kind", h.kind, "event", event, "externalRepo", u.ExternalRepo)
+				continue
+			}
+		}
+		if u.SyncExternalService != 0 {
+			requestSync(u.SyncExternalService)
+			log15.Debug("webhook: requested sync", "kind", h.kind, "event", event, "externalService", u.SyncExternalService)
+		}
+		if u.Name == "" {
+			continue
+		}
+		if !enqueueWebhookUpdate(r.Context(), u) {
+			// The repository isn't configured (yet), so we leave it to the
+			// sync of the code host connection, which applies its repos,
+			// repositoryQuery and exclude settings.
+			log15.Debug("webhook: ignoring unknown repo", "kind", h.kind, "event", event, "repo", u.Name)
+			continue
+		}
+		log15.Debug("webhook: enqueued repo update", "kind", h.kind, "event", event, "repo", u.Name)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// enqueueWebhookUpdate enqueues a high priority update of the repository with
+// the active scheduler, if the repository is known to it. It reports whether
+// the update was enqueued.
+var enqueueWebhookUpdate = func(ctx context.Context, u webhookUpdate) bool {
+	if conf.UpdateScheduler2Enabled() {
+		return Scheduler.UpdateFromWebhook(u.Name, u.URL)
+	}
+	return UpdateFromWebhook(ctx, u.Name, u.URL)
+}
+
+// validHMACSignature reports whether signature is the hex-encoded HMAC of body
+// with the given secret, preceded by prefix (such as "sha1=").
+func validHMACSignature(h func() hash.Hash, prefix, secret string, body []byte, signature string) bool {
+	if secret == "" || !strings.HasPrefix(signature, prefix) {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, prefix))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// hostnameOf returns the lowercased hostname of rawurl, or "" if it can't be
+// parsed.
+func hostnameOf(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func parseGitHubWebhook(header http.Header, body []byte) (string, []webhookUpdate, error) {
+	var conns []*githubConnection
+	for _, c := range githubConnections.Get().([]*githubConnection) {
+		if validHMACSignature(sha1.New, "sha1=", c.config.WebhookSecret, body, header.Get("X-Hub-Signature")) {
+			conns = append(conns, c)
+		}
+	}
+	if len(conns) == 0 {
+		return "", nil, errInvalidWebhookSignature
+	}
+
+	event := header.Get("X-GitHub-Event")
+	var payload struct {
+		Action     string `json:"action"`
+		Repository *struct {
+			NodeID   string `json:"node_id"`
+			FullName string `json:"full_name"`
+			HTMLURL  string `json:"html_url"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return event, nil, err
+	}
+
+	switch event {
+	case "push", "create":
+	case "repository":
+		switch payload.Action {
+		case "created", "deleted", "renamed", "transferred":
+		default:
+			return event, nil, nil
+		}
+	default:
+		return event, nil, nil
+	}
+
+	if payload.Repository == nil || payload.Repository.FullName == "" {
+		return event, nil, errors.New("missing repository")
+	}
+	host := hostnameOf(payload.Repository.HTMLURL)
+	for _, c := range conns {
+		if host != c.originalHostname {
+			continue
+		}
+		repo := &github.Repository{
+			ID:            payload.Repository.NodeID,
+			NameWithOwner: payload.Repository.FullName,
+			URL:           payload.Repository.HTMLURL,
+		}
+		u := webhookUpdate{
+			Name: githubRepositoryToRepoPath(c, repo),
+			URL:  c.authenticatedRemoteURL(repo),
+		}
+		switch payload.Action {
+		case "created":
+			u.SyncExternalService = c.externalServiceID
+		case "deleted":
+			u = webhookUpdate{SyncExternalService: c.externalServiceID}
+		case "renamed", "transferred":
+			if repo.ID == "" {
+				return event, nil, errors.New("missing repository node ID")
+			}
+			u = webhookUpdate{
+				ExternalRepo:        github.ExternalRepoSpec(repo, *c.baseURL),
+				SyncExternalService: c.externalServiceID,
+			}
+		}
+		return event, []webhookUpdate{u}, nil
+	}
+	return event, nil, errors.Errorf("no GitHub connection for repository %q", payload.Repository.HTMLURL)
+}
+
+func parseGitLabWebhook(header http.Header, body []byte) (string, []webhookUpdate, error) {
+	token := []byte(header.Get("X-Gitlab-Token"))
+	var conns []*gitlabConnection
+	for _, c := range gitlabConnections.Get().([]*gitlabConnection) {
+		if c.config.WebhookSecret != "" && subtle.ConstantTimeCompare(token, []byte(c.config.WebhookSecret)) == 1 {
+			conns = append(conns, c)
+		}
+	}
+	if len(conns) == 0 {
+		return "", nil, errInvalidWebhookSignature
+	}
+
+	var payload struct {
+		ObjectKind string `json:"object_kind"` // set for project webhooks
+		EventName  string `json:"event_name"`  // set for system hooks
+
+		// Push and tag push events.
+		Project *struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+			WebURL            string `json:"web_url"`
+			GitHTTPURL        string `json:"git_http_url"`
+			GitSSHURL         string `json:"git_ssh_url"`
+			VisibilityLevel   int    `json:"visibility_level"`
+		} `json:"project"`
+
+		// Project system hook events.
+		ProjectID         int    `json:"project_id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+		ProjectVisibility string `json:"project_visibility"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", nil, err
+	}
+
+	event := payload.ObjectKind
+	if event == "" {
+		event = payload.EventName
+	}
+
+	var proj gitlab.Project
+	switch event {
+	case "push", "tag_push":
+		if payload.Project == nil || payload.Project.PathWithNamespace == "" {
+			return event, nil, errors.New("missing project")
+		}
+		proj.PathWithNamespace = payload.Project.PathWithNamespace
+		proj.WebURL = payload.Project.WebURL
+		proj.HTTPURLToRepo = payload.Project.GitHTTPURL
+		proj.SSHURLToRepo = payload.Project.GitSSHURL
+		proj.Visibility = gitlab.Public
+		if payload.Project.VisibilityLevel < 20 {
+			proj.Visibility = gitlab.Private
+		}
+
+	case "project_create", "project_destroy", "project_rename", "project_transfer":
+		// Project system hooks don't include the project's URLs.
+		if payload.PathWithNamespace == "" {
+			return event, nil, errors.New("missing project path")
+		}
+		proj.ID = payload.ProjectID
+		proj.PathWithNamespace = payload.PathWithNamespace
+		proj.Visibility = gitlab.Visibility(payload.ProjectVisibility)
+
+	default:
+		return event, nil, nil
+	}
+
+	conn := conns[0]
+	if proj.WebURL != "" {
+		conn = nil
+		host := hostnameOf(proj.WebURL)
+		for _, c := range conns {
+			if host == c.baseURL.Hostname() {
+				conn = c
+				break
+			}
+		}
+		if conn == nil {
+			return event, nil, errors.Errorf("no GitLab connection for project %q", proj.WebURL)
+		}
+	} else if len(conns) > 1 {
+		return event, nil, errors.New("webhook secret is shared by multiple GitLab connections")
+	}
+
+	if proj.HTTPURLToRepo == "" {
+		proj.HTTPURLToRepo = conn.baseURL.String() + proj.PathWithNamespace + ".git"
+	}
+	u := webhookUpdate{
+		Name: gitlabProjectToRepoPath(conn, &proj),
+		URL:  conn.authenticatedRemoteURL(&proj),
+	}
+	switch event {
+	case "project_create":
+		u.SyncExternalService = conn.externalServiceID
+	case "project_destroy":
+		u = webhookUpdate{SyncExternalService: conn.externalServiceID}
+	case "project_rename", "project_transfer":
+		if proj.ID == 0 {
+			return event, nil, errors.New("missing project ID")
+		}
+		u = webhookUpdate{
+			ExternalRepo:        gitlab.ExternalRepoSpec(&proj, *conn.baseURL),
+			SyncExternalService: conn.externalServiceID,
+		}
+	}
+	return event, []webhookUpdate{u}, nil
+}
+
+func parseBitbucketServerWebhook(header http.Header, body []byte) (string, []webhookUpdate, error) {
+	var conns []*bitbucketServerConnection
+	for _, c := range bitbucketServerConnections.Get().([]*bitbucketServerConnection) {
+		if validHMACSignature(sha256.New, "sha256=", c.config.WebhookSecret, body, header.Get("X-Hub-Signature")) {
+			conns = append(conns, c)
+		}
+	}
+	if len(conns) == 0 {
+		return "", nil, errInvalidWebhookSignature
+	}
+
+	event := header.Get("X-Event-Key")
+	var payload struct {
+		Repository *bitbucketserver.Repo `json:"repository"` // repo:refs_changed and repo:forked
+		New        *bitbucketserver.Repo `json:"new"`        // repo:modified
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return event, nil, err
+	}
+
+	var repo *bitbucketserver.Repo
+	switch event {
+	case "repo:refs_changed", "repo:forked":
+		repo = payload.Repository
+	case "repo:modified":
+		repo = payload.New
+	default:
+		return event, nil, nil
+	}
+	if repo == nil || repo.Slug == "" {
+		return event, nil, errors.New("missing repository")
+	}
+
+	conn := conns[0]
+	if len(repo.Links.Self) > 0 {
+		conn = nil
+		host := hostnameOf(repo.Links.Self[0].Href)
+		for _, c := range conns {
+			if host == c.client.URL.Hostname() {
+				conn = c
+				break
+			}
+		}
+		if conn == nil {
+			return event, nil, errors.Errorf("no Bitbucket Server connection for repository %q", repo.Links.Self[0].Href)
+		}
+	} else if len(conns) > 1 {
+		return event, nil, errors.New("webhook secret is shared by multiple Bitbucket Server connections")
+	}
+
+	info := bitbucketServerRepoInfo(conn.config, repo)
+	if info == nil {
+		return event, nil, errors.Errorf("invalid Bitbucket Server connection URL %q", conn.config.Url)
+	}
+	return event, []webhookUpdate{{Name: info.Name, URL: info.VCS.URL}}, nil
+}
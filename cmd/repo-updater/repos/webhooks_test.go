@@ -0,0 +1,285 @@
+package repos
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func sign(h func() hash.Hash, prefix, secret string, body []byte) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return prefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookHandler(t *testing.T) {
+	origGitHub, origGitLab, origBitbucketServer := githubConnections.Get(), gitlabConnections.Get(), bitbucketServerConnections.Get()
+	githubConnections.Set(func() interface{} {
+		return []*githubConnection{
+			{originalHostname: "github.com", baseURL: &url.URL{Scheme: "https", Host: "github.com", Path: "/"}, config: &schema.GitHubConnection{Token: "t"}},
+			{externalServiceID: 2, originalHostname: "github.example.com", baseURL: &url.URL{Scheme: "https", Host: "github.example.com", Path: "/"}, config: &schema.GitHubConnection{Token: "t", WebhookSecret: "s"}},
+		}
+	})
+	gitlabConnections.Set(func() interface{} {
+		return []*gitlabConnection{
+			{externalServiceID: 3, baseURL: &url.URL{Scheme: "https", Host: "gitlab.example.com", Path: "/"}, config: &schema.GitLabConnection{Token: "t", WebhookSecret: "s", RepositoryPathPattern: "gl/{pathWithNamespace}"}},
+		}
+	})
+	bitbucketServerConnections.Set(func() interface{} {
+		return []*bitbucketServerConnection{
+			{
+				config: &schema.BitbucketServerConnection{Url: "https://bitbucket.example.com", Token: "t", WebhookSecret: "s"},
+				client: &bitbucketserver.Client{URL: &url.URL{Scheme: "https", Host: "bitbucket.example.com", Path: "/"}},
+			},
+		}
+	})
+	defer func() {
+		githubConnections.Set(func() interface{} { return origGitHub })
+		gitlabConnections.Set(func() interface{} { return origGitLab })
+		bitbucketServerConnections.Set(func() interface{} { return origBitbucketServer })
+	}()
+
+	var enqueued []webhookUpdate
+	origEnqueue := enqueueWebhookUpdate
+	enqueueWebhookUpdate = func(_ context.Context, u webhookUpdate) bool {
+		enqueued = append(enqueued, u)
+		return true
+	}
+	defer func() { enqueueWebhookUpdate = origEnqueue }()
+
+	var synced []int64
+	origRequestSync := requestSync
+	requestSync = func(externalServiceID int64) {
+		synced = append(synced, externalServiceID)
+	}
+	defer func() { requestSync = origRequestSync }()
+
+	// Only the GitHub repository with node ID "known" exists.
+	api.MockReposGetByExternalRepo = func(spec api.ExternalRepoSpec) (*api.Repo, error) {
+		if spec.ID == "known" || spec.ID == "7" {
+			return &api.Repo{Name: "old", ExternalRepo: &spec}, nil
+		}
+		return nil, nil
+	}
+	defer func() { api.MockReposGetByExternalRepo = nil }()
+
+	githubPush := []byte(`{"ref":"refs/heads/master","repository":{"full_name":"foo/bar","html_url":"https://github.example.com/foo/bar"}}`)
+	githubCreated := []byte(`{"action":"created","repository":{"node_id":"new","full_name":"foo/bar","html_url":"https://github.example.com/foo/bar"}}`)
+	githubDeleted := []byte(`{"action":"deleted","repository":{"full_name":"foo/bar","html_url":"https://github.example.com/foo/bar"}}`)
+	githubRenamed := []byte(`{"action":"renamed","repository":{"node_id":"known","full_name":"foo/baz","html_url":"https://github.example.com/foo/baz"}}`)
+	githubTransferredUnknown := []byte(`{"action":"transferred","repository":{"node_id":"unknown","full_name":"qux/bar","html_url":"https://github.example.com/qux/bar"}}`)
+	githubUnknownHost := []byte(`{"repository":{"full_name":"foo/bar","html_url":"https://github.com/foo/bar"}}`)
+	gitlabPush := []byte(`{"object_kind":"push","project":{"path_with_namespace":"foo/bar","web_url":"https://gitlab.example.com/foo/bar","git_http_url":"https://gitlab.example.com/foo/bar.git","visibility_level":20}}`)
+	gitlabCreate := []byte(`{"event_name":"project_create","path_with_namespace":"foo/baz","project_visibility":"private"}`)
+	gitlabDestroy := []byte(`{"event_name":"project_destroy","project_id":7,"path_with_namespace":"foo/baz","project_visibility":"private"}`)
+	gitlabRename := []byte(`{"event_name":"project_rename","project_id":7,"path_with_namespace":"foo/qux","old_path_with_namespace":"foo/baz","project_visibility":"private"}`)
+	gitlabTransferUnknown := []byte(`{"event_name":"project_transfer","project_id":8,"path_with_namespace":"qux/baz","old_path_with_namespace":"foo/baz","project_visibility":"private"}`)
+	bitbucketPush := []byte(`{"repository":{"slug":"bar","project":{"key":"FOO"}}}`)
+
+	tests := []struct {
+		name       string
+		path       string
+		header     http.Header
+		body       []byte
+		wantStatus int
+		want       []webhookUpdate
+		wantSynced []int64
+	}{
+		{
+			name:       "github push",
+			path:       "/webhooks/github",
+			header:     http.Header{"X-Github-Event": {"push"}, "X-Hub-Signature": {sign(sha1.New, "sha1=", "s", githubPush)}},
+			body:       githubPush,
+			wantStatus: http.StatusNoContent,
+			want:       []webhookUpdate{{Name: "github.example.com/foo/bar", URL: "https://t@github.example.com/foo/bar"}},
+		},
+		{
+			name:       "github invalid signature",
+			path:       "/webhooks/github",
+			header:     http.Header{"X-Github-Event": {"push"}, "X-Hub-Signature": {sign(sha1.New, "sha1=", "x", githubPush)}},
+			body:       githubPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "github missing signature",
+			path:       "/webhooks/github",
+			header:     http.Header{"X-Github-Event": {"push"}},
+			body:       githubPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "github repository created",
+			path:       "/webhooks/github",
+			header:     http.Header{"X-Github-Event": {"repository"}, "X-Hub-Signature": {sign(sha1.New, "sha1=", "s", githubCreated)}},
+			body:       githubCreated,
+			wantStatus: http.StatusNoContent,
+			want:       []webhookUpdate{{Name: "github.example.com/foo/bar", URL: "https://t@github.example.com/foo/bar", SyncExternalService: 2}},
+			wantSynced: []int64{2},
+		},
+		{
+			name:       "github repository deleted",
+			path:       "/webhooks/github",
+			header:     http.Header{"X-Github-Event": {"repository"}, "X-Hub-Signature": {sign(sha1.New, "sha1=", "s", githubDeleted)}},
+			body:       githubDeleted,
+			wantStatus: http.StatusNoContent,
+			wantSynced: []int64{2},
+		},
+		{
+			name:       "github repository renamed",
+			path:       "/webhooks/github",
+			header:     http.Header{"X-Github-Event": {"repository"}, "X-Hub-Signature": {sign(sha1.New, "sha1=", "s", githubRenamed)}},
+			body:       githubRenamed,
+			wantStatus: http.StatusNoContent,
+			wantSynced: []int64{2},
+		},
+		{
+			name:       "github unknown repository transferred",
+			path:       "/webhooks/github",
+			header:     http.Header{"X-Github-Event": {"repository"}, "X-Hub-Signature": {sign(sha1.New, "sha1=", "s", githubTransferredUnknown)}},
+			body:       githubTransferredUnknown,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "github connection without secret",
+			path:       "/webhooks/github",
+			header:     http.Header{"X-Github-Event": {"push"}, "X-Hub-Signature": {sign(sha1.New, "sha1=", "s", githubUnknownHost)}},
+			body:       githubUnknownHost,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "gitlab push",
+			path:       "/webhooks/gitlab",
+			header:     http.Header{"X-Gitlab-Event": {"Push Hook"}, "X-Gitlab-Token": {"s"}},
+			body:       gitlabPush,
+			wantStatus: http.StatusNoContent,
+			want:       []webhookUpdate{{Name: "gl/foo/bar", URL: "https://gitlab.example.com/foo/bar.git"}},
+		},
+		{
+			name:       "gitlab project created",
+			path:       "/webhooks/gitlab",
+			header:     http.Header{"X-Gitlab-Event": {"System Hook"}, "X-Gitlab-Token": {"s"}},
+			body:       gitlabCreate,
+			wantStatus: http.StatusNoContent,
+			want:       []webhookUpdate{{Name: "gl/foo/baz", URL: "https://git:t@gitlab.example.com/foo/baz.git", SyncExternalService: 3}},
+			wantSynced: []int64{3},
+		},
+		{
+			name:       "gitlab project destroyed",
+			path:       "/webhooks/gitlab",
+			header:     http.Header{"X-Gitlab-Event": {"System Hook"}, "X-Gitlab-Token": {"s"}},
+			body:       gitlabDestroy,
+			wantStatus: http.StatusNoContent,
+			wantSynced: []int64{3},
+		},
+		{
+			name:       "gitlab project renamed",
+			path:       "/webhooks/gitlab",
+			header:     http.Header{"X-Gitlab-Event": {"System Hook"}, "X-Gitlab-Token": {"s"}},
+			body:       gitlabRename,
+			wantStatus: http.StatusNoContent,
+			wantSynced: []int64{3},
+		},
+		{
+			name:       "gitlab unknown project transferred",
+			path:       "/webhooks/gitlab",
+			header:     http.Header{"X-Gitlab-Event": {"System Hook"}, "X-Gitlab-Token": {"s"}},
+			body:       gitlabTransferUnknown,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "gitlab invalid token",
+			path:       "/webhooks/gitlab",
+			header:     http.Header{"X-Gitlab-Event": {"Push Hook"}, "X-Gitlab-Token": {"x"}},
+			body:       gitlabPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "bitbucket server push",
+			path:       "/webhooks/bitbucket-server",
+			header:     http.Header{"X-Event-Key": {"repo:refs_changed"}, "X-Hub-Signature": {sign(sha256.New, "sha256=", "s", bitbucketPush)}},
+			body:       bitbucketPush,
+			wantStatus: http.StatusNoContent,
+			want:       []webhookUpdate{{Name: "bitbucket.example.com/FOO/bar"}},
+		},
+		{
+			name:       "bitbucket server wrong hash",
+			path:       "/webhooks/bitbucket-server",
+			header:     http.Header{"X-Event-Key": {"repo:refs_changed"}, "X-Hub-Signature": {sign(sha1.New, "sha256=", "s", bitbucketPush)}},
+			body:       bitbucketPush,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			enqueued, synced = nil, nil
+			req := httptest.NewRequest("POST", test.path, bytes.NewReader(test.body))
+			req.Header = test.header
+			rec := httptest.NewRecorder()
+			WebhookHandler().ServeHTTP(rec, req)
+			if rec.Code != test.wantStatus {
+				t.Errorf("got status %d, want %d (body %q)", rec.Code, test.wantStatus, rec.Body.String())
+			}
+			if !reflect.DeepEqual(enqueued, test.want) {
+				t.Errorf("got updates %+v, want %+v", enqueued, test.want)
+			}
+			if !reflect.DeepEqual(synced, test.wantSynced) {
+				t.Errorf("got synced external services %v, want %v", synced, test.wantSynced)
+			}
+		})
+	}
+}
+
+func TestUpdateScheduler_UpdateFromWebhook(t *testing.T) {
+	r, stop := startRecording()
+	defer stop()
+
+	s := newUpdateScheduler()
+	s.sourceRepos["a"] = sourceRepoMap{"a": &configuredRepo2{Name: "a", URL: "a.com", Enabled: true}}
+
+	if !s.UpdateFromWebhook("a", "payload.example.com/a") {
+		t.Error("a: got false, want the update to be enqueued")
+	}
+	// b isn't configured, so it is left to the sync of its code host
+	// connection.
+	if s.UpdateFromWebhook("b", "b.com") {
+		t.Error("b: got true, want unknown repo to be ignored")
+	}
+
+	want := []*repoUpdate{
+		{Repo: &configuredRepo2{Name: "a", URL: "a.com"}, Priority: priorityHigh, Seq: 1},
+	}
+	if !reflect.DeepEqual(s.updateQueue.heap, want) {
+		t.Errorf("got queue %+v, want %+v", s.updateQueue.heap, want)
+	}
+	if len(r.notifications) != 1 {
+		t.Errorf("got %d notifications, want 1", len(r.notifications))
+	}
+}
+
+func TestRequestSync(t *testing.T) {
+	requestSync(42)
+	requestSync(42) // coalesced with the pending request
+	select {
+	case <-syncRequested(42):
+	default:
+		t.Fatal("expected a sync to be requested")
+	}
+	select {
+	case <-syncRequested(42):
+		t.Fatal("expected only one pending sync request")
+	default:
+	}
+}
@@ -31,6 +31,8 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/repo-lookup", s.handleRepoLookup)
 	mux.HandleFunc("/enqueue-repo-update", s.handleEnqueueRepoUpdate)
 	mux.HandleFunc("/sync-external-service", s.handleExternalServiceSync)
+	mux.HandleFunc("/dry-run-external-service", s.handleExternalServiceDryRun)
+	mux.Handle("/webhooks/", repos.WebhookHandler())
 	return mux
 }
 
@@ -100,18 +102,58 @@ func (s *Server) handleExternalServiceSync(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	var result protocol.ExternalServiceSyncResult
 	switch req.ExternalService.Kind {
 	case "OTHER":
 		res := s.OtherReposSyncer.Sync(r.Context(), &req.ExternalService)
 		if len(res.Errors) > 0 {
 			log15.Error("server.external-service-sync", res.Errors)
 			http.Error(w, res.Errors.Error(), http.StatusInternalServerError)
+			return
 		}
+		result.Status = res.Status
 	case "":
 		http.Error(w, "empty external service kind", http.StatusBadRequest)
+		return
 	default:
 		// TODO(tsenart): Handle other external service kinds.
 	}
+
+	if err := json.NewEncoder(w).Encode(&result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// dryRunTimeout is the maximum time a dry run spends listing the repositories of an external
+// service. Listing the repositories of a large code host can take a long time, and the site admin
+// is waiting for the result.
+const dryRunTimeout = 2 * time.Minute
+
+func (s *Server) handleExternalServiceDryRun(w http.ResponseWriter, r *http.Request) {
+	var req protocol.ExternalServiceDryRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Proposed.Kind == "" {
+		http.Error(w, "empty external service kind", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), dryRunTimeout)
+	defer cancel()
+
+	result, err := repos.DryRun(ctx, req.Current, &req.Proposed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 }
 
 var mockRepoLookup func(protocol.RepoLookupArgs) (*protocol.RepoLookupResult, error)
@@ -148,6 +190,8 @@ func (s *Server) repoLookup(ctx context.Context, args protocol.RepoLookupArgs) (
 		repos.GetGitHubRepository,
 		repos.GetGitLabRepository,
 		repos.GetBitbucketServerRepository,
+		repos.GetBitbucketCloudRepository,
+		repos.GetGiteaRepository,
 		repos.GetAWSCodeCommitRepository,
 		repos.GetGitoliteRepository,
 	} {
@@ -52,7 +52,7 @@ func TestServer_handleExternalServiceSync(t *testing.T) {
 			cli := repoupdater.Client{URL: ts.URL, HTTPClient: http.DefaultClient}
 			ctx := context.Background()
 
-			err := cli.SyncExternalService(ctx, *tc.svc)
+			_, err := cli.SyncExternalService(ctx, *tc.svc)
 			if have, want := fmt.Sprint(err), tc.err; have != want {
 				t.Errorf("\nhave: %s\nwant: %s", have, want)
 			}
@@ -52,6 +52,9 @@ type PatternInfo struct {
 	Pattern string
 
 	// IsRegExp if true will treat the Pattern as a regular expression.
+	// A regular expression that can match a newline (such as `\n`, `\s` or
+	// `(?s).`) is matched against the whole file, so its matches may span
+	// lines. Other regular expressions are matched line by line.
 	IsRegExp bool
 
 	// IsWordMatch if true will only match the pattern at word boundaries.
@@ -61,6 +64,25 @@ type PatternInfo struct {
 	// when finding matches.
 	IsCaseSensitive bool
 
+	// IsStructuralPat if true will treat the Pattern as a structural search
+	// template, such as "foo(:[args])". Holes like :[args] match any text
+	// with balanced parentheses, brackets and braces (taking the string
+	// literals and comments of each file's language into account).
+	// IsRegExp and IsWordMatch are ignored, and only file contents are
+	// matched.
+	IsStructuralPat bool
+
+	// IsReplace if true will replace each match of Pattern with Replacement,
+	// and return the changes to each file in FileMatch.Patch. Replacement
+	// may refer to the capture groups of the match like regexp.Expand (e.g.
+	// $1 or ${name}); use $$ for a literal $. IsReplace may not be used with
+	// IsStructuralPat, and only file contents are matched.
+	IsReplace bool
+
+	// Replacement is the template that each match is replaced with, if
+	// IsReplace.
+	Replacement string
+
 	// ExcludePattern is a pattern that may not match the returned files' paths.
 	// eg '**/node_modules'
 	ExcludePattern string
@@ -130,10 +152,42 @@ type FileMatch struct {
 	Path        string
 	LineMatches []LineMatch
 
+	// MatchRanges is the range of each match in the file. Unlike
+	// LineMatches, a range may span multiple lines (e.g. for the regexp
+	// `foo\nbar`). Such a match is reported in LineMatches as a match on
+	// each line it spans, so clients that only understand LineMatches
+	// still highlight it.
+	MatchRanges []Range
+
+	// Patch is a unified diff that replaces every match in the file with
+	// PatternInfo.Replacement, if PatternInfo.IsReplace. Unlike LineMatches,
+	// it is not limited to a number of matches. The paths in it are relative
+	// to the repository root, so it can be applied with `git apply`.
+	Patch string `json:",omitempty"`
+
 	// LimitHit is true if LineMatches may not include all LineMatches.
 	LimitHit bool
 }
 
+// Range is a range of text in a file. Start is inclusive and End is
+// exclusive.
+type Range struct {
+	Start, End Location
+}
+
+// Location is a position in a file.
+type Location struct {
+	// Offset is the 0-based byte offset from the start of the file.
+	Offset int
+
+	// Line is the 0-based line number.
+	Line int
+
+	// Column is the 0-based offset on the line, measured in characters (not
+	// bytes) like LineMatch.OffsetAndLengths.
+	Column int
+}
+
 // LineMatch is the struct used by vscode to receive search results for a line.
 type LineMatch struct {
 	// Preview is the matched line.
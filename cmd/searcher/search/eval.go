@@ -186,6 +186,7 @@ nextFileMatch:
 			Path:        cp.file.Name,
 			Repository:  repo,
 			LineMatches: matches,
+			MatchRanges: cp.fillRanges(candidates),
 		})
 
 		if opts.TotalMaxMatchCount > 0 && len(res.Files) > opts.TotalMaxMatchCount {
@@ -473,6 +474,28 @@ func (c *contentProvider) fillMatches(candidates []*candidateMatch) []api.LineMa
 	return result
 }
 
+// fillRanges returns the range of each candidate. Unlike fillMatches, a
+// candidate spanning multiple lines is not merged with the lines around it.
+func (c *contentProvider) fillRanges(candidates []*candidateMatch) []api.Range {
+	byteRanges := make([][2]int, len(candidates))
+	for i, m := range candidates {
+		byteRanges[i] = [2]int{m.byteOffset, m.byteOffset + m.byteMatchSz}
+	}
+
+	ranges := matchRanges(c.Data(false), byteRanges)
+	if len(ranges) == 0 {
+		return nil
+	}
+	result := make([]api.Range, len(ranges))
+	for i, r := range ranges {
+		result[i] = api.Range{
+			Start: api.Location{Offset: r.Start.Offset, Line: r.Start.Line, Column: r.Start.Column},
+			End:   api.Location{Offset: r.End.Offset, Line: r.End.Line, Column: r.End.Column},
+		}
+	}
+	return result
+}
+
 type sortByOffsetSlice []*candidateMatch
 
 func (m sortByOffsetSlice) Len() int      { return len(m) }
@@ -17,6 +17,7 @@ import (
 
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/pathmatch"
+	"github.com/sourcegraph/sourcegraph/pkg/search/multiline"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -68,6 +69,19 @@ type readerGrep struct {
 	// re is the regexp to match, or nil if empty ("match all files' content").
 	re *regexp.Regexp
 
+	// multiline if true means re can match a newline, so it is matched
+	// against the whole file instead of line by line.
+	multiline bool
+
+	// structural is the structural search template to match instead of re,
+	// if not nil.
+	structural *structuralTemplate
+
+	// replace if true means FindZip also returns a patch replacing each
+	// match of re with replacement.
+	replace     bool
+	replacement string
+
 	// ignoreCase if true means we need to do case insensitive matching.
 	ignoreCase bool
 
@@ -92,9 +106,22 @@ type readerGrep struct {
 func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 	var (
 		re               *regexp.Regexp
+		isMultiline      bool
+		structural       *structuralTemplate
 		literalSubstring []byte
 	)
-	if p.Pattern != "" {
+	if p.IsReplace && (p.IsStructuralPat || p.Pattern == "") {
+		return nil, errors.New("replacement requires a non-empty, non-structural pattern")
+	}
+
+	if p.IsStructuralPat {
+		var err error
+		structural, err = compileStructural(p.Pattern, !p.IsCaseSensitive)
+		if err != nil {
+			return nil, err
+		}
+		literalSubstring = structural.longestLiteral()
+	} else if p.Pattern != "" {
 		expr := p.Pattern
 		if !p.IsRegExp {
 			expr = regexp.QuoteMeta(expr)
@@ -125,6 +152,13 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 			return nil, err
 		}
 
+		if p.IsRegExp {
+			isMultiline, err = multiline.MatchesNewline(expr)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		// Only use literalSubstring optimization if the regex engine doesn't
 		// have a prefix to use.
 		if pre, _ := re.LiteralPrefix(); pre == "" {
@@ -148,6 +182,10 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 
 	return &readerGrep{
 		re:               re,
+		multiline:        isMultiline,
+		structural:       structural,
+		replace:          p.IsReplace,
+		replacement:      p.Replacement,
 		ignoreCase:       !p.IsCaseSensitive,
 		matchPath:        matchPath,
 		literalSubstring: literalSubstring,
@@ -163,6 +201,10 @@ func (rg *readerGrep) Copy() *readerGrep {
 	}
 	return &readerGrep{
 		re:               reCopy,
+		multiline:        rg.multiline,
+		structural:       rg.structural,
+		replace:          rg.replace,
+		replacement:      rg.replacement,
 		ignoreCase:       rg.ignoreCase,
 		matchPath:        rg.matchPath.Copy(),
 		literalSubstring: rg.literalSubstring,
@@ -181,10 +223,11 @@ func (rg *readerGrep) matchString(s string) bool {
 	return rg.re.MatchString(s)
 }
 
-// Find returns a LineMatch for each line that matches rg in reader.
-// LimitHit is true if some matches may not have been included in the result.
+// Find returns a LineMatch for each line that matches rg in reader, and the
+// Range of each match. LimitHit is true if some matches may not have been
+// included in the result.
 // NOTE: This is not safe to use concurrently.
-func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatch, limitHit bool, err error) {
+func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatch, ranges []protocol.Range, limitHit bool, err error) {
 	if rg.ignoreCase && rg.transformBuf == nil {
 		rg.transformBuf = make([]byte, zf.MaxLen)
 	}
@@ -212,19 +255,42 @@ func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatc
 	// per-line. Additionally if we have a non-empty literalSubstring, we use
 	// that to prune out files since doing bytes.Index is very fast.
 	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) {
-		return nil, false, nil
+		return nil, nil, false, nil
 	}
+
+	if rg.structural != nil {
+		byteRanges, limitHit := rg.structural.findAll(fileMatchBuf, structuralSyntaxFor(f.Name), maxLineMatches)
+		matches, lineLimitHit := rangesToLineMatches(fileBuf, byteRanges)
+		return matches, matchRanges(fileBuf, byteRanges), limitHit || lineLimitHit, nil
+	}
+
+	if rg.multiline {
+		// Matches may span lines, so we can't search line by line.
+		limit := maxLineMatches * maxOffsets
+		var byteRanges [][2]int
+		for _, loc := range rg.re.FindAllIndex(fileMatchBuf, limit) {
+			byteRanges = append(byteRanges, [2]int{loc[0], loc[1]})
+		}
+		matches, lineLimitHit := rangesToLineMatches(fileBuf, byteRanges)
+		return matches, matchRanges(fileBuf, byteRanges), len(byteRanges) == limit || lineLimitHit, nil
+	}
+
 	first := rg.re.FindIndex(fileMatchBuf)
 	if first == nil {
-		return nil, false, nil
+		return nil, nil, false, nil
 	}
 
+	// origFileBuf is fileBuf before we advance it line by line. byteRanges
+	// are offsets into it.
+	origFileBuf := fileBuf
+	var byteRanges [][2]int
+
 	idx := 0
 	for i := 0; len(matches) < maxLineMatches; i++ {
 		advance, lineBuf, err := bufio.ScanLines(fileBuf, true)
 		if err != nil {
 			// ScanLines should never return an err
-			return nil, false, err
+			return nil, nil, false, err
 		}
 		if advance == 0 { // EOF
 			break
@@ -240,6 +306,7 @@ func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatc
 		fileMatchBuf = fileMatchBuf[advance:]
 
 		// Check whether we're before the first match.
+		lineStart := idx
 		idx += advance
 		if idx < first[0] {
 			continue
@@ -259,6 +326,7 @@ func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatc
 				offset := utf8.RuneCount(lineBuf[:start])
 				length := utf8.RuneCount(lineBuf[start:end])
 				offsetAndLengths[i] = [2]int{offset, length}
+				byteRanges = append(byteRanges, [2]int{lineStart + start, lineStart + end})
 			}
 			matches = append(matches, protocol.LineMatch{
 				// making a copy of lineBuf is intentional.
@@ -275,17 +343,113 @@ func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatc
 		}
 	}
 	limitHit = len(matches) == maxLineMatches
-	return matches, limitHit, nil
+	return matches, matchRanges(origFileBuf, byteRanges), limitHit, nil
+}
+
+// rangesToLineMatches converts the byte ranges of matches in buf to
+// LineMatches. A match that spans lines is reported as a match on each line
+// it covers.
+func rangesToLineMatches(buf []byte, ranges [][2]int) (matches []protocol.LineMatch, limitHit bool) {
+	lineNumber, lineStart := 0, 0
+	for _, r := range ranges {
+		for start, end := r[0], r[1]; start < end; {
+			// Advance to the line containing start.
+			lineEnd := len(buf)
+			for {
+				i := bytes.IndexByte(buf[lineStart:], '\n')
+				if i < 0 {
+					break
+				}
+				if lineStart+i >= start {
+					lineEnd = lineStart + i
+					break
+				}
+				lineStart += i + 1
+				lineNumber++
+			}
+
+			segmentEnd := end
+			if segmentEnd > lineEnd {
+				segmentEnd = lineEnd
+			}
+			line := bytes.TrimSuffix(buf[lineStart:lineEnd], []byte{'\r'})
+			if segmentEnd > lineStart+len(line) {
+				segmentEnd = lineStart + len(line)
+			}
+
+			if start < segmentEnd && len(line) <= maxLineSize {
+				offsetAndLength := [2]int{
+					utf8.RuneCount(buf[lineStart:start]),
+					utf8.RuneCount(buf[start:segmentEnd]),
+				}
+				if n := len(matches); n > 0 && matches[n-1].LineNumber == lineNumber {
+					if len(matches[n-1].OffsetAndLengths) < maxOffsets {
+						matches[n-1].OffsetAndLengths = append(matches[n-1].OffsetAndLengths, offsetAndLength)
+					} else {
+						matches[n-1].LimitHit = true
+					}
+				} else if len(matches) == maxLineMatches {
+					return matches, true
+				} else {
+					matches = append(matches, protocol.LineMatch{
+						Preview:          string(line),
+						LineNumber:       lineNumber,
+						OffsetAndLengths: [][2]int{offsetAndLength},
+					})
+				}
+			}
+
+			// Continue with the rest of the match on the next line.
+			start = lineEnd + 1
+		}
+	}
+	return matches, false
+}
+
+// matchRanges converts the byte ranges of matches in buf, which must be
+// sorted and non-overlapping, to Ranges.
+func matchRanges(buf []byte, ranges [][2]int) []protocol.Range {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	var (
+		loc       protocol.Location
+		lineStart int
+	)
+	advance := func(offset int) protocol.Location {
+		for loc.Offset < offset {
+			if buf[loc.Offset] == '\n' {
+				loc.Line++
+				lineStart = loc.Offset + 1
+			}
+			loc.Offset++
+		}
+		loc.Column = utf8.RuneCount(buf[lineStart:loc.Offset])
+		return loc
+	}
+
+	result := make([]protocol.Range, len(ranges))
+	for i, r := range ranges {
+		result[i].Start = advance(r[0])
+		result[i].End = advance(r[1])
+	}
+	return result
 }
 
 // FindZip is a convenience function to run Find on f.
 func (rg *readerGrep) FindZip(zf *zipFile, f *srcFile) (protocol.FileMatch, error) {
-	lm, limitHit, err := rg.Find(zf, f)
-	return protocol.FileMatch{
+	lm, ranges, limitHit, err := rg.Find(zf, f)
+	fm := protocol.FileMatch{
 		Path:        f.Name,
 		LineMatches: lm,
+		MatchRanges: ranges,
 		LimitHit:    limitHit,
-	}, err
+	}
+	if err == nil && rg.replace && len(lm) > 0 {
+		fm.Patch = rg.patch(zf, f)
+	}
+	return fm, err
 }
 
 // concurrentFind searches files in zr looking for matches using rg.
@@ -295,6 +459,9 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchL
 	if rg.re != nil {
 		span.SetTag("re", rg.re.String())
 	}
+	if rg.structural != nil {
+		span.SetTag("structural", true)
+	}
 	span.SetTag("path", rg.matchPath.String())
 	defer func() {
 		if err != nil {
@@ -331,7 +498,7 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchL
 		matches   = []protocol.FileMatch{}
 	)
 
-	if patternMatchesPaths && (!patternMatchesContent || rg.re == nil) {
+	if patternMatchesPaths && (!patternMatchesContent || (rg.re == nil && rg.structural == nil)) {
 		// Fast path for only matching file paths (or with a nil pattern, which matches all files,
 		// so is effectively matching only on file paths).
 		for _, f := range files {
@@ -450,7 +450,7 @@ func TestLineLimit(t *testing.T) {
 				Data:   bytes.Repeat([]byte("A"), test.size),
 			}
 			fakeSrcFile := srcFile{Len: int32(test.size)}
-			matches, limitHit, err := rg.Find(&fakeZipFile, &fakeSrcFile)
+			matches, _, limitHit, err := rg.Find(&fakeZipFile, &fakeSrcFile)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -529,6 +529,73 @@ func TestMaxMatches(t *testing.T) {
 	}
 }
 
+func TestRangesToLineMatches(t *testing.T) {
+	buf := []byte("aa foo(b,\r\n  c) foo()\nd ü foo(e)")
+	ranges := [][2]int{{3, 15}, {16, 21}, {27, 33}}
+	want := []protocol.LineMatch{
+		{Preview: "aa foo(b,", LineNumber: 0, OffsetAndLengths: [][2]int{{3, 6}}},
+		{Preview: "  c) foo()", LineNumber: 1, OffsetAndLengths: [][2]int{{0, 4}, {5, 5}}},
+		{Preview: "d ü foo(e)", LineNumber: 2, OffsetAndLengths: [][2]int{{4, 6}}},
+	}
+	got, limitHit := rangesToLineMatches(buf, ranges)
+	if limitHit {
+		t.Error("unexpected limitHit")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestMatchRanges(t *testing.T) {
+	buf := []byte("aa foo(b,\r\n  c) foo()\nd ü foo(e)")
+	ranges := [][2]int{{3, 15}, {16, 21}, {27, 33}}
+	want := []protocol.Range{
+		{Start: protocol.Location{Offset: 3, Line: 0, Column: 3}, End: protocol.Location{Offset: 15, Line: 1, Column: 4}},
+		{Start: protocol.Location{Offset: 16, Line: 1, Column: 5}, End: protocol.Location{Offset: 21, Line: 1, Column: 10}},
+		{Start: protocol.Location{Offset: 27, Line: 2, Column: 4}, End: protocol.Location{Offset: 33, Line: 2, Column: 10}},
+	}
+	got := matchRanges(buf, ranges)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestFind_multiline(t *testing.T) {
+	data := []byte("a foo\nbar b\nfoo\nfoo\nbar\n")
+	fakeZipFile := zipFile{MaxLen: len(data), Data: data}
+	fakeSrcFile := srcFile{Len: int32(len(data))}
+
+	rg, err := compile(&protocol.PatternInfo{Pattern: `foo\nbar`, IsRegExp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, ranges, limitHit, err := rg.Find(&fakeZipFile, &fakeSrcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limitHit {
+		t.Error("unexpected limitHit")
+	}
+
+	wantMatches := []protocol.LineMatch{
+		{Preview: "a foo", LineNumber: 0, OffsetAndLengths: [][2]int{{2, 3}}},
+		{Preview: "bar b", LineNumber: 1, OffsetAndLengths: [][2]int{{0, 3}}},
+		{Preview: "foo", LineNumber: 3, OffsetAndLengths: [][2]int{{0, 3}}},
+		{Preview: "bar", LineNumber: 4, OffsetAndLengths: [][2]int{{0, 3}}},
+	}
+	if !reflect.DeepEqual(matches, wantMatches) {
+		t.Errorf("got  %+v\nwant %+v", matches, wantMatches)
+	}
+
+	wantRanges := []protocol.Range{
+		{Start: protocol.Location{Offset: 2, Line: 0, Column: 2}, End: protocol.Location{Offset: 9, Line: 1, Column: 3}},
+		{Start: protocol.Location{Offset: 16, Line: 3, Column: 0}, End: protocol.Location{Offset: 23, Line: 4, Column: 3}},
+	}
+	if !reflect.DeepEqual(ranges, wantRanges) {
+		t.Errorf("got  %+v\nwant %+v", ranges, wantRanges)
+	}
+}
+
 // Tests that:
 //
 // - IncludePatterns can match the path in any order
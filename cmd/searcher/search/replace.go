@@ -0,0 +1,219 @@
+package search
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// This file implements search-and-replace. Each match of the pattern is
+// replaced with a template (which may refer to the match's capture groups
+// like regexp.Expand), and the changes to each file are returned as a
+// unified diff that can be applied with `git apply`.
+
+// diffContextLines is the number of unchanged lines shown around each change
+// in a unified diff (the same as diff -u and git diff).
+const diffContextLines = 3
+
+// patch returns a unified diff replacing each match of rg in f with
+// rg.replacement.
+func (rg *readerGrep) patch(zf *zipFile, f *srcFile) string {
+	buf := zf.DataFor(f)
+	matchBuf := buf
+	if rg.ignoreCase {
+		if rg.transformBuf == nil {
+			rg.transformBuf = make([]byte, zf.MaxLen)
+		}
+		matchBuf = rg.transformBuf[:len(buf)]
+		bytesToLowerASCII(matchBuf, buf)
+	}
+	return unifiedDiff(f.Name, buf, replaceAll(rg.re, rg.replacement, buf, matchBuf))
+}
+
+// lineEdit replaces the bytes [start, end) of a file, which are whole lines,
+// with the bytes new.
+type lineEdit struct {
+	start, end int
+	new        []byte
+}
+
+// replaceAll returns the edits that replace each match of re in buf with
+// template. matchBuf is what re is matched against. It must have the same
+// length as buf (see readerGrep.ignoreCase), and capture groups are expanded
+// from buf.
+//
+// Each edit spans the whole lines around its matches, so that the edits can
+// be turned into a line-based diff.
+func replaceAll(re *regexp.Regexp, template string, buf, matchBuf []byte) []lineEdit {
+	matches := re.FindAllSubmatchIndex(matchBuf, -1)
+
+	var edits []lineEdit
+	for i := 0; i < len(matches); {
+		edit := lineEdit{start: lineStart(buf, matches[i][0])}
+		end, pos := edit.start, edit.start
+		first := true
+		for {
+			for ; i < len(matches) && (first || matches[i][0] < end); i++ {
+				m := matches[i]
+				first = false
+				edit.new = append(edit.new, buf[pos:m[0]]...)
+				edit.new = re.Expand(edit.new, []byte(template), buf, m)
+				pos = m[1]
+
+				last := m[1] - 1
+				if last < m[0] {
+					last = m[0]
+				}
+				if e := lineEnd(buf, last); e > end {
+					end = e
+				}
+			}
+			edit.new = append(edit.new, buf[pos:end]...)
+			pos = end
+
+			// If the replacement removed the newline at the end of the
+			// edit, it joins the next line, which is then part of the edit
+			// too.
+			if n := len(edit.new); n > 0 && edit.new[n-1] != '\n' && end < len(buf) {
+				end = lineEnd(buf, end)
+				continue
+			}
+			break
+		}
+		edit.end = end
+
+		if !bytes.Equal(buf[edit.start:edit.end], edit.new) {
+			edits = append(edits, edit)
+		}
+	}
+	return edits
+}
+
+// lineStart returns the offset of the start of the line containing offset.
+func lineStart(buf []byte, offset int) int {
+	return bytes.LastIndexByte(buf[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset just after the end of the line containing
+// offset (including its newline).
+func lineEnd(buf []byte, offset int) int {
+	if offset >= len(buf) {
+		return len(buf)
+	}
+	i := bytes.IndexByte(buf[offset:], '\n')
+	if i < 0 {
+		return len(buf)
+	}
+	return offset + i + 1
+}
+
+// splitLines splits buf into lines, each including its newline (except
+// possibly the last).
+func splitLines(buf []byte) [][]byte {
+	lines := bytes.SplitAfter(buf, []byte{'\n'})
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// unifiedDiff returns a unified diff for applying edits (which must be
+// sorted and non-overlapping) to the file at path with contents buf. It
+// returns "" if there are no edits.
+func unifiedDiff(path string, buf []byte, edits []lineEdit) string {
+	if len(edits) == 0 {
+		return ""
+	}
+
+	lines := splitLines(buf)
+	lineStarts := make([]int, len(lines))
+	offset := 0
+	for i, l := range lines {
+		lineStarts[i] = offset
+		offset += len(l)
+	}
+	lineOf := func(offset int) int {
+		return sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] >= offset })
+	}
+
+	// hunkEdit is an edit in terms of lines: the old lines [start, end) are
+	// replaced with new.
+	type hunkEdit struct {
+		start, end int
+		new        [][]byte
+	}
+	hunkEdits := make([]hunkEdit, len(edits))
+	for i, e := range edits {
+		hunkEdits[i] = hunkEdit{start: lineOf(e.start), end: lineOf(e.end), new: splitLines(e.new)}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n--- a/%s\n+++ b/%s\n", path, path, path, path)
+
+	writeLine := func(prefix byte, line []byte) {
+		b.WriteByte(prefix)
+		b.Write(line)
+		if len(line) == 0 || line[len(line)-1] != '\n' {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+
+	// delta is the number of lines added (or, if negative, removed) by the
+	// edits before the current hunk.
+	delta := 0
+	for len(hunkEdits) > 0 {
+		// A hunk contains the edits whose context overlaps.
+		n := 1
+		for n < len(hunkEdits) && hunkEdits[n].start-hunkEdits[n-1].end <= 2*diffContextLines {
+			n++
+		}
+		hunk := hunkEdits[:n]
+		hunkEdits = hunkEdits[n:]
+
+		start := hunk[0].start - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := hunk[n-1].end + diffContextLines
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		oldCount, newCount := end-start, end-start
+		for _, e := range hunk {
+			newCount += len(e.new) - (e.end - e.start)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(start, oldCount), hunkRange(start+delta, newCount))
+		delta += newCount - oldCount
+
+		pos := start
+		for _, e := range hunk {
+			for ; pos < e.start; pos++ {
+				writeLine(' ', lines[pos])
+			}
+			for ; pos < e.end; pos++ {
+				writeLine('-', lines[pos])
+			}
+			for _, l := range e.new {
+				writeLine('+', l)
+			}
+		}
+		for ; pos < end; pos++ {
+			writeLine(' ', lines[pos])
+		}
+	}
+	return b.String()
+}
+
+// hunkRange formats the 0-based line range [start, start+count) for a hunk
+// header. An empty range is written as the line before it, like diff -u.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
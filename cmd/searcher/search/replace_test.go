@@ -0,0 +1,157 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+)
+
+func TestReplace(t *testing.T) {
+	cases := []struct {
+		name        string
+		pattern     string
+		replacement string
+		input       string
+		want        string
+	}{
+		{
+			name:        "capture groups",
+			pattern:     `foo\((\w+)\)`,
+			replacement: "bar($1, nil)",
+			input:       "a\nb\nc\nx := foo(y) + foo(z)\nd\ne\nf\ng\n",
+			want: `@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-x := foo(y) + foo(z)
++x := bar(y, nil) + bar(z, nil)
+ d
+ e
+ f
+`,
+		},
+		{
+			name:        "separate hunks",
+			pattern:     `x`,
+			replacement: "y\ny",
+			input:       "x\n1\n2\n3\n4\n5\n6\n7\n8\nx\n",
+			want: `@@ -1,4 +1,5 @@
+-x
++y
++y
+ 1
+ 2
+ 3
+@@ -7,4 +8,5 @@
+ 6
+ 7
+ 8
+-x
++y
++y
+`,
+		},
+		{
+			name:        "merged hunks",
+			pattern:     `x`,
+			replacement: "y",
+			input:       "x\n1\n2\n3\n4\n5\n6\nx\n",
+			want: `@@ -1,8 +1,8 @@
+-x
++y
+ 1
+ 2
+ 3
+ 4
+ 5
+ 6
+-x
++y
+`,
+		},
+		{
+			name:        "multiline match",
+			pattern:     `(?s)begin.*?end\n`,
+			replacement: "",
+			input:       "a\nbegin\nb\nend\nc\n",
+			want: `@@ -1,5 +1,2 @@
+ a
+-begin
+-b
+-end
+ c
+`,
+		},
+		{
+			name:        "joins next line",
+			pattern:     `,\n\s*`,
+			replacement: ", ",
+			input:       "f(a,\n  b)\n",
+			want: `@@ -1,2 +1 @@
+-f(a,
+-  b)
++f(a, b)
+`,
+		},
+		{
+			name:        "no newline at end of file",
+			pattern:     `b`,
+			replacement: "c",
+			input:       "a\nb",
+			want: `@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++c
+\ No newline at end of file
+`,
+		},
+		{
+			name:        "unchanged",
+			pattern:     `a`,
+			replacement: "a",
+			input:       "a\n",
+			want:        "",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			re := regexp.MustCompile(c.pattern)
+			buf := []byte(c.input)
+			got := unifiedDiff("dir/f.go", buf, replaceAll(re, c.replacement, buf, buf))
+			want := c.want
+			if want != "" {
+				want = "diff --git a/dir/f.go b/dir/f.go\n--- a/dir/f.go\n+++ b/dir/f.go\n" + want
+			}
+			if got != want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestReplace_ignoreCase(t *testing.T) {
+	rg, err := compile(&protocol.PatternInfo{
+		Pattern:     `foo\((\d)\)`,
+		IsRegExp:    true,
+		IsReplace:   true,
+		Replacement: "bar($1)",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("Foo(1)\nfoo(2)\n")
+	zf := zipFile{MaxLen: len(data), Data: data}
+	f := srcFile{Name: "a.go", Len: int32(len(data))}
+
+	fm, err := rg.FindZip(&zf, &f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "@@ -1,2 +1,2 @@\n-Foo(1)\n+bar(1)\n-foo(2)\n+bar(2)\n"
+	if !strings.HasSuffix(fm.Patch, want) {
+		t.Errorf("got:\n%s\nwant suffix:\n%s", fm.Patch, want)
+	}
+}
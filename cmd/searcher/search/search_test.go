@@ -129,6 +129,10 @@ main.go:6:	fmt.Println("Hello world")
 `},
 
 		{protocol.PatternInfo{Pattern: "doesnotmatch"}, ""},
+		{protocol.PatternInfo{Pattern: "fmt.Println(:[args])", IsStructuralPat: true}, `
+main.go:6:	fmt.Println("Hello world")
+`},
+		{protocol.PatternInfo{Pattern: "func :[name]() {", IsStructuralPat: true, IncludePatterns: []string{"*.md"}}, ""},
 		{protocol.PatternInfo{Pattern: "", IsRegExp: false, IncludePatterns: []string{"\\.png"}, PathPatternsAreRegExps: true, PatternMatchesPath: true}, `
 milton.png
 `},
@@ -351,6 +355,9 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	if p.IsCaseSensitive {
 		form.Set("IsCaseSensitive", "true")
 	}
+	if p.IsStructuralPat {
+		form.Set("IsStructuralPat", "true")
+	}
 	if p.PathPatternsAreRegExps {
 		form.Set("PathPatternsAreRegExps", "true")
 	}
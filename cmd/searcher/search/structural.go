@@ -0,0 +1,411 @@
+package search
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/sourcegraph/pkg/inventory/filelang"
+)
+
+// This file implements structural search. A structural search template is
+// matched literally, except that:
+//
+// - A hole, written :[name], matches any balanced text. Text is balanced if
+//   its parentheses, brackets and braces are balanced, ignoring delimiters in
+//   the string literals and comments of the file's language. A hole that is
+//   not inside delimiters in the template does not match a newline (except
+//   inside delimiters, string literals and comments in the file).
+// - Whitespace in the template matches any amount of whitespace.
+//
+// For example, the template foo(:[args]) matches foo(), foo(a, b) and
+// foo(bar(a), ")"), even when the arguments span several lines. Holes are
+// lazy: they match the shortest text for which the rest of the template
+// matches. So a hole at the start or end of a template matches nothing.
+
+type structuralTokenKind int
+
+const (
+	structuralLiteral structuralTokenKind = iota
+	structuralHole
+	structuralSpace
+)
+
+type structuralToken struct {
+	kind   structuralTokenKind
+	text   []byte // the literal text (lowercased if ignoreCase), or the hole name
+	nested bool   // whether a hole is inside delimiters in the template
+}
+
+// structuralTemplate is a compiled structural search template. It is safe for
+// concurrent use.
+type structuralTemplate struct {
+	tokens     []structuralToken
+	ignoreCase bool
+}
+
+// delimiters maps each opening delimiter to its closing delimiter.
+var delimiters = map[byte]byte{
+	'(': ')',
+	'[': ']',
+	'{': '}',
+}
+
+func isClosingDelimiter(c byte) bool {
+	return c == ')' || c == ']' || c == '}'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
+
+// compileStructural compiles a structural search template. If ignoreCase,
+// it must be matched against input lowercased with bytesToLowerASCII.
+func compileStructural(template string, ignoreCase bool) (*structuralTemplate, error) {
+	t := &structuralTemplate{ignoreCase: ignoreCase}
+
+	var literal []byte
+	flush := func() {
+		if len(literal) > 0 {
+			if ignoreCase {
+				bytesToLowerASCII(literal, literal)
+			}
+			t.tokens = append(t.tokens, structuralToken{kind: structuralLiteral, text: literal})
+			literal = nil
+		}
+	}
+	for i := 0; i < len(template); {
+		if name, n := parseHole(template[i:]); n > 0 {
+			flush()
+			if len(t.tokens) > 0 && t.tokens[len(t.tokens)-1].kind == structuralHole {
+				return nil, fmt.Errorf("structural template %q has adjacent holes, which are ambiguous", template)
+			}
+			t.tokens = append(t.tokens, structuralToken{kind: structuralHole, text: []byte(name)})
+			i += n
+			continue
+		}
+		if isSpace(template[i]) {
+			flush()
+			for i < len(template) && isSpace(template[i]) {
+				i++
+			}
+			if len(t.tokens) > 0 && i < len(template) {
+				t.tokens = append(t.tokens, structuralToken{kind: structuralSpace})
+			}
+			continue
+		}
+		literal = append(literal, template[i])
+		i++
+	}
+	flush()
+
+	if len(t.tokens) == 0 {
+		return nil, errors.New("structural template must not be empty")
+	}
+	markNested(t.tokens)
+	return t, nil
+}
+
+// parseHole parses the hole :[name] at the start of s. It returns the hole's
+// name and length, or n == 0 if s does not start with a hole.
+func parseHole(s string) (name string, n int) {
+	if len(s) < 3 || s[0] != ':' || s[1] != '[' {
+		return "", 0
+	}
+	for i := 2; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ']':
+			return s[2:i], i + 1
+		case c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9'):
+		default:
+			return "", 0
+		}
+	}
+	return "", 0
+}
+
+// markNested records which holes are inside delimiters in the template.
+// Unbalanced delimiters are allowed, as in "if :[cond] {".
+func markNested(tokens []structuralToken) {
+	depth := 0
+	for i, tok := range tokens {
+		switch tok.kind {
+		case structuralHole:
+			tokens[i].nested = depth > 0
+		case structuralLiteral:
+			for _, c := range tok.text {
+				if _, ok := delimiters[c]; ok {
+					depth++
+				} else if isClosingDelimiter(c) && depth > 0 {
+					depth--
+				}
+			}
+		}
+	}
+}
+
+// longestLiteral returns the longest literal in the template. It appears in
+// every match, so files that do not contain it can be skipped.
+func (t *structuralTemplate) longestLiteral() []byte {
+	var longest []byte
+	for _, tok := range t.tokens {
+		if tok.kind == structuralLiteral && len(tok.text) > len(longest) {
+			longest = tok.text
+		}
+	}
+	return longest
+}
+
+// findAll returns the byte ranges of up to limit non-overlapping matches of t
+// in buf. limitHit is true if there may be more matches, either because
+// limit was reached or because matching buf was too expensive.
+func (t *structuralTemplate) findAll(buf []byte, lang *structuralSyntax, limit int) (ranges [][2]int, limitHit bool) {
+	m := &structuralMatcher{
+		tokens: t.tokens,
+		lang:   lang,
+		buf:    buf,
+		budget: 100*len(buf) + 100000,
+	}
+
+	for pos := 0; pos < len(buf); {
+		// Only start matching in code, not inside string literals or
+		// comments (but a match may start with one).
+		next, skipped := lang.skip(buf, pos)
+		if !skipped {
+			_, size := utf8.DecodeRune(buf[pos:])
+			next = pos + size
+		}
+
+		if end, ok := m.match(0, pos); ok && end > pos {
+			if len(ranges) == limit {
+				return ranges, true
+			}
+			ranges = append(ranges, [2]int{pos, end})
+			next = end
+		}
+		if m.budget < 0 {
+			return ranges, true
+		}
+		pos = next
+	}
+	return ranges, false
+}
+
+// structuralMatcher matches a template against a single file.
+type structuralMatcher struct {
+	tokens []structuralToken
+	lang   *structuralSyntax
+	buf    []byte
+
+	// budget is the number of steps left before we give up on the file, to
+	// bound the cost of pathological templates and inputs.
+	budget int
+}
+
+// match reports whether m.tokens[ti:] matches the input starting at pos, and
+// if so where the match ends.
+func (m *structuralMatcher) match(ti, pos int) (end int, ok bool) {
+	m.budget--
+	if m.budget < 0 {
+		return 0, false
+	}
+	if ti == len(m.tokens) {
+		return pos, true
+	}
+
+	switch tok := m.tokens[ti]; tok.kind {
+	case structuralLiteral:
+		if !bytes.HasPrefix(m.buf[pos:], tok.text) {
+			return 0, false
+		}
+		return m.match(ti+1, pos+len(tok.text))
+
+	case structuralSpace:
+		for pos < len(m.buf) && isSpace(m.buf[pos]) {
+			pos++
+		}
+		return m.match(ti+1, pos)
+
+	case structuralHole:
+		for {
+			if end, ok := m.match(ti+1, pos); ok {
+				return end, true
+			}
+			next, ok := m.skipUnit(pos, !tok.nested)
+			if !ok {
+				return 0, false
+			}
+			pos = next
+		}
+	}
+	panic("unreachable")
+}
+
+// skipUnit returns the end of the balanced unit starting at pos: a string
+// literal, a comment, a delimited group or a single character. It returns
+// ok == false if there is no such unit, such as at a closing delimiter, or at
+// a newline if topLevel.
+func (m *structuralMatcher) skipUnit(pos int, topLevel bool) (end int, ok bool) {
+	m.budget--
+	if pos >= len(m.buf) || m.budget < 0 {
+		return 0, false
+	}
+	if end, ok := m.lang.skip(m.buf, pos); ok {
+		return end, true
+	}
+
+	c := m.buf[pos]
+	if closer, ok := delimiters[c]; ok {
+		pos++
+		for pos < len(m.buf) {
+			if m.buf[pos] == closer {
+				return pos + 1, true
+			}
+			if pos, ok = m.skipUnit(pos, false); !ok {
+				return 0, false
+			}
+		}
+		return 0, false
+	}
+	if isClosingDelimiter(c) || (topLevel && c == '\n') {
+		return 0, false
+	}
+	_, size := utf8.DecodeRune(m.buf[pos:])
+	return pos + size, true
+}
+
+// structuralSyntax describes the lexical syntax of a language that matters
+// for balancing delimiters: string literals and comments.
+type structuralSyntax struct {
+	lineComments  []string
+	blockComments [][2]string
+	quotes        []structuralQuote // longest delimiters first
+}
+
+type structuralQuote struct {
+	delimiter string
+	escapes   bool // whether a backslash escapes the next character
+	multiline bool // whether the literal may span lines
+}
+
+// skip returns the end of the string literal or comment starting at pos, if
+// any. Unterminated string literals end at the end of the line (if they may
+// not span lines) or the end of the file.
+func (s *structuralSyntax) skip(buf []byte, pos int) (end int, ok bool) {
+	rest := buf[pos:]
+	for _, c := range s.lineComments {
+		if bytes.HasPrefix(rest, []byte(c)) {
+			if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+				return pos + i, true
+			}
+			return len(buf), true
+		}
+	}
+	for _, c := range s.blockComments {
+		if bytes.HasPrefix(rest, []byte(c[0])) {
+			if i := bytes.Index(rest[len(c[0]):], []byte(c[1])); i >= 0 {
+				return pos + len(c[0]) + i + len(c[1]), true
+			}
+			return len(buf), true
+		}
+	}
+	for _, q := range s.quotes {
+		if !bytes.HasPrefix(rest, []byte(q.delimiter)) {
+			continue
+		}
+		for i := len(q.delimiter); i < len(rest); i++ {
+			switch {
+			case q.escapes && rest[i] == '\\':
+				i++
+			case rest[i] == '\n' && !q.multiline:
+				return pos + i, true
+			case bytes.HasPrefix(rest[i:], []byte(q.delimiter)):
+				return pos + i + len(q.delimiter), true
+			}
+		}
+		return len(buf), true
+	}
+	return 0, false
+}
+
+var (
+	cQuotes = []structuralQuote{{delimiter: `"`, escapes: true}, {delimiter: `'`, escapes: true}}
+
+	cSyntax = &structuralSyntax{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        cQuotes,
+	}
+	goSyntax = &structuralSyntax{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        append([]structuralQuote{{delimiter: "`", multiline: true}}, cQuotes...),
+	}
+	javaScriptSyntax = &structuralSyntax{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        append([]structuralQuote{{delimiter: "`", escapes: true, multiline: true}}, cQuotes...),
+	}
+	// In Rust and Swift, ' is also used for lifetimes and the like, so it
+	// does not reliably start a string literal.
+	rustSyntax = &structuralSyntax{
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        cQuotes[:1],
+	}
+	pythonSyntax = &structuralSyntax{
+		lineComments: []string{"#"},
+		quotes: append([]structuralQuote{
+			{delimiter: `"""`, escapes: true, multiline: true},
+			{delimiter: `'''`, escapes: true, multiline: true},
+		}, cQuotes...),
+	}
+	hashSyntax = &structuralSyntax{
+		lineComments: []string{"#"},
+		quotes:       cQuotes,
+	}
+
+	// defaultStructuralSyntax is used for files in other languages. It
+	// assumes as little as possible: ' is often an apostrophe in prose.
+	defaultStructuralSyntax = &structuralSyntax{quotes: cQuotes[:1]}
+
+	// structuralSyntaxes maps filelang language names to their syntax.
+	structuralSyntaxes = map[string]*structuralSyntax{
+		"C":           cSyntax,
+		"C#":          cSyntax,
+		"C++":         cSyntax,
+		"Dart":        cSyntax,
+		"Go":          goSyntax,
+		"JSX":         javaScriptSyntax,
+		"Java":        cSyntax,
+		"JavaScript":  javaScriptSyntax,
+		"Kotlin":      cSyntax,
+		"Objective-C": cSyntax,
+		"PHP":         cSyntax,
+		"Perl":        hashSyntax,
+		"Python":      pythonSyntax,
+		"Ruby":        hashSyntax,
+		"Rust":        rustSyntax,
+		"Scala":       cSyntax,
+		"Shell":       hashSyntax,
+		"Swift":       rustSyntax,
+		"TypeScript":  javaScriptSyntax,
+		"YAML":        hashSyntax,
+	}
+)
+
+var languagesByFilename = filelang.Langs.CompileByFilename()
+
+// structuralSyntaxFor returns the syntax of the file named name, based on its
+// most likely language.
+func structuralSyntaxFor(name string) *structuralSyntax {
+	for _, l := range languagesByFilename(path.Base(name)) {
+		if s, ok := structuralSyntaxes[l.Name]; ok {
+			return s
+		}
+	}
+	return defaultStructuralSyntax
+}
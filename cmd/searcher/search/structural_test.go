@@ -0,0 +1,119 @@
+package search
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestStructuralTemplate(t *testing.T) {
+	cases := []struct {
+		template   string
+		ignoreCase bool
+		name       string
+		input      string
+		want       []string
+	}{
+		{
+			template: "foo(:[args])",
+			name:     "a.go",
+			input:    "foo() foo(a, b) foo(bar(a), \")\") fooo(x)",
+			want:     []string{"foo()", "foo(a, b)", `foo(bar(a), ")")`},
+		},
+		{
+			// Holes inside delimiters match across lines.
+			template: "foo(:[args])",
+			name:     "a.go",
+			input:    "x := foo(a,\n\tb)\n",
+			want:     []string{"foo(a,\n\tb)"},
+		},
+		{
+			// Delimiters in comments and strings are ignored.
+			template: "foo(:[args])",
+			name:     "a.go",
+			input:    "foo(a /* ) */, `)`, ')', \"\\\")\")",
+			want:     []string{"foo(a /* ) */, `)`, ')', \"\\\")\")"},
+		},
+		{
+			// Matches do not start inside comments or strings.
+			template: "foo(:[args])",
+			name:     "a.go",
+			input:    "// foo(a)\n\"foo(b)\"\nfoo(c)",
+			want:     []string{"foo(c)"},
+		},
+		{
+			// Comments are language specific.
+			template: "foo(:[args])",
+			name:     "a.py",
+			input:    "# foo(a)\nfoo(b # )\n)",
+			want:     []string{"foo(b # )\n)"},
+		},
+		{
+			// Whitespace in the template matches any amount of whitespace.
+			template: "if :[cond] {",
+			name:     "a.go",
+			input:    "if  x == y {\nif a\nb {",
+			want:     []string{"if  x == y {"},
+		},
+		{
+			// Holes outside delimiters do not match newlines.
+			template: "a :[x] b",
+			name:     "a.txt",
+			input:    "a 1\n2 b a (1\n2) b",
+			want:     []string{"a (1\n2) b"},
+		},
+		{
+			template:   "FOO(:[x])",
+			ignoreCase: true,
+			name:       "a.go",
+			input:      "foo(1) Foo(2)",
+			want:       []string{"foo(1)", "foo(2)"},
+		},
+		{
+			template: "[:[x]]",
+			name:     "a.js",
+			input:    "a[b[c]] [`]`]",
+			want:     []string{"[b[c]]", "[`]`]"},
+		},
+	}
+	for _, c := range cases {
+		tmpl, err := compileStructural(c.template, c.ignoreCase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input := []byte(c.input)
+		if c.ignoreCase {
+			bytesToLowerASCII(input, input)
+		}
+		ranges, limitHit := tmpl.findAll(input, structuralSyntaxFor(c.name), 10)
+		if limitHit {
+			t.Errorf("%q in %q: unexpected limitHit", c.template, c.input)
+		}
+		var got []string
+		for _, r := range ranges {
+			got = append(got, string(input[r[0]:r[1]]))
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q in %q:\ngot  %q\nwant %q", c.template, c.input, got, c.want)
+		}
+	}
+}
+
+func TestCompileStructural_errors(t *testing.T) {
+	for _, template := range []string{"", "  ", ":[a]:[b]"} {
+		if _, err := compileStructural(template, false); err == nil {
+			t.Errorf("%q: got err == nil, want error", template)
+		}
+	}
+}
+
+func TestStructuralTemplate_budget(t *testing.T) {
+	tmpl, err := compileStructural("(:[a], :[b], :[c]);", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := append([]byte("("), bytes.Repeat([]byte("x, "), 10000)...)
+	if _, limitHit := tmpl.findAll(input, defaultStructuralSyntax, 10); !limitHit {
+		t.Error("got limitHit == false, want true")
+	}
+}
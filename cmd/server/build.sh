@@ -32,7 +32,6 @@ for pkg in $server_pkg \
     github.com/sourcegraph/sourcegraph/cmd/github-proxy \
     github.com/sourcegraph/sourcegraph/cmd/gitserver \
     github.com/sourcegraph/sourcegraph/cmd/query-runner \
-    github.com/sourcegraph/sourcegraph/cmd/symbols \
     github.com/sourcegraph/sourcegraph/cmd/repo-updater \
     github.com/sourcegraph/sourcegraph/cmd/searcher \
     github.com/google/zoekt/cmd/zoekt-archive-index \
@@ -42,6 +41,10 @@ for pkg in $server_pkg \
     go build -ldflags "-X github.com/sourcegraph/sourcegraph/pkg/version.version=$VERSION" -buildmode exe -tags dist -o "$bindir/$(basename "$pkg")" "$pkg"
 done
 
+# The symbols service stores its index in SQLite, which requires cgo. The
+# binary is linked statically so it runs on alpine (see cmd/symbols/build.sh).
+CGO_ENABLED=1 go build -ldflags "-X github.com/sourcegraph/sourcegraph/pkg/version.version=$VERSION -extldflags '-static'" -buildmode exe -tags "dist netgo" -o "$bindir/symbols" github.com/sourcegraph/sourcegraph/cmd/symbols
+
 mkdir -p "$OUTPUT/.ctags.d"
 cp cmd/symbols/.ctags.d/additional-languages.ctags "$OUTPUT/.ctags.d/additional-languages.ctags"
 
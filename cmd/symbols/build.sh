@@ -14,11 +14,13 @@ trap cleanup EXIT
 export GO111MODULE=on
 export GOARCH=amd64
 export GOOS=linux
-export CGO_ENABLED=0
+# The symbols service stores its index in SQLite, which requires cgo. The
+# binary is linked statically so it runs on alpine.
+export CGO_ENABLED=1
 
 echo "Compiling the symbols service..."
 for pkg in github.com/sourcegraph/sourcegraph/cmd/symbols; do
-    go build -ldflags "-X github.com/sourcegraph/sourcegraph/pkg/version.version=$VERSION" -buildmode exe -tags dist -o $OUTPUT/$(basename $pkg) $pkg
+    go build -ldflags "-X github.com/sourcegraph/sourcegraph/pkg/version.version=$VERSION -extldflags '-static'" -buildmode exe -tags "dist netgo" -o $OUTPUT/$(basename $pkg) $pkg
 done
 
 mkdir "$OUTPUT/.ctags.d"
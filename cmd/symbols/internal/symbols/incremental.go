@@ -0,0 +1,213 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+const (
+	// maxIncrementalAncestors is the number of ancestors of a commit we look
+	// at to find one which is already indexed.
+	maxIncrementalAncestors = 100
+
+	// maxIncrementalChanges is the number of changed files above which we
+	// parse every file instead.
+	maxIncrementalChanges = 1000
+
+	// maxIncrementalDepth is the length of the chain of parent indexes above
+	// which we parse every file instead. This bounds how long mistakes (eg
+	// a file ctags failed to parse) are carried forward.
+	maxIncrementalDepth = 50
+)
+
+// errNotIndexed is returned by the fetcher we use to check if a commit is
+// already indexed.
+var errNotIndexed = errors.New("not indexed")
+
+// parseIndex returns the index of the symbols of repo at commitID. It only
+// parses the files which changed since the closest indexed ancestor of
+// commitID, if there is one (see Service.GitDiff).
+func (s *Service) parseIndex(ctx context.Context, repo api.RepoName, commitID api.CommitID) (*symbolIndex, error) {
+	if s.FetchTarPaths != nil && s.ListAncestors != nil && s.GitDiff != nil {
+		idx, err := s.parseIncremental(ctx, repo, commitID)
+		if err != nil && ctx.Err() == nil {
+			log15.Warn("Incremental symbol indexing failed, parsing every file.", "repo", repo, "commitID", commitID, "error", err)
+		}
+		if idx != nil {
+			incrementalIndexes.Inc()
+			return idx, nil
+		}
+	}
+
+	symbols, err := s.parseUncached(ctx, repo, commitID, nil)
+	if err != nil {
+		return nil, err
+	}
+	sortSymbols(symbols)
+	return &symbolIndex{Symbols: symbols}, nil
+}
+
+// parseIncremental computes the index of repo at commitID from the index of
+// its closest indexed ancestor. It returns a nil index if there is no such
+// ancestor, or if too much changed since it.
+func (s *Service) parseIncremental(ctx context.Context, repo api.RepoName, commitID api.CommitID) (*symbolIndex, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "parseIncremental")
+	defer span.Finish()
+
+	parent, parentIdx, err := s.closestIndexedAncestor(ctx, repo, commitID)
+	if err != nil || parentIdx == nil {
+		return nil, err
+	}
+	span.SetTag("parent", string(parent))
+	if parentIdx.Depth >= maxIncrementalDepth {
+		return nil, nil
+	}
+
+	diff, err := s.GitDiff(ctx, gitserver.Repo{Name: repo}, parent, commitID)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := parseNameStatus(diff)
+	if err != nil {
+		return nil, err
+	}
+	span.SetTag("changed", len(changed))
+	if len(changed) > maxIncrementalChanges {
+		return nil, nil
+	}
+
+	var paths []string
+	for path, status := range changed {
+		if status != 'D' {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	symbols := make([]protocol.Symbol, 0, len(parentIdx.Symbols))
+	for _, symbol := range parentIdx.Symbols {
+		if _, ok := changed[symbol.Path]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(paths) > 0 {
+		parsed, err := s.parseUncached(ctx, repo, commitID, paths)
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, parsed...)
+	}
+	sortSymbols(symbols)
+
+	return &symbolIndex{
+		Parent:  parent,
+		Depth:   parentIdx.Depth + 1,
+		Symbols: symbols,
+	}, nil
+}
+
+// closestIndexedAncestor returns the closest ancestor of commitID which is
+// already indexed, and its index. It returns a nil index if none of the
+// nearest maxIncrementalAncestors ancestors are indexed.
+func (s *Service) closestIndexedAncestor(ctx context.Context, repo api.RepoName, commitID api.CommitID) (api.CommitID, *symbolIndex, error) {
+	ancestors, err := s.ListAncestors(ctx, gitserver.Repo{Name: repo}, commitID, maxIncrementalAncestors)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor == commitID {
+			continue
+		}
+		f, err := s.cache.Open(ctx, indexKey(repo, ancestor), func(context.Context) (io.ReadCloser, error) {
+			return nil, errNotIndexed
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", nil, ctx.Err()
+			}
+			continue
+		}
+		// Read the index while we hold the cache entry open, so that the
+		// cache can't evict it in the meantime (see watchAndEvict).
+		idx, err := readIndex(ctx, f.Path)
+		f.Close()
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", nil, ctx.Err()
+			}
+			log15.Warn("Failed to read symbol index of ancestor, trying older ancestors.", "repo", repo, "commitID", ancestor, "error", err)
+			continue
+		}
+		return ancestor, idx, nil
+	}
+	return "", nil, nil
+}
+
+// parseNameStatus parses the output of `git diff -z --name-status`. It
+// returns the status letter (eg 'M' or 'D') of each changed path. For
+// renames and copies, the old path is reported as deleted and the new path as
+// added.
+func parseNameStatus(out []byte) (map[string]byte, error) {
+	changed := map[string]byte{}
+	if len(out) == 0 {
+		return changed, nil
+	}
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	for i := 0; i < len(fields); {
+		status := fields[i]
+		if len(status) == 0 {
+			return nil, errors.New("invalid git diff output: empty status")
+		}
+		switch status[0] {
+		case 'R', 'C':
+			if i+2 >= len(fields) {
+				return nil, errors.New("invalid git diff output: missing path")
+			}
+			if status[0] == 'R' {
+				changed[string(fields[i+1])] = 'D'
+			}
+			changed[string(fields[i+2])] = 'A'
+			i += 3
+		default:
+			if i+1 >= len(fields) {
+				return nil, errors.New("invalid git diff output: missing path")
+			}
+			changed[string(fields[i+1])] = status[0]
+			i += 2
+		}
+	}
+	return changed, nil
+}
+
+// sortSymbols sorts symbols by path and line, so that the index of a commit
+// is the same no matter how it was computed.
+func sortSymbols(symbols []protocol.Symbol) {
+	sort.SliceStable(symbols, func(i, j int) bool {
+		a, b := &symbols[i], &symbols[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+}
+
+var incrementalIndexes = prometheus.NewCounter(prometheus.CounterOpts{
+	Namespace: "symbols",
+	Subsystem: "parse",
+	Name:      "incremental_indexes",
+	Help:      "The total number of commits indexed by only parsing the files changed since an indexed ancestor.",
+})
+
+func init() {
+	prometheus.MustRegister(incrementalIndexes)
+}
@@ -0,0 +1,153 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+func TestService_incremental(t *testing.T) {
+	commits := map[api.CommitID]map[string]string{
+		"c1": {"a.js": "a1", "b.js": "b1", "d.js": "d1"},
+		"c2": {"a.js": "a2", "c.js": "c2", "d.js": "d1"},
+	}
+	fetchTar := func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+		return createTar(commits[commit])
+	}
+	var fetchedPaths []string
+	fetchTarPaths := func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+		fetchedPaths = append(fetchedPaths, paths...)
+		files := map[string]string{}
+		for _, p := range paths {
+			files[p] = commits[commit][p]
+		}
+		return createTar(files)
+	}
+
+	newService := func(incremental bool) (*Service, func()) {
+		tmpDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := &Service{
+			FetchTar: fetchTar,
+			NewParser: func() (ctags.Parser, error) {
+				return contentParser{}, nil
+			},
+			Path: tmpDir,
+		}
+		if incremental {
+			s.FetchTarPaths = fetchTarPaths
+			s.ListAncestors = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error) {
+				if commit == "c2" {
+					return []api.CommitID{"c1"}, nil
+				}
+				return nil, nil
+			}
+			s.GitDiff = func(ctx context.Context, repo gitserver.Repo, base, head api.CommitID) ([]byte, error) {
+				if base != "c1" || head != "c2" {
+					t.Fatalf("unexpected diff %s..%s", base, head)
+				}
+				return []byte("M\x00a.js\x00D\x00b.js\x00A\x00c.js\x00"), nil
+			}
+		}
+		if err := s.Start(); err != nil {
+			t.Fatal(err)
+		}
+		return s, func() { os.RemoveAll(tmpDir) }
+	}
+
+	ctx := context.Background()
+	s, cleanup := newService(true)
+	defer cleanup()
+	if _, err := s.search(ctx, protocol.SearchArgs{Repo: "r", CommitID: "c1"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fetchedPaths) != 0 {
+		t.Fatalf("c1 has no indexed ancestor, but only paths %v were fetched", fetchedPaths)
+	}
+	got, err := s.search(ctx, protocol.SearchArgs{Repo: "r", CommitID: "c2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.js", "c.js"}; !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("fetched paths %v, want %v", fetchedPaths, want)
+	}
+
+	// The result is the same as when parsing every file.
+	full, cleanupFull := newService(false)
+	defer cleanupFull()
+	want, err := full.search(ctx, protocol.SearchArgs{Repo: "r", CommitID: "c2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+
+	// The index records the commit it was computed from.
+	idx, err := s.parseIndex(ctx, "r", "c2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Parent != "c1" || idx.Depth != 1 {
+		t.Errorf("got parent %q depth %d, want parent c1 depth 1", idx.Parent, idx.Depth)
+	}
+
+	// An ancestor whose index can't be read is skipped.
+	f, err := s.cache.Open(ctx, indexKey("r", "c1"), func(context.Context) (io.ReadCloser, error) {
+		return nil, errNotIndexed
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(f.Path, []byte("not an index"), 0600)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, parentIdx, err := s.closestIndexedAncestor(ctx, "r", "c2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent != "" || parentIdx != nil {
+		t.Errorf("got ancestor %q with an unreadable index, want none", parent)
+	}
+}
+
+func TestParseNameStatus(t *testing.T) {
+	got, err := parseNameStatus([]byte("M\x00a\x00D\x00b b\x00R100\x00c\x00d\x00C50\x00e\x00f\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]byte{"a": 'M', "b b": 'D', "c": 'D', "d": 'A', "f": 'A'}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := parseNameStatus([]byte("M\x00")); err == nil {
+		t.Error("missing path: got err == nil, want error")
+	}
+}
+
+// contentParser returns a symbol for each word in a file.
+type contentParser struct{}
+
+func (contentParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
+	var entries []ctags.Entry
+	for i, word := range strings.Fields(string(content)) {
+		entries = append(entries, ctags.Entry{Name: word, Path: name, Line: i + 1})
+	}
+	return entries, nil
+}
+
+func (contentParser) Close() {}
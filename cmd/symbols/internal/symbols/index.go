@@ -1,12 +1,9 @@
 package symbols
 
 import (
-	"bytes"
-	"compress/gzip"
 	"context"
-	"encoding/gob"
+	"database/sql"
 	"io"
-	"io/ioutil"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -16,7 +13,28 @@ import (
 	"golang.org/x/net/trace"
 )
 
-func (s *Service) indexedSymbols(ctx context.Context, repo api.RepoName, commitID api.CommitID) (symbols []protocol.Symbol, err error) {
+// symbolIndex is the cache entry for the symbols of a repository at a commit.
+type symbolIndex struct {
+	// Parent is the commit whose index this index was computed from, by only
+	// parsing the files which changed since Parent. It is empty if every file
+	// was parsed.
+	Parent api.CommitID
+
+	// Depth is the length of the chain of Parents.
+	Depth int
+
+	Symbols []protocol.Symbol
+}
+
+// indexKey returns the cache key of the index of repo at commitID.
+func indexKey(repo api.RepoName, commitID api.CommitID) string {
+	return string(repo) + ":" + string(commitID) + ":v3" // suffix is index format version (vN)
+}
+
+// indexedSymbols returns the SQLite database with the index of repo at
+// commitID (see indexSchema), computing the index first if it is not cached.
+// The caller must close the database.
+func (s *Service) indexedSymbols(ctx context.Context, repo api.RepoName, commitID api.CommitID) (db *sql.DB, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "indexedSymbols")
 	defer func() {
 		if err != nil {
@@ -26,14 +44,12 @@ func (s *Service) indexedSymbols(ctx context.Context, repo api.RepoName, commitI
 		span.Finish()
 	}()
 
-	key := string(repo) + ":" + string(commitID) + ":v1" // suffix is index format version (vN)
-
 	tr := trace.New("indexedSymbols", string(repo))
 	tr.LazyPrintf("commitID: %s", commitID)
 
 	var fetched bool
 	defer func() {
-		tr.LazyPrintf("fetched=%v symbols=%d", fetched, len(symbols))
+		tr.LazyPrintf("fetched=%v", fetched)
 		if err != nil {
 			tr.LazyPrintf("error: %s", err)
 			tr.SetError()
@@ -41,69 +57,33 @@ func (s *Service) indexedSymbols(ctx context.Context, repo api.RepoName, commitI
 		tr.Finish()
 	}()
 
-	f, err := s.cache.Open(ctx, key, func(ctx context.Context) (io.ReadCloser, error) {
+	f, err := s.cache.Open(ctx, indexKey(repo, commitID), func(ctx context.Context) (io.ReadCloser, error) {
 		fetched = true
 
-		var err error
-		symbols, err = s.parseUncached(ctx, repo, commitID)
+		idx, err := s.parseIndex(ctx, repo, commitID)
 		if err != nil {
 			return nil, err
 		}
-		return encodeSymbols(symbols)
+		tr.LazyPrintf("symbols=%d", len(idx.Symbols))
+		return writeIndex(s.Path, idx)
 	})
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	// Skip deserializing symbols if we just serialized them in the s.cache.Open call above and still have the
-	// slice.
-	if symbols == nil {
-		var size int64
-		if fi, err := f.Stat(); err == nil {
-			size = fi.Size()
-		}
-		tr.LazyPrintf("decode bytes=%d", size)
-		symbols, err = decodeSymbols(ctx, f)
-		tr.LazyPrintf("decode (done) symbols=%d", len(symbols))
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	span.LogFields(otlog.String("event", "result"), otlog.Int("count", len(symbols)))
-	return symbols, nil
-}
-
-func encodeSymbols(symbols []protocol.Symbol) (io.ReadCloser, error) {
-	var buf bytes.Buffer
-	zw := gzip.NewWriter(&buf)
-	enc := gob.NewEncoder(zw)
-	if err := enc.Encode(symbols); err != nil {
-		return nil, err
-	}
-	if err := zw.Close(); err != nil {
+	db, err = openIndex(f.Path)
+	if err != nil {
 		return nil, err
 	}
-	return ioutil.NopCloser(&buf), nil
-}
-
-func decodeSymbols(ctx context.Context, r io.Reader) (symbols []protocol.Symbol, err error) {
-	span, _ := opentracing.StartSpanFromContext(ctx, "decodeSymbols")
-	defer func() {
-		if err != nil {
-			ext.Error.Set(span, true)
-			span.LogFields(otlog.Error(err))
-		}
-		span.Finish()
-	}()
-
-	zr, err := gzip.NewReader(r)
-	if err != nil {
+	// sql.Open doesn't open the file, so connect while we still hold the
+	// cache entry open, before the cache can evict it (see watchAndEvict).
+	// Once connected, SQLite keeps using the open file even if it is
+	// removed, so we limit the pool to that single connection.
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, err
 	}
-	defer zr.Close()
-	dec := gob.NewDecoder(zr)
-	err = dec.Decode(&symbols)
-	return symbols, err
+	return db, nil
 }
@@ -5,12 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"regexp"
-	"strings"
 	"time"
 
-	"github.com/sourcegraph/sourcegraph/pkg/pathmatch"
-
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
 	otlog "github.com/opentracing/opentracing-go/log"
@@ -55,7 +51,9 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (result
 	span.SetTag("repo", args.Repo)
 	span.SetTag("commitID", args.CommitID)
 	span.SetTag("query", args.Query)
+	span.SetTag("match", args.Match)
 	span.SetTag("first", args.First)
+	span.SetTag("offset", args.Offset)
 	defer func() {
 		if err != nil {
 			ext.Error.Set(span, true)
@@ -73,93 +71,23 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (result
 		tr.Finish()
 	}()
 
-	symbols, err := s.indexedSymbols(ctx, args.Repo, args.CommitID)
+	db, err := s.indexedSymbols(ctx, args.Repo, args.CommitID)
 	if err != nil {
 		return nil, err
 	}
+	defer db.Close()
 
 	const maxFirst = 500
 	if args.First < 0 || args.First > maxFirst {
 		args.First = maxFirst
 	}
-
-	result = &protocol.SearchResult{}
-	if args.Query == "" && len(args.IncludePatterns) == 0 && args.ExcludePattern == "" {
-		// No filters were provided, save iterating the symbols and return a slice
-		if args.First != 0 && len(symbols) > args.First {
-			symbols = symbols[:args.First]
-		}
-		result.Symbols = symbols
-	} else {
-		res, err := filterSymbols(ctx, symbols, args)
-		if err != nil {
-			return nil, err
-		}
-		result.Symbols = res
-	}
-	return result, nil
-}
-
-func filterSymbols(ctx context.Context, symbols []protocol.Symbol, args protocol.SearchArgs) (res []protocol.Symbol, err error) {
-	span, _ := opentracing.StartSpanFromContext(ctx, "filterSymbols")
-	defer func() {
-		if err != nil {
-			ext.Error.Set(span, true)
-			span.LogFields(otlog.Error(err))
-		}
-		span.Finish()
-	}()
-	span.SetTag("before", len(symbols))
-
-	query := args.Query
-	if !args.IsRegExp {
-		query = regexp.QuoteMeta(query)
-	}
-	if !args.IsCaseSensitive {
-		query = "(?i:" + query + ")"
-	}
-	queryRegex, err := regexp.Compile(query)
-	if err != nil {
-		return nil, err
+	if args.Offset < 0 {
+		args.Offset = 0
 	}
 
-	fileFilter, err := pathmatch.CompilePathPatterns(args.IncludePatterns, args.ExcludePattern, pathmatch.CompileOptions{
-		CaseSensitive: args.IsCaseSensitive,
-		RegExp:        args.IsRegExp,
-	})
+	symbols, hasMore, err := querySymbols(ctx, db, args)
 	if err != nil {
 		return nil, err
 	}
-
-	pathFilter := func(s protocol.Symbol) bool {
-		return fileFilter.MatchPath(s.Path)
-	}
-
-	nameFilter := func(s protocol.Symbol) bool {
-		return queryRegex.MatchString(s.Name)
-	}
-
-	var filters []func(s protocol.Symbol) bool
-
-	if strings.HasPrefix(args.Query, "^") {
-		filters = []func(s protocol.Symbol) bool{nameFilter, pathFilter}
-	} else {
-		filters = []func(s protocol.Symbol) bool{pathFilter, nameFilter}
-	}
-
-SYMBOL:
-	for _, symbol := range symbols {
-		for _, filter := range filters {
-			if !filter(symbol) {
-				continue SYMBOL
-			}
-		}
-		res = append(res, symbol)
-		if args.First > 0 && len(res) == args.First {
-			break
-		}
-	}
-
-	span.SetTag("after", len(res))
-	return res, nil
+	return &protocol.SearchResult{Symbols: symbols, HasMore: hasMore}, nil
 }
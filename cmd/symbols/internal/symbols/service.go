@@ -22,6 +22,21 @@ type Service struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(context.Context, gitserver.Repo, api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but the archive only contains the
+	// given paths.
+	FetchTarPaths func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error)
+
+	// ListAncestors returns up to n ancestors of commit, nearest first.
+	ListAncestors func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error)
+
+	// GitDiff returns the output of `git diff -z --name-status --no-renames
+	// base head`.
+	//
+	// If FetchTarPaths, ListAncestors and GitDiff are set, the symbols of a
+	// commit are computed from those of its closest indexed ancestor, by only
+	// parsing the files which changed since the ancestor.
+	GitDiff func(ctx context.Context, repo gitserver.Repo, base, head api.CommitID) ([]byte, error)
+
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
 	MaxConcurrentFetchTar int
@@ -0,0 +1,336 @@
+package symbols
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	// Register the sqlite3 database/sql driver.
+	_ "github.com/mattn/go-sqlite3"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/pathmatch"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+// The index of a repository at a commit is a SQLite database with the
+// following schema. Symbols are inserted in the order of sortSymbols, so
+// ordering by rowid gives the same order as before the index was stored in
+// SQLite.
+const indexSchema = `
+CREATE TABLE meta (
+	parent TEXT NOT NULL,
+	depth INTEGER NOT NULL
+);
+
+CREATE TABLE symbols (
+	name TEXT NOT NULL,
+	namelowercase TEXT NOT NULL,
+	path TEXT NOT NULL,
+	line INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	language TEXT NOT NULL,
+	parent TEXT NOT NULL,
+	parentkind TEXT NOT NULL,
+	signature TEXT NOT NULL,
+	pattern TEXT NOT NULL,
+	filelimited BOOLEAN NOT NULL
+);
+
+CREATE INDEX name_index ON symbols(name);
+CREATE INDEX namelowercase_index ON symbols(namelowercase);
+CREATE INDEX path_index ON symbols(path);
+`
+
+const symbolColumns = "name, path, line, kind, language, parent, parentkind, signature, pattern, filelimited"
+
+// writeIndex writes idx to a new SQLite database. The returned reader reads
+// the database file, which is removed when the reader is closed.
+func writeIndex(dir string, idx *symbolIndex) (_ io.ReadCloser, err error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	tmp, err := ioutil.TempFile(dir, "index-*.sqlite.tmp")
+	if err != nil {
+		return nil, err
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer func() {
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(indexSchema); err != nil {
+		return nil, errors.Wrap(err, "create schema")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO meta (parent, depth) VALUES (?, ?)", string(idx.Parent), idx.Depth); err != nil {
+		return nil, err
+	}
+	stmt, err := tx.Prepare("INSERT INTO symbols (namelowercase, " + symbolColumns + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for _, s := range idx.Symbols {
+		if _, err := stmt.Exec(strings.ToLower(s.Name), s.Name, s.Path, s.Line, s.Kind, s.Language, s.Parent, s.ParentKind, s.Signature, s.Pattern, s.FileLimited); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &removeOnClose{File: f}, nil
+}
+
+// removeOnClose is an *os.File which is removed when closed.
+type removeOnClose struct {
+	*os.File
+}
+
+func (f *removeOnClose) Close() error {
+	err := f.File.Close()
+	if err2 := os.Remove(f.Name()); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// openIndex opens the SQLite database at path read-only.
+func openIndex(path string) (*sql.DB, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	// The database is never modified once it is in the cache, so we can tell
+	// SQLite to skip locking (immutable=1).
+	return sql.Open("sqlite3", "file:"+path+"?mode=ro&immutable=1")
+}
+
+// readIndex reads every symbol of the index in the SQLite database at path.
+func readIndex(ctx context.Context, path string) (_ *symbolIndex, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "readIndex")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+
+	db, err := openIndex(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var idx symbolIndex
+	if err := db.QueryRowContext(ctx, "SELECT parent, depth FROM meta").Scan(&idx.Parent, &idx.Depth); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, "SELECT "+symbolColumns+" FROM symbols ORDER BY rowid")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		s, err := scanSymbol(rows)
+		if err != nil {
+			return nil, err
+		}
+		idx.Symbols = append(idx.Symbols, s)
+	}
+	return &idx, rows.Err()
+}
+
+func scanSymbol(rows *sql.Rows) (s protocol.Symbol, err error) {
+	err = rows.Scan(&s.Name, &s.Path, &s.Line, &s.Kind, &s.Language, &s.Parent, &s.ParentKind, &s.Signature, &s.Pattern, &s.FileLimited)
+	return s, err
+}
+
+// querySymbols returns the symbols in db which match args, and whether there
+// are more matches after them.
+func querySymbols(ctx context.Context, db *sql.DB, args protocol.SearchArgs) (res []protocol.Symbol, hasMore bool, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "querySymbols")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+
+	where, whereArgs, nameFilter, err := nameConditions(args)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(args.Kinds) > 0 {
+		where = append(where, "lower(kind) IN ("+placeholders(len(args.Kinds))+")")
+		for _, kind := range args.Kinds {
+			whereArgs = append(whereArgs, strings.ToLower(kind))
+		}
+	}
+	if len(args.Languages) > 0 {
+		where = append(where, "lower(language) IN ("+placeholders(len(args.Languages))+")")
+		for _, language := range args.Languages {
+			whereArgs = append(whereArgs, strings.ToLower(language))
+		}
+	}
+
+	var pathFilter pathmatch.PathMatcher
+	if len(args.IncludePatterns) > 0 || args.ExcludePattern != "" {
+		pathFilter, err = pathmatch.CompilePathPatterns(args.IncludePatterns, args.ExcludePattern, pathmatch.CompileOptions{
+			CaseSensitive: args.IsCaseSensitive,
+			RegExp:        args.IsRegExp,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	q := "SELECT " + symbolColumns + " FROM symbols"
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	q += " ORDER BY rowid"
+
+	// When every condition is in the query, SQLite does the pagination.
+	// Otherwise we skip and count matches as we filter them.
+	offset := args.Offset
+	if nameFilter == nil && pathFilter == nil {
+		if args.First > 0 {
+			q += fmt.Sprintf(" LIMIT %d", args.First+1)
+		} else {
+			q += " LIMIT -1"
+		}
+		if offset > 0 {
+			q += fmt.Sprintf(" OFFSET %d", offset)
+		}
+		offset = 0
+	}
+	span.LogFields(otlog.String("query", q))
+
+	rows, err := db.QueryContext(ctx, q, whereArgs...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		symbol, err := scanSymbol(rows)
+		if err != nil {
+			return nil, false, err
+		}
+		if nameFilter != nil && !nameFilter.MatchString(symbol.Name) {
+			continue
+		}
+		if pathFilter != nil && !pathFilter.MatchPath(symbol.Path) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if args.First > 0 && len(res) == args.First {
+			hasMore = true
+			break
+		}
+		res = append(res, symbol)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	span.SetTag("count", len(res))
+	return res, hasMore, nil
+}
+
+// nameConditions returns the SQL conditions for matching symbol names with
+// args.Query. If the query can't be expressed in SQL, it returns a regexp
+// which names must match instead.
+func nameConditions(args protocol.SearchArgs) (where []string, whereArgs []interface{}, filter *regexp.Regexp, err error) {
+	if args.Query == "" {
+		return nil, nil, nil, nil
+	}
+
+	column, query := "name", args.Query
+	if !args.IsCaseSensitive {
+		column, query = "namelowercase", strings.ToLower(query)
+	}
+
+	switch args.Match {
+	case protocol.MatchExact:
+		return []string{column + " = ?"}, []interface{}{query}, nil, nil
+
+	case protocol.MatchPrefix:
+		// UTF-8 never contains the byte 0xff, so every name with the prefix
+		// sorts before prefix+"\xff". Unlike LIKE, the comparison uses the
+		// index.
+		return []string{column + " >= ?", column + " < ?"}, []interface{}{query, query + "\xff"}, nil, nil
+
+	case protocol.MatchFuzzy:
+		// The characters of the query must appear in order in the name.
+		var pattern strings.Builder
+		pattern.WriteByte('*')
+		for _, r := range query {
+			pattern.WriteString(globEscape(r))
+			pattern.WriteByte('*')
+		}
+		return []string{column + " GLOB ?"}, []interface{}{pattern.String()}, nil, nil
+
+	case "":
+		q := args.Query
+		if !args.IsRegExp {
+			q = regexp.QuoteMeta(q)
+		}
+		if !args.IsCaseSensitive {
+			q = "(?i:" + q + ")"
+		}
+		filter, err := regexp.Compile(q)
+		return nil, nil, filter, err
+
+	default:
+		return nil, nil, nil, fmt.Errorf("invalid match mode %q", args.Match)
+	}
+}
+
+// globEscape returns a GLOB pattern which matches r.
+func globEscape(r rune) string {
+	switch r {
+	case '*', '?', '[', ']':
+		return "[" + string(r) + "]"
+	}
+	return string(r)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,99 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+func TestQuerySymbols(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	symbols := []protocol.Symbol{
+		{Name: "NewServer", Path: "server.go", Line: 1, Kind: "function", Language: "Go"},
+		{Name: "Server", Path: "server.go", Line: 2, Kind: "type", Language: "Go"},
+		{Name: "serve", Path: "server.go", Line: 3, Kind: "function", Language: "Go"},
+		{Name: "newService", Path: "web/service.ts", Line: 1, Kind: "function", Language: "TypeScript"},
+		{Name: "a*b", Path: "web/service.ts", Line: 2, Kind: "variable", Language: "TypeScript"},
+	}
+	r, err := writeIndex(tmpDir, &symbolIndex{Parent: "p", Depth: 2, Symbols: symbols})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(tmpDir, "index.sqlite")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	r.Close()
+
+	idx, err := readIndex(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&symbolIndex{Parent: "p", Depth: 2, Symbols: symbols}); !reflect.DeepEqual(idx, want) {
+		t.Errorf("readIndex: got %+v, want %+v", idx, want)
+	}
+
+	db, err := openIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tests := map[string]struct {
+		args        protocol.SearchArgs
+		wantNames   []string
+		wantHasMore bool
+	}{
+		"all":                 {args: protocol.SearchArgs{}, wantNames: []string{"NewServer", "Server", "serve", "newService", "a*b"}},
+		"substring":           {args: protocol.SearchArgs{Query: "serv"}, wantNames: []string{"NewServer", "Server", "serve", "newService"}},
+		"regexp":              {args: protocol.SearchArgs{Query: "^server?$", IsRegExp: true}, wantNames: []string{"Server", "serve"}},
+		"exact":               {args: protocol.SearchArgs{Query: "server", Match: protocol.MatchExact}, wantNames: []string{"Server"}},
+		"exact case":          {args: protocol.SearchArgs{Query: "server", Match: protocol.MatchExact, IsCaseSensitive: true}},
+		"prefix":              {args: protocol.SearchArgs{Query: "new", Match: protocol.MatchPrefix}, wantNames: []string{"NewServer", "newService"}},
+		"prefix case":         {args: protocol.SearchArgs{Query: "New", Match: protocol.MatchPrefix, IsCaseSensitive: true}, wantNames: []string{"NewServer"}},
+		"fuzzy":               {args: protocol.SearchArgs{Query: "nwsrv", Match: protocol.MatchFuzzy}, wantNames: []string{"NewServer", "newService"}},
+		"fuzzy glob chars":    {args: protocol.SearchArgs{Query: "*", Match: protocol.MatchFuzzy}, wantNames: []string{"a*b"}},
+		"kind":                {args: protocol.SearchArgs{Kinds: []string{"FUNCTION"}}, wantNames: []string{"NewServer", "serve", "newService"}},
+		"language":            {args: protocol.SearchArgs{Query: "new", Match: protocol.MatchPrefix, Languages: []string{"typescript"}}, wantNames: []string{"newService"}},
+		"path":                {args: protocol.SearchArgs{IncludePatterns: []string{"^web/"}, IsRegExp: true}, wantNames: []string{"newService", "a*b"}},
+		"first":               {args: protocol.SearchArgs{First: 2}, wantNames: []string{"NewServer", "Server"}, wantHasMore: true},
+		"offset":              {args: protocol.SearchArgs{First: 2, Offset: 2}, wantNames: []string{"serve", "newService"}, wantHasMore: true},
+		"last page":           {args: protocol.SearchArgs{First: 2, Offset: 4}, wantNames: []string{"a*b"}},
+		"offset with filters": {args: protocol.SearchArgs{Query: "serv", First: 1, Offset: 1}, wantNames: []string{"Server"}, wantHasMore: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			res, hasMore, err := querySymbols(context.Background(), db, test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, s := range res {
+				names = append(names, s.Name)
+			}
+			if !reflect.DeepEqual(names, test.wantNames) || hasMore != test.wantHasMore {
+				t.Errorf("got %v hasMore=%v, want %v hasMore=%v", names, hasMore, test.wantNames, test.wantHasMore)
+			}
+		})
+	}
+
+	if _, _, err := querySymbols(context.Background(), db, protocol.SearchArgs{Query: "x", Match: "bogus"}); err == nil {
+		t.Error("invalid match mode: got err == nil, want error")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"os/signal"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
@@ -51,6 +52,22 @@ func main() {
 		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
 			return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar"})
 		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			pathspecs := make([]string, len(paths))
+			for i, p := range paths {
+				pathspecs[i] = ":(literal)" + p
+			}
+			return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: pathspecs})
+		},
+		ListAncestors: listAncestors,
+		GitDiff: func(ctx context.Context, repo gitserver.Repo, base, head api.CommitID) ([]byte, error) {
+			if !git.IsAbsoluteRevision(string(base)) || !git.IsAbsoluteRevision(string(head)) {
+				return nil, fmt.Errorf("invalid commit IDs %q and %q", base, head)
+			}
+			cmd := gitserver.DefaultClient.Command("git", "diff", "-z", "--name-status", "--no-renames", string(base), string(head))
+			cmd.Repo = repo
+			return cmd.Output(ctx)
+		},
 		NewParser: func() (ctags.Parser, error) {
 			parser, err := ctags.NewParser(ctagsCommand)
 			if err != nil {
@@ -90,6 +107,26 @@ func main() {
 	}
 }
 
+// listAncestors returns up to n ancestors of commit, nearest first.
+func listAncestors(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error) {
+	if !git.IsAbsoluteRevision(string(commit)) {
+		return nil, fmt.Errorf("invalid commit ID %q", commit)
+	}
+	cmd := gitserver.DefaultClient.Command("git", "rev-list", "--max-count="+strconv.Itoa(n+1), string(commit))
+	cmd.Repo = repo
+	out, err := cmd.Output(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ancestors []api.CommitID
+	for _, line := range strings.Fields(string(out)) {
+		if line != string(commit) {
+			ancestors = append(ancestors, api.CommitID(line))
+		}
+	}
+	return ancestors, nil
+}
+
 func shutdownOnSIGINT(s *http.Server) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
@@ -0,0 +1,135 @@
+package authz
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	permbbs "github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend/internal/authz/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/schema"
+	"golang.org/x/time/rate"
+)
+
+func bitbucketServerProviders(ctx context.Context) (
+	authzProviders []authz.Provider,
+	seriousProblems []string,
+	warnings []string,
+) {
+	bitbucketServers, err := db.ExternalServices.ListBitbucketServerConnections(ctx)
+	if err != nil {
+		seriousProblems = append(seriousProblems, fmt.Sprintf("Could not load Bitbucket Server external service configs: %s", err))
+		return
+	}
+
+	for _, c := range bitbucketServers {
+		p, err := bitbucketServerProvider(c)
+		if err != nil {
+			seriousProblems = append(seriousProblems, err.Error())
+			continue
+		}
+		if p != nil {
+			authzProviders = append(authzProviders, p)
+		}
+	}
+	for _, provider := range authzProviders {
+		for _, problem := range provider.Validate() {
+			warnings = append(warnings, fmt.Sprintf("Bitbucket Server config for %s was invalid: %s", provider.ServiceID(), problem))
+		}
+	}
+	return authzProviders, seriousProblems, warnings
+}
+
+func bitbucketServerProvider(c *schema.BitbucketServerConnection) (authz.Provider, error) {
+	if c.Authorization == nil {
+		return nil, nil
+	}
+
+	baseURL, err := url.Parse(c.Url)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse URL for Bitbucket Server instance %q: %s", c.Url, err)
+	}
+	baseURL = extsvc.NormalizeBaseURL(baseURL)
+
+	ttl, err := parseTTL(c.Authorization.Ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := transportWithCertTrusted(c.Certificate)
+	if err != nil {
+		return nil, fmt.Errorf("Could not use the certificate of Bitbucket Server instance %q: %s", c.Url, err)
+	}
+
+	var identityProvider string
+	if c.Authorization.IdentityProvider != nil {
+		identityProvider = c.Authorization.IdentityProvider.Type
+	}
+
+	var oauth *bitbucketserver.OAuthConsumer
+	if o := c.Authorization.Oauth; o != nil {
+		signingKey, err := bitbucketserver.ParseOAuthSigningKey(o.SigningKey)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse authorization.oauth.signingKey for Bitbucket Server instance %q: %s", c.Url, err)
+		}
+		oauth = &bitbucketserver.OAuthConsumer{Key: o.ConsumerKey, SigningKey: signingKey}
+	}
+
+	cli := &bitbucketserver.Client{
+		URL:      baseURL,
+		Token:    c.Token,
+		Username: c.Username,
+		Password: c.Password,
+		HTTPClient: &http.Client{
+			Transport: bitbucketserver.WithRequestCounter(transport),
+		},
+		RateLimit: bitbucketServerRateLimiter(baseURL.String()),
+		OAuth:     oauth,
+	}
+	return permbbs.NewProvider(cli, identityProvider, ttl, nil), nil
+}
+
+// bitbucketServerRateLimiters holds one rate limiter per Bitbucket Server instance. Providers are
+// recreated every time the site config is polled, so they can't own their rate limiter.
+var bitbucketServerRateLimiters = struct {
+	sync.Mutex
+	m map[string]*rate.Limiter
+}{m: map[string]*rate.Limiter{}}
+
+// bitbucketServerRateLimiter returns the rate limiter of the Bitbucket Server instance with the
+// given normalized base URL. It allows the same request rate as repo-updater does.
+func bitbucketServerRateLimiter(baseURL string) *rate.Limiter {
+	bitbucketServerRateLimiters.Lock()
+	defer bitbucketServerRateLimiters.Unlock()
+	l, ok := bitbucketServerRateLimiters.m[baseURL]
+	if !ok {
+		l = rate.NewLimiter(2, 500)
+		bitbucketServerRateLimiters.m[baseURL] = l
+	}
+	return l
+}
+
+// transportWithCertTrusted returns an HTTP transport that trusts the given PEM-encoded
+// certificate, in addition to the system's root certificates if cert is empty.
+func transportWithCertTrusted(cert string) (http.RoundTripper, error) {
+	transport := http.DefaultTransport
+	if cert != "" {
+		certPool := x509.NewCertPool()
+		if ok := certPool.AppendCertsFromPEM([]byte(cert)); !ok {
+			return nil, errors.New("invalid certificate value")
+		}
+		transport = &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: certPool},
+		}
+	}
+	return &nethttp.Transport{RoundTripper: transport}, nil
+}
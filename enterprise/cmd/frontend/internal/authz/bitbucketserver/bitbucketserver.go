@@ -0,0 +1,317 @@
+// Package bitbucketserver contains an authorization provider for Bitbucket Server that checks the
+// permissions of Bitbucket Server users with the credentials of a Bitbucket Server admin.
+package bitbucketserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+)
+
+// Provider implements authz.Provider for Bitbucket Server repository permissions.
+type Provider struct {
+	client           *bitbucketserver.Client
+	codeHost         *bitbucketserver.CodeHost
+	identityProvider string
+	cacheTTL         time.Duration
+	cache            cache
+}
+
+// NewProvider returns a new Bitbucket Server authorization provider that uses the given client,
+// whose credentials must belong to a Bitbucket Server admin. The identityProvider ("email", the
+// default, or "username") defines how Sourcegraph users are matched to Bitbucket Server users.
+func NewProvider(cli *bitbucketserver.Client, identityProvider string, cacheTTL time.Duration, mockCache cache) *Provider {
+	p := &Provider{
+		client:           cli,
+		codeHost:         bitbucketserver.NewCodeHost(cli.URL),
+		identityProvider: identityProvider,
+		cache:            mockCache,
+		cacheTTL:         cacheTTL,
+	}
+	if p.identityProvider == "" {
+		p.identityProvider = "email"
+	}
+	if p.cache == nil {
+		p.cache = rcache.NewWithTTL(fmt.Sprintf("bitbucketServerAuthz:%s", p.codeHost.ServiceID()), int(math.Ceil(cacheTTL.Seconds())))
+	}
+	return p
+}
+
+var _ authz.Provider = ((*Provider)(nil))
+
+// Repos implements the authz.Provider interface.
+func (p *Provider) Repos(ctx context.Context, repos map[authz.Repo]struct{}) (mine map[authz.Repo]struct{}, others map[authz.Repo]struct{}) {
+	return authz.GetCodeHostRepos(p.codeHost, repos)
+}
+
+// RepoPerms implements the authz.Provider interface.
+//
+// A user can read a repository if it is public or if it is among the repositories Bitbucket Server
+// lists as readable by the user, which Sourcegraph requests by impersonating the user. Both the
+// public repositories and those of each user are listed in bulk and cached for the configured TTL.
+func (p *Provider) RepoPerms(ctx context.Context, userAccount *extsvc.ExternalAccount, repos map[authz.Repo]struct{}) (map[api.RepoName]map[authz.Perm]bool, error) {
+	remaining, _ := p.Repos(ctx, repos)
+	if len(remaining) == 0 {
+		return nil, nil
+	}
+
+	var user *bitbucketserver.User
+	if userAccount != nil && userAccount.ServiceID == p.codeHost.ServiceID() && userAccount.ServiceType == p.codeHost.ServiceType() {
+		var err error
+		if user, err = accountUser(userAccount); err != nil {
+			return nil, err
+		}
+	}
+
+	public, err := p.publicRepos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var readable map[string]bool
+	if user != nil {
+		if readable, err = p.userRepos(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	perms := map[api.RepoName]map[authz.Perm]bool{}
+	for repo := range remaining {
+		id := repo.ExternalRepoSpec.ID
+		switch {
+		case public[id]:
+			perms[repo.RepoName] = map[authz.Perm]bool{authz.Read: true}
+		case user != nil:
+			perms[repo.RepoName] = map[authz.Perm]bool{authz.Read: readable[id]}
+		}
+	}
+	return perms, nil
+}
+
+// publicRepos returns the set of IDs of the public repositories.
+func (p *Provider) publicRepos(ctx context.Context) (map[string]bool, error) {
+	return p.cachedRepoSet(publicReposCacheKey, func() ([]*bitbucketserver.Repo, error) {
+		var public []*bitbucketserver.Repo
+		err := listAllRepos(ctx, p.client.Repos, func(r *bitbucketserver.Repo) {
+			if r.Public || (r.Project != nil && r.Project.Public) {
+				public = append(public, r)
+			}
+		})
+		return public, err
+	})
+}
+
+// userRepos returns the set of IDs of the repositories the given user can read.
+func (p *Provider) userRepos(ctx context.Context, user *bitbucketserver.User) (map[string]bool, error) {
+	return p.cachedRepoSet(userReposCacheKey(user.ID), func() ([]*bitbucketserver.Repo, error) {
+		sudo, err := p.client.Sudo(user.Name)
+		if err != nil {
+			return nil, err
+		}
+		var readable []*bitbucketserver.Repo
+		err = listAllRepos(ctx, sudo.ReadableRepos, func(r *bitbucketserver.Repo) {
+			readable = append(readable, r)
+		})
+		return readable, err
+	})
+}
+
+// cachedRepoSet returns the set of IDs of the repositories cached under key, or of the
+// repositories returned by list (which are then cached) if they aren't cached.
+func (p *Provider) cachedRepoSet(key string, list func() ([]*bitbucketserver.Repo, error)) (map[string]bool, error) {
+	if v := p.cache.GetMulti(key)[0]; len(v) > 0 {
+		var val repoSetCacheVal
+		if err := json.Unmarshal(v, &val); err != nil {
+			return nil, err
+		}
+		// If the cache TTL is now less than the cache entry TTL, the entry is invalid.
+		if val.TTL <= p.cacheTTL {
+			return repoSet(val.IDs), nil
+		}
+	}
+
+	repos, err := list()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(repos))
+	for _, r := range repos {
+		if r.Project != nil {
+			ids = append(ids, r.Project.Key+"/"+r.Slug)
+		}
+	}
+	val, err := json.Marshal(repoSetCacheVal{IDs: ids, TTL: p.cacheTTL})
+	if err != nil {
+		return nil, err
+	}
+	p.cache.SetMulti([2]string{key, string(val)})
+	return repoSet(ids), nil
+}
+
+func repoSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// listAllRepos calls f with every repository returned by the paginated listing list.
+func listAllRepos(ctx context.Context, list func(context.Context, *bitbucketserver.PageToken) ([]*bitbucketserver.Repo, *bitbucketserver.PageToken, error), f func(*bitbucketserver.Repo)) error {
+	next := &bitbucketserver.PageToken{Limit: 1000}
+	for next != nil && next.HasMore() {
+		repos, page, err := list(ctx, next)
+		if err != nil {
+			return err
+		}
+		for _, r := range repos {
+			f(r)
+		}
+		next = page
+	}
+	return nil
+}
+
+// FetchAccount implements the authz.Provider interface. It returns the Bitbucket Server user with
+// one of the Sourcegraph user's verified email addresses or, if the identity provider is
+// "username", the Bitbucket Server user with the same username as the Sourcegraph user.
+func (p *Provider) FetchAccount(ctx context.Context, user *types.User, current []*extsvc.ExternalAccount) (mine *extsvc.ExternalAccount, err error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	var bbUser *bitbucketserver.User
+	switch p.identityProvider {
+	case "username":
+		bbUser, err = p.findUser(ctx, user.Username, func(u *bitbucketserver.User) bool {
+			return strings.EqualFold(u.Name, user.Username)
+		})
+		if err != nil {
+			return nil, err
+		}
+	default:
+		emails, err := verifiedEmails(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, email := range emails {
+			bbUser, err = p.findUser(ctx, email, func(u *bitbucketserver.User) bool {
+				return strings.EqualFold(u.EmailAddress, email)
+			})
+			if err != nil {
+				return nil, err
+			}
+			if bbUser != nil {
+				break
+			}
+		}
+	}
+	if bbUser == nil {
+		return nil, nil
+	}
+
+	accountData, err := json.Marshal(bbUser)
+	if err != nil {
+		return nil, err
+	}
+	data := json.RawMessage(accountData)
+	return &extsvc.ExternalAccount{
+		UserID: user.ID,
+		ExternalAccountSpec: extsvc.ExternalAccountSpec{
+			ServiceType: p.codeHost.ServiceType(),
+			ServiceID:   p.codeHost.ServiceID(),
+			AccountID:   strconv.Itoa(bbUser.ID),
+		},
+		ExternalAccountData: extsvc.ExternalAccountData{
+			AccountData: &data,
+		},
+	}, nil
+}
+
+// findUser returns the first Bitbucket Server user matching filter for which match returns true,
+// or nil if there is none.
+func (p *Provider) findUser(ctx context.Context, filter string, match func(*bitbucketserver.User) bool) (*bitbucketserver.User, error) {
+	next := &bitbucketserver.PageToken{Limit: 100}
+	for next != nil && next.HasMore() {
+		users, page, err := p.client.Users(ctx, next, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			if match(u) {
+				return u, nil
+			}
+		}
+		next = page
+	}
+	return nil, nil
+}
+
+// verifiedEmails returns the verified email addresses of a Sourcegraph user. It is a variable so
+// that tests can mock it.
+var verifiedEmails = func(ctx context.Context, userID int32) ([]string, error) {
+	emails, err := db.UserEmails.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var verified []string
+	for _, e := range emails {
+		if e.VerifiedAt != nil {
+			verified = append(verified, e.Email)
+		}
+	}
+	return verified, nil
+}
+
+// accountUser returns the Bitbucket Server user stored in the account data of an external
+// account returned by FetchAccount.
+func accountUser(acct *extsvc.ExternalAccount) (*bitbucketserver.User, error) {
+	if acct.AccountData == nil {
+		return nil, errors.Errorf("no Bitbucket Server user data in external account %d", acct.ID)
+	}
+	var u bitbucketserver.User
+	if err := json.Unmarshal(*acct.AccountData, &u); err != nil {
+		return nil, errors.Wrap(err, "could not decode Bitbucket Server user data")
+	}
+	return &u, nil
+}
+
+func (p *Provider) ServiceID() string {
+	return p.codeHost.ServiceID()
+}
+
+func (p *Provider) ServiceType() string {
+	return p.codeHost.ServiceType()
+}
+
+// Validate implements the authz.Provider interface. It doesn't access the Bitbucket Server API,
+// because providers are validated every time the site config is polled.
+func (p *Provider) Validate() (problems []string) {
+	switch p.identityProvider {
+	case "email":
+	case "username":
+		// Sourcegraph usernames are chosen by users, not by Bitbucket Server.
+		problems = append(problems, `authorization.identityProvider.type "username" grants a Sourcegraph user the repository permissions of the Bitbucket Server user with the same username, so any user who signs up with or changes their username to a Bitbucket Server username can read that user's repositories; use "email" unless only trusted users can sign up and choose usernames`)
+	default:
+		problems = append(problems, fmt.Sprintf("authorization.identityProvider.type must be \"username\" or \"email\", got %q", p.identityProvider))
+	}
+	if p.client.Token == "" && p.client.Username == "" {
+		problems = append(problems, "authorization requires the token (or username and password) of a Bitbucket Server admin")
+	}
+	if p.client.OAuth == nil {
+		problems = append(problems, "authorization requires authorization.oauth, to list the repositories each user can read")
+	}
+	return problems
+}
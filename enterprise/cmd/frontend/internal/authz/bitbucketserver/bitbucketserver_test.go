@@ -0,0 +1,243 @@
+package bitbucketserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/bitbucketserver"
+	"golang.org/x/time/rate"
+)
+
+// fakeBitbucketServer serves the repositories and users endpoints of a Bitbucket Server instance
+// where readers maps a {projectKey}/{repoSlug} to the users with the REPO_READ permission on it.
+// Requests must be authenticated with the admin token, or impersonate a user with OAuth.
+type fakeBitbucketServer struct {
+	users    []*bitbucketserver.User
+	repos    map[string]*bitbucketserver.Repo
+	readers  map[string][]string
+	requests int
+}
+
+func (s *fakeBitbucketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests++
+	q := r.URL.Query()
+	auth := r.Header.Get("Authorization")
+	sudo := q.Get("xoauth_requestor_id")
+	switch {
+	case auth == "Bearer admin-token" && sudo == "":
+	case strings.HasPrefix(auth, "OAuth ") && strings.Contains(auth, `oauth_consumer_key="sourcegraph"`) && strings.Contains(auth, `oauth_signature_method="RSA-SHA1"`) && sudo != "":
+	default:
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/rest/api/1.0/repos":
+		var repos []*bitbucketserver.Repo
+		for id, repo := range s.repos {
+			if sudo != "" {
+				if q.Get("permission") != "REPO_READ" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				found := false
+				for _, name := range s.readers[id] {
+					found = found || name == sudo
+				}
+				if !found {
+					continue
+				}
+			}
+			repos = append(repos, repo)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"isLastPage": true, "values": repos})
+
+	case "/rest/api/1.0/users":
+		filter := strings.ToLower(q.Get("filter"))
+		var users []*bitbucketserver.User
+		for _, u := range s.users {
+			if strings.Contains(u.Name, filter) || strings.Contains(u.EmailAddress, filter) {
+				users = append(users, u)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"isLastPage": true, "values": users})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestProvider(t *testing.T, identityProvider string) (*Provider, *fakeBitbucketServer, func()) {
+	bbs := &fakeBitbucketServer{
+		users: []*bitbucketserver.User{
+			{ID: 1, Name: "alice", EmailAddress: "alice@example.com"},
+			{ID: 2, Name: "alice2", EmailAddress: "alice2@example.com"},
+			{ID: 3, Name: "bob", EmailAddress: "bob@example.com"},
+		},
+		repos: map[string]*bitbucketserver.Repo{
+			"PUB/public":  {Slug: "public", Project: &bitbucketserver.Project{Key: "PUB", Public: true}},
+			"PRJ/private": {Slug: "private", Project: &bitbucketserver.Project{Key: "PRJ"}},
+			"PRJ/secret":  {Slug: "secret", Project: &bitbucketserver.Project{Key: "PRJ"}},
+		},
+		readers: map[string][]string{
+			"PUB/public":  {"alice", "alice2", "bob"},
+			"PRJ/private": {"alice", "alice2"},
+			"PRJ/secret":  {"alice2"},
+		},
+	}
+	srv := httptest.NewServer(bbs)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := &bitbucketserver.Client{
+		URL:        u,
+		Token:      "admin-token",
+		HTTPClient: srv.Client(),
+		RateLimit:  rate.NewLimiter(rate.Inf, 0),
+		OAuth:      &bitbucketserver.OAuthConsumer{Key: "sourcegraph", SigningKey: signingKey},
+	}
+	return NewProvider(cli, identityProvider, time.Hour, make(authz.MockCache)), bbs, srv.Close
+}
+
+func TestProvider_FetchAccount(t *testing.T) {
+	orig := verifiedEmails
+	verifiedEmails = func(ctx context.Context, userID int32) ([]string, error) {
+		return map[int32][]string{
+			1: {"nobody@example.com", "alice@example.com"},
+			2: {"carol@example.com"},
+		}[userID], nil
+	}
+	defer func() { verifiedEmails = orig }()
+
+	tests := []struct {
+		identityProvider string
+		user             *types.User
+		wantAccountID    string
+	}{
+		{identityProvider: "username", user: &types.User{ID: 1, Username: "alice"}, wantAccountID: "1"},
+		{identityProvider: "username", user: &types.User{ID: 1, Username: "Bob"}, wantAccountID: "3"},
+		{identityProvider: "username", user: &types.User{ID: 1, Username: "carol"}},
+		{identityProvider: "email", user: &types.User{ID: 1, Username: "bob"}, wantAccountID: "1"},
+		{identityProvider: "email", user: &types.User{ID: 2, Username: "alice"}},
+	}
+	for _, test := range tests {
+		p, _, done := newTestProvider(t, test.identityProvider)
+		acct, err := p.FetchAccount(context.Background(), test.user, nil)
+		done()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var accountID string
+		if acct != nil {
+			if acct.ServiceID != p.ServiceID() || acct.ServiceType != bitbucketserver.ServiceType || acct.UserID != test.user.ID {
+				t.Errorf("%s %q: unexpected account %+v", test.identityProvider, test.user.Username, acct)
+			}
+			accountID = acct.AccountID
+		}
+		if accountID != test.wantAccountID {
+			t.Errorf("%s %q: got account ID %q, want %q", test.identityProvider, test.user.Username, accountID, test.wantAccountID)
+		}
+	}
+}
+
+func TestProvider_RepoPerms(t *testing.T) {
+	p, bbs, done := newTestProvider(t, "username")
+	defer done()
+
+	repo := func(id string) authz.Repo {
+		return authz.Repo{
+			RepoName: api.RepoName("bitbucket.example.com/" + id),
+			ExternalRepoSpec: api.ExternalRepoSpec{
+				ID:          id,
+				ServiceType: bitbucketserver.ServiceType,
+				ServiceID:   p.ServiceID(),
+			},
+		}
+	}
+	repos := map[authz.Repo]struct{}{
+		repo("PUB/public"):  {},
+		repo("PRJ/private"): {},
+		repo("PRJ/secret"):  {},
+		repo("PRJ/missing"): {},
+		{RepoName: "github.com/foo/bar", ExternalRepoSpec: api.ExternalRepoSpec{ID: "x", ServiceType: "github", ServiceID: "https://github.com/"}}: {},
+	}
+
+	alice, err := p.FetchAccount(context.Background(), &types.User{ID: 1, Username: "alice"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		account *extsvc.ExternalAccount
+		want    map[api.RepoName]map[authz.Perm]bool
+	}{
+		{
+			name: "unauthenticated",
+			want: map[api.RepoName]map[authz.Perm]bool{
+				"bitbucket.example.com/PUB/public": {authz.Read: true},
+			},
+		},
+		{
+			name:    "alice",
+			account: alice,
+			want: map[api.RepoName]map[authz.Perm]bool{
+				"bitbucket.example.com/PUB/public":  {authz.Read: true},
+				"bitbucket.example.com/PRJ/private": {authz.Read: true},
+				"bitbucket.example.com/PRJ/secret":  {authz.Read: false},
+				"bitbucket.example.com/PRJ/missing": {authz.Read: false},
+			},
+		},
+	}
+	for _, test := range tests {
+		for _, cached := range []bool{false, true} {
+			bbs.requests = 0
+			got, err := p.RepoPerms(context.Background(), test.account, repos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s (cached=%v): got %v, want %v", test.name, cached, got, test.want)
+			}
+			// The repositories are listed in bulk, so a warm cache needs no requests.
+			if cached && bbs.requests != 0 {
+				t.Errorf("%s: got %d requests with a warm cache, want 0", test.name, bbs.requests)
+			}
+		}
+	}
+}
+
+func TestProvider_Validate(t *testing.T) {
+	tests := map[string][]string{
+		"":         nil,
+		"email":    nil,
+		"username": {`authorization.identityProvider.type "username" grants a Sourcegraph user the repository permissions of the Bitbucket Server user with the same username, so any user who signs up with or changes their username to a Bitbucket Server username can read that user's repositories; use "email" unless only trusted users can sign up and choose usernames`},
+		"ldap":     {`authorization.identityProvider.type must be "username" or "email", got "ldap"`},
+	}
+	for identityProvider, want := range tests {
+		p, _, done := newTestProvider(t, identityProvider)
+		if got := p.Validate(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %q, want %q", identityProvider, got, want)
+		}
+		done()
+	}
+}
@@ -0,0 +1,27 @@
+package bitbucketserver
+
+import (
+	"fmt"
+	"time"
+)
+
+// cache describes the shape of the repo permissions cache that Provider uses internally.
+type cache interface {
+	GetMulti(keys ...string) [][]byte
+	SetMulti(keyvals ...[2]string)
+}
+
+// publicReposCacheKey is the cache key of the IDs of the public repositories.
+const publicReposCacheKey = "public"
+
+// userReposCacheKey returns the cache key of the IDs of the repositories the Bitbucket Server user
+// with the given ID can read.
+func userReposCacheKey(userID int) string {
+	return fmt.Sprintf("u:%d", userID)
+}
+
+// repoSetCacheVal is a cached set of repository IDs of the form {projectKey}/{repoSlug}.
+type repoSetCacheVal struct {
+	IDs []string
+	TTL time.Duration
+}
@@ -59,6 +59,20 @@ func init() {
 			}
 		}
 
+		bitbucketServers, err := db.ExternalServices.ListBitbucketServerConnections(ctx)
+		if err != nil {
+			return []*graphqlbackend.Alert{{
+				TypeValue:    graphqlbackend.AlertTypeError,
+				MessageValue: fmt.Sprintf("Unable to fetch Bitbucket Server external services: %s", err),
+			}}
+		}
+		for _, b := range bitbucketServers {
+			if b.Authorization != nil {
+				authzTypes = append(authzTypes, "Bitbucket Server")
+				break
+			}
+		}
+
 		if len(authzTypes) > 0 {
 			return []*graphqlbackend.Alert{{
 				TypeValue:    graphqlbackend.AlertTypeError,
@@ -67,6 +81,28 @@ func init() {
 		}
 		return nil
 	})
+
+	// Warn about Bitbucket Server permissions that match users by username.
+	graphqlbackend.AlertFuncs = append(graphqlbackend.AlertFuncs, func(args graphqlbackend.AlertFuncArgs) []*graphqlbackend.Alert {
+		// Only site admins can act on this alert, so only show it to site admins.
+		if !args.IsSiteAdmin {
+			return nil
+		}
+
+		bitbucketServers, err := db.ExternalServices.ListBitbucketServerConnections(context.Background())
+		if err != nil {
+			return nil
+		}
+		for _, b := range bitbucketServers {
+			if a := b.Authorization; a != nil && a.IdentityProvider != nil && a.IdentityProvider.Type == "username" {
+				return []*graphqlbackend.Alert{{
+					TypeValue:    graphqlbackend.AlertTypeWarning,
+					MessageValue: "Bitbucket Server repository permissions match Sourcegraph users to Bitbucket Server users by username. Any user who signs up with or changes their username to a Bitbucket Server username can read that user's repositories. [**Match users by email instead.**](/help/admin/repo/permissions#bitbucket-server)",
+				}}
+			}
+		}
+		return nil
+	})
 }
 
 func init() {
@@ -114,5 +150,10 @@ func providersFromConfig(ctx context.Context, cfg *conf.Unified) (
 	seriousProblems = append(seriousProblems, ghproblems...)
 	warnings = append(warnings, ghwarnings...)
 
+	bbsp, bbsproblems, bbswarnings := bitbucketServerProviders(ctx)
+	authzProviders = append(authzProviders, bbsp...)
+	seriousProblems = append(seriousProblems, bbsproblems...)
+	warnings = append(warnings, bbswarnings...)
+
 	return allowAccessByDefault, authzProviders, seriousProblems, warnings
 }
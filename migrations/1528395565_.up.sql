@@ -0,0 +1,12 @@
+CREATE TABLE user_permissions (
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission text NOT NULL,
+    object_type text NOT NULL,
+    object_ids integer[] NOT NULL,
+    service_type text NOT NULL,
+    service_id text NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT user_permissions_unique UNIQUE (user_id, permission, object_type, service_type, service_id)
+);
+
+CREATE INDEX user_permissions_updated_at ON user_permissions(updated_at);
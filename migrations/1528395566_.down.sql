@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS repo_redirects;
+DROP INDEX IF EXISTS repo_external_repo_idx;
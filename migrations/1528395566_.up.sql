@@ -0,0 +1,7 @@
+CREATE INDEX repo_external_repo_idx ON repo(external_service_type, external_service_id, external_id) WHERE external_id IS NOT NULL;
+
+CREATE TABLE repo_redirects (
+    name citext PRIMARY KEY,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
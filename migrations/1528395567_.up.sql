@@ -0,0 +1,10 @@
+CREATE TABLE external_service_sync_status (
+    external_service_id bigint PRIMARY KEY REFERENCES external_services(id) ON DELETE CASCADE,
+    started_at timestamp with time zone NOT NULL,
+    finished_at timestamp with time zone NOT NULL,
+    repos_added integer NOT NULL,
+    repos_removed integer NOT NULL,
+    repos_unchanged integer NOT NULL,
+    errors text[] NOT NULL DEFAULT '{}',
+    repos text[] NOT NULL DEFAULT '{}'
+);
@@ -0,0 +1,11 @@
+ALTER TABLE discussion_threads ADD COLUMN status text NOT NULL DEFAULT 'open';
+ALTER TABLE discussion_threads ADD CONSTRAINT discussion_threads_status_check CHECK (status IN ('open', 'resolved', 'wontfix'));
+ALTER TABLE discussion_threads ADD COLUMN labels text[] NOT NULL DEFAULT '{}';
+CREATE INDEX discussion_threads_labels_idx ON discussion_threads USING gin (labels);
+
+CREATE TABLE discussion_threads_assignees (
+    thread_id bigint NOT NULL REFERENCES discussion_threads(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (thread_id, user_id)
+);
+CREATE INDEX discussion_threads_assignees_user_id_idx ON discussion_threads_assignees(user_id);
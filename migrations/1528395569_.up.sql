@@ -0,0 +1,20 @@
+CREATE TABLE discussion_notification_deliveries (
+    id bigserial NOT NULL PRIMARY KEY,
+    event text NOT NULL CHECK (event IN ('threadCreated', 'commentAdded', 'mention')),
+    thread_id bigint NOT NULL REFERENCES discussion_threads(id) ON DELETE CASCADE,
+    comment_id bigint NOT NULL REFERENCES discussion_comments(id) ON DELETE CASCADE,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    org_id integer REFERENCES orgs(id) ON DELETE CASCADE,
+    target_type text NOT NULL CHECK (target_type IN ('slack', 'microsoftTeams', 'webhook')),
+    url text NOT NULL,
+    body text NOT NULL,
+    signature text NOT NULL DEFAULT '',
+    attempts integer NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamp with time zone,
+    delivered_at timestamp with time zone,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT discussion_notification_deliveries_subject_check CHECK ((user_id IS NULL) <> (org_id IS NULL))
+);
+CREATE INDEX discussion_notification_deliveries_next_attempt_at_idx ON discussion_notification_deliveries(next_attempt_at) WHERE next_attempt_at IS NOT NULL;
+CREATE INDEX discussion_notification_deliveries_created_at_idx ON discussion_notification_deliveries(created_at);
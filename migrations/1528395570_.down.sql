@@ -0,0 +1 @@
+ALTER TABLE saved_queries DROP COLUMN IF EXISTS result_fingerprints;
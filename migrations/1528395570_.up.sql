@@ -0,0 +1 @@
+ALTER TABLE saved_queries ADD COLUMN result_fingerprints text[];
@@ -0,0 +1,13 @@
+CREATE TABLE saved_query_runs (
+    id bigserial NOT NULL PRIMARY KEY,
+    query text NOT NULL REFERENCES saved_queries(query) ON DELETE CASCADE,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    org_id integer REFERENCES orgs(id) ON DELETE CASCADE,
+    key text NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    exec_duration_ns bigint NOT NULL,
+    result_count integer,
+    error text,
+    notified boolean NOT NULL DEFAULT false
+);
+CREATE INDEX saved_query_runs_query_key_id_idx ON saved_query_runs(query, key, id);
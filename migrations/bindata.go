@@ -192,6 +192,20 @@
 // 1528395563_.up.sql (181B)
 // 1528395564_.down.sql (0)
 // 1528395564_.up.sql (0)
+// 1528395565_.down.sql (39B)
+// 1528395565_.up.sql (508B)
+// 1528395566_.down.sql (82B)
+// 1528395566_.up.sql (326B)
+// 1528395567_.down.sql (51B)
+// 1528395567_.up.sql (431B)
+// 1528395568_.down.sql (315B)
+// 1528395568_.up.sql (705B)
+// 1528395569_.down.sql (57B)
+// 1528395569_.up.sql (1.242kB)
+// 1528395570_.down.sql (69B)
+// 1528395570_.up.sql (65B)
+// 1528395571_.down.sql (39B)
+// 1528395571_.up.sql (548B)
 
 package migrations
 
@@ -4100,6 +4114,286 @@ func _1528395564_UpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395565_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x27\x00\xd8\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x75\x73\x65\x72\x5f\x70\x65\x72\x6d\x69\x73\x73\x69\x6f\x6e\x73\x3b\x0a\x03\x00\x8c\x60\x69\x93\x27\x00\x00\x00")
+
+func _1528395565_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395565_DownSql,
+		"1528395565_.down.sql",
+	)
+}
+
+func _1528395565_DownSql() (*asset, error) {
+	bytes, err := _1528395565_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395565_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x78, 0x12, 0x58, 0x12, 0xcc, 0xaf, 0x2f, 0x38, 0x37, 0x11, 0x24, 0xed, 0xdd, 0xc5, 0xa, 0x3d, 0xf0, 0xd2, 0x3a, 0x6b, 0xbf, 0x38, 0xc9, 0x99, 0xf0, 0xb2, 0x54, 0xfc, 0xed, 0x23, 0xc1, 0xa4}}
+	return a, nil
+}
+
+var __1528395565_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x91\x5f\x4b\xc3\x30\x14\xc5\xdf\xfb\x29\xce\x63\x0b\xfd\x06\x7b\x8a\xed\x1d\x0c\x4a\x8a\x6d\x0a\x82\x48\xa9\xcb\x45\xaf\xd0\x3f\x36\xa9\x53\x3f\xbd\xd0\x6e\xab\xba\xe9\xe3\xcd\xef\xdc\x93\x9c\x93\xa4\x20\x65\x08\x46\xdd\x64\x84\xc9\xf1\x58\x0f\x3c\xb6\xe2\x9c\xf4\x9d\x43\x18\x00\x58\x8e\xc5\x42\x3a\xcf\x4f\x3c\x42\xe7\x06\xba\xca\x32\x14\xb4\xa5\x82\x74\x42\xe5\xac\x71\xa1\xd8\x08\xb9\x46\x4a\x19\x19\x42\xa2\xca\x44\xa5\x14\xcf\x26\xab\x2d\x3c\xbf\xfb\xb3\xc9\x42\xfb\xc7\x17\xde\xfb\xda\x7f\x0c\xfc\x0f\x16\xeb\x4e\x8f\xb8\x7f\xf8\x25\x71\x3c\xbe\xc9\x9e\xff\xb4\x38\x71\xb1\xd7\xe8\x34\xd8\xc6\xb3\xad\x1b\x0f\x2f\x2d\x3b\xdf\xb4\x03\x0e\xe2\x9f\xe7\x11\x9f\x7d\xc7\xe7\x0d\xa4\xb4\x55\x55\x66\xd0\xf5\x87\x30\x5a\xf6\x93\x5c\x97\xa6\x50\x3b\x6d\x2e\x4a\xac\xa7\x4e\x5e\x27\x46\xa5\x77\xb7\x15\x21\x3c\xb6\x19\x63\xd5\xc4\xdf\xf3\xc7\x3f\xa2\xac\x93\xd8\x28\x88\x36\x41\x70\xfc\xb1\x9d\x4e\xe9\xee\xca\x65\x6b\x90\x5c\x5f\xe0\x70\xcd\x19\x6d\x82\xaf\x01\x00\x3a\x04\x13\xd6\xfc\x01\x00\x00")
+
+func _1528395565_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395565_UpSql,
+		"1528395565_.up.sql",
+	)
+}
+
+func _1528395565_UpSql() (*asset, error) {
+	bytes, err := _1528395565_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395565_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xbd, 0x5d, 0x6, 0x93, 0x2f, 0x29, 0xea, 0xdf, 0x6e, 0xa8, 0xf8, 0xad, 0x2, 0x18, 0xb4, 0x23, 0x7c, 0x16, 0x17, 0xdf, 0x99, 0x13, 0xce, 0x42, 0xa3, 0x23, 0x7e, 0xa9, 0xf, 0xfe, 0xe0, 0xbc}}
+	return a, nil
+}
+
+var __1528395566_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x52\x00\xad\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x72\x65\x64\x69\x72\x65\x63\x74\x73\x3b\x0a\x44\x52\x4f\x50\x20\x49\x4e\x44\x45\x58\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x72\x65\x70\x6f\x5f\x69\x64\x78\x3b\x0a\x03\x00\x73\x8a\xe5\x46\x52\x00\x00\x00")
+
+func _1528395566_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395566_DownSql,
+		"1528395566_.down.sql",
+	)
+}
+
+func _1528395566_DownSql() (*asset, error) {
+	bytes, err := _1528395566_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395566_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb6, 0x38, 0x7, 0x41, 0x9e, 0xc, 0xfb, 0x44, 0xb9, 0xcb, 0x3b, 0x0, 0x2, 0x34, 0x54, 0x53, 0xff, 0xf9, 0xf8, 0x5f, 0xb, 0x72, 0xc8, 0x61, 0x56, 0x4e, 0xb5, 0x8d, 0xdb, 0xda, 0xf4, 0x8e}}
+	return a, nil
+}
+
+var __1528395566_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\x8e\xc1\x4a\xc3\x40\x10\x86\xef\x79\x8a\xff\x98\x40\xdf\xa0\xa7\x35\x99\x62\x30\x6e\x64\x93\xa2\x3d\x85\x25\x3b\xe8\x82\xd9\x84\xcd\x60\xa3\x4f\x2f\xae\xa5\x16\x3c\xce\xff\xc1\x37\x5f\x69\x48\xf5\x84\x5a\x57\xf4\x82\xc8\xcb\x3c\xf0\x26\x1c\x83\x7d\x1f\xd2\xe5\xdd\x86\x56\x27\x92\x5f\xc9\xca\xf1\xc3\x8f\x3c\xc8\xe7\xc2\x3b\xfc\x9b\xbd\xbb\x19\xbd\x2b\xf0\x7c\x4f\x86\x6e\x27\xd4\x1d\x74\xdb\x43\x1f\x9b\x66\x9f\x65\x97\x88\x5e\xdd\x35\x94\x5e\x0d\x91\x9d\x8f\x3c\xca\x8a\x3c\x03\x80\x60\x27\xc6\xe8\x85\x37\xc1\x93\xa9\x1f\x95\x39\xe1\x81\x4e\xbb\x04\x2f\xa1\xf0\x41\xf8\x95\xe3\xd5\x0c\x43\x07\x32\xa4\x4b\xea\x92\x35\xff\x69\x69\x35\x2a\x6a\xa8\x27\x94\xaa\x2b\x55\x45\xbf\x8e\x31\xb2\x15\x76\x83\x15\x88\x9f\x78\x15\x3b\x2d\x38\x7b\x79\x4b\x27\xbe\xe6\xc0\x7f\xde\x8a\x0e\xea\xd8\xf4\x08\xf3\x39\x2f\xb2\x62\x9f\x7d\x0f\x00\x7b\xd3\xce\x26\x46\x01\x00\x00")
+
+func _1528395566_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395566_UpSql,
+		"1528395566_.up.sql",
+	)
+}
+
+func _1528395566_UpSql() (*asset, error) {
+	bytes, err := _1528395566_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395566_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xd9, 0x63, 0x13, 0xda, 0xf7, 0xec, 0x39, 0x4a, 0xbe, 0x97, 0x68, 0xb6, 0xf2, 0xda, 0x71, 0x96, 0xc8, 0x69, 0x6e, 0x5f, 0x64, 0x3e, 0x79, 0xc7, 0x5a, 0x88, 0x34, 0x52, 0x3a, 0x7c, 0x81, 0x1a}}
+	return a, nil
+}
+
+var __1528395567_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x33\x00\xcc\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x73\x65\x72\x76\x69\x63\x65\x5f\x73\x79\x6e\x63\x5f\x73\x74\x61\x74\x75\x73\x3b\x0a\x03\x00\xeb\xee\x6e\x71\x33\x00\x00\x00")
+
+func _1528395567_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395567_DownSql,
+		"1528395567_.down.sql",
+	)
+}
+
+func _1528395567_DownSql() (*asset, error) {
+	bytes, err := _1528395567_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395567_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x6d, 0xf4, 0xb0, 0xa2, 0x55, 0x56, 0xb, 0x57, 0x91, 0x1c, 0xa5, 0xb2, 0x13, 0x74, 0xbd, 0x85, 0xd6, 0xba, 0xc5, 0xa3, 0x5b, 0x62, 0x31, 0xf4, 0xa5, 0xdc, 0x76, 0xe7, 0xff, 0x71, 0x3f, 0xd8}}
+	return a, nil
+}
+
+var __1528395567_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x95\x90\xdd\x4a\x03\x31\x10\x85\xef\xf7\x29\xe6\xae\x2d\xf8\x06\x5e\xc5\xed\x14\xc4\xb8\x95\x74\x7b\x51\x44\x42\xdc\x4c\x77\x07\xdc\x6c\x49\xa6\xf5\xa7\xf4\xdd\x0d\x15\x44\xa8\x5a\x3a\x77\x87\xf9\xce\x99\x9f\xd2\xa0\xaa\x11\x6a\x75\xa3\x11\xe8\x4d\x28\x06\xf7\x62\x13\xc5\x1d\x37\x64\xd3\x7b\x68\x6c\x12\x27\xdb\x04\xe3\x02\x72\x9d\x20\xec\xe1\x99\x5b\x0e\x02\x0f\xe6\xf6\x5e\x99\x15\xdc\xe1\x0a\x0c\xce\xd0\x60\x55\xe2\xe2\xc4\x91\xc6\xec\x27\x30\xaf\x60\x8a\x1a\xf3\xe8\x52\x2d\x4a\x35\xc5\xab\x63\x7c\x9e\x15\x85\xbc\x75\x02\xc2\x3d\x65\xd9\x6f\xe0\x95\xa5\x3b\x4a\xf8\x18\x02\x41\x35\xaf\xa1\x5a\x6a\xfd\xe5\x58\x73\xe0\xd4\x5d\x64\x89\xb4\x19\x92\x75\xde\x93\x87\xbc\x38\xb5\x14\x7f\x25\x22\xf5\xc3\xee\x0c\xb3\x0d\x4d\xe7\x42\xfb\x27\x45\x31\x0e\x31\x81\xe4\x2f\x3c\x3e\x7d\xf7\xf2\xed\x33\xb5\xd4\x35\x8c\xf6\x87\xd1\x8f\xb8\x7f\xb9\x62\x72\x5d\x7c\x02\x73\xee\xf5\x4c\xaf\x01\x00\x00")
+
+func _1528395567_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395567_UpSql,
+		"1528395567_.up.sql",
+	)
+}
+
+func _1528395567_UpSql() (*asset, error) {
+	bytes, err := _1528395567_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395567_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb8, 0x6, 0x30, 0xff, 0xac, 0x92, 0xd5, 0x23, 0xaa, 0xec, 0x65, 0xa9, 0x23, 0x33, 0x43, 0x21, 0xbf, 0xe9, 0x3f, 0xd, 0x3d, 0xc1, 0x1f, 0x6c, 0x9d, 0x80, 0x21, 0x6, 0xa3, 0x4b, 0xbe, 0xfc}}
+	return a, nil
+}
+
+var __1528395568_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x8e\xd1\x0a\xc2\x20\x00\x45\xdf\xfd\x0a\xff\xc3\x27\x6b\x06\x82\xb9\x50\x83\xbd\x89\xa9\x34\x69\x2c\xe8\x2a\xf4\xf9\xc1\xea\x21\x08\x46\xec\xfd\x9e\x73\x4f\x67\xfa\x13\x75\x7c\xa7\x04\x95\x07\x2a\x06\x69\x9d\xa5\xa9\x20\x36\xa0\xdc\x67\x5f\xc7\x47\x0e\x09\x3e\x00\xe5\x3a\xe7\x0c\x46\x16\x44\xea\x4e\x0c\xeb\xc8\x14\x2e\x79\x82\x2f\xe9\xc9\x08\x57\x4e\x98\xcf\xcf\xef\x94\x2e\xca\x7d\xaf\xce\x47\xfd\xe5\x7c\x0b\xfe\x85\xb5\x75\x86\x4b\xed\xd6\xa3\x50\x43\x6d\xf0\x71\xcc\xf1\xb6\x31\x0b\x35\xd4\x06\x46\x5e\x03\x00\x92\x55\x94\xd2\x3b\x01\x00\x00")
+
+func _1528395568_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395568_DownSql,
+		"1528395568_.down.sql",
+	)
+}
+
+func _1528395568_DownSql() (*asset, error) {
+	bytes, err := _1528395568_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395568_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xdc, 0x85, 0x98, 0xa6, 0x91, 0xe3, 0x52, 0x72, 0x8d, 0xae, 0x2c, 0xb0, 0x44, 0xd, 0x65, 0xd1, 0x7e, 0x30, 0x8d, 0x28, 0x60, 0xec, 0x57, 0xcb, 0x10, 0x19, 0xde, 0xbd, 0xff, 0xc0, 0x3a, 0x52}}
+	return a, nil
+}
+
+var __1528395568_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x90\xc1\x4e\xb3\x40\x14\x85\xf7\x3c\xc5\xd9\x01\x09\x6f\xc0\x6a\x7e\xb8\xfd\x25\xa5\x53\x03\x43\x62\x63\xcc\x84\x96\x91\x4e\x6c\x06\xc3\x0c\xda\xc4\xf8\xee\xa6\xa5\xad\x2e\xa8\x76\x7f\xee\x77\xbe\x73\x59\x2e\xa8\x80\x60\xff\x72\x42\xa3\xed\x66\xb0\x56\x77\x46\xba\x6d\xaf\xea\xc6\x82\xa5\x29\x92\x65\x5e\x2d\x38\xac\xab\xdd\x60\xe1\xd4\xde\x81\x2f\x05\x78\x95\xe7\x48\x69\xc6\xaa\x5c\xc0\xef\x5e\x95\xf1\x63\xef\x26\x1c\x2f\x45\xc1\x32\x2e\x26\x12\x72\x6c\x91\x9b\xad\xda\xbc\x20\xb9\xa3\x64\x8e\xe0\xd4\x9c\x71\x04\x63\x4f\x04\xbf\x57\xb6\xdb\xbd\xa9\xc6\x8f\xe0\xbf\x77\xc6\x3d\xeb\xbd\x1f\x86\x37\x0a\x1c\xf7\xec\xea\xb5\xda\x8d\x7b\x1e\x9f\x26\x16\x7d\x7c\xfa\xb1\x97\x14\xc4\x04\x21\xe3\x29\x3d\x4c\xe9\x8e\x10\xa9\x9b\x3d\x96\x7c\xaa\xb0\x2a\x33\xfe\x1f\xad\x36\x08\xc6\x6c\x18\x7b\x67\xea\x35\x4b\x59\x5b\xab\x5b\xa3\x94\x45\xe0\x01\xc0\x08\x93\xba\xc1\x5a\xb7\xda\xfc\xf8\x7f\x41\x33\x2a\x88\x27\x54\x4e\x70\x02\xdd\x84\x07\xad\x94\x72\x12\x84\x84\x95\x09\x4b\x29\x3a\x22\x07\xab\xfa\x03\x50\x1b\xa7\x5a\xd5\x4f\x12\x0f\x99\x5f\x21\xf7\x45\xb6\x60\xc5\x0a\x73\x5a\x21\xb8\x48\x46\x67\x78\xe8\x85\x7f\x7f\xf0\xb2\x55\x9e\xae\xae\x3f\xf3\x3b\x1b\x0c\x56\xf5\x52\x37\x61\xec\x7d\x0d\x00\xaf\xce\x20\x52\xc1\x02\x00\x00")
+
+func _1528395568_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395568_UpSql,
+		"1528395568_.up.sql",
+	)
+}
+
+func _1528395568_UpSql() (*asset, error) {
+	bytes, err := _1528395568_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395568_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x48, 0x98, 0x42, 0xf7, 0x16, 0x32, 0xc0, 0x29, 0x11, 0x7, 0x34, 0xec, 0x3e, 0x5f, 0x30, 0xe5, 0xa2, 0x19, 0x4d, 0xf7, 0xe6, 0x6a, 0xfe, 0x26, 0x7e, 0x54, 0x71, 0x87, 0xd6, 0x9f, 0xb7, 0xf6}}
+	return a, nil
+}
+
+var __1528395569_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x39\x00\xc6\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x64\x69\x73\x63\x75\x73\x73\x69\x6f\x6e\x5f\x6e\x6f\x74\x69\x66\x69\x63\x61\x74\x69\x6f\x6e\x5f\x64\x65\x6c\x69\x76\x65\x72\x69\x65\x73\x3b\x0a\x03\x00\xe0\x38\x30\xec\x39\x00\x00\x00")
+
+func _1528395569_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395569_DownSql,
+		"1528395569_.down.sql",
+	)
+}
+
+func _1528395569_DownSql() (*asset, error) {
+	bytes, err := _1528395569_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395569_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x98, 0x58, 0x58, 0x9c, 0xcc, 0xbb, 0xe8, 0xac, 0x28, 0x6b, 0x54, 0xd, 0xdd, 0xf8, 0xce, 0xa2, 0xe3, 0xce, 0x8f, 0xb0, 0xe9, 0x8c, 0x58, 0x25, 0x68, 0x2c, 0xb3, 0xbe, 0xbd, 0x90, 0x77, 0xb5}}
+	return a, nil
+}
+
+var __1528395569_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x53\x4d\x6f\xdb\x30\x0c\xbd\xe7\x57\xf0\x66\x1b\xe8\x61\xf7\x0e\x03\x3c\x47\x45\x8d\x66\xce\x60\xbb\xd8\x7a\x32\x14\x89\x75\xb4\xd8\x52\x20\xd1\x4d\xba\x5f\x3f\xd8\x72\x3e\x96\xb4\x69\x7a\x24\xf5\xde\x13\xf9\x48\x26\x39\x8b\x4b\x06\x65\xfc\x7d\xc6\x40\x2a\x27\x3a\xe7\x94\xd1\x95\x36\xa4\x9e\x95\xe0\xd4\x07\x12\x1b\xf5\x82\x56\xa1\x83\x70\x02\x00\xa0\x24\x2c\x54\xed\xd0\x2a\xde\x40\x36\x2f\x21\x7b\x9c\xcd\xe0\x67\x9e\xfe\x88\xf3\x27\x78\x60\x4f\x37\x03\x0c\x5f\x50\x13\x10\x6e\xe9\x00\x4a\xee\x59\xf2\x00\xa1\x7f\x4a\x33\x08\x03\x5a\x5a\xe4\x32\xb1\xc8\x09\x65\x70\x03\x81\x30\x6d\x8b\x9a\x62\x29\x7d\xdc\x07\xca\xe8\x20\x8a\xbc\xac\x27\x54\xbe\x08\xa5\x8f\xc4\x73\x76\xc7\x72\x96\x25\xac\x38\xee\xc5\xe3\x5d\xa8\x64\x04\xf3\x0c\xa6\x6c\xc6\x4a\x06\x49\x5c\x24\xf1\x94\x79\xc9\xf1\xcb\xeb\x35\x47\xc2\x45\xd1\xce\xa1\xed\x15\x95\x26\xac\xd1\x1e\x2b\xf5\x4f\x17\xb9\xc6\xd6\xef\x50\x8d\xad\x2f\x32\x89\xdb\x1a\xa9\xa2\xd7\x35\xbe\x6d\xfd\x31\x60\x18\x80\x6b\xb8\x58\x0d\x46\x2b\x61\x8d\x33\xcf\x54\x22\x6f\x5d\x9f\xd9\xe0\x62\x69\xcc\x6a\x6f\x7d\x67\x9b\xff\x45\xbd\x7d\x0b\x23\x5f\xdf\xca\x3b\x55\x6b\x4e\x9d\x3d\xad\x64\xca\xee\xe2\xc7\x59\x09\x41\xe0\x71\x9c\x08\xdb\x35\xb9\x7d\xbf\x67\xc8\x2f\x1e\xd8\x70\x47\x15\x5a\x6b\xec\xa0\xe8\x93\x1a\xb7\x54\x8d\x12\x15\x27\x20\xd5\xa2\x23\xde\xae\x61\xa3\x68\x39\x84\xf0\xd7\x68\xf4\xe8\x71\x99\x51\x7e\x0c\x15\x7e\x27\x2f\x01\xcf\x7b\xd2\x66\x13\x8e\x6e\x25\xf3\xac\x28\xf3\x38\xcd\xca\x2b\x4e\xab\x72\xdd\xe2\x0f\x0a\xaa\xc4\x12\xc5\x6a\x37\xac\x70\xb7\x44\x69\x31\x7c\x13\xc1\xd7\x6f\x10\x8e\xdb\xb1\xcb\x45\x93\xe8\x76\x32\x5e\x72\x9a\x4d\xd9\xef\x6b\xbe\x3b\x71\xad\x52\x72\xdb\x5f\xc7\xc7\xcc\xf0\x84\x19\xc1\xaf\x7b\x96\xb3\xb3\x31\xa4\xc5\xde\x9c\xcf\x57\x77\xb0\xfe\x13\x85\x1d\x48\xd1\xed\xe4\xdf\x00\x62\xa6\x13\x33\xda\x04\x00\x00")
+
+func _1528395569_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395569_UpSql,
+		"1528395569_.up.sql",
+	)
+}
+
+func _1528395569_UpSql() (*asset, error) {
+	bytes, err := _1528395569_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395569_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xd6, 0xab, 0xa3, 0xdf, 0x28, 0x9d, 0x12, 0x48, 0x89, 0x23, 0x17, 0x8f, 0xb, 0xd6, 0x40, 0xb0, 0xf0, 0xa6, 0xc7, 0x7, 0xc3, 0x96, 0x92, 0x6d, 0xc0, 0xd9, 0x11, 0x93, 0x18, 0x2b, 0xa9, 0x1f}}
+	return a, nil
+}
+
+var __1528395570_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x45\x00\xba\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x73\x61\x76\x65\x64\x5f\x71\x75\x65\x72\x69\x65\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x73\x75\x6c\x74\x5f\x66\x69\x6e\x67\x65\x72\x70\x72\x69\x6e\x74\x73\x3b\x0a\x03\x00\x85\x3e\xd5\xac\x45\x00\x00\x00")
+
+func _1528395570_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395570_DownSql,
+		"1528395570_.down.sql",
+	)
+}
+
+func _1528395570_DownSql() (*asset, error) {
+	bytes, err := _1528395570_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395570_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x68, 0x40, 0x60, 0xfb, 0xb, 0xdc, 0xa2, 0xfb, 0xeb, 0x5e, 0xcd, 0x27, 0x52, 0x29, 0x35, 0xa4, 0xa2, 0x6b, 0xd4, 0x2c, 0x29, 0x5c, 0xaf, 0x51, 0x19, 0x75, 0x59, 0x9d, 0xd6, 0xf8, 0x2f, 0xee}}
+	return a, nil
+}
+
+var __1528395570_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x41\x00\xbe\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x73\x61\x76\x65\x64\x5f\x71\x75\x65\x72\x69\x65\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x72\x65\x73\x75\x6c\x74\x5f\x66\x69\x6e\x67\x65\x72\x70\x72\x69\x6e\x74\x73\x20\x74\x65\x78\x74\x5b\x5d\x3b\x0a\x03\x00\x3d\xf7\x1f\x98\x41\x00\x00\x00")
+
+func _1528395570_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395570_UpSql,
+		"1528395570_.up.sql",
+	)
+}
+
+func _1528395570_UpSql() (*asset, error) {
+	bytes, err := _1528395570_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395570_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2d, 0xe0, 0xd, 0x33, 0x27, 0x7c, 0x76, 0x55, 0xd2, 0x9, 0xec, 0xab, 0x5f, 0xc9, 0x39, 0x90, 0x84, 0xeb, 0x71, 0x1e, 0x53, 0x80, 0x63, 0xa3, 0x91, 0xb0, 0x6e, 0xf4, 0x6c, 0x87, 0x19, 0xc6}}
+	return a, nil
+}
+
+var __1528395571_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x27\x00\xd8\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x73\x61\x76\x65\x64\x5f\x71\x75\x65\x72\x79\x5f\x72\x75\x6e\x73\x3b\x0a\x03\x00\x10\x43\xef\xff\x27\x00\x00\x00")
+
+func _1528395571_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395571_DownSql,
+		"1528395571_.down.sql",
+	)
+}
+
+func _1528395571_DownSql() (*asset, error) {
+	bytes, err := _1528395571_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395571_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2, 0xde, 0x91, 0xe0, 0x36, 0x43, 0x84, 0x16, 0x3e, 0x1c, 0xb4, 0xc5, 0xb4, 0xdc, 0x58, 0x42, 0x72, 0x3, 0xec, 0x9e, 0x22, 0x1c, 0x7c, 0x5a, 0x7b, 0xc, 0x3, 0x24, 0x86, 0xdf, 0xa4, 0x21}}
+	return a, nil
+}
+
+var __1528395571_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x02\xff\x7d\x51\xcb\x4e\xc3\x30\x10\xbc\xe7\x2b\xf6\x98\x4a\xf9\x03\x4e\x21\xd9\x4a\x15\x21\x45\x69\x2a\xd1\x93\x65\x9a\x6d\xb0\x48\x6d\xf0\x03\x02\x5f\xcf\xe6\x21\xb5\x0a\x2a\x96\x0f\x5e\xef\xcc\x7a\x3c\x93\x55\x98\xd6\x08\x75\x7a\x5f\x20\x38\xf9\x49\x8d\xf8\x08\x64\xbf\x85\x0d\xda\x41\x1c\x01\x2f\xd5\xc0\x8b\x6a\x1d\x59\x25\x3b\x28\xb7\x35\x94\xfb\xa2\x80\xa7\x6a\xf3\x98\x56\x07\x78\xc0\x43\x32\xc2\x46\x1e\x78\xea\xfd\x05\x54\xe1\x1a\x2b\x2c\x33\xdc\x5d\x0d\x57\xe4\xe2\x11\xbc\x82\x6d\x09\x39\x16\xc8\x0a\xb2\x74\x97\xa5\x39\x4e\xa3\x02\x3f\x26\xf8\x59\xa5\x3d\xb5\x64\xaf\xc7\x0c\x2d\x17\xab\xe6\x26\xd7\xd8\xf6\x06\x95\x3b\xff\x32\xdf\x68\x21\x7f\xba\x76\x5e\x5a\xcf\xd2\xa5\x07\xaf\xce\xc4\xe5\xf9\x1d\xbe\x94\x7f\x1d\x4b\xf8\x31\x9a\x16\x0c\xea\xe9\x28\x9a\x60\xa5\x57\x46\x0b\xf6\x91\xed\x63\x3d\x0b\x94\x25\x17\x3a\x2f\x8e\x26\x70\x6b\x96\x3b\xf3\xad\x35\x76\x94\x32\xd5\xda\x78\x75\x52\xc4\x31\x18\xd3\x91\xd4\x17\x7f\x73\x5c\xa7\xfb\xa2\x86\x93\xec\x1c\x45\xab\xbb\x28\x9b\xf2\xdc\x94\x39\x3e\xff\xc9\x73\x3e\xf2\x3f\xd9\x21\xde\xfd\xe0\xc4\x12\x34\x45\x93\x0c\x6e\x24\x1c\x3d\xcf\xfc\x05\xf1\xbc\x2b\x86\x24\x02\x00\x00")
+
+func _1528395571_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395571_UpSql,
+		"1528395571_.up.sql",
+	)
+}
+
+func _1528395571_UpSql() (*asset, error) {
+	bytes, err := _1528395571_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395571_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x49, 0x44, 0x9d, 0x4f, 0x1a, 0x84, 0x6a, 0x72, 0x21, 0xc9, 0xd6, 0xb4, 0x7e, 0x20, 0x33, 0xb9, 0x9c, 0x34, 0x48, 0xe2, 0x3, 0x1c, 0xea, 0x6b, 0xec, 0x99, 0x39, 0x37, 0xc9, 0x67, 0xc, 0x46}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -4574,6 +4868,34 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395564_.down.sql": _1528395564_DownSql,
 
 	"1528395564_.up.sql": _1528395564_UpSql,
+
+	"1528395565_.down.sql": _1528395565_DownSql,
+
+	"1528395565_.up.sql": _1528395565_UpSql,
+
+	"1528395566_.down.sql": _1528395566_DownSql,
+
+	"1528395566_.up.sql": _1528395566_UpSql,
+
+	"1528395567_.down.sql": _1528395567_DownSql,
+
+	"1528395567_.up.sql": _1528395567_UpSql,
+
+	"1528395568_.down.sql": _1528395568_DownSql,
+
+	"1528395568_.up.sql": _1528395568_UpSql,
+
+	"1528395569_.down.sql": _1528395569_DownSql,
+
+	"1528395569_.up.sql": _1528395569_UpSql,
+
+	"1528395570_.down.sql": _1528395570_DownSql,
+
+	"1528395570_.up.sql": _1528395570_UpSql,
+
+	"1528395571_.down.sql": _1528395571_DownSql,
+
+	"1528395571_.up.sql": _1528395571_UpSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -4809,6 +5131,20 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395563_.up.sql":                                          {_1528395563_UpSql, map[string]*bintree{}},
 	"1528395564_.down.sql":                                        {_1528395564_DownSql, map[string]*bintree{}},
 	"1528395564_.up.sql":                                          {_1528395564_UpSql, map[string]*bintree{}},
+	"1528395565_.down.sql":                                        {_1528395565_DownSql, map[string]*bintree{}},
+	"1528395565_.up.sql":                                          {_1528395565_UpSql, map[string]*bintree{}},
+	"1528395566_.down.sql":                                        {_1528395566_DownSql, map[string]*bintree{}},
+	"1528395566_.up.sql":                                          {_1528395566_UpSql, map[string]*bintree{}},
+	"1528395567_.down.sql":                                        {_1528395567_DownSql, map[string]*bintree{}},
+	"1528395567_.up.sql":                                          {_1528395567_UpSql, map[string]*bintree{}},
+	"1528395568_.down.sql":                                        {_1528395568_DownSql, map[string]*bintree{}},
+	"1528395568_.up.sql":                                          {_1528395568_UpSql, map[string]*bintree{}},
+	"1528395569_.down.sql":                                        {_1528395569_DownSql, map[string]*bintree{}},
+	"1528395569_.up.sql":                                          {_1528395569_UpSql, map[string]*bintree{}},
+	"1528395570_.down.sql":                                        {_1528395570_DownSql, map[string]*bintree{}},
+	"1528395570_.up.sql":                                          {_1528395570_UpSql, map[string]*bintree{}},
+	"1528395571_.down.sql":                                        {_1528395571_DownSql, map[string]*bintree{}},
+	"1528395571_.up.sql":                                          {_1528395571_UpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -129,3 +129,21 @@ type ExternalService struct {
 	UpdatedAt   time.Time
 	DeletedAt   *time.Time
 }
+
+// ExternalServiceSyncStatus is the outcome of the last sync of the repositories of an external
+// service by repo-updater.
+type ExternalServiceSyncStatus struct {
+	ExternalServiceID int64
+	StartedAt         time.Time
+	FinishedAt        time.Time
+	ReposAdded        int      // repositories synced that were not synced by the previous sync
+	ReposRemoved      int      // repositories synced by the previous sync that were not synced
+	ReposUnchanged    int      // repositories synced by both this and the previous sync
+	Errors            []string // errors listing or syncing repositories
+
+	// Repos and Incomplete are only set by repo-updater when it records the status. The frontend
+	// computes the counts above from them and the repositories synced by the previous sync, which
+	// it stores.
+	Repos      []RepoName // repositories synced
+	Incomplete bool       // listing the repositories failed, so that Repos may be missing some
+}
@@ -66,3 +66,9 @@ type ExternalServiceConfigsRequest struct {
 type ExternalServicesListRequest struct {
 	Kind string `json:"kind"`
 }
+
+// ReposListReadableRequest asks which of the repositories a user can read.
+type ReposListReadableRequest struct {
+	UserID int32      `json:"userID"`
+	Repos  []RepoName `json:"repos"`
+}
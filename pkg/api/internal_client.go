@@ -97,6 +97,7 @@ type ConfigSavedQuery struct {
 	ShowOnHomepage bool   `json:"showOnHomepage"`
 	Notify         bool   `json:"notify,omitempty"`
 	NotifySlack    bool   `json:"notifySlack,omitempty"`
+	Schedule       string `json:"schedule,omitempty"`
 }
 
 func (sq ConfigSavedQuery) Equals(other ConfigSavedQuery) bool {
@@ -140,13 +141,17 @@ type SavedQueryInfo struct {
 	// executed.
 	LastExecuted time.Time
 
-	// LatestResult is the timestamp of the latest-known result for the search
-	// query. Therefore, searching `after:<LatestResult>` will return the new
-	// search results not yet seen.
+	// LatestResult is the timestamp of the last time that new results were
+	// found for the search query.
 	LatestResult time.Time
 
 	// ExecDuration is the amount of time it took for the query to execute.
 	ExecDuration time.Duration
+
+	// ResultFingerprints are the fingerprints of the search results of the
+	// last execution, which are compared with those of the next execution to
+	// find the new results. It is nil if the query was never executed.
+	ResultFingerprints []string
 }
 
 // SavedQueriesGetInfo gets the info from the DB for the given saved query. nil
@@ -165,6 +170,37 @@ func (c *internalClient) SavedQueriesSetInfo(ctx context.Context, info *SavedQue
 	return c.postInternal(ctx, "saved-queries/set-info", info, nil)
 }
 
+// SavedQueryRun describes a single run of a saved query.
+type SavedQueryRun struct {
+	// Spec identifies the saved query that was run.
+	Spec SavedQueryIDSpec
+
+	// Query is the search query in question.
+	Query string
+
+	// StartedAt is the timestamp of when the run started.
+	StartedAt time.Time
+
+	// ExecDuration is the amount of time it took for the query to execute.
+	ExecDuration time.Duration
+
+	// ResultCount is the number of search results that the saved query's owner
+	// can read, or nil if the search failed or the count is not shown to the
+	// owner.
+	ResultCount *int32
+
+	// Error is the error that the search failed with, or nil if it succeeded.
+	Error *string
+
+	// Notified is whether notifications of new search results were sent.
+	Notified bool
+}
+
+// SavedQueriesRecordRun records a run in the run history of the saved query.
+func (c *internalClient) SavedQueriesRecordRun(ctx context.Context, run *SavedQueryRun) error {
+	return c.postInternal(ctx, "saved-queries/record-run", run, nil)
+}
+
 func (c *internalClient) SavedQueriesDeleteInfo(ctx context.Context, query string) error {
 	return c.postInternal(ctx, "saved-queries/delete-info", query, nil)
 }
@@ -260,6 +296,20 @@ func (c *internalClient) ReposCreateIfNotExists(ctx context.Context, op RepoCrea
 	return &repo, nil
 }
 
+// MockReposGetByExternalRepo mocks (*internalClient).ReposGetByExternalRepo.
+var MockReposGetByExternalRepo func(spec ExternalRepoSpec) (*Repo, error)
+
+// ReposGetByExternalRepo returns the repository with the given external
+// repository, or nil if there is none.
+func (c *internalClient) ReposGetByExternalRepo(ctx context.Context, spec ExternalRepoSpec) (*Repo, error) {
+	if MockReposGetByExternalRepo != nil {
+		return MockReposGetByExternalRepo(spec)
+	}
+	var repo *Repo
+	err := c.postInternal(ctx, "repos/get-by-external-repo", spec, &repo)
+	return repo, err
+}
+
 // ReposListEnabled returns a list of all enabled repository names.
 func (c *internalClient) ReposListEnabled(ctx context.Context) ([]RepoName, error) {
 	var names []RepoName
@@ -267,6 +317,20 @@ func (c *internalClient) ReposListEnabled(ctx context.Context) ([]RepoName, erro
 	return names, err
 }
 
+// MockReposListReadable mocks (*internalClient).ReposListReadable.
+var MockReposListReadable func(userID int32, repos []RepoName) ([]RepoName, error)
+
+// ReposListReadable returns the repositories among repos which the user can
+// read.
+func (c *internalClient) ReposListReadable(ctx context.Context, userID int32, repos []RepoName) ([]RepoName, error) {
+	if MockReposListReadable != nil {
+		return MockReposListReadable(userID, repos)
+	}
+	var names []RepoName
+	err := c.postInternal(ctx, "repos/list-readable", &ReposListReadableRequest{UserID: userID, Repos: repos}, &names)
+	return names, err
+}
+
 // MockInternalClientConfiguration mocks (*internalClient).Configuration.
 var MockInternalClientConfiguration func() (conftypes.RawUnified, error)
 
@@ -342,6 +406,11 @@ func (c *internalClient) ExternalServicesList(ctx context.Context, opts External
 	return extsvcs, c.postInternal(ctx, "external-services/list", &opts, &extsvcs)
 }
 
+// ExternalServicesSetSyncStatus records the outcome of the last sync of an external service.
+func (c *internalClient) ExternalServicesSetSyncStatus(ctx context.Context, status *ExternalServiceSyncStatus) error {
+	return c.postInternal(ctx, "external-services/sync-status", status, nil)
+}
+
 func (c *internalClient) LogTelemetry(ctx context.Context, env string, reqBody interface{}) error {
 	return c.postInternal(ctx, "telemetry/log/v1/"+env, reqBody, nil)
 }
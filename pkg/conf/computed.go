@@ -8,11 +8,13 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf/confdefaults"
 	"github.com/sourcegraph/sourcegraph/pkg/conf/conftypes"
 	"github.com/sourcegraph/sourcegraph/pkg/env"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
 	"github.com/sourcegraph/sourcegraph/pkg/legacyconf"
 	"github.com/sourcegraph/sourcegraph/schema"
@@ -106,6 +108,14 @@ func AWSCodeCommitConfigs(ctx context.Context) ([]*schema.AWSCodeCommitConnectio
 	return config, nil
 }
 
+func BitbucketCloudConfigs(ctx context.Context) ([]*schema.BitbucketCloudConnection, error) {
+	var config []*schema.BitbucketCloudConnection
+	if err := api.InternalClient.ExternalServiceConfigs(ctx, "BITBUCKETCLOUD", &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 func BitbucketServerConfigs(ctx context.Context) ([]*schema.BitbucketServerConnection, error) {
 	var config []*schema.BitbucketServerConnection
 	if err := api.InternalClient.ExternalServiceConfigs(ctx, "BITBUCKETSERVER", &config); err != nil {
@@ -114,6 +124,14 @@ func BitbucketServerConfigs(ctx context.Context) ([]*schema.BitbucketServerConne
 	return config, nil
 }
 
+func GiteaConfigs(ctx context.Context) ([]*schema.GiteaConnection, error) {
+	var config []*schema.GiteaConnection
+	if err := api.InternalClient.ExternalServiceConfigs(ctx, "GITEA", &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 func GitHubConfigs(ctx context.Context) ([]*schema.GitHubConnection, error) {
 	var config []*schema.GitHubConnection
 	if err := api.InternalClient.ExternalServiceConfigs(ctx, "GITHUB", &config); err != nil {
@@ -263,6 +281,44 @@ func SearchIndexEnabled() bool {
 	return DeployType() != DeployDocker
 }
 
+// GitReplicationFactor returns the number of gitservers which keep a copy of
+// each repository. It is at least 1.
+func GitReplicationFactor() int {
+	if n := Get().GitReplicationFactor; n > 1 {
+		return n
+	}
+	return 1
+}
+
+// GitSharding returns the algorithm for assigning repositories to gitservers.
+// It is protocol.HashMod unless rendezvous hashing is enabled, because
+// changing the algorithm moves most repositories.
+func GitSharding() protocol.Sharding {
+	if Get().GitShardingAlgorithm == "rendezvous" {
+		return protocol.Rendezvous
+	}
+	return protocol.HashMod
+}
+
+// PermissionsBackgroundSync returns whether repository permissions are synced in the background,
+// how often the permissions of recently active users are refreshed and the maximum age of stored
+// permissions. Invalid durations are reported by the config validator and fall back to the
+// defaults here.
+func PermissionsBackgroundSync() (enabled bool, userInterval, maxStaleness time.Duration) {
+	userInterval, maxStaleness = time.Hour, 24*time.Hour
+	c := Get().PermissionsBackgroundSync
+	if c == nil {
+		return false, userInterval, maxStaleness
+	}
+	if d, err := time.ParseDuration(c.UserInterval); err == nil && d > 0 {
+		userInterval = d
+	}
+	if d, err := time.ParseDuration(c.MaxStaleness); err == nil && d > 0 {
+		maxStaleness = d
+	}
+	return c.Enabled, userInterval, maxStaleness
+}
+
 // SrcGitServers represents the SRC_GIT_SERVERS environment variable.
 //
 // Non-frontend callers should go through api.InternalClient.GitServerAddrs() instead.
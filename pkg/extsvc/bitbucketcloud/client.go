@@ -0,0 +1,217 @@
+package bitbucketcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/metrics"
+	"golang.org/x/net/context/ctxhttp"
+	"golang.org/x/oauth2"
+	"golang.org/x/time/rate"
+)
+
+var requestCounter = metrics.NewRequestCounter("bitbucketcloud", "Total number of requests sent to the Bitbucket Cloud API.")
+
+// WithRequestCounter wraps the given transport with a request counter metric.
+func WithRequestCounter(transport http.RoundTripper) http.RoundTripper {
+	return requestCounter.Transport(transport, func(u *url.URL) string {
+		// API to URL mapping looks like this:
+		//
+		// 	Repo -> 2.0/repositories/%s/%s
+		// 	Repos -> 2.0/repositories/%s or 2.0/repositories?role=member
+		//
+		// We guess the category based on the number of path components after "repositories".
+		parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+		for i, part := range parts {
+			if part != "repositories" {
+				continue
+			}
+			switch len(parts) - i - 1 {
+			case 0, 1:
+				return "Repos"
+			case 2:
+				return "Repo"
+			}
+		}
+		// don't return the path directly as that could introduce too much dimensionality
+		return "unknown"
+	})
+}
+
+// TokenURL is the URL of the Bitbucket Cloud OAuth 2.0 token endpoint.
+const TokenURL = "https://bitbucket.org/site/oauth2/access_token"
+
+// Client accesses Bitbucket Cloud via the REST API 2.0.
+type Client struct {
+	// URL is the base URL of the Bitbucket Cloud API (https://api.bitbucket.org/).
+	URL *url.URL
+
+	// The username and app password for accessing the API.
+	Username, AppPassword string
+
+	// TokenSource provides OAuth access tokens for accessing the API. If set, it is used
+	// instead of Username and AppPassword.
+	TokenSource oauth2.TokenSource
+
+	// HTTPClient is the client used to access Bitbucket Cloud. To enable tracing, ensure the
+	// transport includes nethttp.Transport.
+	//
+	// To enable metrics, always wrap the Transport using WithRequestCounter.
+	HTTPClient *http.Client
+
+	// RateLimit is the self-imposed rate limiter, which keeps us below the hourly limits
+	// that Bitbucket Cloud imposes on the repositories API.
+	RateLimit *rate.Limiter
+}
+
+// Repo returns the repository with the given full name ("team/name"). The name may also be
+// the repository's UUID (in braces), with the team left empty.
+func (c *Client) Repo(ctx context.Context, team, name string) (*Repo, error) {
+	if team == "" {
+		team = "{}"
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("2.0/repositories/%s/%s", url.PathEscape(team), url.PathEscape(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var repo Repo
+	if err := c.do(ctx, req, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// Repos returns a page of the repositories of the given team (or user). If team is empty, it
+// returns the repositories that the authenticated user is a member of. Pass a nil pageToken
+// to get the first page.
+func (c *Client) Repos(ctx context.Context, pageToken *PageToken, team string) ([]*Repo, *PageToken, error) {
+	var u string
+	if pageToken != nil && pageToken.Next != "" {
+		u = pageToken.Next
+	} else {
+		q := url.Values{"pagelen": {"100"}}
+		if pageToken != nil && pageToken.Pagelen != 0 {
+			q.Set("pagelen", strconv.Itoa(pageToken.Pagelen))
+		}
+		if team == "" {
+			u = "2.0/repositories?role=member&" + q.Encode()
+		} else {
+			u = fmt.Sprintf("2.0/repositories/%s?%s", url.PathEscape(team), q.Encode())
+		}
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var resp struct {
+		PageToken
+		Values []*Repo `json:"values"`
+	}
+	if err := c.do(ctx, req, &resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Values, &resp.PageToken, nil
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
+	req.URL = c.URL.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+
+	// Authenticate request, preferring OAuth.
+	if c.TokenSource != nil {
+		tok, err := c.TokenSource.Token()
+		if err != nil {
+			return errors.Wrap(err, "get Bitbucket Cloud OAuth token")
+		}
+		tok.SetAuthHeader(req)
+	} else if c.Username != "" || c.AppPassword != "" {
+		req.SetBasicAuth(c.Username, c.AppPassword)
+	}
+
+	req, ht := nethttp.TraceRequest(opentracing.GlobalTracer(), req,
+		nethttp.OperationName("Bitbucket Cloud"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	resp, err := ctxhttp.Do(ctx, c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.WithStack(&httpError{URL: req.URL, StatusCode: resp.StatusCode})
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// PageToken describes a page of a paginated Bitbucket Cloud API response.
+type PageToken struct {
+	Size    int    `json:"size"`
+	Page    int    `json:"page"`
+	Pagelen int    `json:"pagelen"`
+	Next    string `json:"next"`
+}
+
+// HasMore reports whether there is a page after the one described by t.
+func (t *PageToken) HasMore() bool {
+	if t == nil {
+		return true
+	}
+	return t.Next != ""
+}
+
+// Repo is a Bitbucket Cloud repository.
+type Repo struct {
+	UUID        string `json:"uuid"`      // such as "{b6b4fa5b-...}"
+	FullName    string `json:"full_name"` // "team/name"
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsPrivate   bool   `json:"is_private"`
+	SCM         string `json:"scm"` // "git" or "hg"
+	Parent      *struct {
+		FullName string `json:"full_name"`
+	} `json:"parent"`
+	Links struct {
+		Clone []Link `json:"clone"` // named "https" and "ssh"
+		HTML  Link   `json:"html"`
+	} `json:"links"`
+}
+
+// Link is a link to a resource related to a Bitbucket Cloud object.
+type Link struct {
+	Href string `json:"href"`
+	Name string `json:"name"`
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("unexpected %d response from Bitbucket Cloud API at %s", e.StatusCode, e.URL)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,2 @@
+// Package bitbucketcloud implements a Bitbucket Cloud (bitbucket.org) API client.
+package bitbucketcloud
@@ -27,8 +27,9 @@ func WithRequestCounter(transport http.RoundTripper) http.RoundTripper {
 		// 	Repo -> rest/api/1.0/profile/recent/repos%s
 		// 	Repos -> rest/api/1.0/projects/%s/repos/%s
 		// 	RecentRepos -> rest/api/1.0/repos%s
+		// 	Users -> rest/api/1.0/users%s
 		//
-		// We guess the category based on the fourth path component ("profile", "projects", "repos%s").
+		// We guess the category based on the fourth path component ("profile", "projects", "repos%s", "users%s").
 		var category string
 		if parts := strings.SplitN(u.Path, "/", 3); len(parts) >= 4 {
 			category = parts[3]
@@ -40,6 +41,8 @@ func WithRequestCounter(transport http.RoundTripper) http.RoundTripper {
 			return "Repos"
 		case strings.HasPrefix(category, "repos"):
 			return "RecentRepos"
+		case strings.HasPrefix(category, "users"):
+			return "Users"
 		default:
 			// don't return category directly as that could introduce too much dimensionality
 			return "unknown"
@@ -70,6 +73,24 @@ type Client struct {
 	// RateLimit is the self-imposed rate limiter (since Bitbucket does not have a concept
 	// of rate limiting in HTTP response headers).
 	RateLimit *rate.Limiter
+
+	// OAuth is the consumer of an application link which allows impersonating users. It is
+	// only required by Sudo.
+	OAuth *OAuthConsumer
+
+	// sudo is the username of the user whose identity requests are made with (see Sudo).
+	sudo string
+}
+
+// Sudo returns a copy of the client which makes requests as the Bitbucket Server user with the
+// given username, by impersonating the user with c.OAuth.
+func (c *Client) Sudo(username string) (*Client, error) {
+	if c.OAuth == nil {
+		return nil, errors.New("impersonating Bitbucket Server users requires an OAuth consumer")
+	}
+	sudo := *c
+	sudo.sudo = username
+	return &sudo, nil
 }
 
 func (c *Client) Repo(ctx context.Context, projectKey, repoSlug string) (*Repo, error) {
@@ -100,6 +121,26 @@ func (c *Client) Repos(ctx context.Context, pageToken *PageToken) ([]*Repo, *Pag
 	return resp.Values, resp.PageToken, nil
 }
 
+// ReadableRepos returns a page of the repositories which the user of the client can read. It is
+// usually used with a client returned by Sudo.
+func (c *Client) ReadableRepos(ctx context.Context, pageToken *PageToken) ([]*Repo, *PageToken, error) {
+	qry := pageToken.values()
+	qry.Set("permission", "REPO_READ")
+	req, err := http.NewRequest("GET", "rest/api/1.0/repos?"+qry.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var resp struct {
+		*PageToken
+		Values []*Repo
+	}
+	err = c.do(ctx, req, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Values, resp.PageToken, nil
+}
+
 func (c *Client) RecentRepos(ctx context.Context, pageToken *PageToken) ([]*Repo, *PageToken, error) {
 	u := fmt.Sprintf("rest/api/1.0/profile/recent/repos%s", pageToken.Query())
 	req, err := http.NewRequest("GET", u, nil)
@@ -117,12 +158,46 @@ func (c *Client) RecentRepos(ctx context.Context, pageToken *PageToken) ([]*Repo
 	return resp.Values, resp.PageToken, nil
 }
 
+// Users returns a page of the users whose username, name or email address
+// contains filter (if non-empty) and who have all the given permissions.
+func (c *Client) Users(ctx context.Context, pageToken *PageToken, filter string, perms ...PermissionFilter) ([]*User, *PageToken, error) {
+	qry := pageToken.values()
+	if filter != "" {
+		qry.Set("filter", filter)
+	}
+	for i, p := range perms {
+		p.encode(qry, i+1)
+	}
+
+	u := "rest/api/1.0/users"
+	if len(qry) > 0 {
+		u += "?" + qry.Encode()
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var resp struct {
+		*PageToken
+		Values []*User
+	}
+	err = c.do(ctx, req, &resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Values, resp.PageToken, nil
+}
+
 func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
 	req.URL = c.URL.ResolveReference(req.URL)
 	req.Header.Set("Content-Type", "application/json; charset=utf-8")
 
-	// Authenticate request, preferring token.
-	if c.Token != "" {
+	// Authenticate request, preferring impersonation, then token.
+	if c.sudo != "" {
+		if err := c.OAuth.sign(req, c.sudo); err != nil {
+			return err
+		}
+	} else if c.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	} else if c.Username != "" || c.Password != "" {
 		req.SetBasicAuth(c.Username, c.Password)
@@ -165,20 +240,57 @@ func (t *PageToken) HasMore() bool {
 }
 
 func (t *PageToken) Query() string {
-	if t == nil {
+	v := t.values()
+	if len(v) == 0 {
 		return ""
 	}
+	return "?" + v.Encode()
+}
+
+func (t *PageToken) values() url.Values {
 	v := url.Values{}
+	if t == nil {
+		return v
+	}
 	if t.NextPageStart != 0 {
 		v.Set("start", strconv.Itoa(t.NextPageStart))
 	}
 	if t.Limit != 0 {
 		v.Set("limit", strconv.Itoa(t.Limit))
 	}
-	if len(v) == 0 {
-		return ""
+	return v
+}
+
+// PermissionFilter restricts a user listing to the users who have a permission,
+// such as "REPO_READ" on the repository with the given project key and slug.
+type PermissionFilter struct {
+	Root           string
+	ProjectKey     string
+	RepositorySlug string
+}
+
+// encode sets the query parameters of the n-th (starting at 1) permission
+// filter of a request.
+func (p PermissionFilter) encode(qry url.Values, n int) {
+	key := "permission." + strconv.Itoa(n)
+	qry.Set(key, p.Root)
+	if p.ProjectKey != "" {
+		qry.Set(key+".projectKey", p.ProjectKey)
 	}
-	return "?" + v.Encode()
+	if p.RepositorySlug != "" {
+		qry.Set(key+".repositorySlug", p.RepositorySlug)
+	}
+}
+
+// User is a Bitbucket Server user. Its Name is the username.
+type User struct {
+	Name         string `json:"name"`
+	EmailAddress string `json:"emailAddress"`
+	ID           int    `json:"id"`
+	DisplayName  string `json:"displayName"`
+	Active       bool   `json:"active"`
+	Slug         string `json:"slug"`
+	Type         string `json:"type"`
 }
 
 type Repo struct {
@@ -0,0 +1,139 @@
+package bitbucketserver
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OAuthConsumer is the consumer of an application link to Bitbucket Server
+// with "Allow 2-Legged OAuth" and "Allow user impersonation through 2-Legged
+// OAuth" enabled. A client with an OAuthConsumer can make requests as any
+// user (see Client.Sudo).
+type OAuthConsumer struct {
+	// Key is the consumer key of the application link.
+	Key string
+
+	// SigningKey is the private key whose public key is configured on the
+	// application link.
+	SigningKey *rsa.PrivateKey
+}
+
+// ParseOAuthSigningKey parses a base64-encoded PEM RSA private key, in
+// PKCS#1 or PKCS#8 form.
+func ParseOAuthSigningKey(s string) (*rsa.PrivateKey, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "signing key is not base64-encoded")
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid signing key")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA private key")
+	}
+	return rsaKey, nil
+}
+
+// sign authenticates req as the Bitbucket Server user with the given
+// username, with an OAuth 1.0a Authorization header signed with RSA-SHA1.
+// req.URL must be absolute.
+func (c *OAuthConsumer) sign(req *http.Request, username string) error {
+	qry := req.URL.Query()
+	qry.Set("xoauth_requestor_id", username)
+	req.URL.RawQuery = qry.Encode()
+
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return err
+	}
+	params := map[string]string{
+		"oauth_consumer_key":     c.Key,
+		"oauth_nonce":            hex.EncodeToString(nonce[:]),
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_version":          "1.0",
+	}
+
+	// The signature base string is described in
+	// https://tools.ietf.org/html/rfc5849#section-3.4.1.
+	var pairs []string
+	for k, vs := range qry {
+		for _, v := range vs {
+			pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+		}
+	}
+	for k, v := range params {
+		pairs = append(pairs, oauthEscape(k)+"="+oauthEscape(v))
+	}
+	sort.Strings(pairs)
+	baseURL := url.URL{
+		Scheme: strings.ToLower(req.URL.Scheme),
+		Host:   strings.ToLower(req.URL.Host),
+		Path:   req.URL.EscapedPath(),
+	}
+	if port := req.URL.Port(); (baseURL.Scheme == "http" && port == "80") || (baseURL.Scheme == "https" && port == "443") {
+		baseURL.Host = strings.ToLower(req.URL.Hostname())
+	}
+	base := strings.Join([]string{
+		oauthEscape(req.Method),
+		oauthEscape(baseURL.String()),
+		oauthEscape(strings.Join(pairs, "&")),
+	}, "&")
+
+	sum := sha1.Sum([]byte(base))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, c.SigningKey, crypto.SHA1, sum[:])
+	if err != nil {
+		return err
+	}
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(sig)
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	header := make([]string, len(keys))
+	for i, k := range keys {
+		header[i] = oauthEscape(k) + `="` + oauthEscape(params[k]) + `"`
+	}
+	req.Header.Set("Authorization", "OAuth "+strings.Join(header, ", "))
+	return nil
+}
+
+// oauthEscape percent-encodes s as required by OAuth 1.0a: every byte except
+// the unreserved characters of RFC 3986 is encoded.
+func oauthEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return b.String()
+}
@@ -0,0 +1,192 @@
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/opentracing-contrib/go-stdlib/nethttp"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/metrics"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+var requestCounter = metrics.NewRequestCounter("gitea", "Total number of requests sent to the Gitea API.")
+
+// WithRequestCounter wraps the given transport with a request counter metric.
+func WithRequestCounter(transport http.RoundTripper) http.RoundTripper {
+	return requestCounter.Transport(transport, func(u *url.URL) string {
+		// API to URL mapping looks like this:
+		//
+		// 	Repository -> api/v1/repos/%s/%s, api/v1/repositories/%d
+		// 	SearchRepositories -> api/v1/repos/search
+		// 	UserRepositories -> api/v1/user/repos
+		switch {
+		case strings.Contains(u.Path, "/api/v1/repos/search"):
+			return "SearchRepositories"
+		case strings.Contains(u.Path, "/api/v1/repos/"), strings.Contains(u.Path, "/api/v1/repositories/"):
+			return "Repository"
+		case strings.Contains(u.Path, "/api/v1/user/repos"):
+			return "UserRepositories"
+		default:
+			// don't return the path directly as that could introduce too much dimensionality
+			return "unknown"
+		}
+	})
+}
+
+// PerPage is the maximum number of items per page that Gitea returns by
+// default. (Gogs ignores the limit parameter and always returns all items.)
+const PerPage = 50
+
+// Client accesses a Gitea (or Gogs) instance via the REST API.
+type Client struct {
+	// URL is the base URL of the Gitea instance, such as https://gitea.example.com/.
+	URL *url.URL
+
+	// Token is the access token for accessing the instance. If empty, requests
+	// are anonymous and only public repositories are visible.
+	Token string
+
+	// HTTPClient is the client used to access Gitea. To enable tracing, ensure
+	// the transport includes nethttp.Transport.
+	//
+	// To enable metrics, always wrap the Transport using WithRequestCounter.
+	HTTPClient *http.Client
+}
+
+// Repository is a Gitea repository.
+type Repository struct {
+	ID            int64  `json:"id"`
+	Owner         *User  `json:"owner"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"` // "owner/name"
+	Description   string `json:"description"`
+	Private       bool   `json:"private"`
+	Fork          bool   `json:"fork"`
+	Archived      bool   `json:"archived"` // always false on Gogs and Gitea 1.4 and older
+	Mirror        bool   `json:"mirror"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+// User is a Gitea user or organization.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+// GetRepository returns the repository with the given owner and name.
+func (c *Client) GetRepository(ctx context.Context, owner, name string) (*Repository, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("api/v1/repos/%s/%s", url.PathEscape(owner), url.PathEscape(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	var repo Repository
+	if err := c.do(ctx, req, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// GetRepositoryByID returns the repository with the given ID. Gogs doesn't
+// support looking up repositories by ID, so it always returns a not found
+// error there.
+func (c *Client) GetRepositoryByID(ctx context.Context, id int64) (*Repository, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("api/v1/repositories/%d", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var repo Repository
+	if err := c.do(ctx, req, &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// ListUserRepositories returns a page (starting at 1) of the repositories
+// that the authenticated user owns, collaborates on or can access through an
+// organization.
+func (c *Client) ListUserRepositories(ctx context.Context, page int) ([]*Repository, error) {
+	q := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(PerPage)}}
+	req, err := http.NewRequest("GET", "api/v1/user/repos?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var repos []*Repository
+	if err := c.do(ctx, req, &repos); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+// SearchRepositories returns a page (starting at 1) of the repositories
+// visible to the authenticated user whose names contain the keyword. An
+// empty keyword matches all repositories.
+func (c *Client) SearchRepositories(ctx context.Context, keyword string, page int) ([]*Repository, error) {
+	q := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(PerPage)}}
+	if keyword != "" {
+		q.Set("q", keyword)
+	}
+	req, err := http.NewRequest("GET", "api/v1/repos/search?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		OK   bool          `json:"ok"`
+		Data []*Repository `json:"data"`
+	}
+	if err := c.do(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request, result interface{}) error {
+	req.URL = c.URL.ResolveReference(req.URL)
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "token "+c.Token)
+	}
+
+	req, ht := nethttp.TraceRequest(opentracing.GlobalTracer(), req,
+		nethttp.OperationName("Gitea"),
+		nethttp.ClientTrace(false))
+	defer ht.Finish()
+
+	resp, err := ctxhttp.Do(ctx, c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.WithStack(&httpError{URL: req.URL, StatusCode: resp.StatusCode})
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+type httpError struct {
+	StatusCode int
+	URL        *url.URL
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("unexpected %d response from Gitea API at %s", e.StatusCode, e.URL)
+}
+
+func (e *httpError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized
+}
+
+func (e *httpError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,22 @@
+package gitea
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
+)
+
+// ServiceType is the (api.ExternalRepoSpec).ServiceType value for Gitea repositories. The ServiceID
+// value is the base URL to the Gitea instance.
+const ServiceType = "gitea"
+
+// ExternalRepoSpec returns an api.ExternalRepoSpec that refers to the specified Gitea repository.
+func ExternalRepoSpec(repo *Repository, baseURL url.URL) *api.ExternalRepoSpec {
+	return &api.ExternalRepoSpec{
+		ID:          strconv.FormatInt(repo.ID, 10),
+		ServiceType: ServiceType,
+		ServiceID:   extsvc.NormalizeBaseURL(&baseURL).String(),
+	}
+}
@@ -0,0 +1,3 @@
+// Package gitea implements a Gitea API client. Gogs serves the same API, so
+// the client works with Gogs too.
+package gitea
@@ -3,8 +3,6 @@ package gitserver
 import (
 	"bytes"
 	"context"
-	"crypto/md5"
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -123,12 +121,51 @@ type Client struct {
 	// UserAgent is a string identifing who the client is. It will be logged in
 	// the telemetry in gitserver.
 	UserAgent string
+
+	locationsMu sync.Mutex
+	// locations maps repositories which are being transferred between
+	// gitservers to the gitserver which still has them (see
+	// protocol.LocationHeader).
+	locations map[api.RepoName]string
+
+	unreachableMu sync.Mutex
+	// unreachable is when requests to each gitserver last failed. Requests
+	// for a repository go to a different replica while its owner is
+	// unreachable (see conf.GitReplicationFactor).
+	unreachable map[string]time.Time
 }
 
+// unreachableTTL is how long we prefer other replicas after a request to a
+// gitserver fails.
+const unreachableTTL = 10 * time.Second
+
 // addrForRepo returns the gitserver address to use for the given repo name.
-func (c *Client) addrForRepo(ctx context.Context, repo api.RepoName) string {
+// This is the gitserver which owns the repository, unless the repository is
+// still being transferred to its owner or the owner is unreachable. Addresses
+// in skip are not returned. It returns "" if every replica is in skip.
+func (c *Client) addrForRepo(ctx context.Context, repo api.RepoName, skip map[string]bool) string {
 	repo = protocol.NormalizeRepo(repo) // in case the caller didn't already normalize it
-	return c.addrForKey(ctx, string(repo))
+	if addr := c.locationOf(repo); addr != "" && !skip[addr] {
+		return addr
+	}
+
+	addrs := c.Addrs(ctx)
+	if len(addrs) == 0 {
+		panic("unexpected state: no gitserver addresses")
+	}
+	var fallback string
+	for _, addr := range conf.GitSharding().AddrsForRepo(repo, addrs, conf.GitReplicationFactor()) {
+		if skip[addr] {
+			continue
+		}
+		if !c.isUnreachable(addr) {
+			return addr
+		}
+		if fallback == "" {
+			fallback = addr
+		}
+	}
+	return fallback
 }
 
 // addrForKey returns the gitserver address to use for the given string key,
@@ -138,11 +175,67 @@ func (c *Client) addrForKey(ctx context.Context, key string) string {
 	if len(addrs) == 0 {
 		panic("unexpected state: no gitserver addresses")
 	}
-	sum := md5.Sum([]byte(key))
-	serverIndex := binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs))
-	return addrs[serverIndex]
+	return conf.GitSharding().AddrForKey(key, addrs)
+}
+
+// setLocation records that repo is on the gitserver at addr until it has
+// been transferred to its owner. If addr is empty, requests for repo are sent
+// to its owner again.
+func (c *Client) setLocation(repo api.RepoName, addr string) {
+	repo = protocol.NormalizeRepo(repo)
+	c.locationsMu.Lock()
+	defer c.locationsMu.Unlock()
+	if addr == "" {
+		delete(c.locations, repo)
+		return
+	}
+	if c.locations == nil {
+		c.locations = map[api.RepoName]string{}
+	}
+	c.locations[repo] = addr
+}
+
+// locationOf returns the gitserver repo is being transferred from, or "".
+func (c *Client) locationOf(repo api.RepoName) string {
+	c.locationsMu.Lock()
+	defer c.locationsMu.Unlock()
+	return c.locations[protocol.NormalizeRepo(repo)]
 }
 
+// setUnreachable records whether the last request to addr failed.
+func (c *Client) setUnreachable(addr string, unreachable bool) {
+	c.unreachableMu.Lock()
+	defer c.unreachableMu.Unlock()
+	if !unreachable {
+		delete(c.unreachable, addr)
+		return
+	}
+	if c.unreachable == nil {
+		c.unreachable = map[string]time.Time{}
+	}
+	c.unreachable[addr] = time.Now()
+}
+
+// isUnreachable returns whether a request to addr failed recently.
+func (c *Client) isUnreachable(addr string) bool {
+	c.unreachableMu.Lock()
+	defer c.unreachableMu.Unlock()
+	at, ok := c.unreachable[addr]
+	return ok && time.Since(at) < unreachableTTL
+}
+
+// failoverMethods are the gitserver methods which only read a repository, so
+// can be sent to any of its replicas.
+var failoverMethods = map[string]bool{
+	"exec":           true,
+	"repo":           true,
+	"is-repo-cloned": true,
+}
+
+// maxMisdirectedRetries is the number of times a request for a repository is
+// retried when gitserver responds with http.StatusMisdirectedRequest.
+const maxMisdirectedRetries = 2
+
 func (c *Cmd) sendExec(ctx context.Context) (_ io.ReadCloser, _ http.Header, errRes error) {
 	repoName := protocol.NormalizeRepo(c.Repo.Name)
 
@@ -579,6 +672,27 @@ func (c *Client) Remove(ctx context.Context, repo api.RepoName) error {
 	return nil
 }
 
+// RenameRepo moves the repository clone on gitserver from one name to
+// another, after the repository was renamed or transferred on its code host.
+// It is not an error if there is no clone of the repository.
+func (c *Client) RenameRepo(ctx context.Context, from, to api.RepoName) error {
+	req := &protocol.RepoRenameRequest{
+		From: from,
+		To:   to,
+	}
+	resp, err := c.httpPost(ctx, from, "rename", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return &url.Error{URL: resp.Request.URL.String(), Op: "RenameRepo", Err: fmt.Errorf("RenameRepo: http status %d: %s", resp.StatusCode, string(body))}
+	}
+	return nil
+}
+
 func (c *Client) httpPost(ctx context.Context, repo api.RepoName, method string, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Client.httpPost")
 	defer func() {
@@ -594,7 +708,72 @@ func (c *Client) httpPost(ctx context.Context, repo api.RepoName, method string,
 		return nil, err
 	}
 
-	addr := c.addrForRepo(ctx, repo)
+	// While repositories are rebalanced between gitservers, the gitserver we
+	// send a request to may tell us that a different one has the repository.
+	// If a gitserver is unreachable, reads are retried on the other replicas
+	// of the repository.
+	// Requests are attempted at most once on each replica and on the
+	// gitserver the repository is being transferred from, plus the
+	// misdirected retries.
+	tried := map[string]bool{}
+	maxAttempts := conf.GitReplicationFactor() + 1 + maxMisdirectedRetries
+	var lastErr error
+	for attempt, misdirected := 0, 0; ; attempt++ {
+		location := c.locationOf(repo)
+		addr := c.addrForRepo(ctx, repo, tried)
+		if addr == "" || attempt == maxAttempts {
+			if lastErr == nil {
+				lastErr = fmt.Errorf("no reachable gitserver for repo %q after %d attempts", repo, attempt)
+			}
+			return nil, lastErr
+		}
+		resp, err := c.doPost(ctx, span, addr, method, reqBody)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			lastErr = err
+			tried[addr] = true
+			c.setUnreachable(addr, true)
+			if addr == location {
+				// The gitserver which had the repository may have been
+				// removed. Fallback to the owner.
+				c.setLocation(repo, "")
+				continue
+			}
+			if failoverMethods[method] {
+				if next := c.addrForRepo(ctx, repo, tried); next != "" {
+					span.LogKV("event", "failover", "addr", addr, "next", next, "err", err)
+					failoverCounter.WithLabelValues(method).Inc()
+					continue
+				}
+			}
+			return nil, err
+		}
+		c.setUnreachable(addr, false)
+		if resp.StatusCode != http.StatusMisdirectedRequest || misdirected == maxMisdirectedRetries {
+			return resp, nil
+		}
+		misdirected++
+		resp.Body.Close()
+		location = resp.Header.Get(protocol.LocationHeader)
+		span.LogKV("event", "misdirected", "addr", addr, "location", location)
+		c.setLocation(repo, location)
+	}
+}
+
+var failoverCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "gitserver",
+	Name:      "client_failover",
+	Help:      "Times that a request was retried on another replica because a gitserver was unreachable.",
+}, []string{"method"})
+
+func init() {
+	prometheus.MustRegister(failoverCounter)
+}
+
+func (c *Client) doPost(ctx context.Context, span opentracing.Span, addr, method string, reqBody []byte) (*http.Response, error) {
 	req, err := http.NewRequest("POST", "http://"+addr+"/"+method, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, err
@@ -621,7 +800,7 @@ func (c *Client) httpPost(ctx context.Context, repo api.RepoName, method string,
 
 func (c *Client) UploadPack(repoName api.RepoName, w http.ResponseWriter, r *http.Request) {
 	repoName = protocol.NormalizeRepo(repoName)
-	addr := c.addrForRepo(r.Context(), repoName)
+	addr := c.addrForRepo(r.Context(), repoName, nil)
 
 	u, err := url.Parse("http://" + addr + "/upload-pack?repo=" + url.QueryEscape(string(repoName)))
 	if err != nil {
@@ -633,6 +812,14 @@ func (c *Client) UploadPack(repoName api.RepoName, w http.ResponseWriter, r *htt
 		Director: func(r *http.Request) {
 			r.URL = u
 		},
+		// We can't retry a proxied request, but we can make sure the next
+		// request for the repository goes to the right gitserver.
+		ModifyResponse: func(resp *http.Response) error {
+			if resp.StatusCode == http.StatusMisdirectedRequest {
+				c.setLocation(repoName, resp.Header.Get(protocol.LocationHeader))
+			}
+			return nil
+		},
 		ErrorLog: uploadPackErrorLog,
 	}).ServeHTTP(w, r)
 }
@@ -654,6 +841,8 @@ func (c *Client) CreateCommitFromPatch(ctx context.Context, req protocol.CreateC
 	}
 
 	var res protocol.CreatePatchFromPatchResponse
-
-	return res.Rev, json.NewDecoder(resp.Body).Decode(&res)
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return "", err
+	}
+	return res.Rev, nil
 }
@@ -0,0 +1,51 @@
+package gitserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// unreachableTransport fails every request, as if no gitserver were up.
+type unreachableTransport struct {
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (t *unreachableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hosts = append(t.hosts, req.URL.Host)
+	return nil, errors.New("connection refused")
+}
+
+func TestClient_httpPost_allReplicasUnreachable(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{GitReplicationFactor: 2}})
+	defer conf.Mock(nil)
+
+	for _, method := range []string{"exec", "delete"} {
+		for _, location := range []string{"", "gitserver-3"} {
+			transport := &unreachableTransport{}
+			c := &Client{
+				HTTPClient: &http.Client{Transport: transport},
+				Addrs: func(ctx context.Context) []string {
+					return []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+				},
+			}
+			c.setLocation("github.com/foo/bar", location)
+
+			_, err := c.httpPost(context.Background(), "github.com/foo/bar", method, struct{}{})
+			if err == nil {
+				t.Fatalf("%s (location %q): expected an error", method, location)
+			}
+			if max := conf.GitReplicationFactor() + 1 + maxMisdirectedRetries; len(transport.hosts) > max {
+				t.Errorf("%s (location %q): got %d attempts %v, want at most %d", method, location, len(transport.hosts), transport.hosts, max)
+			}
+		}
+	}
+}
@@ -44,6 +44,10 @@ type RepoUpdateRequest struct {
 	Repo  api.RepoName  `json:"repo"`  // identifying URL for repo
 	URL   string        `json:"url"`   // repo's remote URL
 	Since time.Duration `json:"since"` // debounce interval for queries, used only with request-repo-update
+
+	// Replica is set when the owner of Repo asks one of its other replicas
+	// to update (or clone) its copy.
+	Replica bool `json:"replica,omitempty"`
 }
 
 // RepoUpdateResponse returns meta information of the repo enqueued for
@@ -53,6 +57,7 @@ type RepoUpdateRequest struct {
 type RepoUpdateResponse struct {
 	Cloned          bool
 	CloneInProgress bool
+	Evicted         bool // the repo was evicted to free up disk space, and is only cloned again when it is used
 	LastFetched     *time.Time
 	LastChanged     *time.Time
 	Error           string // an error reported by the update, as opposed to a protocol error
@@ -90,6 +95,13 @@ type IsRepoCloneableResponse struct {
 type IsRepoClonedRequest struct {
 	// Repo is the repository to check.
 	Repo api.RepoName
+
+	// Local if true only reports whether this gitserver has the repository.
+	// Otherwise a gitserver which owns the repository but does not have it
+	// yet may redirect the request to the gitserver which does (see
+	// LocationHeader). Gitservers set Local when looking for a repository
+	// among their peers.
+	Local bool `json:",omitempty"`
 }
 
 // RepoInfoRequest is a request for information about a repository on gitserver.
@@ -104,6 +116,20 @@ type RepoDeleteRequest struct {
 	Repo api.RepoName
 }
 
+// RepoRenameRequest is a request to move a repository clone on gitserver to
+// the directory of its new name, after the repository was renamed or
+// transferred on its code host.
+type RepoRenameRequest struct {
+	// From is the old name of the repository.
+	From api.RepoName
+	// To is the new name of the repository.
+	To api.RepoName
+
+	// Replica is set when the owner of From forwards the request to the other
+	// gitservers which keep a copy of it (see conf.GitReplicationFactor).
+	Replica bool `json:",omitempty"`
+}
+
 // RepoInfoResponse is the response to a repository information request (RepoInfoRequest).
 type RepoInfoResponse struct {
 	URL             string     // this repository's Git remote URL
@@ -117,6 +143,12 @@ type RepoInfoResponse struct {
 	// recloned automatically, so this time is likely to move forward
 	// periodically.
 	CloneTime *time.Time
+
+	// TransferInProgress is whether the repository is being moved to or
+	// from this gitserver, because the list of gitservers changed.
+	TransferInProgress bool
+	// TransferProgress is a progress message for the transfer.
+	TransferProgress string
 }
 
 // CreateCommitFromPatchRequest is the request information needed for creating
@@ -130,6 +162,9 @@ type CreateCommitFromPatchRequest struct {
 	Patch string
 	// TargetRef is the ref that will be created for this patch
 	TargetRef string
+	// NoOverwrite, if true, makes the request fail if TargetRef already
+	// exists instead of overwriting it.
+	NoOverwrite bool
 	// CommitInfo is the information that will be used when creating the commit from a patch
 	CommitInfo PatchCommitInfo
 }
@@ -0,0 +1,130 @@
+package protocol
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"sort"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+// LocationHeader is set on responses with the status
+// http.StatusMisdirectedRequest, which a gitserver sends for requests for a
+// repository it does not have. If the gitserver owns the repository (see
+// AddrForRepo), LocationHeader is the address of the gitserver which still
+// has it, and clients should send requests for the repository there until it
+// has been transferred. Otherwise LocationHeader is empty, and clients should
+// send requests to the owner.
+const LocationHeader = "X-Gitserver-Location"
+
+// Sharding is an algorithm for assigning repositories to gitservers.
+type Sharding int
+
+const (
+	// HashMod assigns a key to addrs[md5(key) mod len(addrs)]. Adding or
+	// removing an address moves most keys to a different address.
+	HashMod Sharding = iota
+
+	// Rendezvous uses rendezvous (highest random weight) hashing, which is a
+	// form of consistent hashing: adding an address to addrs only moves the
+	// keys which the new address owns, and removing an address only moves
+	// the keys it owned. The order of addrs does not matter.
+	//
+	// Switching from HashMod to Rendezvous moves most keys, like adding an
+	// address with HashMod does.
+	Rendezvous
+)
+
+// AddrForRepo returns the address of the gitserver in addrs which owns repo.
+// Clients send requests for repo to this gitserver, and gitservers transfer
+// repositories they hold but do not own to their owner (see the gitserver
+// rebalancer).
+func (s Sharding) AddrForRepo(repo api.RepoName, addrs []string) string {
+	return s.AddrForKey(string(NormalizeRepo(repo)), addrs)
+}
+
+// AddrsForRepo returns the addresses of the n gitservers in addrs which keep
+// a copy of repo (its replicas), in order of preference. The first is the
+// owner of repo (see AddrForRepo). If n is larger than len(addrs), every
+// address is returned.
+func (s Sharding) AddrsForRepo(repo api.RepoName, addrs []string, n int) []string {
+	return s.AddrsForKey(string(NormalizeRepo(repo)), addrs, n)
+}
+
+// AddrsForKey returns the n addresses in addrs which keep a copy of key,
+// starting with its owner (see AddrForKey). With HashMod, the replicas are
+// the addresses following the owner in addrs. With Rendezvous, they are the
+// addresses with the highest scores for key.
+func (s Sharding) AddrsForKey(key string, addrs []string, n int) []string {
+	if n > len(addrs) {
+		n = len(addrs)
+	}
+	if n <= 1 {
+		if addr := s.AddrForKey(key, addrs); addr != "" {
+			return []string{addr}
+		}
+		return nil
+	}
+
+	if s == HashMod {
+		i := hashModIndex(key, len(addrs))
+		replicas := make([]string, n)
+		for j := range replicas {
+			replicas[j] = addrs[(i+j)%len(addrs)]
+		}
+		return replicas
+	}
+
+	scores := make(map[string]uint64, len(addrs))
+	sorted := make([]string, len(addrs))
+	copy(sorted, addrs)
+	for _, addr := range sorted {
+		scores[addr] = rendezvousScore(addr, key)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if scores[a] != scores[b] {
+			return scores[a] > scores[b]
+		}
+		return a < b
+	})
+	return sorted[:n]
+}
+
+// AddrForKey returns the address in addrs which owns key.
+//
+// It returns "" if addrs is empty.
+func (s Sharding) AddrForKey(key string, addrs []string) string {
+	if len(addrs) == 0 {
+		return ""
+	}
+	if s == HashMod {
+		return addrs[hashModIndex(key, len(addrs))]
+	}
+
+	var (
+		best      string
+		bestScore uint64
+	)
+	for _, addr := range addrs {
+		score := rendezvousScore(addr, key)
+		if best == "" || score > bestScore || (score == bestScore && addr < best) {
+			best, bestScore = addr, score
+		}
+	}
+	return best
+}
+
+func hashModIndex(key string, n int) int {
+	sum := md5.Sum([]byte(key))
+	return int(binary.BigEndian.Uint64(sum[:]) % uint64(n))
+}
+
+func rendezvousScore(addr, key string) uint64 {
+	h := md5.New()
+	h.Write([]byte(addr))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	var sum [md5.Size]byte
+	return binary.BigEndian.Uint64(h.Sum(sum[:0]))
+}
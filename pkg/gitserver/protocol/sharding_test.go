@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func TestRendezvous_AddrForKey(t *testing.T) {
+	addrs := []string{"gitserver-0:3178", "gitserver-1:3178", "gitserver-2:3178"}
+	reversed := []string{addrs[2], addrs[1], addrs[0]}
+	grown := append(append([]string{}, addrs...), "gitserver-3:3178")
+
+	if got := Rendezvous.AddrForKey("foo", nil); got != "" {
+		t.Errorf("no addrs: got %q, want empty", got)
+	}
+
+	counts := map[string]int{}
+	moved := 0
+	const n = 3000
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("example.com/repo%d", i)
+		addr := Rendezvous.AddrForKey(key, addrs)
+		counts[addr]++
+
+		if got := Rendezvous.AddrForKey(key, reversed); got != addr {
+			t.Fatalf("%s: order of addrs changed owner from %q to %q", key, addr, got)
+		}
+
+		// Adding an address only moves keys to the new address.
+		if got := Rendezvous.AddrForKey(key, grown); got != addr {
+			if got != "gitserver-3:3178" {
+				t.Fatalf("%s: adding an address moved it from %q to %q", key, addr, got)
+			}
+			moved++
+		}
+	}
+
+	for _, addr := range addrs {
+		if c := counts[addr]; c < n/3*8/10 {
+			t.Errorf("%s owns %d of %d keys, want roughly %d", addr, c, n, n/3)
+		}
+	}
+	if moved < n/4*8/10 || moved > n/4*12/10 {
+		t.Errorf("adding an address moved %d of %d keys, want roughly %d", moved, n, n/4)
+	}
+}
+
+func TestRendezvous_AddrsForKey(t *testing.T) {
+	addrs := []string{"gitserver-0:3178", "gitserver-1:3178", "gitserver-2:3178", "gitserver-3:3178"}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("example.com/repo%d", i)
+		replicas := Rendezvous.AddrsForKey(key, addrs, 3)
+		if len(replicas) != 3 {
+			t.Fatalf("%s: got %d replicas, want 3", key, len(replicas))
+		}
+		if replicas[0] != Rendezvous.AddrForKey(key, addrs) {
+			t.Fatalf("%s: first replica %q is not the owner %q", key, replicas[0], Rendezvous.AddrForKey(key, addrs))
+		}
+		seen := map[string]bool{}
+		for _, addr := range replicas {
+			if seen[addr] {
+				t.Fatalf("%s: duplicate replica %q in %v", key, addr, replicas)
+			}
+			seen[addr] = true
+		}
+
+		// Removing a replica promotes the next one.
+		var remaining []string
+		for _, addr := range addrs {
+			if addr != replicas[0] {
+				remaining = append(remaining, addr)
+			}
+		}
+		if got := Rendezvous.AddrForKey(key, remaining); got != replicas[1] {
+			t.Fatalf("%s: after removing owner got %q, want %q", key, got, replicas[1])
+		}
+	}
+
+	if got := Rendezvous.AddrsForKey("foo", addrs[:2], 3); len(got) != 2 {
+		t.Errorf("more replicas than addrs: got %v, want 2 addrs", got)
+	}
+	if got := Rendezvous.AddrsForKey("foo", nil, 3); len(got) != 0 {
+		t.Errorf("no addrs: got %v, want none", got)
+	}
+}
+
+func TestHashMod(t *testing.T) {
+	addrs := []string{"gitserver-0:3178", "gitserver-1:3178", "gitserver-2:3178"}
+	if got := HashMod.AddrForKey("foo", nil); got != "" {
+		t.Errorf("no addrs: got %q, want empty", got)
+	}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("example.com/repo%d", i)
+
+		// Keys are assigned like before other algorithms were supported, so
+		// that upgrading doesn't move repositories.
+		sum := md5.Sum([]byte(key))
+		want := addrs[binary.BigEndian.Uint64(sum[:])%uint64(len(addrs))]
+		if got := HashMod.AddrForKey(key, addrs); got != want {
+			t.Fatalf("%s: got %q, want %q", key, got, want)
+		}
+
+		replicas := HashMod.AddrsForKey(key, addrs, 2)
+		if len(replicas) != 2 || replicas[0] != want || replicas[1] == want {
+			t.Fatalf("%s: got replicas %v, want 2 starting with %q", key, replicas, want)
+		}
+	}
+}
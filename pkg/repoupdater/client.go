@@ -160,23 +160,53 @@ func (c *Client) EnqueueRepoUpdate(ctx context.Context, repo gitserver.Repo) err
 }
 
 // SyncExternalService requests the given external service to be synced.
-func (c *Client) SyncExternalService(ctx context.Context, svc api.ExternalService) error {
+func (c *Client) SyncExternalService(ctx context.Context, svc api.ExternalService) (*protocol.ExternalServiceSyncResult, error) {
 	req := &protocol.ExternalServiceSyncRequest{ExternalService: svc}
 	resp, err := c.httpPost(ctx, "sync-external-service", req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		bs, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return err
+		return nil, errors.New(string(bs))
+	}
+
+	var result protocol.ExternalServiceSyncResult
+	if len(bs) > 0 {
+		if err := json.Unmarshal(bs, &result); err != nil {
+			return nil, err
 		}
-		return errors.New(string(bs))
 	}
+	return &result, nil
+}
 
-	return nil
+// DryRunExternalService lists the repositories of a proposed configuration of an external service
+// and compares them with those of its current configuration (if any), without syncing anything.
+func (c *Client) DryRunExternalService(ctx context.Context, req protocol.ExternalServiceDryRunRequest) (*protocol.ExternalServiceDryRunResult, error) {
+	resp, err := c.httpPost(ctx, "dry-run-external-service", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bs, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return nil, errors.New(string(bs))
+	}
+
+	var result protocol.ExternalServiceDryRunResult
+	if err := json.Unmarshal(bs, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
 func (c *Client) httpPost(ctx context.Context, method string, payload interface{}) (resp *http.Response, err error) {
@@ -129,3 +129,39 @@ type RepoUpdateRequest struct {
 type ExternalServiceSyncRequest struct {
 	ExternalService api.ExternalService
 }
+
+// ExternalServiceSyncResult is the result of an ExternalServiceSyncRequest.
+type ExternalServiceSyncResult struct {
+	// Status is the outcome of the sync, as recorded for the external service. It is nil if the
+	// external service's kind is not synced eagerly.
+	Status *api.ExternalServiceSyncStatus `json:",omitempty"`
+}
+
+// ExternalServiceDryRunRequest is a request to list the repositories of a proposed configuration of
+// an external service and compare them with those of its current configuration, without syncing
+// anything.
+type ExternalServiceDryRunRequest struct {
+	// Current is the external service as it is currently configured, or nil if it does not exist yet.
+	Current *api.ExternalService `json:",omitempty"`
+
+	// Proposed is the external service with the proposed configuration.
+	Proposed api.ExternalService
+}
+
+// ExternalServiceDryRunResult is the result of an ExternalServiceDryRunRequest.
+type ExternalServiceDryRunResult struct {
+	Added     []api.RepoName // repositories that would be added
+	Removed   []api.RepoName // repositories that would no longer be synced
+	Renamed   []RepoRename   // repositories that would be synced under a different name
+	Unchanged int            // number of repositories that would be synced under the same name
+
+	// Errors are the errors encountered listing the repositories. If there are any, the lists above
+	// may be incomplete.
+	Errors []string
+}
+
+// RepoRename describes a repository that would be renamed.
+type RepoRename struct {
+	From api.RepoName
+	To   api.RepoName
+}
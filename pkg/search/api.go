@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sourcegraph/sourcegraph/pkg/api"
@@ -19,6 +20,10 @@ type FileMatch struct {
 	// LineMatches contains the lines that match. If empty then we matched the
 	// Path only.
 	LineMatches []LineMatch
+
+	// MatchRanges contains the range of each match in the file. Unlike a
+	// LineMatch, a range may span multiple lines.
+	MatchRanges []Range
 }
 
 // IsPathMatch returns true if the FileMatch is a match for Path.
@@ -44,6 +49,25 @@ type LineFragmentMatch struct {
 	MatchLength int
 }
 
+// Range is a range of text in a file. Start is inclusive and End is
+// exclusive.
+type Range struct {
+	Start, End Location
+}
+
+// Location is a position in a file.
+type Location struct {
+	// Offset from the start of the file, in bytes.
+	Offset int
+
+	// Line is the 0-based line number. Note: LineMatch.LineNumber is
+	// 1-based.
+	Line int
+
+	// Column is the offset within the line, in characters (not bytes).
+	Column int
+}
+
 // Repository is a repository at a commit.
 type Repository struct {
 	Name       api.RepoName
@@ -147,6 +171,17 @@ func (s *Stats) Add(o *Stats) {
 	s.Unavailable = append(s.Unavailable, o.Unavailable...)
 }
 
+// StatusCounts returns the number of repositories in Status for each
+// RepositoryStatusType. A repository is counted once per Source it was
+// searched with.
+func (s *Stats) StatusCounts() map[RepositoryStatusType]int {
+	counts := make(map[RepositoryStatusType]int)
+	for _, st := range s.Status {
+		counts[st.Status]++
+	}
+	return counts
+}
+
 // Result contains search matches and extra data
 type Result struct {
 	Stats
@@ -170,6 +205,70 @@ type Searcher interface {
 	String() string
 }
 
+// Sender receives partial results from a StreamSearcher. Send may be called
+// concurrently, and the Result passed to it must not be modified after it is
+// sent.
+type Sender interface {
+	Send(*Result)
+}
+
+// SenderFunc is an adapter to allow the use of ordinary functions as a
+// Sender.
+type SenderFunc func(*Result)
+
+// Send calls f(r).
+func (f SenderFunc) Send(r *Result) {
+	f(r)
+}
+
+// StreamSearcher is a Searcher which can send results as they are found
+// instead of only returning once every repository has been searched.
+type StreamSearcher interface {
+	Searcher
+
+	// StreamSearch is like Search, but results are sent to s as they are
+	// found. The Stats sent to s sum up to the Stats Search would return.
+	StreamSearch(ctx context.Context, q query.Q, opts *Options, s Sender) error
+}
+
+// Collector is a Sender which buffers every Result sent to it. It is useful
+// to implement Search in terms of StreamSearch.
+type Collector struct {
+	mu     sync.Mutex
+	result Result
+}
+
+// Send implements Sender.
+func (c *Collector) Send(r *Result) {
+	c.mu.Lock()
+	c.result.Add(r)
+	c.mu.Unlock()
+}
+
+// Result returns the combination of every Result sent so far.
+func (c *Collector) Result() *Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := c.result
+	return &r
+}
+
+// StreamSearch calls s.StreamSearch if s is a StreamSearcher. Otherwise it
+// falls back to s.Search and sends the result as a single Result.
+func StreamSearch(ctx context.Context, s Searcher, q query.Q, opts *Options, sender Sender) error {
+	if ss, ok := s.(StreamSearcher); ok {
+		return ss.StreamSearch(ctx, q, opts, sender)
+	}
+	r, err := s.Search(ctx, q, opts)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		sender.Send(r)
+	}
+	return nil
+}
+
 // Options for Search.
 type Options struct {
 	// Repositories limits search to the named repositories.
@@ -38,45 +38,43 @@ type shard struct {
 	*search.Options
 }
 
-type searchResponse struct {
-	*search.Result
-	error
+func shardedSearch(ctx context.Context, shards <-chan shard) (*search.Result, error) {
+	var c search.Collector
+	if err := shardedStreamSearch(ctx, shards, &c); err != nil {
+		return nil, err
+	}
+	return c.Result(), nil
 }
 
-func shardedSearch(ctx context.Context, shards <-chan shard) (*search.Result, error) {
+// shardedStreamSearch searches every shard concurrently, sending results to
+// sender as each shard produces them. If any shard fails the remaining
+// shards are canceled and the first error is returned.
+func shardedStreamSearch(ctx context.Context, shards <-chan shard, sender search.Sender) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	var wg sync.WaitGroup
-	resC := make(chan searchResponse)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
 	for shard := range shards {
 		shard := shard
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			r, err := shard.Searcher.Search(ctx, shard.Q, shard.Options)
-			resC <- searchResponse{r, err}
-		}()
-	}
-
-	go func() {
-		wg.Wait()
-		close(resC)
-	}()
-
-	all := search.Result{}
-	for r := range resC {
-		if r.error != nil {
-			// Drain resC
-			cancel()
-			for range resC {
+			err := search.StreamSearch(ctx, shard.Searcher, shard.Q, shard.Options, sender)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
 			}
-			return nil, r.error
-		}
-		all.Add(r.Result)
+		}()
 	}
+	wg.Wait()
 
-	return &all, nil
+	return firstErr
 }
 
 func handleError(source search.Source, r search.Repository, err error) (*search.RepositoryStatus, error) {
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/zoekt"
 	zoektquery "github.com/google/zoekt/query"
@@ -36,11 +37,39 @@ type Text struct {
 	// search. This should be TextJIT, but is an interface for testing
 	// purposes.
 	Fallback search.Searcher
+
+	// Ranker if non-nil orders the results of Search, so that the most
+	// relevant results survive trimming to MaxDocDisplayCount. Otherwise
+	// results are in the order they were found.
+	Ranker search.Ranker
+}
+
+// Search searches the indexed and fallback searchers, ranks the combined
+// results with t.Ranker and then trims them to opts.MaxDocDisplayCount.
+func (t *Text) Search(ctx context.Context, q query.Q, opts *search.Options) (*search.Result, error) {
+	var c search.Collector
+	if err := t.StreamSearch(ctx, q, opts, &c); err != nil {
+		return nil, err
+	}
+	r := c.Result()
+
+	if t.Ranker != nil {
+		if err := t.Ranker.Rank(ctx, r.Files); err != nil {
+			// Ranking is best effort, so don't fail the search.
+			log15.Warn("Text.Search: failed to rank results", "query", q.String(), "error", err)
+		}
+	}
+	if opts.MaxDocDisplayCount > 0 && len(r.Files) > opts.MaxDocDisplayCount {
+		r.Files = r.Files[:opts.MaxDocDisplayCount]
+	}
+	return r, nil
 }
 
-func (t *Text) Search(ctx context.Context, q query.Q, opts *search.Options) (res *search.Result, err error) {
+// StreamSearch is like Search, but sends results from the indexed and
+// fallback searchers as they arrive.
+func (t *Text) StreamSearch(ctx context.Context, q query.Q, opts *search.Options, sender search.Sender) (err error) {
 	if len(opts.Repositories) == 0 {
-		return nil, errors.Errorf("repository list empty for text search on %s", q.String())
+		return errors.Errorf("repository list empty for text search on %s", q.String())
 	}
 
 	tr, ctx := trace.New(ctx, "Text.Search", fmt.Sprintf("query: %v, numRepoRevs: %d", q, len(opts.Repositories)))
@@ -52,7 +81,6 @@ func (t *Text) Search(ctx context.Context, q query.Q, opts *search.Options) (res
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	isIndexAvailable := true
 	shards := make(chan shard)
 	go func() {
 		defer close(shards)
@@ -61,8 +89,9 @@ func (t *Text) Search(ctx context.Context, q query.Q, opts *search.Options) (res
 		if err != nil {
 			// Don't hard fail if index is not available yet.
 			tr.LogFields(otlog.String("indexErr", err.Error()))
-			isIndexAvailable = false
-			err = nil
+			sender.Send(&search.Result{
+				Stats: search.Stats{Unavailable: []search.Source{SourceZoekt}},
+			})
 			index = nil
 			fallback = opts.Repositories
 		}
@@ -80,11 +109,7 @@ func (t *Text) Search(ctx context.Context, q query.Q, opts *search.Options) (res
 		}
 	}()
 
-	res, err = shardedSearch(ctx, shards)
-	if res != nil && !isIndexAvailable {
-		res.Stats.Unavailable = append(res.Stats.Unavailable, SourceZoekt)
-	}
-	return res, err
+	return shardedStreamSearch(ctx, shards, sender)
 }
 
 func (t *Text) Close() {}
@@ -111,9 +136,20 @@ type TextJIT struct {
 // Search distributes the search across the searcher replicas, and merges the
 // results. opts.Repositories is required to be non-empty.
 func (t *TextJIT) Search(ctx context.Context, q query.Q, opts *search.Options) (*search.Result, error) {
+	var c search.Collector
+	if err := t.StreamSearch(ctx, q, opts, &c); err != nil {
+		return nil, err
+	}
+	return c.Result(), nil
+}
+
+// StreamSearch distributes the search across the searcher replicas, sending
+// the result for each repository as soon as it has been searched.
+// opts.Repositories is required to be non-empty.
+func (t *TextJIT) StreamSearch(ctx context.Context, q query.Q, opts *search.Options, sender search.Sender) error {
 	repos, err := expandRepoRefs(q, opts.Repositories)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	var cancel context.CancelFunc
@@ -126,86 +162,79 @@ func (t *TextJIT) Search(ctx context.Context, q query.Q, opts *search.Options) (
 
 	sem, err := t.semaphore()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	resC := make(chan searchResponse)
-	go func() {
-		defer close(resC)
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	setErr := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
 
-		var wg sync.WaitGroup
-		defer wg.Wait()
+	origOpts := opts
+	for _, r := range repos {
+		if err := sem.Acquire(ctx); err != nil {
+			break
+		}
 
-		origOpts := opts
-		for _, r := range repos {
-			if err := sem.Acquire(ctx); err != nil {
+		wg.Add(1)
+		go func(r search.Repository) {
+			defer sem.Release()
+			defer wg.Done()
+
+			commit, err := t.Resolve(ctx, r.Name, r.RefPattern)
+			if err != nil {
+				if s, err := handleError(SourceSearcher, r, err); err != nil {
+					setErr(err)
+				} else {
+					var result search.Result
+					result.Stats.Status = append(result.Stats.Status, *s)
+					sender.Send(&result)
+				}
 				return
 			}
+			r.Commit = commit
+			opts := *origOpts
+			opts.Repositories = []api.RepoName{r.Name}
 
-			wg.Add(1)
-			go func(r search.Repository) {
-				defer sem.Release()
-				defer wg.Done()
-
-				commit, err := t.Resolve(ctx, r.Name, r.RefPattern)
-				if err != nil {
-					if s, err := handleError(SourceSearcher, r, err); err != nil {
-						resC <- searchResponse{error: err}
-					} else {
-						var result search.Result
-						result.Stats.Status = append(result.Stats.Status, *s)
-						resC <- searchResponse{Result: &result}
-					}
-					return
-				}
-				r.Commit = commit
-				opts := *origOpts
-				opts.Repositories = []api.RepoName{r.Name}
-
-				qSearcher, err := expandForRepoAtCommit(q, r)
-				if err != nil {
-					resC <- searchResponse{error: err}
-					return
-				}
-
-				client, err := t.client(r)
-				if err != nil {
-					resC <- searchResponse{error: err}
-					return
-				}
-
-				result, err := client.Search(ctx, qSearcher, &opts)
-				if err != nil {
-					resC <- searchResponse{error: err}
-					return
-				}
+			qSearcher, err := expandForRepoAtCommit(q, r)
+			if err != nil {
+				setErr(err)
+				return
+			}
 
-				// Searcher doesn't know the repo.RefPattern used, so we set it.
-				for i := range result.Stats.Status {
-					result.Stats.Status[i].Repository = r
-				}
-				for i := range result.Files {
-					result.Files[i].Repository = r
-				}
+			client, err := t.client(r)
+			if err != nil {
+				setErr(err)
+				return
+			}
 
-				resC <- searchResponse{Result: result}
-			}(r)
-		}
-	}()
+			result, err := client.Search(ctx, qSearcher, &opts)
+			if err != nil {
+				setErr(err)
+				return
+			}
 
-	all := &search.Result{}
-	for r := range resC {
-		if r.error != nil {
-			// Drain resC
-			cancel()
-			for range resC {
+			// Searcher doesn't know the repo.RefPattern used, so we set it.
+			for i := range result.Stats.Status {
+				result.Stats.Status[i].Repository = r
 			}
-			return nil, r.error
-		}
-		all.Add(r.Result)
+			for i := range result.Files {
+				result.Files[i].Repository = r
+			}
+
+			sender.Send(result)
+		}(r)
 	}
+	wg.Wait()
 
-	return all, nil
+	return firstErr
 }
 
 // semaphore returns a semaphore for limiting search concurrency.
@@ -609,6 +638,7 @@ func mapZoektFileMatch(zf []zoekt.FileMatch) []search.FileMatch {
 	files := make([]search.FileMatch, len(zf))
 	for i, fm := range zf {
 		lines := make([]search.LineMatch, 0, len(fm.LineMatches))
+		var ranges []search.Range
 		for _, lm := range fm.LineMatches {
 			if lm.FileName {
 				continue
@@ -620,6 +650,21 @@ func mapZoektFileMatch(zf []zoekt.FileMatch) []search.FileMatch {
 					LineOffset:  f.LineOffset,
 					MatchLength: f.MatchLength,
 				}
+
+				// Zoekt matches never span lines.
+				column := utf8.RuneCount(lm.Line[:f.LineOffset])
+				ranges = append(ranges, search.Range{
+					Start: search.Location{
+						Offset: int(f.Offset),
+						Line:   lm.LineNumber - 1,
+						Column: column,
+					},
+					End: search.Location{
+						Offset: int(f.Offset) + f.MatchLength,
+						Line:   lm.LineNumber - 1,
+						Column: column + utf8.RuneCount(lm.Line[f.LineOffset:f.LineOffset+f.MatchLength]),
+					},
+				})
 			}
 
 			lines = append(lines, search.LineMatch{
@@ -633,6 +678,7 @@ func mapZoektFileMatch(zf []zoekt.FileMatch) []search.FileMatch {
 			Path:        fm.FileName,
 			Repository:  search.Repository{Name: api.RepoName(fm.Repository)}, // Branch? Safe to case RepoName?
 			LineMatches: lines,
+			MatchRanges: ranges,
 		}
 	}
 	return files
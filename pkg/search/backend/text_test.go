@@ -255,6 +255,63 @@ func TestText_error(t *testing.T) {
 	expectError("foo3")
 }
 
+func TestText_stream(t *testing.T) {
+	mz := &mockZoekt{
+		SearchResult: &zoekt.SearchResult{},
+		ListResult:   zoektRepoList("a"),
+	}
+	index := &backend.Zoekt{
+		Client:       mz,
+		DisableCache: true,
+	}
+	fallback := &backend.Mock{Result: &search.Result{
+		Stats: search.Stats{
+			MatchCount: 1,
+			Status: []search.RepositoryStatus{{
+				Repository: search.Repository{Name: "b"},
+				Source:     backend.SourceSearcher,
+				Status:     search.RepositoryStatusCloning,
+			}},
+		},
+	}}
+	s := &backend.Text{
+		Index:    index,
+		Fallback: fallback,
+	}
+	defer s.Close()
+
+	var (
+		mu   sync.Mutex
+		sent []*search.Result
+	)
+	err := s.StreamSearch(context.Background(), &query.Const{Value: true}, &search.Options{Repositories: repoList("a b")}, search.SenderFunc(func(r *search.Result) {
+		mu.Lock()
+		sent = append(sent, r)
+		mu.Unlock()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One result from the index, one from the fallback.
+	if len(sent) != 2 {
+		t.Fatalf("expected 2 results to be sent, got %d", len(sent))
+	}
+
+	var all search.Result
+	for _, r := range sent {
+		all.Add(r)
+	}
+	got := all.Stats.StatusCounts()
+	want := map[search.RepositoryStatusType]int{
+		search.RepositoryStatusSearched: 1,
+		search.RepositoryStatusCloning:  1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected status counts\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
 func TestZoekt(t *testing.T) {
 	parse := func(s string) query.Q {
 		q, err := query.Parse(s)
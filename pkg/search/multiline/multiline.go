@@ -0,0 +1,58 @@
+// Package multiline decides whether a search pattern's matches may span
+// lines.
+package multiline
+
+import "regexp/syntax"
+
+// MatchesNewline returns whether the regexp expr explicitly matches a
+// newline, in which case its matches may span lines and it must be matched
+// against whole files. That is the case if expr contains a literal newline
+// (such as `foo\nbar` or `[\r\n]`), or matches any character including a
+// newline (such as `(?s)foo.*bar` or `[\s\S]`).
+//
+// Character classes that only incidentally contain a newline, such as `\s`
+// and `[^a]`, are not considered to match one. Patterns like `foo\s+bar` are
+// almost always meant to match within a line, and treating them as multiline
+// would prevent them from using the index.
+func MatchesNewline(expr string) (bool, error) {
+	re, err := syntax.Parse(expr, syntax.Perl&^syntax.ClassNL)
+	if err != nil {
+		return false, err
+	}
+	return regexpMatchesNewline(re), nil
+}
+
+func regexpMatchesNewline(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpAnyChar:
+		return true
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if r == '\n' {
+				return true
+			}
+		}
+	case syntax.OpCharClass:
+		// A class that contains a newline but not a space is an explicit
+		// newline class like [\r\n], rather than a whitespace class like \s.
+		if classContains(re.Rune, '\n') && !classContains(re.Rune, ' ') {
+			return true
+		}
+	}
+	for _, sub := range re.Sub {
+		if regexpMatchesNewline(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// classContains reports whether the character class ranges contain r.
+func classContains(ranges []rune, r rune) bool {
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i] <= r && r <= ranges[i+1] {
+			return true
+		}
+	}
+	return false
+}
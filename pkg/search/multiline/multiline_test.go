@@ -0,0 +1,29 @@
+package multiline
+
+import "testing"
+
+func TestMatchesNewline(t *testing.T) {
+	cases := map[string]bool{
+		"foo":         false,
+		"foo.bar":     false,
+		"[^a]":        false,
+		`\S+`:         false,
+		`foo\s+bar`:   false,
+		`foo\nbar`:    true,
+		`foo\x0abar`:  true,
+		`(?s)foo.bar`: true,
+		`[\s\S]`:      true, // any character, like (?s).
+		`[\n]`:        true,
+		`[\r\n]+`:     true,
+		`(a|b\n)+`:    true,
+	}
+	for expr, want := range cases {
+		got, err := MatchesNewline(expr)
+		if err != nil {
+			t.Fatal(expr, err)
+		}
+		if got != want {
+			t.Errorf("MatchesNewline(%q) == %t != %t", expr, got, want)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package search
+
+import (
+	"context"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/inventory/filelang"
+)
+
+// Ranker orders file matches so that the most relevant come first. Searchers
+// which combine results from several sources (eg backend.Text) rank the
+// combined results before trimming them to Options.MaxDocDisplayCount.
+type Ranker interface {
+	// Rank sorts files in place. If it returns an error, files are still
+	// sorted, but may not take every signal into account.
+	Rank(ctx context.Context, files []FileMatch) error
+}
+
+// Signal is a measure of how relevant file matches are.
+type Signal interface {
+	// Scores returns the score of each file in files, in the range [0, 1].
+	// Higher scores are more relevant.
+	Scores(ctx context.Context, files []FileMatch) ([]float64, error)
+}
+
+// SignalFunc is an adapter to allow the use of ordinary functions which
+// score a single FileMatch as a Signal.
+type SignalFunc func(fm *FileMatch) float64
+
+// Scores calls f for each file.
+func (f SignalFunc) Scores(ctx context.Context, files []FileMatch) ([]float64, error) {
+	scores := make([]float64, len(files))
+	for i := range files {
+		scores[i] = f(&files[i])
+	}
+	return scores, nil
+}
+
+// WeightedSignal is a Signal and how much it contributes to the score of a
+// FileMatch.
+type WeightedSignal struct {
+	Name   string
+	Weight float64
+	Signal Signal
+}
+
+// ScoreRanker is a Ranker which orders files by the weighted sum of the
+// scores from Signals. Files with the same score are ordered by repository
+// name and path.
+type ScoreRanker struct {
+	Signals []WeightedSignal
+}
+
+// Rank implements Ranker. A signal which fails does not contribute to the
+// score of any file, and the first such error is returned.
+func (r *ScoreRanker) Rank(ctx context.Context, files []FileMatch) error {
+	var firstErr error
+	total := make([]float64, len(files))
+	for _, s := range r.Signals {
+		scores, err := s.Signal.Scores(ctx, files)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for i, score := range scores {
+			total[i] += s.Weight * score
+		}
+	}
+
+	sort.Sort(&byScore{files: files, scores: total})
+	return firstErr
+}
+
+type byScore struct {
+	files  []FileMatch
+	scores []float64
+}
+
+func (s *byScore) Len() int { return len(s.files) }
+
+func (s *byScore) Less(i, j int) bool {
+	if s.scores[i] != s.scores[j] {
+		return s.scores[i] > s.scores[j]
+	}
+	a, b := &s.files[i], &s.files[j]
+	if a.Repository.Name != b.Repository.Name {
+		return a.Repository.Name < b.Repository.Name
+	}
+	return a.Path < b.Path
+}
+
+func (s *byScore) Swap(i, j int) {
+	s.files[i], s.files[j] = s.files[j], s.files[i]
+	s.scores[i], s.scores[j] = s.scores[j], s.scores[i]
+}
+
+// PathDepthSignal prefers files closer to the root of their repository.
+var PathDepthSignal = SignalFunc(func(fm *FileMatch) float64 {
+	return 1 / float64(1+strings.Count(fm.Path, "/"))
+})
+
+// VendoredSignal prefers files which are not vendored (see
+// filelang.IsVendored).
+var VendoredSignal = SignalFunc(func(fm *FileMatch) float64 {
+	if filelang.IsVendored(fm.Path, false) {
+		return 0
+	}
+	return 1
+})
+
+// TestFileSignal prefers files which are not tests.
+var TestFileSignal = SignalFunc(func(fm *FileMatch) float64 {
+	if IsTestFile(fm.Path) {
+		return 0
+	}
+	return 1
+})
+
+// MatchCountSignal prefers files with more matches. Path matches count as a
+// single match.
+var MatchCountSignal = SignalFunc(func(fm *FileMatch) float64 {
+	n := 0
+	for _, lm := range fm.LineMatches {
+		n += len(lm.LineFragments)
+	}
+	if n == 0 {
+		n = 1
+	}
+	return 1 - 1/float64(1+n)
+})
+
+// testDirs are the names of directories which usually only contain tests or
+// test data.
+var testDirs = map[string]bool{
+	"test":      true,
+	"tests":     true,
+	"testdata":  true,
+	"testing":   true,
+	"__tests__": true,
+	"spec":      true,
+	"fixtures":  true,
+}
+
+// IsTestFile returns whether path is probably a test (or test data), based
+// on the naming conventions of popular languages.
+func IsTestFile(p string) bool {
+	dir, name := path.Split(p)
+	for _, d := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if testDirs[strings.ToLower(d)] {
+			return true
+		}
+	}
+
+	base := strings.TrimSuffix(name, path.Ext(name))
+	switch {
+	case strings.HasSuffix(base, "_test"), strings.HasSuffix(base, "_spec"): // Go, Ruby, C++
+		return true
+	case strings.HasSuffix(base, ".test"), strings.HasSuffix(base, ".spec"): // JavaScript, TypeScript
+		return true
+	case strings.HasPrefix(base, "test_"): // Python
+		return true
+	case strings.HasSuffix(base, "Test"), strings.HasSuffix(base, "Tests"): // Java, C#
+		return len(base) > len("Tests")
+	}
+	return false
+}
+
+// RepoMetadata is the metadata about a repository used for ranking.
+type RepoMetadata struct {
+	// Stars is the number of stars the repository has on its code host, or 0
+	// if unknown.
+	Stars int
+
+	Fork     bool
+	Archived bool
+}
+
+// RepoSignal prefers files in popular repositories, and files in
+// repositories which are neither forks nor archived.
+type RepoSignal struct {
+	// Metadata returns the metadata of the named repositories. Repositories
+	// missing from the returned map get the lowest score.
+	Metadata func(ctx context.Context, names []api.RepoName) (map[api.RepoName]RepoMetadata, error)
+}
+
+// Scores implements Signal.
+func (s *RepoSignal) Scores(ctx context.Context, files []FileMatch) ([]float64, error) {
+	seen := map[api.RepoName]bool{}
+	var names []api.RepoName
+	for _, fm := range files {
+		if !seen[fm.Repository.Name] {
+			seen[fm.Repository.Name] = true
+			names = append(names, fm.Repository.Name)
+		}
+	}
+	metadata, err := s.Metadata(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]float64, len(files))
+	for i, fm := range files {
+		m, ok := metadata[fm.Repository.Name]
+		if !ok {
+			continue
+		}
+		// Stars contribute half of the score, saturating at 10k stars.
+		score := 0.5 * math.Min(1, math.Log10(float64(1+m.Stars))/4)
+		if !m.Fork {
+			score += 0.25
+		}
+		if !m.Archived {
+			score += 0.25
+		}
+		scores[i] = score
+	}
+	return scores, nil
+}
+
+// symbolSignalConcurrency is the number of concurrent calls SymbolSignal
+// makes to SymbolLines.
+const symbolSignalConcurrency = 8
+
+// SymbolSignal prefers files where a match is on the line of a symbol
+// definition (as found by ctags in the symbols service).
+type SymbolSignal struct {
+	// SymbolLines returns the 1-based line numbers of the symbols defined in
+	// each of paths in repo. It is called once per repository and commit.
+	SymbolLines func(ctx context.Context, repo Repository, paths []string) (map[string][]int, error)
+
+	// Timeout if non-zero bounds the time spent calling SymbolLines. If it
+	// is exceeded, Scores returns an error so that the signal is skipped.
+	Timeout time.Duration
+
+	// MaxRepos if non-zero is the maximum number of repositories (and
+	// commits) for which SymbolLines is called. If files are from more
+	// repositories, Scores returns an error without calling SymbolLines.
+	MaxRepos int
+}
+
+// Scores implements Signal.
+func (s *SymbolSignal) Scores(ctx context.Context, files []FileMatch) ([]float64, error) {
+	type key struct {
+		name   api.RepoName
+		commit api.CommitID
+	}
+	var keys []key
+	byKey := map[key][]int{}
+	for i, fm := range files {
+		if fm.IsPathMatch() {
+			continue
+		}
+		k := key{name: fm.Repository.Name, commit: fm.Repository.Commit}
+		if _, ok := byKey[k]; !ok {
+			keys = append(keys, k)
+		}
+		byKey[k] = append(byKey[k], i)
+	}
+	if s.MaxRepos > 0 && len(keys) > s.MaxRepos {
+		return nil, errors.Errorf("not looking up symbols in %d repositories (the limit is %d)", len(keys), s.MaxRepos)
+	}
+
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, symbolSignalConcurrency)
+		scores   = make([]float64, len(files))
+	)
+	for _, k := range keys {
+		idx := byKey[k]
+		paths := make([]string, len(idx))
+		for j, i := range idx {
+			paths[j] = files[i].Path
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(repo Repository, idx []int, paths []string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			lines, err := s.SymbolLines(ctx, repo, paths)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			// Each index is written by exactly one goroutine, so scores does
+			// not need to be guarded by mu.
+			for _, i := range idx {
+				if matchesSymbolLine(&files[i], lines[files[i].Path]) {
+					scores[i] = 1
+				}
+			}
+		}(files[idx[0]].Repository, idx, paths)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "looking up symbols")
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return scores, nil
+}
+
+// matchesSymbolLine returns whether any of the line matches of fm are on one
+// of lines.
+func matchesSymbolLine(fm *FileMatch, lines []int) bool {
+	for _, lm := range fm.LineMatches {
+		for _, l := range lines {
+			if lm.LineNumber == l {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Limits of the symbol lookups made by the SymbolSignal of NewDefaultRanker,
+// so that a slow symbols service doesn't hold up search results.
+const (
+	defaultSymbolSignalTimeout  = 2 * time.Second
+	defaultSymbolSignalMaxRepos = 50
+)
+
+// NewDefaultRanker returns a Ranker using every signal we have. repoMetadata
+// and symbolLines may be nil, in which case the corresponding signal is not
+// used (see RepoSignal and SymbolSignal).
+func NewDefaultRanker(
+	repoMetadata func(context.Context, []api.RepoName) (map[api.RepoName]RepoMetadata, error),
+	symbolLines func(context.Context, Repository, []string) (map[string][]int, error),
+) *ScoreRanker {
+	r := &ScoreRanker{
+		Signals: []WeightedSignal{
+			{Name: "vendored", Weight: 4, Signal: VendoredSignal},
+			{Name: "test", Weight: 2, Signal: TestFileSignal},
+			{Name: "depth", Weight: 1, Signal: PathDepthSignal},
+			{Name: "matches", Weight: 1, Signal: MatchCountSignal},
+		},
+	}
+	if symbolLines != nil {
+		r.Signals = append(r.Signals, WeightedSignal{Name: "symbol", Weight: 3, Signal: &SymbolSignal{
+			SymbolLines: symbolLines,
+			Timeout:     defaultSymbolSignalTimeout,
+			MaxRepos:    defaultSymbolSignalMaxRepos,
+		}})
+	}
+	if repoMetadata != nil {
+		r.Signals = append(r.Signals, WeightedSignal{Name: "repo", Weight: 2, Signal: &RepoSignal{Metadata: repoMetadata}})
+	}
+	return r
+}
@@ -0,0 +1,171 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestIsTestFile(t *testing.T) {
+	cases := map[string]bool{
+		"main.go":                     false,
+		"main_test.go":                true,
+		"src/app.test.ts":             true,
+		"src/app.spec.js":             true,
+		"lib/foo_spec.rb":             true,
+		"pkg/test_foo.py":             true,
+		"src/main/java/FooTest.java":  true,
+		"src/main/java/Test.java":     false,
+		"src/test/java/Foo.java":      true,
+		"pkg/testdata/input.txt":      true,
+		"web/__tests__/foo.js":        true,
+		"pkg/testutil/util.go":        false,
+		"cmd/contest/main.go":         false,
+		"docs/testing-guidelines.md":  false,
+		"Tests/Foo.cs":                true,
+		"src/attestation/verify.go":   false,
+		"spec/fixtures/example.json":  true,
+		"internal/latest/response.go": false,
+	}
+	for path, want := range cases {
+		if got := IsTestFile(path); got != want {
+			t.Errorf("IsTestFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestScoreRanker(t *testing.T) {
+	lines := func(n int) []LineMatch {
+		lms := make([]LineMatch, n)
+		for i := range lms {
+			lms[i] = LineMatch{LineNumber: i + 1, LineFragments: []LineFragmentMatch{{}}}
+		}
+		return lms
+	}
+	files := []FileMatch{
+		{Repository: Repository{Name: "a"}, Path: "vendor/github.com/foo/foo.go", LineMatches: lines(1)},
+		{Repository: Repository{Name: "a"}, Path: "foo/foo_test.go", LineMatches: lines(1)},
+		{Repository: Repository{Name: "b"}, Path: "foo/bar/foo.go", LineMatches: lines(1)},
+		{Repository: Repository{Name: "a"}, Path: "foo/foo.go", LineMatches: lines(1)},
+		{Repository: Repository{Name: "a"}, Path: "foo/bar/foo.go", LineMatches: lines(3)},
+		{Repository: Repository{Name: "a"}, Path: "foo/bar/baz.go", LineMatches: lines(1)},
+	}
+
+	r := NewDefaultRanker(nil, nil)
+	if err := r.Rank(context.Background(), files); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"a/foo/bar/foo.go",
+		"a/foo/foo.go",
+		"a/foo/bar/baz.go",
+		"b/foo/bar/foo.go",
+		"a/foo/foo_test.go",
+		"a/vendor/github.com/foo/foo.go",
+	}
+	if got := fileNames(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
+
+func TestScoreRanker_repoAndSymbols(t *testing.T) {
+	files := []FileMatch{
+		{Repository: Repository{Name: "fork"}, Path: "a.go", LineMatches: []LineMatch{{LineNumber: 1}}},
+		{Repository: Repository{Name: "popular"}, Path: "a.go", LineMatches: []LineMatch{{LineNumber: 1}}},
+		{Repository: Repository{Name: "popular"}, Path: "b.go", LineMatches: []LineMatch{{LineNumber: 10}}},
+		{Repository: Repository{Name: "unknown"}, Path: "a.go", LineMatches: []LineMatch{{LineNumber: 1}}},
+	}
+
+	r := NewDefaultRanker(
+		func(ctx context.Context, names []api.RepoName) (map[api.RepoName]RepoMetadata, error) {
+			return map[api.RepoName]RepoMetadata{
+				"fork":    {Fork: true},
+				"popular": {Stars: 5000},
+			}, nil
+		},
+		func(ctx context.Context, repo Repository, paths []string) (map[string][]int, error) {
+			if repo.Name != "popular" {
+				return nil, nil
+			}
+			return map[string][]int{"b.go": {3, 10}}, nil
+		},
+	)
+	if err := r.Rank(context.Background(), files); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"popular/b.go", "popular/a.go", "fork/a.go", "unknown/a.go"}
+	if got := fileNames(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
+
+func TestScoreRanker_signalError(t *testing.T) {
+	files := []FileMatch{
+		{Repository: Repository{Name: "a"}, Path: "b/c.go"},
+		{Repository: Repository{Name: "a"}, Path: "c.go"},
+	}
+	r := &ScoreRanker{Signals: []WeightedSignal{
+		{Name: "depth", Weight: 1, Signal: PathDepthSignal},
+		{Name: "failing", Weight: 1, Signal: &RepoSignal{
+			Metadata: func(context.Context, []api.RepoName) (map[api.RepoName]RepoMetadata, error) {
+				return nil, errors.New("boom")
+			},
+		}},
+	}}
+
+	if err := r.Rank(context.Background(), files); err == nil {
+		t.Error("got err == nil, want error")
+	}
+	// The remaining signals are still used.
+	want := []string{"a/c.go", "a/b/c.go"}
+	if got := fileNames(files); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
+
+func TestSymbolSignal_limits(t *testing.T) {
+	files := []FileMatch{
+		{Repository: Repository{Name: "a"}, Path: "a.go", LineMatches: []LineMatch{{LineNumber: 1}}},
+		{Repository: Repository{Name: "b"}, Path: "b.go", LineMatches: []LineMatch{{LineNumber: 1}}},
+	}
+
+	slow := &SymbolSignal{
+		SymbolLines: func(ctx context.Context, repo Repository, paths []string) (map[string][]int, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		Timeout: 10 * time.Millisecond,
+	}
+	if _, err := slow.Scores(context.Background(), files); err == nil {
+		t.Error("slow: got err == nil, want error")
+	}
+
+	called := false
+	tooMany := &SymbolSignal{
+		SymbolLines: func(ctx context.Context, repo Repository, paths []string) (map[string][]int, error) {
+			called = true
+			return nil, nil
+		},
+		MaxRepos: 1,
+	}
+	if _, err := tooMany.Scores(context.Background(), files); err == nil {
+		t.Error("tooMany: got err == nil, want error")
+	}
+	if called {
+		t.Error("tooMany: SymbolLines was called")
+	}
+}
+
+func fileNames(files []FileMatch) []string {
+	names := make([]string, len(files))
+	for i, fm := range files {
+		names[i] = string(fm.Repository.Name) + "/" + fm.Path
+	}
+	return names
+}
@@ -133,7 +133,9 @@ func registerGob() {
 		gob.RegisterName("*sgsearch.FileMatch", &search.FileMatch{})
 		gob.RegisterName("*sgsearch.LineFragmentMatch", &search.LineFragmentMatch{})
 		gob.RegisterName("*sgsearch.LineMatch", &search.LineMatch{})
+		gob.RegisterName("*sgsearch.Location", &search.Location{})
 		gob.RegisterName("*sgsearch.Options", &search.Options{})
+		gob.RegisterName("*sgsearch.Range", &search.Range{})
 		gob.RegisterName("*sgsearch.RepositoryStatus", &search.RepositoryStatus{})
 		gob.RegisterName("*sgsearch.Result", &search.Result{})
 		gob.RegisterName("*sgsearch.Stats", &search.Stats{})
@@ -35,13 +35,49 @@ type SearchArgs struct {
 	// need to match to get included in the result
 	ExcludePattern string
 
+	// Match is how Query is matched against symbol names. If empty, Query is
+	// a regular expression or substring (see IsRegExp). Otherwise it is one
+	// of MatchExact, MatchPrefix or MatchFuzzy, and IsRegExp is ignored for
+	// names.
+	Match string `json:",omitempty"`
+
+	// Kinds, if non-empty, only includes symbols of these kinds (eg
+	// "function"). It is case insensitive.
+	Kinds []string `json:",omitempty"`
+
+	// Languages, if non-empty, only includes symbols in these languages (eg
+	// "Go"). It is case insensitive.
+	Languages []string `json:",omitempty"`
+
 	// First indicates that only the first n symbols should be returned.
 	First int
+
+	// Offset is the number of matching symbols to skip before the first one
+	// returned. With First it allows paginating through results.
+	Offset int `json:",omitempty"`
 }
 
+// Values of SearchArgs.Match.
+const (
+	// MatchExact matches symbols named Query.
+	MatchExact = "exact"
+
+	// MatchPrefix matches symbols whose name starts with Query.
+	MatchPrefix = "prefix"
+
+	// MatchFuzzy matches symbols whose name contains the characters of Query
+	// in order, but not necessarily next to each other (eg "nwsrv" matches
+	// "NewServer").
+	MatchFuzzy = "fuzzy"
+)
+
 // SearchResult is the result of a search on the symbols service.
 type SearchResult struct {
 	Symbols []Symbol // code symbols
+
+	// HasMore is whether there are more matching symbols after Symbols (see
+	// SearchArgs.First and SearchArgs.Offset).
+	HasMore bool `json:",omitempty"`
 }
 
 // Symbol is a code symbol.
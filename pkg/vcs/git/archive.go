@@ -37,6 +37,9 @@ func (a *archiveReader) Read(p []byte) (int, error) {
 		if strings.Contains(err.Error(), "Not a valid object") {
 			return 0, &RevisionNotFoundError{Repo: a.repo, Spec: a.spec}
 		}
+		if isMissingObjectOutput([]byte(err.Error())) {
+			return 0, &MissingObjectError{Repo: a.repo, Output: err.Error()}
+		}
 	}
 	return n, err
 }
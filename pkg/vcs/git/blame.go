@@ -41,21 +41,52 @@ func BlameFile(ctx context.Context, repo gitserver.Repo, path string, opt *Blame
 	span.SetTag("path", path)
 	span.SetTag("opt", opt)
 	defer span.Finish()
-	return blameFileCmd(ctx, gitserverCmdFunc(repo), path, opt)
+
+	command := gitserverCmdFunc(repo)
+	hunks, boundary, err := blameFileCmd(ctx, command, path, opt)
+	if err != nil {
+		return nil, err
+	}
+	if boundary {
+		// A boundary commit is either the root commit or, in a shallow
+		// clone, the commit the history is truncated at. In the latter case
+		// blame attributes all lines older than the truncated history to
+		// it, which is misleading.
+		shallow, err := isShallowClone(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+		if shallow {
+			return nil, &ShallowCloneError{Repo: repo.Name, Op: "blame"}
+		}
+	}
+	return hunks, nil
 }
 
-func blameFileCmd(ctx context.Context, command cmdFunc, path string, opt *BlameOptions) ([]*Hunk, error) {
+// isShallowClone reports whether the repository is a shallow clone, whose
+// history is truncated.
+func isShallowClone(ctx context.Context, command cmdFunc) (bool, error) {
+	out, err := command([]string{"rev-parse", "--is-shallow-repository"}).Output(ctx)
+	if err != nil {
+		return false, errors.WithMessage(err, fmt.Sprintf("git command rev-parse --is-shallow-repository failed (output: %q)", out))
+	}
+	return strings.TrimSpace(string(out)) == "true", nil
+}
+
+// blameFileCmd runs git blame. It also reports whether any lines were
+// attributed to a boundary commit.
+func blameFileCmd(ctx context.Context, command cmdFunc, path string, opt *BlameOptions) (hunks []*Hunk, boundary bool, err error) {
 	if opt == nil {
 		opt = &BlameOptions{}
 	}
 	if opt.OldestCommit != "" {
-		return nil, fmt.Errorf("OldestCommit not implemented")
+		return nil, false, fmt.Errorf("OldestCommit not implemented")
 	}
 	if err := checkSpecArgSafety(string(opt.NewestCommit)); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	if err := checkSpecArgSafety(string(opt.OldestCommit)); err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	args := []string{"blame", "-w", "--porcelain"}
@@ -66,21 +97,21 @@ func blameFileCmd(ctx context.Context, command cmdFunc, path string, opt *BlameO
 
 	out, err := command(args).Output(ctx)
 	if err != nil {
-		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", args, out))
+		return nil, false, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", args, out))
 	}
 	if len(out) == 0 {
-		return nil, nil
+		return nil, false, nil
 	}
 
 	commits := make(map[string]Commit)
-	hunks := make([]*Hunk, 0)
+	hunks = make([]*Hunk, 0)
 	remainingLines := strings.Split(string(out[:len(out)-1]), "\n")
 	byteOffset := 0
 	for len(remainingLines) > 0 {
 		// Consume hunk
 		hunkHeader := strings.Split(remainingLines[0], " ")
 		if len(hunkHeader) != 4 {
-			return nil, fmt.Errorf("Expected at least 4 parts to hunkHeader, but got: '%s'", hunkHeader)
+			return nil, false, fmt.Errorf("Expected at least 4 parts to hunkHeader, but got: '%s'", hunkHeader)
 		}
 		commitID := hunkHeader[0]
 		lineNoCur, _ := strconv.Atoi(hunkHeader[2])
@@ -105,7 +136,7 @@ func blameFileCmd(ctx context.Context, command cmdFunc, path string, opt *BlameO
 			}
 			authorTime, err := strconv.ParseInt(strings.Join(strings.Split(remainingLines[3], " ")[1:], " "), 10, 64)
 			if err != nil {
-				return nil, fmt.Errorf("Failed to parse author-time %q", remainingLines[3])
+				return nil, false, fmt.Errorf("Failed to parse author-time %q", remainingLines[3])
 			}
 			summary := strings.Join(strings.Split(remainingLines[9], " ")[1:], " ")
 			commit := Commit{
@@ -122,6 +153,7 @@ func blameFileCmd(ctx context.Context, command cmdFunc, path string, opt *BlameO
 				byteOffset += len(remainingLines[12])
 				remainingLines = remainingLines[13:]
 			} else if len(remainingLines) >= 13 && remainingLines[10] == "boundary" {
+				boundary = true
 				byteOffset += len(remainingLines[12])
 				remainingLines = remainingLines[13:]
 			} else if len(remainingLines) >= 12 {
@@ -131,7 +163,7 @@ func blameFileCmd(ctx context.Context, command cmdFunc, path string, opt *BlameO
 				// Empty file
 				remainingLines = remainingLines[11:]
 			} else {
-				return nil, fmt.Errorf("Unexpected number of remaining lines (%d):\n%s", len(remainingLines), "  "+strings.Join(remainingLines, "\n  "))
+				return nil, false, fmt.Errorf("Unexpected number of remaining lines (%d):\n%s", len(remainingLines), "  "+strings.Join(remainingLines, "\n  "))
 			}
 
 			commits[commitID] = commit
@@ -156,5 +188,5 @@ func blameFileCmd(ctx context.Context, command cmdFunc, path string, opt *BlameO
 		hunks = append(hunks, hunk)
 	}
 
-	return hunks, nil
+	return hunks, boundary, nil
 }
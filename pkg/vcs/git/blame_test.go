@@ -66,3 +66,20 @@ func TestRepository_BlameFile(t *testing.T) {
 		}
 	}
 }
+
+func TestRepository_BlameFile_shallowClone(t *testing.T) {
+	t.Parallel()
+
+	repo := makeGitRepository(t,
+		"echo line1 > f",
+		"git add f",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m foo --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		// Truncate the history at the commit, as a shallow clone does.
+		"git rev-parse HEAD > .git/shallow",
+	)
+
+	_, err := git.BlameFile(ctx, repo, "f", &git.BlameOptions{NewestCommit: "master"})
+	if !git.IsShallowClone(err) {
+		t.Errorf("got error %v, want a ShallowCloneError", err)
+	}
+}
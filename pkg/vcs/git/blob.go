@@ -38,6 +38,9 @@ func readFileBytes(ctx context.Context, repo gitserver.Repo, commit api.CommitID
 	cmd.Repo = repo
 	out, err := cmd.CombinedOutput(ctx)
 	if err != nil {
+		if isMissingObjectOutput(out) {
+			return nil, &MissingObjectError{Repo: repo.Name, Output: string(out)}
+		}
 		if bytes.Contains(out, []byte("exists on disk, but not in")) || bytes.Contains(out, []byte("does not exist")) {
 			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
 		}
@@ -0,0 +1,225 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+)
+
+// A FileDiff describes the changes to a file between two commits.
+type FileDiff struct {
+	OrigName string // the path of the file in the base commit, or "" if it was added
+	NewName  string // the path of the file in the head commit, or "" if it was deleted
+	Hunks    []*DiffHunk
+}
+
+// A DiffHunk is a range of lines that were changed, without context lines.
+type DiffHunk struct {
+	// OrigStartLine is the 1-indexed line number of the first removed line. If
+	// no lines were removed, it is the line after which lines were added (0
+	// for the beginning of the file).
+	OrigStartLine int
+	OrigLines     int // the number of removed lines
+	NewStartLine  int // like OrigStartLine, for the added lines
+	NewLines      int // the number of added lines
+
+	Removed []string // the contents of the removed lines
+	Added   []string // the contents of the added lines
+}
+
+// maxDiffFilesSize is the maximum size of the diff output that DiffFiles
+// reads.
+const maxDiffFilesSize = 50 * 1024 * 1024
+
+// DiffFiles returns the changes to the files that changed between the base and
+// head commits, with renamed files detected. Hunks do not include context
+// lines, and the changes of binary files are omitted.
+//
+// If paths is not empty, only the changes to those paths are returned, and
+// renames are only detected among them (so a file renamed to a path which is
+// not in paths is reported as deleted).
+func DiffFiles(ctx context.Context, repo gitserver.Repo, base, head api.CommitID, paths []string) ([]*FileDiff, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: DiffFiles")
+	span.SetTag("Base", base)
+	span.SetTag("Head", head)
+	span.SetTag("Paths", paths)
+	defer span.Finish()
+
+	ensureAbsCommit(base)
+	ensureAbsCommit(head)
+
+	args := []string{"-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", "--find-renames", "--unified=0", string(base), string(head), "--"}
+	for _, path := range paths {
+		args = append(args, ":(literal)"+path)
+	}
+	cmd := gitserver.DefaultClient.Command("git", args...)
+	cmd.Repo = repo
+	rc, err := gitserver.StdoutReader(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	out, err := ioutil.ReadAll(io.LimitReader(rc, maxDiffFilesSize+1))
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed", cmd.Args))
+	}
+	if len(out) > maxDiffFilesSize {
+		return nil, fmt.Errorf("diff between %s and %s is larger than %d bytes", base, head, maxDiffFilesSize)
+	}
+	return parseDiff(out)
+}
+
+// parseDiff parses the output of `git diff --unified=0`.
+func parseDiff(out []byte) ([]*FileDiff, error) {
+	var (
+		files []*FileDiff
+		file  *FileDiff
+		hunk  *DiffHunk
+
+		// The number of lines of the current hunk that are yet to be read.
+		origLeft, newLeft int
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if hunk != nil && (origLeft > 0 || newLeft > 0) {
+			switch {
+			case strings.HasPrefix(line, "-") && origLeft > 0:
+				hunk.Removed = append(hunk.Removed, line[1:])
+				origLeft--
+			case strings.HasPrefix(line, "+") && newLeft > 0:
+				hunk.Added = append(hunk.Added, line[1:])
+				newLeft--
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			default:
+				return nil, fmt.Errorf("unexpected line in diff hunk: %q", line)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file = &FileDiff{}
+			hunk = nil
+			files = append(files, file)
+			// The names are set from the headers below, but for unchanged
+			// renames (and mode changes) there are no "---" and "+++" headers.
+			if a, b, ok := parseDiffGitHeader(line); ok {
+				file.OrigName, file.NewName = a, b
+			}
+		case file == nil:
+			return nil, fmt.Errorf("unexpected line in diff: %q", line)
+		case strings.HasPrefix(line, "new file mode "):
+			file.OrigName = ""
+		case strings.HasPrefix(line, "deleted file mode "):
+			file.NewName = ""
+		case strings.HasPrefix(line, "rename from "):
+			file.OrigName = unquoteDiffName(strings.TrimPrefix(line, "rename from "))
+		case strings.HasPrefix(line, "rename to "):
+			file.NewName = unquoteDiffName(strings.TrimPrefix(line, "rename to "))
+		case strings.HasPrefix(line, "--- "):
+			file.OrigName = diffFileHeaderName(strings.TrimPrefix(line, "--- "), "a/")
+		case strings.HasPrefix(line, "+++ "):
+			file.NewName = diffFileHeaderName(strings.TrimPrefix(line, "+++ "), "b/")
+		case strings.HasPrefix(line, "@@ "):
+			var err error
+			hunk, err = parseDiffHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			file.Hunks = append(file.Hunks, hunk)
+			origLeft, newLeft = hunk.OrigLines, hunk.NewLines
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if origLeft > 0 || newLeft > 0 {
+		return nil, errors.New("unexpected end of diff hunk")
+	}
+	return files, nil
+}
+
+// parseDiffGitHeader parses the names from a "diff --git a/foo b/foo" line.
+// The names are ambiguous if they contain " b/", in which case ok is false.
+func parseDiffGitHeader(line string) (origName, newName string, ok bool) {
+	names := strings.TrimPrefix(line, "diff --git ")
+	if strings.HasPrefix(names, `"`) {
+		return "", "", false // quoted names are set by the other headers
+	}
+	i := strings.Index(names, " b/")
+	if i == -1 || strings.LastIndex(names, " b/") != i || !strings.HasPrefix(names, "a/") {
+		return "", "", false
+	}
+	return names[len("a/"):i], names[i+len(" b/"):], true
+}
+
+// parseDiffHunkHeader parses a hunk header like "@@ -1,2 +1,3 @@ func foo()".
+func parseDiffHunkHeader(line string) (*DiffHunk, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return nil, fmt.Errorf("invalid diff hunk header: %q", line)
+	}
+	var (
+		hunk DiffHunk
+		err  error
+	)
+	hunk.OrigStartLine, hunk.OrigLines, err = parseDiffHunkRange(fields[1][1:])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid diff hunk header: %q", line)
+	}
+	hunk.NewStartLine, hunk.NewLines, err = parseDiffHunkRange(fields[2][1:])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid diff hunk header: %q", line)
+	}
+	return &hunk, nil
+}
+
+// parseDiffHunkRange parses a range like "1,2" or "1" (1 line) of a hunk
+// header.
+func parseDiffHunkRange(s string) (start, lines int, err error) {
+	lines = 1
+	if i := strings.Index(s, ","); i != -1 {
+		lines, err = strconv.Atoi(s[i+1:])
+		if err != nil {
+			return 0, 0, err
+		}
+		s = s[:i]
+	}
+	start, err = strconv.Atoi(s)
+	return start, lines, err
+}
+
+// unquoteDiffName unquotes a file name of a diff header, which Git quotes if it
+// contains unusual characters.
+func unquoteDiffName(name string) string {
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			return unquoted
+		}
+	}
+	return name
+}
+
+// diffFileHeaderName returns the file name of a "---" or "+++" diff header
+// without its "a/" or "b/" prefix, or "" for /dev/null. Git terminates names
+// that contain spaces with a tab.
+func diffFileHeaderName(name, prefix string) string {
+	name = unquoteDiffName(strings.TrimSuffix(name, "\t"))
+	if name == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(name, prefix)
+}
@@ -0,0 +1,89 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDiff(t *testing.T) {
+	// Output of `git diff --find-renames --unified=0`.
+	out := "diff --git a/b.bin b/b.bin\n" +
+		"new file mode 100644\n" +
+		"index 0000000..87ae6b6\n" +
+		"Binary files /dev/null and b/b.bin differ\n" +
+		"diff --git a/f.txt b/f.txt\n" +
+		"index f00c965..29bbc98 100644\n" +
+		"--- a/f.txt\n" +
+		"+++ b/f.txt\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+0\n" +
+		"@@ -4 +5 @@\n" +
+		"-4\n" +
+		"+four\n" +
+		"@@ -6 +6,0 @@\n" +
+		"-6\n" +
+		"@@ -10,0 +11 @@\n" +
+		"+new\n" +
+		"\\ No newline at end of file\n" +
+		"diff --git a/old.txt b/new.txt\n" +
+		"similarity index 100%\n" +
+		"rename from old.txt\n" +
+		"rename to new.txt\n" +
+		"diff --git a/sp ace.txt b/sp ace.txt\n" +
+		"index adaaafc..587be6b 100644\n" +
+		"--- a/sp ace.txt\t\n" +
+		"+++ b/sp ace.txt\t\n" +
+		"@@ -2 +1,0 @@ x\n" +
+		"--- y\n" +
+		"diff --git a/gone.txt b/gone.txt\n" +
+		"deleted file mode 100644\n" +
+		"index 6b584e8..0000000\n" +
+		"--- a/gone.txt\n" +
+		"+++ /dev/null\n" +
+		"@@ -1,2 +0,0 @@\n" +
+		"-a\n" +
+		"-b\n"
+
+	want := []*FileDiff{
+		{NewName: "b.bin"},
+		{
+			OrigName: "f.txt",
+			NewName:  "f.txt",
+			Hunks: []*DiffHunk{
+				{OrigStartLine: 0, OrigLines: 0, NewStartLine: 1, NewLines: 1, Added: []string{"0"}},
+				{OrigStartLine: 4, OrigLines: 1, NewStartLine: 5, NewLines: 1, Removed: []string{"4"}, Added: []string{"four"}},
+				{OrigStartLine: 6, OrigLines: 1, NewStartLine: 6, NewLines: 0, Removed: []string{"6"}},
+				{OrigStartLine: 10, OrigLines: 0, NewStartLine: 11, NewLines: 1, Added: []string{"new"}},
+			},
+		},
+		{OrigName: "old.txt", NewName: "new.txt"},
+		{
+			OrigName: "sp ace.txt",
+			NewName:  "sp ace.txt",
+			Hunks: []*DiffHunk{
+				{OrigStartLine: 2, OrigLines: 1, NewStartLine: 1, NewLines: 0, Removed: []string{"-- y"}},
+			},
+		},
+		{
+			OrigName: "gone.txt",
+			Hunks: []*DiffHunk{
+				{OrigStartLine: 1, OrigLines: 2, NewStartLine: 0, NewLines: 0, Removed: []string{"a", "b"}},
+			},
+		},
+	}
+
+	got, err := parseDiff([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		for i := range got {
+			t.Logf("got file diff %d: %+v", i, got[i])
+		}
+		t.Error("unexpected file diffs")
+	}
+
+	if _, err := parseDiff([]byte("diff --git a/f b/f\n--- a/f\n+++ b/f\n@@ -1,2 +1 @@\n-a\n")); err == nil {
+		t.Error("expected error for truncated hunk")
+	}
+}
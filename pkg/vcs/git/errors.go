@@ -1,6 +1,7 @@
 package git
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/sourcegraph/sourcegraph/pkg/api"
@@ -25,3 +26,43 @@ func IsRevisionNotFound(err error) bool {
 	_, ok := err.(*RevisionNotFoundError)
 	return ok
 }
+
+// ShallowCloneError is an error that reports that an operation needs the full
+// history of a repository, which is unavailable because the repository is a
+// shallow clone (see the gitCloneOptions site configuration).
+type ShallowCloneError struct {
+	Repo api.RepoName
+	Op   string // the operation, such as "blame"
+}
+
+func (e *ShallowCloneError) Error() string {
+	return fmt.Sprintf("%s is unavailable for %s because it is a shallow clone without full history", e.Op, e.Repo)
+}
+
+func (e *ShallowCloneError) BadRequest() bool {
+	return true
+}
+
+// IsShallowClone reports if err is a ShallowCloneError.
+func IsShallowClone(err error) bool {
+	_, ok := err.(*ShallowCloneError)
+	return ok
+}
+
+// MissingObjectError is an error that reports that an object is missing from
+// a partial clone of a repository (see the gitCloneOptions site
+// configuration) and could not be fetched from the repository's remote.
+type MissingObjectError struct {
+	Repo   api.RepoName
+	Output string // the output of the failed git command
+}
+
+func (e *MissingObjectError) Error() string {
+	return fmt.Sprintf("failed to fetch missing object of partial clone %s from its remote: %s", e.Repo, e.Output)
+}
+
+// isMissingObjectOutput reports whether the output of a failed git command
+// shows that it failed to fetch an object missing from a partial clone.
+func isMissingObjectOutput(output []byte) bool {
+	return bytes.Contains(output, []byte("promisor remote"))
+}
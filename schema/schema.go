@@ -79,16 +79,53 @@ func (v *AuthProviders) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("tagged union type must have a %q property whose value is one of %s", "type", []string{"builtin", "saml", "openidconnect", "http-header", "github", "gitlab"})
 }
 
+type BitbucketCloudConnection struct {
+	ApiURL                      string   `json:"apiURL,omitempty"`
+	AppPassword                 string   `json:"appPassword,omitempty"`
+	GitURLType                  string   `json:"gitURLType,omitempty"`
+	InitialRepositoryEnablement bool     `json:"initialRepositoryEnablement,omitempty"`
+	OauthKey                    string   `json:"oauthKey,omitempty"`
+	OauthSecret                 string   `json:"oauthSecret,omitempty"`
+	RepositoryPathPattern       string   `json:"repositoryPathPattern,omitempty"`
+	Repos                       []string `json:"repos,omitempty"`
+	Teams                       []string `json:"teams,omitempty"`
+	Url                         string   `json:"url,omitempty"`
+	Username                    string   `json:"username,omitempty"`
+}
+
+// BitbucketServerAuthorization description: If non-null, enforces Bitbucket Server repository permissions. Sourcegraph users are matched to Bitbucket Server users as configured in `identityProvider`. The `token` (or `username` and `password`) of this `BitbucketServerConnection` must belong to a Bitbucket Server user with the "Admin" global permission, so that Sourcegraph can look up other users and list the public repositories. The repositories each user can read are listed by impersonating the user through the application link configured in `oauth`.
+type BitbucketServerAuthorization struct {
+	IdentityProvider *BitbucketServerIdentityProvider `json:"identityProvider,omitempty"`
+	Oauth            *BitbucketServerOAuth            `json:"oauth"`
+	Ttl              string                           `json:"ttl,omitempty"`
+}
 type BitbucketServerConnection struct {
-	Certificate                 string `json:"certificate,omitempty"`
-	ExcludePersonalRepositories bool   `json:"excludePersonalRepositories,omitempty"`
-	GitURLType                  string `json:"gitURLType,omitempty"`
-	InitialRepositoryEnablement bool   `json:"initialRepositoryEnablement,omitempty"`
-	Password                    string `json:"password,omitempty"`
-	RepositoryPathPattern       string `json:"repositoryPathPattern,omitempty"`
-	Token                       string `json:"token,omitempty"`
-	Url                         string `json:"url"`
-	Username                    string `json:"username,omitempty"`
+	Authorization               *BitbucketServerAuthorization `json:"authorization,omitempty"`
+	Certificate                 string                        `json:"certificate,omitempty"`
+	ExcludePersonalRepositories bool                          `json:"excludePersonalRepositories,omitempty"`
+	GitURLType                  string                        `json:"gitURLType,omitempty"`
+	InitialRepositoryEnablement bool                          `json:"initialRepositoryEnablement,omitempty"`
+	Password                    string                        `json:"password,omitempty"`
+	RepositoryPathPattern       string                        `json:"repositoryPathPattern,omitempty"`
+	Token                       string                        `json:"token,omitempty"`
+	Url                         string                        `json:"url"`
+	Username                    string                        `json:"username,omitempty"`
+	WebhookSecret               string                        `json:"webhookSecret,omitempty"`
+}
+
+// BitbucketServerIdentityProvider description: The way Sourcegraph users are matched to Bitbucket Server users.
+//
+// If "email" (the default), a Sourcegraph user is matched to the Bitbucket Server user whose email address is one of the Sourcegraph user's verified email addresses.
+//
+// If "username", a Sourcegraph user is matched to the Bitbucket Server user with the same username. Sourcegraph users choose their own usernames, so any user who signs up with or changes their username to a Bitbucket Server username can read that user's repositories. Only use "username" if only trusted users can sign up and choose usernames.
+type BitbucketServerIdentityProvider struct {
+	Type string `json:"type"`
+}
+
+// BitbucketServerOAuth description: An incoming application link on Bitbucket Server with "Allow 2-Legged OAuth" and "Allow user impersonation through 2-Legged OAuth" enabled, which Sourcegraph uses to list the repositories each user can read.
+type BitbucketServerOAuth struct {
+	ConsumerKey string `json:"consumerKey"`
+	SigningKey  string `json:"signingKey"`
 }
 
 // BuiltinAuthProvider description: Configures the builtin username-password authentication provider.
@@ -128,6 +165,14 @@ type Discussions struct {
 	AbuseProtection bool     `json:"abuseProtection,omitempty"`
 }
 
+// DiscussionsNotificationSubscription description: A chat service or webhook to send notifications of discussion activity to.
+type DiscussionsNotificationSubscription struct {
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+	Type   string   `json:"type"`
+	Url    string   `json:"url"`
+}
+
 // ExperimentalFeatures description: Experimental features to enable or disable. Features that are now enabled by default are marked as deprecated.
 type ExperimentalFeatures struct {
 	Discussions      string `json:"discussions,omitempty"`
@@ -151,6 +196,13 @@ type GitHubAuthProvider struct {
 	Url          string `json:"url,omitempty"`
 }
 
+// GitCloneOptions description: Options for cloning the repositories whose name matches a pattern. Objects that a partial clone omits are fetched from the code host when they are needed. Features that need a repository's full history (such as blame) are unavailable for shallow clones.
+type GitCloneOptions struct {
+	Depth   int    `json:"depth,omitempty"`
+	Filter  string `json:"filter,omitempty"`
+	Pattern string `json:"pattern"`
+}
+
 // GitHubAuthorization description: If non-null, enforces GitHub repository permissions. This requires that there is an item in the `auth.providers` field of type "github" with the same `url` field as specified in this `GitHubConnection`.
 type GitHubAuthorization struct {
 	Ttl string `json:"ttl,omitempty"`
@@ -165,6 +217,7 @@ type GitHubConnection struct {
 	RepositoryQuery             []string             `json:"repositoryQuery,omitempty"`
 	Token                       string               `json:"token"`
 	Url                         string               `json:"url"`
+	WebhookSecret               string               `json:"webhookSecret,omitempty"`
 }
 
 // GitLabAuthProvider description: Configures the GitLab OAuth authentication provider for SSO. In addition to specifying this configuration object, you must also create a OAuth App on your GitLab instance: https://docs.gitlab.com/ee/integration/oauth_provider.html. The application should have `api` and `read_user` scopes and the callback URL set to the concatenation of your Sourcegraph instance URL and "/.auth/gitlab/callback".
@@ -189,6 +242,17 @@ type GitLabConnection struct {
 	RepositoryPathPattern       string               `json:"repositoryPathPattern,omitempty"`
 	Token                       string               `json:"token"`
 	Url                         string               `json:"url"`
+	WebhookSecret               string               `json:"webhookSecret,omitempty"`
+}
+type GiteaConnection struct {
+	Certificate                 string   `json:"certificate,omitempty"`
+	GitURLType                  string   `json:"gitURLType,omitempty"`
+	InitialRepositoryEnablement bool     `json:"initialRepositoryEnablement,omitempty"`
+	RepositoryPathPattern       string   `json:"repositoryPathPattern,omitempty"`
+	RepositoryQuery             []string `json:"repositoryQuery,omitempty"`
+	Repos                       []string `json:"repos,omitempty"`
+	Token                       string   `json:"token,omitempty"`
+	Url                         string   `json:"url"`
 }
 type GitoliteConnection struct {
 	Blacklist                  string       `json:"blacklist,omitempty"`
@@ -240,6 +304,13 @@ type ParentSourcegraph struct {
 	Url string `json:"url,omitempty"`
 }
 
+// PermissionsBackgroundSync description: Sync repository permissions from code hosts in the background and store them in the database, instead of asking the code hosts on every request. Only takes effect if repository permissions are enforced for at least one external service.
+type PermissionsBackgroundSync struct {
+	Enabled      bool   `json:"enabled,omitempty"`
+	MaxStaleness string `json:"maxStaleness,omitempty"`
+	UserInterval string `json:"userInterval,omitempty"`
+}
+
 // Phabricator description: Phabricator instance that integrates with this Gitolite instance
 type Phabricator struct {
 	CallsignCommand string `json:"callsignCommand"`
@@ -287,6 +358,7 @@ type SearchSavedQueries struct {
 	Notify         bool   `json:"notify,omitempty"`
 	NotifySlack    bool   `json:"notifySlack,omitempty"`
 	Query          string `json:"query"`
+	Schedule       string `json:"schedule,omitempty"`
 	ShowOnHomepage bool   `json:"showOnHomepage,omitempty"`
 }
 type SearchScope struct {
@@ -303,12 +375,13 @@ type Sentry struct {
 
 // Settings description: Configuration settings for users and organizations on Sourcegraph.
 type Settings struct {
-	Extensions             map[string]bool           `json:"extensions,omitempty"`
-	Motd                   []string                  `json:"motd,omitempty"`
-	NotificationsSlack     *SlackNotificationsConfig `json:"notifications.slack,omitempty"`
-	SearchRepositoryGroups map[string][]string       `json:"search.repositoryGroups,omitempty"`
-	SearchSavedQueries     []*SearchSavedQueries     `json:"search.savedQueries,omitempty"`
-	SearchScopes           []*SearchScope            `json:"search.scopes,omitempty"`
+	Extensions               map[string]bool                        `json:"extensions,omitempty"`
+	Motd                     []string                               `json:"motd,omitempty"`
+	NotificationsDiscussions []*DiscussionsNotificationSubscription `json:"notifications.discussions,omitempty"`
+	NotificationsSlack       *SlackNotificationsConfig              `json:"notifications.slack,omitempty"`
+	SearchRepositoryGroups   map[string][]string                    `json:"search.repositoryGroups,omitempty"`
+	SearchSavedQueries       []*SearchSavedQueries                  `json:"search.savedQueries,omitempty"`
+	SearchScopes             []*SearchScope                         `json:"search.scopes,omitempty"`
 }
 
 // SiteConfiguration description: Configuration for a Sourcegraph site.
@@ -325,12 +398,16 @@ type SiteConfiguration struct {
 	EmailSmtp                         *SMTPServerConfig           `json:"email.smtp,omitempty"`
 	ExperimentalFeatures              *ExperimentalFeatures       `json:"experimentalFeatures,omitempty"`
 	Extensions                        *Extensions                 `json:"extensions,omitempty"`
+	GitCloneOptions                   []*GitCloneOptions          `json:"gitCloneOptions,omitempty"`
 	GitCloneURLToRepositoryName       []*CloneURLToRepositoryName `json:"git.cloneURLToRepositoryName,omitempty"`
 	GitMaxConcurrentClones            int                         `json:"gitMaxConcurrentClones,omitempty"`
+	GitReplicationFactor              int                         `json:"gitReplicationFactor,omitempty"`
+	GitShardingAlgorithm              string                      `json:"gitShardingAlgorithm,omitempty"`
 	GithubClientID                    string                      `json:"githubClientID,omitempty"`
 	GithubClientSecret                string                      `json:"githubClientSecret,omitempty"`
 	MaxReposToSearch                  int                         `json:"maxReposToSearch,omitempty"`
 	ParentSourcegraph                 *ParentSourcegraph          `json:"parentSourcegraph,omitempty"`
+	PermissionsBackgroundSync         *PermissionsBackgroundSync  `json:"permissions.backgroundSync,omitempty"`
 	RepoListUpdateInterval            int                         `json:"repoListUpdateInterval,omitempty"`
 	SearchIndexEnabled                *bool                       `json:"search.index.enabled,omitempty"`
 }
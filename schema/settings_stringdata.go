@@ -39,6 +39,12 @@ const SettingsSchemaJSON = `{
           "notifySlack": {
             "type": "boolean",
             "description": "Notify Slack via the organization's Slack webhook URL when new results are available"
+          },
+          "schedule": {
+            "type": "string",
+            "description": "A cron expression (minute, hour, day of month, month and day of week, in UTC) or one of @hourly, @daily, @weekly and @monthly, specifying when to run this saved query to check for new results. By default, it is run at an interval proportional to how long it takes to execute. A schedule can't make it run more often than that interval.",
+            "pattern": "^\\s*(@(hourly|daily|weekly|monthly)|[0-9*,/-]+(\\s+[0-9*,/-]+){4})\\s*$",
+            "examples": ["@daily", "0 9 * * 1-5", "*/30 * * * *"]
           }
         },
         "additionalProperties": false,
@@ -64,6 +70,14 @@ const SettingsSchemaJSON = `{
     "notifications.slack": {
       "$ref": "#/definitions/SlackNotificationsConfig"
     },
+    "notifications.discussions": {
+      "description":
+        "Chat services and webhooks to send notifications of discussion activity to.\n\nIn user settings, notifications are sent for new threads and comments in the threads that the user is subscribed to (by being mentioned in, commenting on or being assigned to them), and when the user is mentioned. In organization settings, notifications are sent for new threads and comments by the organization's members. Notifications are only sent for threads on repositories that the user (or every member of the organization) can read.",
+      "type": "array",
+      "items": {
+        "$ref": "#/definitions/DiscussionsNotificationSubscription"
+      }
+    },
     "motd": {
       "description":
         "An array (often with just one element) of messages to display at the top of all pages, including for unauthenticated users. Users may dismiss a message (and any message with the same string value will remain dismissed for the user).\n\nMarkdown formatting is supported.\n\nUsually this setting is used in global and organization settings. If set in user settings, the message will only be displayed to that user. (This is useful for testing the correctness of the message's Markdown formatting.)\n\nMOTD stands for \"message of the day\" (which is the conventional Unix name for this type of message).",
@@ -110,6 +124,38 @@ const SettingsSchemaJSON = `{
         }
       }
     },
+    "DiscussionsNotificationSubscription": {
+      "type": "object",
+      "description": "A chat service or webhook to send notifications of discussion activity to.",
+      "additionalProperties": false,
+      "required": ["type", "url"],
+      "properties": {
+        "type": {
+          "type": "string",
+          "description":
+            "The kind of service that the URL refers to:\n\n- ` + "`" + `slack` + "`" + `: a Slack incoming webhook\n- ` + "`" + `microsoftTeams` + "`" + `: a Microsoft Teams incoming webhook\n- ` + "`" + `webhook` + "`" + `: any URL, which is sent a JSON description of the event in a POST request",
+          "enum": ["slack", "microsoftTeams", "webhook"]
+        },
+        "url": {
+          "type": "string",
+          "description": "The URL that notifications are posted to.",
+          "format": "uri"
+        },
+        "secret": {
+          "type": "string",
+          "description":
+            "For ` + "`" + `webhook` + "`" + ` subscriptions, the secret used to sign requests. The hex-encoded HMAC-SHA256 of the request body is sent in the ` + "`" + `X-Sourcegraph-Signature` + "`" + ` header as ` + "`" + `sha256=SIGNATURE` + "`" + `."
+        },
+        "events": {
+          "description": "The events to send notifications for. Defaults to all events.",
+          "type": "array",
+          "items": {
+            "type": "string",
+            "enum": ["threadCreated", "commentAdded", "mention"]
+          }
+        }
+      }
+    },
     "SlackNotificationsConfig": {
       "type": "object",
       "description": "Configuration for sending notifications to Slack.",
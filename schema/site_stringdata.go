@@ -80,6 +80,28 @@ const SiteSchemaJSON = `{
       "type": "integer",
       "default": 5
     },
+    "gitCloneOptions": {
+      "description":
+        "Partial and shallow clone options for repositories whose name matches a pattern, such as very large monorepos. The first entry whose pattern matches a repository's name applies. Changing the options of a repository takes effect when it is cloned again.",
+      "type": "array",
+      "items": {
+        "$ref": "#/definitions/GitCloneOptions"
+      }
+    },
+    "gitShardingAlgorithm": {
+      "description":
+        "Algorithm for assigning repositories to gitservers. \"hashmod\" assigns each repository by hashing its name modulo the number of gitservers, so adding or removing a gitserver moves most repositories. \"rendezvous\" uses consistent hashing, which only moves the repositories assigned to the added or removed gitserver. Changing this moves most repositories once; gitservers transfer them to their new gitserver in the background.",
+      "type": "string",
+      "enum": ["hashmod", "rendezvous"],
+      "default": "hashmod"
+    },
+    "gitReplicationFactor": {
+      "description":
+        "Number of gitservers which keep a copy of each repository. If the gitserver a repository is assigned to is unreachable, reads are served by another copy. Requires at least this many gitserver instances.",
+      "type": "integer",
+      "minimum": 1,
+      "default": 1
+    },
     "repoListUpdateInterval": {
       "description":
         "Interval (in minutes) for checking code hosts (such as GitHub, Gitolite, etc.) for new repositories.",
@@ -246,6 +268,33 @@ const SiteSchemaJSON = `{
           "default": []
         }
       }
+    },
+    "permissions.backgroundSync": {
+      "title": "PermissionsBackgroundSync",
+      "description":
+        "Sync repository permissions from code hosts in the background and store them in the database, instead of asking the code hosts on every request. Only takes effect if repository permissions are enforced for at least one external service.",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "enabled": {
+          "description":
+            "Whether repository permissions are synced in the background. If false, permissions are checked against the code hosts at request time.",
+          "type": "boolean",
+          "default": false
+        },
+        "userInterval": {
+          "description":
+            "How often the permissions of users who were active today are refreshed. The permissions of other users are refreshed once they are older than half of ` + "`" + `maxStaleness` + "`" + `.",
+          "type": "string",
+          "default": "1h"
+        },
+        "maxStaleness": {
+          "description":
+            "The maximum age of stored permissions. If a user's stored permissions are older than this (or have never been synced), their permissions are checked against the code hosts at request time until the next sync.",
+          "type": "string",
+          "default": "24h"
+        }
+      }
     }
   },
   "definitions": {
@@ -347,6 +396,12 @@ const SiteSchemaJSON = `{
             "Defines whether repositories from this GitHub instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable GitHub repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by GitHub); site admins can still disable them explicitly, and they'll remain disabled.",
           "type": "boolean"
         },
+        "webhookSecret": {
+          "description":
+            "The secret used to validate webhook deliveries from this GitHub instance. When set, configure a webhook on your GitHub organizations or repositories with the payload URL https://[your-sourcegraph-hostname]/.api/webhooks/github, content type \"application/json\", this secret, and the \"push\", \"create\" and \"repository\" events. Sourcegraph updates repositories as soon as they are pushed to instead of waiting for the next scheduled update.",
+          "type": "string",
+          "minLength": 1
+        },
         "authorization": { "$ref": "#/definitions/GitHubAuthorization" }
       }
     },
@@ -417,6 +472,12 @@ const SiteSchemaJSON = `{
             "Defines whether repositories from this GitLab instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable GitLab repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by GitLab); site admins can still disable them explicitly, and they'll remain disabled.",
           "type": "boolean"
         },
+        "webhookSecret": {
+          "description":
+            "The secret token used to validate webhook deliveries from this GitLab instance. When set, configure a webhook on your GitLab groups or projects (or a system hook) with the URL https://[your-sourcegraph-hostname]/.api/webhooks/gitlab, this secret token, and the push and tag push events. Sourcegraph updates repositories as soon as they are pushed to instead of waiting for the next scheduled update.",
+          "type": "string",
+          "minLength": 1
+        },
         "authorization": { "$ref": "#/definitions/GitLabAuthorization" }
       }
     },
@@ -434,6 +495,153 @@ const SiteSchemaJSON = `{
         }
       }
     },
+    "GiteaConnection": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["url"],
+      "properties": {
+        "url": {
+          "description": "URL of a Gitea or Gogs instance, such as https://gitea.example.com.",
+          "type": "string",
+          "pattern": "^https?://",
+          "not": {
+            "type": "string",
+            "pattern": "example\\.com"
+          },
+          "format": "uri",
+          "examples": ["https://gitea.example.com", "https://try.gitea.io"]
+        },
+        "token": {
+          "description":
+            "A Gitea or Gogs access token. Create one at https://[your-gitea-hostname]/user/settings/applications. Without a token, only public repositories are mirrored.",
+          "type": "string",
+          "minLength": 1
+        },
+        "gitURLType": {
+          "description":
+            "The type of Git URLs to use for cloning and fetching Git repositories on this Gitea instance.\n\nIf \"http\", Sourcegraph will access Gitea repositories using Git URLs of the form http(s)://gitea.example.com/myorg/myrepo.git (using https: if the Gitea instance uses HTTPS).\n\nIf \"ssh\", Sourcegraph will access Gitea repositories using Git URLs of the form git@gitea.example.com:myorg/myrepo.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.",
+          "type": "string",
+          "enum": ["http", "ssh"],
+          "default": "http"
+        },
+        "certificate": {
+          "description": "TLS certificate of a Gitea instance. To get the certificate run ` + "`" + `openssl s_client -connect HOST:443 -showcerts < /dev/null 2> /dev/null | openssl x509 -outform PEM` + "`" + `",
+          "type": "string",
+          "pattern": "^-----BEGIN CERTIFICATE-----\n"
+        },
+        "repos": {
+          "description": "An array of repository \"owner/name\" strings specifying which Gitea repositories to mirror on Sourcegraph.",
+          "type": "array",
+          "items": { "type": "string", "pattern": "^[\\w-]+/[\\w.-]+$" }
+        },
+        "repositoryQuery": {
+          "description":
+            "An array of strings specifying which Gitea repositories to mirror on Sourcegraph. The valid values are:\n\n- ` + "`" + `affiliated` + "`" + ` mirrors all repositories the configured token's user owns, is a collaborator on, or can access through an organization\n\n- ` + "`" + `public` + "`" + ` mirrors all repositories visible to the configured token's user (or all public repositories if no token is configured)\n\n- ` + "`" + `none` + "`" + ` mirrors no repositories (except those specified in the ` + "`" + `repos` + "`" + ` configuration property or added manually)\n\n- All other values are executed as a Gitea repository search keyword, such as \"sourcegraph\".\n\nIf multiple values are provided, their results are unioned.",
+          "type": "array",
+          "items": { "type": "string" },
+          "default": ["affiliated"]
+        },
+        "repositoryPathPattern": {
+          "description":
+            "The pattern used to generate the corresponding Sourcegraph repository name for a Gitea repository. In the pattern, the variable \"{host}\" is replaced with the Gitea URL's host (such as gitea.example.com), and \"{nameWithOwner}\" is replaced with the Gitea repository's \"owner/name\" (such as \"myorg/myrepo\").\n\nFor example, if your Gitea is https://gitea.example.com and your Sourcegraph is https://src.example.com, then a repositoryPathPattern of \"{host}/{nameWithOwner}\" would mean that a Gitea repository at https://gitea.example.com/myorg/myrepo is available on Sourcegraph at https://src.example.com/gitea.example.com/myorg/myrepo.\n\nIt is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.",
+          "type": "string",
+          "default": "{host}/{nameWithOwner}"
+        },
+        "initialRepositoryEnablement": {
+          "description":
+            "Defines whether repositories from this Gitea instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable Gitea repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately; site admins can still disable them explicitly, and they'll remain disabled.",
+          "type": "boolean"
+        }
+      }
+    },
+    "BitbucketCloudConnection": {
+      "type": "object",
+      "additionalProperties": false,
+      "oneOf": [
+        {
+          "required": ["username", "appPassword"],
+          "properties": {
+            "oauthKey": {"type": "null"},
+            "oauthSecret": {"type": "null"}
+          }
+        },
+        {
+          "required": ["oauthKey", "oauthSecret"],
+          "properties": {
+            "username": {"type": "null"},
+            "appPassword": {"type": "null"}
+          }
+        }
+      ],
+      "properties": {
+        "url": {
+          "description": "URL of Bitbucket Cloud.",
+          "type": "string",
+          "pattern": "^https?://",
+          "format": "uri",
+          "default": "https://bitbucket.org",
+          "examples": ["https://bitbucket.org"]
+        },
+        "apiURL": {
+          "description": "The API URL of Bitbucket Cloud. Only set this if you access the Bitbucket Cloud API through a proxy.",
+          "type": "string",
+          "pattern": "^https?://",
+          "format": "uri",
+          "default": "https://api.bitbucket.org"
+        },
+        "username": {
+          "description": "The username of the Bitbucket Cloud user whose app password is set in \"appPassword\".",
+          "type": "string",
+          "minLength": 1
+        },
+        "appPassword": {
+          "description":
+            "A Bitbucket Cloud app password with the \"Repositories: Read\" permission (and \"Team membership: Read\" to list the repositories of teams). Create one at https://bitbucket.org/account/settings/app-passwords/new.\n\nTo authenticate as an OAuth consumer instead, specify its credentials in the \"oauthKey\" and \"oauthSecret\" fields.",
+          "type": "string",
+          "minLength": 1
+        },
+        "oauthKey": {
+          "description":
+            "The key of a Bitbucket Cloud OAuth consumer with the \"Repositories: Read\" permission. Sourcegraph acts as the user or team that owns the consumer. Also set the corresponding \"oauthSecret\" field. Create a consumer in the \"OAuth consumers\" settings of your Bitbucket Cloud account or team, and check \"This is a private consumer\".",
+          "type": "string",
+          "minLength": 1
+        },
+        "oauthSecret": {
+          "description": "The secret of the Bitbucket Cloud OAuth consumer set in \"oauthKey\".",
+          "type": "string",
+          "minLength": 1
+        },
+        "gitURLType": {
+          "description":
+            "The type of Git URLs to use for cloning and fetching Git repositories on Bitbucket Cloud.\n\nIf \"http\", Sourcegraph will access Bitbucket Cloud repositories using Git URLs of the form https://bitbucket.org/myteam/myrepo.git.\n\nIf \"ssh\", Sourcegraph will access Bitbucket Cloud repositories using Git URLs of the form git@bitbucket.org:myteam/myrepo.git. See the documentation for how to provide SSH private keys and known_hosts: https://docs.sourcegraph.com/admin/repo/auth#repositories-that-need-http-s-or-ssh-authentication.",
+          "type": "string",
+          "enum": ["http", "ssh"],
+          "default": "http"
+        },
+        "teams": {
+          "description":
+            "An array of Bitbucket Cloud team (workspace) or user names whose repositories to mirror on Sourcegraph. If neither \"teams\" nor \"repos\" is set, every repository that the configured user (or the OAuth consumer's owner) is a member of is mirrored.",
+          "type": "array",
+          "items": { "type": "string", "pattern": "^[\\w.-]+$" }
+        },
+        "repos": {
+          "description": "An array of repository \"team/name\" strings specifying which Bitbucket Cloud repositories to mirror on Sourcegraph.",
+          "type": "array",
+          "items": { "type": "string", "pattern": "^[\\w.-]+/[\\w.-]+$" }
+        },
+        "repositoryPathPattern": {
+          "description":
+            "The pattern used to generate the corresponding Sourcegraph repository name for a Bitbucket Cloud repository. In the pattern, the variable \"{host}\" is replaced with the Bitbucket Cloud URL's host (bitbucket.org), and \"{nameWithOwner}\" is replaced with the Bitbucket Cloud repository's \"team/name\" (such as \"myteam/myrepo\").\n\nFor example, if your Sourcegraph is https://src.example.com, then a repositoryPathPattern of \"{host}/{nameWithOwner}\" would mean that a Bitbucket Cloud repository at https://bitbucket.org/myteam/myrepo is available on Sourcegraph at https://src.example.com/bitbucket.org/myteam/myrepo.\n\nIt is important that the Sourcegraph repository name generated with this pattern be unique to this code host. If different code hosts generate repository names that collide, Sourcegraph's behavior is undefined.",
+          "type": "string",
+          "default": "{host}/{nameWithOwner}"
+        },
+        "initialRepositoryEnablement": {
+          "description":
+            "Defines whether repositories from Bitbucket Cloud should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable Bitbucket Cloud repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by Bitbucket Cloud); site admins can still disable them explicitly, and they'll remain disabled.",
+          "type": "boolean"
+        }
+      }
+    },
     "BitbucketServerConnection": {
       "type": "object",
       "additionalProperties": false,
@@ -504,10 +712,68 @@ const SiteSchemaJSON = `{
             "Whether or not personal repositories should be excluded or not. When true, Sourcegraph will ignore personal repositories it may have access to. See https://docs.sourcegraph.com/integration/bitbucket_server#excluding-personal-repositories for more information. Default: false.",
           "type": "boolean"
         },
+        "webhookSecret": {
+          "description":
+            "The secret used to validate webhook deliveries from this Bitbucket Server instance (Bitbucket Server version 5.15 and newer). When set, configure a webhook on your Bitbucket Server projects or repositories with the URL https://[your-sourcegraph-hostname]/.api/webhooks/bitbucket-server, this secret, and the \"Repository: Push\" and \"Repository: Modified\" events. Sourcegraph updates repositories as soon as they are pushed to instead of waiting for the next scheduled update.",
+          "type": "string",
+          "minLength": 1
+        },
         "initialRepositoryEnablement": {
           "description":
             "Defines whether repositories from this Bitbucket Server instance should be enabled and cloned when they are first seen by Sourcegraph. If false, the site admin must explicitly enable Bitbucket Server repositories (in the site admin area) to clone them and make them searchable on Sourcegraph. If true, they will be enabled and cloned immediately (subject to rate limiting by Bitbucket Server); site admins can still disable them explicitly, and they'll remain disabled.",
           "type": "boolean"
+        },
+        "authorization": { "$ref": "#/definitions/BitbucketServerAuthorization" }
+      }
+    },
+    "BitbucketServerAuthorization": {
+      "description":
+        "If non-null, enforces Bitbucket Server repository permissions. Sourcegraph users are matched to Bitbucket Server users as configured in ` + "`" + `identityProvider` + "`" + `. The ` + "`" + `token` + "`" + ` (or ` + "`" + `username` + "`" + ` and ` + "`" + `password` + "`" + `) of this ` + "`" + `BitbucketServerConnection` + "`" + ` must belong to a Bitbucket Server user with the \"Admin\" global permission, so that Sourcegraph can look up other users and list the public repositories. The repositories each user can read are listed by impersonating the user through the application link configured in ` + "`" + `oauth` + "`" + `.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["oauth"],
+      "properties": {
+        "identityProvider": { "$ref": "#/definitions/BitbucketServerIdentityProvider" },
+        "oauth": { "$ref": "#/definitions/BitbucketServerOAuth" },
+        "ttl": {
+          "description":
+            "The TTL of how long to cache permissions data. This is 3 hours by default.\n\nDecreasing the TTL will increase the load on the code host API. Sourcegraph lists the public repositories, and the repositories each user can read, once for every cache refresh period (1 API request per 1000 repositories).\n\nIf set to zero, Sourcegraph will check a user's permissions on every request (NOT recommended).",
+          "type": "string",
+          "default": "3h"
+        }
+      }
+    },
+    "BitbucketServerOAuth": {
+      "description":
+        "An incoming application link on Bitbucket Server with \"Allow 2-Legged OAuth\" and \"Allow user impersonation through 2-Legged OAuth\" enabled, which Sourcegraph uses to list the repositories each user can read.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["consumerKey", "signingKey"],
+      "properties": {
+        "consumerKey": {
+          "description": "The consumer key of the application link.",
+          "type": "string",
+          "minLength": 1
+        },
+        "signingKey": {
+          "description":
+            "The base64-encoded PEM RSA private key whose public key is configured on the application link.",
+          "type": "string",
+          "minLength": 1
+        }
+      }
+    },
+    "BitbucketServerIdentityProvider": {
+      "description":
+        "The way Sourcegraph users are matched to Bitbucket Server users.\n\nIf \"email\" (the default), a Sourcegraph user is matched to the Bitbucket Server user whose email address is one of the Sourcegraph user's verified email addresses.\n\nIf \"username\", a Sourcegraph user is matched to the Bitbucket Server user with the same username. Sourcegraph users choose their own usernames, so any user who signs up with or changes their username to a Bitbucket Server username can read that user's repositories. Only use \"username\" if only trusted users can sign up and choose usernames.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["type"],
+      "properties": {
+        "type": {
+          "type": "string",
+          "enum": ["username", "email"],
+          "default": "email"
         }
       }
     },
@@ -652,6 +918,31 @@ const SiteSchemaJSON = `{
         }
       }
     },
+    "GitCloneOptions": {
+      "description":
+        "Options for cloning the repositories whose name matches a pattern. Objects that a partial clone omits are fetched from the code host when they are needed. Features that need a repository's full history (such as blame) are unavailable for shallow clones.",
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["pattern"],
+      "properties": {
+        "pattern": {
+          "description":
+            "A regular expression that matches the names of the repositories (such as \"^github\\.com/myorg/monorepo$\"). The regular expression should use the Go regular expression syntax (https://golang.org/pkg/regexp/).",
+          "type": "string"
+        },
+        "filter": {
+          "description":
+            "The object filter of a partial clone, such as \"blob:none\" or \"blob:limit=1m\" (see ` + "`" + `--filter` + "`" + ` in ` + "`" + `git help rev-list` + "`" + `). Requires Git 2.19 or later on the code host, with partial clones enabled (uploadpack.allowFilter).",
+          "type": "string",
+          "examples": ["blob:none", "blob:limit=1m"]
+        },
+        "depth": {
+          "description": "The number of commits of history to clone for each branch and tag. If 0, the full history is cloned.",
+          "type": "integer",
+          "minimum": 0
+        }
+      }
+    },
     "CloneURLToRepositoryName": {
       "description":
         "Describes a mapping from clone URL to repository name. The ` + "`" + `from` + "`" + ` field contains a regular expression with named capturing groups. The ` + "`" + `to` + "`" + ` field contains a template string that references capturing group names. For instance, if ` + "`" + `from` + "`" + ` is \"^../(?P<name>\\w+)$\" and ` + "`" + `to` + "`" + ` is \"github.com/user/{name}\", the clone URL \"../myRepository\" would be mapped to the repository name \"github.com/user/myRepository\".",
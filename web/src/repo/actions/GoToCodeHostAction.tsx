@@ -143,12 +143,16 @@ function serviceTypeDisplayNameAndIcon(
             return { displayName: 'GitHub', icon: GithubCircleIcon }
         case 'gitlab':
             return { displayName: 'GitLab' }
+        case 'bitbucketCloud':
+            return { displayName: 'Bitbucket Cloud' }
         case 'bitbucketserver':
             return { displayName: 'Bitbucket Server' }
         case 'phabricator':
             return { displayName: 'Phabricator', icon: PhabricatorIcon }
         case 'awscodecommit':
             return { displayName: 'AWS CodeCommit' }
+        case 'gitea':
+            return { displayName: 'Gitea' }
     }
     return { displayName: serviceType ? upperFirst(serviceType) : 'code host' }
 }
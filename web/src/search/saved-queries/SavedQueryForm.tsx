@@ -7,7 +7,6 @@ import { Key } from 'ts-key-enum'
 import * as GQL from '../../../../shared/src/graphql/schema'
 import { isSettingsValid, SettingsCascadeProps, SettingsSubject } from '../../../../shared/src/settings/settings'
 import { Form } from '../../components/Form'
-import { eventLogger } from '../../tracking/eventLogger'
 
 export interface SavedQueryFields {
     description: string
@@ -35,7 +34,6 @@ interface State {
     isSubmitting: boolean
     isFocused: boolean
     error?: any
-    sawUnsupportedNotifyQueryWarning: boolean
     slackWebhooks: Map<GQL.ID, string | null> // subject GraphQL ID -> slack webhook
 }
 
@@ -66,7 +64,6 @@ export class SavedQueryForm extends React.Component<Props, State> {
             subjectOptions: [],
             isSubmitting: false,
             isFocused: false,
-            sawUnsupportedNotifyQueryWarning: false,
             slackWebhooks: new Map<GQL.ID, string | null>(),
         }
     }
@@ -232,13 +229,7 @@ export class SavedQueryForm extends React.Component<Props, State> {
                         </span>
                     </div>
                 </div>
-                {this.isUnsupportedNotifyQuery(this.state.values) && (
-                    <div className="alert alert-warning mb-2">
-                        <strong>Warning:</strong> non-commit searches do not currently support notifications. Consider
-                        adding <code>type:diff</code> or <code>type:commit</code> to your query.
-                    </div>
-                )}
-                {notify && !window.context.emailEnabled && !this.isUnsupportedNotifyQuery(this.state.values) && (
+                {notify && !window.context.emailEnabled && (
                     <div className="alert alert-warning mb-2">
                         <strong>Warning:</strong> Sending emails is not currently configured on this Sourcegraph server.{' '}
                         {this.props.authenticatedUser && this.props.authenticatedUser.siteAdmin
@@ -272,14 +263,6 @@ export class SavedQueryForm extends React.Component<Props, State> {
         )
     }
 
-    /**
-     * Tells if the query is unsupported for sending notifications.
-     */
-    private isUnsupportedNotifyQuery(v: SavedQueryFields): boolean {
-        const notifying = v.notify || v.notifySlack
-        return notifying && !v.query.includes('type:diff') && !v.query.includes('type:commit')
-    }
-
     private isSubjectMissingSlackWebhook = () => {
         const chosen = this.state.subjectOptions.find(subjectOption => subjectOption.id === this.state.values.subject)
         if (!chosen) {
@@ -341,15 +324,6 @@ export class SavedQueryForm extends React.Component<Props, State> {
     }
 
     private handleQueryChange = (event: React.ChangeEvent<HTMLInputElement>) => {
-        const newValues = {
-            ...this.state.values,
-            query: event.currentTarget.value,
-        }
-        if (!this.state.sawUnsupportedNotifyQueryWarning && this.isUnsupportedNotifyQuery(newValues)) {
-            this.setState({ sawUnsupportedNotifyQueryWarning: true })
-            eventLogger.log('SavedSearchUnsupportedNotifyQueryWarning')
-        }
-
         const newQuery = event.currentTarget.value
         this.setState(prevState => ({
             values: {